@@ -0,0 +1,153 @@
+// Package main runs a load test of the sending pipeline and prints a JSON
+// report of throughput, latencies, error rates and database timings.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/loadtest"
+	"github.com/popeskul/insdr-messenger/internal/mockgateway"
+)
+
+func main() {
+	opts := loadtest.Options{Gateway: mockgateway.DefaultConfig()}
+	var (
+		configPath string
+		outPath    string
+		verbose    bool
+	)
+
+	flag.StringVar(&configPath, "config", "config.yaml", "Path to the configuration file")
+	flag.StringVar(&outPath, "out", "", "Write the report to this file instead of stdout")
+	flag.BoolVar(&verbose, "v", false, "Log every scheduler run")
+	flag.IntVar(&opts.Messages, "messages", 1000, "Number of messages to create")
+	flag.StringVar(&opts.Mode, "mode", loadtest.ModeSeed, "How to create messages: seed (in process) or api (POST /messages)")
+	flag.StringVar(&opts.APIURL, "api", "http://localhost:8080", "Base URL of the server in api mode")
+	flag.IntVar(&opts.Concurrency, "concurrency", 10, "Number of concurrent message creators")
+	flag.BoolVar(&opts.Scheduler, "scheduler", true, "Run the scheduler in process; disable to measure running servers")
+	flag.DurationVar(&opts.Interval, "interval", 100*time.Millisecond, "Interval of the in-process scheduler")
+	flag.IntVar(&opts.BatchSize, "batch-size", 0, "Override scheduler.batch_size")
+	flag.DurationVar(&opts.Timeout, "timeout", 10*time.Minute, "Maximum time to wait for all messages")
+	flag.DurationVar(&opts.PollInterval, "poll", 250*time.Millisecond, "Interval of progress checks")
+	flag.StringVar(&opts.GatewayURL, "gateway-url", "", "Gateway to send to, empty starts a mock gateway in process")
+	flag.Float64Var(&opts.Gateway.ErrorRate, "gateway-error-rate", 0, "Share of mock gateway requests failing with 500")
+	flag.Float64Var(&opts.Gateway.ThrottleRate, "gateway-throttle-rate", 0, "Share of mock gateway requests answered with 429")
+	flag.StringVar(&opts.Gateway.Latency.Distribution, "gateway-latency", mockgateway.LatencyFixed, "Mock gateway latency distribution")
+	flag.IntVar(&opts.Gateway.Latency.Mean, "gateway-latency-mean", 0, "Mean mock gateway latency in milliseconds")
+	flag.IntVar(&opts.Gateway.Latency.StdDev, "gateway-latency-stddev", 0, "Mock gateway latency standard deviation in milliseconds")
+	flag.Parse()
+
+	level := zapcore.WarnLevel
+	if verbose {
+		level = zapcore.InfoLevel
+	}
+	logCfg := zap.NewProductionConfig()
+	logCfg.Level = zap.NewAtomicLevelAt(level)
+	logger, err := logCfg.Build()
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize logger: %v", err))
+	}
+	defer func() {
+		_ = logger.Sync()
+	}()
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		logger.Fatal("Failed to load configuration", zap.Error(err))
+	}
+
+	queries := loadtest.NewQueryRecorder()
+	connector, err := pq.NewConnector(cfg.Database.GetDSN())
+	if err != nil {
+		logger.Fatal("Invalid database configuration", zap.Error(err))
+	}
+	db := sqlx.NewDb(sql.OpenDB(queries.Wrap(connector)), "postgres")
+	defer func() {
+		if err := db.Close(); err != nil {
+			logger.Error("Failed to close database connection", zap.Error(err))
+		}
+	}()
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	monitor, err := sqlx.Connect("postgres", cfg.Database.GetDSN())
+	if err != nil {
+		logger.Fatal("Failed to connect to database", zap.Error(err))
+	}
+	defer func() {
+		if err := monitor.Close(); err != nil {
+			logger.Error("Failed to close database connection", zap.Error(err))
+		}
+	}()
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer func() {
+		if err := redisClient.Close(); err != nil {
+			logger.Error("Failed to close Redis connection", zap.Error(err))
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		logger.Fatal("Failed to connect to Redis", zap.Error(err))
+	}
+
+	runner := loadtest.NewRunner(cfg, db, monitor, redisClient, queries, logger)
+	report, err := runner.Run(ctx, opts)
+	if err != nil {
+		logger.Fatal("Load test failed", zap.Error(err))
+	}
+
+	if err := writeReport(outPath, report); err != nil {
+		logger.Fatal("Failed to write report", zap.Error(err))
+	}
+
+	if report.TimedOut {
+		logger.Warn("Not all messages were processed before the timeout")
+		stop()
+		os.Exit(1)
+	}
+}
+
+// writeReport writes report as indented JSON to path, or to stdout when
+// path is empty.
+func writeReport(path string, report *loadtest.Report) error {
+	var out io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -0,0 +1,97 @@
+// Package main runs a local mock SMS gateway speaking the webhook protocol,
+// with configurable faults and optional delivery receipts.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/mockgateway"
+)
+
+func main() {
+	cfg := mockgateway.DefaultConfig()
+	var (
+		addr    string
+		authKey string
+		seed    int64
+	)
+
+	flag.StringVar(&addr, "addr", ":9090", "Address to listen on")
+	flag.StringVar(&authKey, "auth-key", "", "Required x-ins-auth-key header, empty accepts any")
+	flag.Int64Var(&seed, "seed", 0, "Seed for fault injection, 0 seeds from the clock")
+	flag.Float64Var(&cfg.ErrorRate, "error-rate", cfg.ErrorRate, "Share of requests failing with -error-status")
+	flag.IntVar(&cfg.ErrorStatus, "error-status", cfg.ErrorStatus, "Status code of failed requests")
+	flag.Float64Var(&cfg.ThrottleRate, "throttle-rate", cfg.ThrottleRate, "Share of requests answered with 429")
+	flag.IntVar(&cfg.RetryAfter, "retry-after", cfg.RetryAfter, "Retry-After seconds of 429 responses")
+	flag.Float64Var(&cfg.MalformedRate, "malformed-rate", cfg.MalformedRate, "Share of accepted requests answered with invalid JSON")
+	flag.StringVar(&cfg.Latency.Distribution, "latency", cfg.Latency.Distribution, "Latency distribution: fixed, uniform, normal or exponential")
+	flag.IntVar(&cfg.Latency.Mean, "latency-mean", cfg.Latency.Mean, "Mean latency in milliseconds")
+	flag.IntVar(&cfg.Latency.StdDev, "latency-stddev", cfg.Latency.StdDev, "Latency standard deviation in milliseconds")
+	flag.IntVar(&cfg.Latency.Min, "latency-min", cfg.Latency.Min, "Minimum latency in milliseconds")
+	flag.IntVar(&cfg.Latency.Max, "latency-max", cfg.Latency.Max, "Maximum latency in milliseconds, 0 for none")
+	flag.IntVar(&cfg.Outage.Every, "outage-every", cfg.Outage.Every, "Length of the outage period in seconds, 0 disables outages")
+	flag.IntVar(&cfg.Outage.Duration, "outage-for", cfg.Outage.Duration, "Seconds at the end of every period answered with 503")
+	flag.StringVar(&cfg.Receipts.URL, "receipt-url", cfg.Receipts.URL, "Delivery receipt callback URL, empty disables receipts")
+	flag.IntVar(&cfg.Receipts.Delay, "receipt-delay", cfg.Receipts.Delay, "Delay of delivery receipts in milliseconds")
+	flag.Float64Var(&cfg.Receipts.DeliveredRate, "receipt-delivered-rate", cfg.Receipts.DeliveredRate, "Share of receipts reporting delivered")
+	flag.StringVar(&cfg.Receipts.Provider, "receipt-provider", cfg.Receipts.Provider, "X-Callback-Provider header of receipts")
+	flag.StringVar(&cfg.Receipts.Token, "receipt-token", cfg.Receipts.Token, "X-Callback-Token header of receipts")
+	flag.StringVar(&cfg.Receipts.Secret, "receipt-secret", cfg.Receipts.Secret, "Secret signing receipts")
+	flag.Parse()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize logger: %v", err))
+	}
+	defer func() {
+		_ = logger.Sync()
+	}()
+
+	gateway, err := mockgateway.New(cfg, mockgateway.Options{
+		AuthKey: authKey,
+		Seed:    seed,
+		Logger:  logger,
+	})
+	if err != nil {
+		logger.Error("Invalid gateway configuration", zap.Error(err))
+		os.Exit(2)
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           gateway,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		logger.Info("Starting mock gateway", zap.String("address", srv.Addr))
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal("Failed to start mock gateway", zap.Error(err))
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down mock gateway...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("Mock gateway forced to shutdown", zap.Error(err))
+	}
+	gateway.Close()
+
+	logger.Info("Mock gateway exited")
+}
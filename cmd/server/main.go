@@ -71,11 +71,46 @@ func main() {
 	}()
 
 	repo := repository.NewRepository(db)
-	svc := service.NewService(cfg, repo, redisClient, logger)
+	svc, err := service.NewService(cfg, repo, redisClient, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize services", zap.Error(err))
+	}
+
+	handler := handler.NewHandler(svc, logger, cfg.Stream.AllowedOrigins)
+
+	callbackProviders := make([]middleware.CallbackProvider, len(cfg.Callbacks.Providers))
+	for i, p := range cfg.Callbacks.Providers {
+		callbackProviders[i] = middleware.CallbackProvider{
+			Name:       p.Name,
+			Secrets:    p.Secrets,
+			Token:      p.Token,
+			AllowedIPs: p.AllowedIPs,
+		}
+	}
+	if len(callbackProviders) == 0 {
+		if cfg.Callbacks.AllowUnverified {
+			logger.Warn("No callback providers configured, callbacks are accepted unverified")
+		} else {
+			logger.Warn("No callback providers configured, callbacks are rejected")
+		}
+	}
+
+	callbackVerifier, err := middleware.NewCallbackVerifier(&middleware.CallbackConfig{
+		Providers:       callbackProviders,
+		Tolerance:       time.Duration(cfg.Callbacks.Tolerance) * time.Second,
+		Nonces:          middleware.NewRedisNonceStore(redisClient),
+		AllowUnverified: cfg.Callbacks.AllowUnverified,
+		Logger:          logger,
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize callback verification", zap.Error(err))
+	}
 
-	handler := handler.NewHandler(svc, logger)
+	router := setupRouter(handler, callbackVerifier.Middleware())
 
-	router := setupRouter(handler)
+	// Provider callbacks only complete messages already sent, so they are
+	// still accepted while draining.
+	drainer := middleware.NewDrainer("/callbacks/")
 
 	middlewareConfig := &middleware.Config{
 		Logger: logger,
@@ -90,6 +125,8 @@ func main() {
 		RateLimit:      rate.Limit(cfg.Middleware.RateLimit),
 		RateLimitBurst: cfg.Middleware.RateLimitBurst,
 		RequestTimeout: 30 * time.Second,
+		StreamPaths:    []string{"/events/stream", "/events/ws"},
+		Drainer:        drainer,
 	}
 
 	finalHandler := middleware.Chain(middlewareConfig)(router)
@@ -109,6 +146,14 @@ func main() {
 		logger.Info("Scheduler started automatically on application startup")
 	}
 
+	if err := svc.Events.Start(); err != nil {
+		logger.Error("Failed to start event dispatcher", zap.Error(err))
+	}
+
+	if err := svc.Stream.Start(); err != nil {
+		logger.Error("Failed to start event stream", zap.Error(err))
+	}
+
 	// Start server in goroutine
 	go func() {
 		logger.Info("Starting server", zap.String("address", srv.Addr))
@@ -124,11 +169,31 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
-	// Stop scheduler
-	if svc.Scheduler.IsRunning() {
-		if err := svc.Scheduler.Stop(); err != nil {
-			logger.Error("Failed to stop scheduler", zap.Error(err))
-		}
+	// Refuse new writes, let in-flight sends finish and hand unsent claims
+	// back to the queue
+	drainer.Start()
+	report := svc.Scheduler.Drain(time.Duration(cfg.Scheduler.DrainTimeout) * time.Second)
+	drainFields := []zap.Field{
+		zap.Int("completed", report.Completed),
+		zap.Int64s("released", report.Released),
+		zap.Int64s("abandoned", report.Abandoned),
+		zap.Bool("timedOut", report.TimedOut),
+		zap.Duration("duration", report.Duration),
+	}
+	if len(report.Abandoned) > 0 {
+		logger.Warn("Drained with abandoned messages", drainFields...)
+	} else {
+		logger.Info("Drained", drainFields...)
+	}
+
+	// Stop event dispatcher, undelivered events stay in the outbox
+	if err := svc.Events.Stop(); err != nil {
+		logger.Error("Failed to stop event dispatcher", zap.Error(err))
+	}
+
+	// Close live streams, they would hold up the shutdown otherwise
+	if err := svc.Stream.Stop(); err != nil {
+		logger.Error("Failed to stop event stream", zap.Error(err))
 	}
 
 	// Graceful shutdown
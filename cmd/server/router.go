@@ -8,7 +8,7 @@ import (
 	"github.com/popeskul/insdr-messenger/internal/api"
 )
 
-func setupRouter(handler api.ServerInterface) http.Handler {
+func setupRouter(handler api.ServerInterface, callbackAuth func(http.Handler) http.Handler) http.Handler {
 	r := chi.NewRouter()
 
 	// Serve OpenAPI spec
@@ -25,8 +25,16 @@ func setupRouter(handler api.ServerInterface) http.Handler {
 		http.StripPrefix("/swagger/", http.FileServer(http.Dir("static/swagger-ui"))).ServeHTTP(w, req)
 	})
 
+	apiHandler := api.Handler(handler)
+
+	// Provider callbacks must pass verification first
+	r.Group(func(r chi.Router) {
+		r.Use(callbackAuth)
+		r.Handle("/callbacks/*", apiHandler)
+	})
+
 	// Mount API routes
-	r.Mount("/", api.Handler(handler))
+	r.Mount("/", apiHandler)
 
 	return r
 }
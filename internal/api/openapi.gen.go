@@ -12,6 +12,28 @@ import (
 	"github.com/oapi-codegen/runtime"
 )
 
+// Defines values for DeliveryAttemptErrorClass.
+const (
+	Permanent DeliveryAttemptErrorClass = "permanent"
+	Transient DeliveryAttemptErrorClass = "transient"
+)
+
+// Defines values for DeliveryReceiptStatus.
+const (
+	DeliveryReceiptStatusDelivered   DeliveryReceiptStatus = "delivered"
+	DeliveryReceiptStatusUndelivered DeliveryReceiptStatus = "undelivered"
+)
+
+// Defines values for EventType.
+const (
+	MessageDelivered   EventType = "message.delivered"
+	MessageFailed      EventType = "message.failed"
+	MessageSent        EventType = "message.sent"
+	MessageUndelivered EventType = "message.undelivered"
+	SchedulerStarted   EventType = "scheduler.started"
+	SchedulerStopped   EventType = "scheduler.stopped"
+)
+
 // Defines values for HealthResponseCircuitBreakerState.
 const (
 	Closed   HealthResponseCircuitBreakerState = "closed"
@@ -46,9 +68,15 @@ const (
 
 // Defines values for MessageStatus.
 const (
-	Failed  MessageStatus = "failed"
-	Pending MessageStatus = "pending"
-	Sent    MessageStatus = "sent"
+	MessageStatusAcceptedUnconfirmed MessageStatus = "accepted_unconfirmed"
+	MessageStatusCancelled           MessageStatus = "cancelled"
+	MessageStatusDelivered           MessageStatus = "delivered"
+	MessageStatusExpired             MessageStatus = "expired"
+	MessageStatusFailed              MessageStatus = "failed"
+	MessageStatusProcessing          MessageStatus = "processing"
+	MessageStatusQueued              MessageStatus = "queued"
+	MessageStatusSent                MessageStatus = "sent"
+	MessageStatusUndelivered         MessageStatus = "undelivered"
 )
 
 // Defines values for SchedulerResponseStatus.
@@ -57,6 +85,124 @@ const (
 	SchedulerResponseStatusStopped SchedulerResponseStatus = "stopped"
 )
 
+// CircuitBreakerHealth defines model for CircuitBreakerHealth.
+type CircuitBreakerHealth struct {
+	// Country Calling country code guarded by the breaker
+	Country *string `json:"country,omitempty"`
+
+	// Failures Failures in the current breaker window
+	Failures int64 `json:"failures"`
+
+	// Name Circuit breaker name
+	Name string `json:"name"`
+
+	// Requests Requests in the current breaker window
+	Requests int64 `json:"requests"`
+
+	// State Circuit breaker state
+	State HealthResponseCircuitBreakerState `json:"state"`
+}
+
+// CreateMessageRequest defines model for CreateMessageRequest.
+type CreateMessageRequest struct {
+	// Campaign Campaign the message belongs to
+	Campaign *string `json:"campaign,omitempty"`
+
+	// Content Message content
+	Content string `json:"content"`
+
+	// PhoneNumber Recipient phone number
+	PhoneNumber string `json:"phone_number"`
+
+	// Priority Message priority used for routing
+	Priority *int `json:"priority,omitempty"`
+}
+
+// CreateSubscriptionRequest defines model for CreateSubscriptionRequest.
+type CreateSubscriptionRequest struct {
+	EventTypes []EventType `json:"event_types"`
+
+	// Secret Secret used to sign the events, generated when omitted
+	Secret *string `json:"secret,omitempty"`
+
+	// Url HTTP or HTTPS URL receiving the events
+	Url string `json:"url"`
+}
+
+// DeadLetter defines model for DeadLetter.
+type DeadLetter struct {
+	// Attempts Number of delivery attempts made
+	Attempts int `json:"attempts"`
+
+	// Event Payload POSTed to subscription URLs
+	Event Event `json:"event"`
+
+	// FailedAt Timestamp of the last delivery attempt
+	FailedAt time.Time `json:"failed_at"`
+
+	// LastError Error of the last delivery attempt
+	LastError *string `json:"last_error"`
+}
+
+// DeadLetterListResponse defines model for DeadLetterListResponse.
+type DeadLetterListResponse struct {
+	DeadLetters []DeadLetter `json:"dead_letters"`
+
+	// SubscriptionId Subscription identifier
+	SubscriptionId int64 `json:"subscription_id"`
+}
+
+// DeliveryAttempt defines model for DeliveryAttempt.
+type DeliveryAttempt struct {
+	// CircuitBreakerState State of the provider's circuit breaker when the call was made
+	CircuitBreakerState HealthResponseCircuitBreakerState `json:"circuit_breaker_state"`
+
+	// Error Error of a failed call
+	Error *string `json:"error"`
+
+	// ErrorClass Whether the failure may succeed on retry
+	ErrorClass *DeliveryAttemptErrorClass `json:"error_class"`
+
+	// LatencyMs Duration of the call in milliseconds
+	LatencyMs int64 `json:"latency_ms"`
+
+	// Provider Provider that was called
+	Provider string `json:"provider"`
+
+	// RequestedAt Time the call started
+	RequestedAt time.Time `json:"requested_at"`
+
+	// ResponseBody Response body returned by the provider, truncated to 2 KB
+	ResponseBody *string `json:"response_body"`
+
+	// Route Route the message was resolved to
+	Route string `json:"route"`
+
+	// StatusCode HTTP status returned by the provider
+	StatusCode *int `json:"status_code"`
+}
+
+// DeliveryAttemptErrorClass Whether the failure may succeed on retry
+type DeliveryAttemptErrorClass string
+
+// DeliveryReceipt defines model for DeliveryReceipt.
+type DeliveryReceipt struct {
+	// Error Provider reason for an undelivered message
+	Error *string `json:"error,omitempty"`
+
+	// MessageId External message ID returned by the provider
+	MessageId string `json:"messageId"`
+
+	// Status Final handset status
+	Status DeliveryReceiptStatus `json:"status"`
+
+	// Timestamp Time the provider observed the status, defaults to the time of the callback
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+// DeliveryReceiptStatus Final handset status
+type DeliveryReceiptStatus string
+
 // ErrorResponse defines model for ErrorResponse.
 type ErrorResponse struct {
 	// Error Error code
@@ -69,6 +215,24 @@ type ErrorResponse struct {
 	Timestamp *time.Time `json:"timestamp"`
 }
 
+// Event Payload POSTed to subscription URLs
+type Event struct {
+	// CreatedAt Timestamp when the event occurred
+	CreatedAt time.Time `json:"created_at"`
+
+	// Data Event details, e.g. the message ID and status of message events
+	Data map[string]interface{} `json:"data"`
+
+	// Id Unique event delivery identifier, stable across retries
+	Id int64 `json:"id"`
+
+	// Type Type of an event delivered to subscriptions
+	Type EventType `json:"type"`
+}
+
+// EventType Type of an event delivered to subscriptions
+type EventType string
+
 // HealthResponse defines model for HealthResponse.
 type HealthResponse struct {
 	// CircuitBreakerState Current circuit breaker state
@@ -80,9 +244,16 @@ type HealthResponse struct {
 	// DatabaseStatus Database connection status
 	DatabaseStatus *HealthResponseDatabaseStatus `json:"database_status"`
 
+	// Providers Circuit breaker state of each delivery provider
+	Providers *[]ProviderHealth `json:"providers,omitempty"`
+
 	// RedisStatus Redis connection status
 	RedisStatus *HealthResponseRedisStatus `json:"redis_status"`
 
+	// SchedulerBatchSize Number of messages claimed by the next batch. With adaptive
+	// batching it follows the backlog and provider health.
+	SchedulerBatchSize *int `json:"scheduler_batch_size"`
+
 	// SchedulerStatus Current scheduler status
 	SchedulerStatus *HealthResponseSchedulerStatus `json:"scheduler_status"`
 
@@ -113,6 +284,9 @@ type Message struct {
 	// Content Message content
 	Content *string `json:"content,omitempty"`
 
+	// DeliveredAt Timestamp of the delivery receipt reported by the provider
+	DeliveredAt *time.Time `json:"delivered_at"`
+
 	// Error Error message if sending failed
 	Error *string `json:"error"`
 
@@ -125,16 +299,42 @@ type Message struct {
 	// PhoneNumber Recipient phone number
 	PhoneNumber string `json:"phone_number"`
 
+	// Provider Name of the provider that delivered the message
+	Provider *string `json:"provider"`
+
+	// ProviderResponse Raw provider response of a message accepted without a usable message ID
+	ProviderResponse *string `json:"provider_response"`
+
 	// SentAt Timestamp when the message was sent
 	SentAt *time.Time `json:"sent_at,omitempty"`
 
-	// Status Message sending status
+	// Status Message lifecycle status. `accepted_unconfirmed` means the provider
+	// accepted the message but its response carried no usable message ID;
+	// the reconciliation job resolves it through the provider.
 	Status MessageStatus `json:"status"`
 }
 
-// MessageStatus Message sending status
+// MessageStatus Message lifecycle status. `accepted_unconfirmed` means the provider
+// accepted the message but its response carried no usable message ID;
+// the reconciliation job resolves it through the provider.
 type MessageStatus string
 
+// MessageAttemptsResponse defines model for MessageAttemptsResponse.
+type MessageAttemptsResponse struct {
+	Attempts []DeliveryAttempt `json:"attempts"`
+
+	// MessageId Message identifier
+	MessageId int64 `json:"message_id"`
+}
+
+// MessageHistoryResponse defines model for MessageHistoryResponse.
+type MessageHistoryResponse struct {
+	History []StatusChange `json:"history"`
+
+	// MessageId Message identifier
+	MessageId int64 `json:"message_id"`
+}
+
 // MessageListResponse defines model for MessageListResponse.
 type MessageListResponse struct {
 	Messages   []Message  `json:"messages"`
@@ -156,8 +356,114 @@ type Pagination struct {
 	TotalPages int `json:"total_pages"`
 }
 
+// ProviderHealth defines model for ProviderHealth.
+type ProviderHealth struct {
+	// Breakers Circuit breakers of the provider, one per destination country when enabled
+	Breakers *[]CircuitBreakerHealth `json:"breakers,omitempty"`
+
+	// Failures Failures in the current breaker window
+	Failures int64 `json:"failures"`
+
+	// Name Provider name
+	Name string `json:"name"`
+
+	// Requests Requests in the current breaker window
+	Requests int64 `json:"requests"`
+
+	// State Circuit breaker state of the provider
+	State HealthResponseCircuitBreakerState `json:"state"`
+}
+
+// ProviderStats defines model for ProviderStats.
+type ProviderStats struct {
+	// AvgLatencyMs Average send latency in milliseconds
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+
+	// Failures Attempts that failed
+	Failures int64 `json:"failures"`
+
+	// Name Provider name
+	Name string `json:"name"`
+
+	// P50LatencyMs Median send latency of recent attempts in milliseconds
+	P50LatencyMs float64 `json:"p50_latency_ms"`
+
+	// P95LatencyMs 95th percentile send latency of recent attempts in milliseconds
+	P95LatencyMs float64 `json:"p95_latency_ms"`
+
+	// P99LatencyMs 99th percentile send latency of recent attempts in milliseconds
+	P99LatencyMs float64 `json:"p99_latency_ms"`
+
+	// Requests Send attempts made through the provider
+	Requests int64 `json:"requests"`
+
+	// SuccessRate Share of successful attempts between 0 and 1
+	SuccessRate float64 `json:"success_rate"`
+
+	// Successes Attempts accepted by the provider
+	Successes int64 `json:"successes"`
+}
+
+// ProviderStatsResponse defines model for ProviderStatsResponse.
+type ProviderStatsResponse struct {
+	Providers []ProviderStats `json:"providers"`
+}
+
+// RouteResolution defines model for RouteResolution.
+type RouteResolution struct {
+	// PhoneNumber Destination phone number
+	PhoneNumber string `json:"phone_number"`
+
+	// Prefix Number prefix that matched, absent for catch-all routes
+	Prefix *string `json:"prefix"`
+
+	// Providers Providers tried in order
+	Providers []string `json:"providers"`
+
+	// Route Name of the matched route
+	Route string `json:"route"`
+}
+
+// SandboxRequest defines model for SandboxRequest.
+type SandboxRequest struct {
+	// Body Request body as it would have been sent
+	Body string `json:"body"`
+
+	// CreatedAt Time the request was recorded
+	CreatedAt time.Time `json:"created_at"`
+
+	// ExternalId Synthetic message ID returned instead of the provider's
+	ExternalId string `json:"external_id"`
+
+	// Headers Request headers with credentials redacted
+	Headers map[string]string `json:"headers"`
+
+	// Id Recorded request identifier
+	Id int64 `json:"id"`
+
+	// MessageId Message the request was built for
+	MessageId int64 `json:"message_id"`
+
+	// Method HTTP method, empty for providers not using HTTP
+	Method string `json:"method"`
+
+	// Provider Provider that built the request
+	Provider string `json:"provider"`
+
+	// Url Gateway URL the request would have been sent to
+	Url string `json:"url"`
+}
+
+// SandboxRequestListResponse defines model for SandboxRequestListResponse.
+type SandboxRequestListResponse struct {
+	Requests []SandboxRequest `json:"requests"`
+}
+
 // SchedulerResponse defines model for SchedulerResponse.
 type SchedulerResponse struct {
+	// BatchSize Number of messages claimed by the next batch
+	BatchSize *int `json:"batch_size,omitempty"`
+
 	// Message Status message
 	Message string `json:"message"`
 
@@ -168,6 +474,72 @@ type SchedulerResponse struct {
 // SchedulerResponseStatus Current status of the scheduler
 type SchedulerResponseStatus string
 
+// StatusChange defines model for StatusChange.
+type StatusChange struct {
+	// ChangedAt Time of the change
+	ChangedAt time.Time `json:"changed_at"`
+
+	// Error Error recorded with the change
+	Error *string `json:"error"`
+
+	// FromStatus Previous status, empty for the initial status
+	FromStatus *MessageStatus `json:"from_status"`
+
+	// ToStatus New status
+	ToStatus MessageStatus `json:"to_status"`
+}
+
+// Subscription defines model for Subscription.
+type Subscription struct {
+	// CreatedAt Timestamp when the subscription was created
+	CreatedAt  time.Time   `json:"created_at"`
+	EventTypes []EventType `json:"event_types"`
+
+	// Id Unique subscription identifier
+	Id int64 `json:"id"`
+
+	// Secret Signing secret, only returned when the subscription is created
+	Secret *string `json:"secret,omitempty"`
+
+	// Url URL receiving the events
+	Url string `json:"url"`
+}
+
+// SubscriptionListResponse defines model for SubscriptionListResponse.
+type SubscriptionListResponse struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+// StreamEventsParams defines parameters for StreamEvents.
+type StreamEventsParams struct {
+	// Campaign Only stream message events of these campaigns
+	Campaign *[]string `form:"campaign,omitempty" json:"campaign,omitempty"`
+
+	// Status Only stream message events with these statuses
+	Status *[]string `form:"status,omitempty" json:"status,omitempty"`
+
+	// LastEventId Resume after this event ID, for clients that cannot set the Last-Event-ID header
+	LastEventId *string `form:"last_event_id,omitempty" json:"last_event_id,omitempty"`
+
+	// LastEventID Resume after this event ID; takes precedence over last_event_id
+	LastEventID *string `json:"Last-Event-ID,omitempty"`
+}
+
+// StreamEventsWebSocketParams defines parameters for StreamEventsWebSocket.
+type StreamEventsWebSocketParams struct {
+	// Campaign Only stream message events of these campaigns
+	Campaign *[]string `form:"campaign,omitempty" json:"campaign,omitempty"`
+
+	// Status Only stream message events with these statuses
+	Status *[]string `form:"status,omitempty" json:"status,omitempty"`
+
+	// LastEventId Resume after this event ID, for clients that cannot set the Last-Event-ID header
+	LastEventId *string `form:"last_event_id,omitempty" json:"last_event_id,omitempty"`
+
+	// LastEventID Resume after this event ID; takes precedence over last_event_id
+	LastEventID *string `json:"Last-Event-ID,omitempty"`
+}
+
 // GetSentMessagesParams defines parameters for GetSentMessages.
 type GetSentMessagesParams struct {
 	// Page Page number for pagination
@@ -177,38 +549,176 @@ type GetSentMessagesParams struct {
 	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
 }
 
+// ResolveRouteParams defines parameters for ResolveRoute.
+type ResolveRouteParams struct {
+	// PhoneNumber Destination phone number
+	PhoneNumber string `form:"phone_number" json:"phone_number"`
+
+	// Priority Message priority
+	Priority *int `form:"priority,omitempty" json:"priority,omitempty"`
+
+	// Campaign Campaign the message belongs to
+	Campaign *string `form:"campaign,omitempty" json:"campaign,omitempty"`
+}
+
+// GetSandboxRequestsParams defines parameters for GetSandboxRequests.
+type GetSandboxRequestsParams struct {
+	// MessageId Only return the requests of this message
+	MessageId *int64 `form:"message_id,omitempty" json:"message_id,omitempty"`
+
+	// Limit Maximum number of requests to return
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetDeadLettersParams defines parameters for GetDeadLetters.
+type GetDeadLettersParams struct {
+	// Limit Maximum number of events to return
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// HandleDeliveryReceiptJSONRequestBody defines body for HandleDeliveryReceipt for application/json ContentType.
+type HandleDeliveryReceiptJSONRequestBody = DeliveryReceipt
+
+// CreateMessageJSONRequestBody defines body for CreateMessage for application/json ContentType.
+type CreateMessageJSONRequestBody = CreateMessageRequest
+
+// CreateSubscriptionJSONRequestBody defines body for CreateSubscription for application/json ContentType.
+type CreateSubscriptionJSONRequestBody = CreateSubscriptionRequest
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Receive a delivery receipt
+	// (POST /callbacks/delivery)
+	HandleDeliveryReceipt(w http.ResponseWriter, r *http.Request)
+	// Stream live events
+	// (GET /events/stream)
+	StreamEvents(w http.ResponseWriter, r *http.Request, params StreamEventsParams)
+	// Stream live events over WebSocket
+	// (GET /events/ws)
+	StreamEventsWebSocket(w http.ResponseWriter, r *http.Request, params StreamEventsWebSocketParams)
 	// Health check endpoint
 	// (GET /health)
 	HealthCheck(w http.ResponseWriter, r *http.Request)
+	// Queue a message
+	// (POST /messages)
+	CreateMessage(w http.ResponseWriter, r *http.Request)
+	// Find a message by provider message ID
+	// (GET /messages/by-external-id/{messageId})
+	GetMessageByExternalId(w http.ResponseWriter, r *http.Request, messageId string)
 	// Get list of sent messages
 	// (GET /messages/sent)
 	GetSentMessages(w http.ResponseWriter, r *http.Request, params GetSentMessagesParams)
+	// Get delivery attempts of a message
+	// (GET /messages/{id}/attempts)
+	GetMessageAttempts(w http.ResponseWriter, r *http.Request, id int64)
+	// Get status history of a message
+	// (GET /messages/{id}/history)
+	GetMessageHistory(w http.ResponseWriter, r *http.Request, id int64)
+	// Get per-provider delivery statistics
+	// (GET /providers/stats)
+	GetProviderStats(w http.ResponseWriter, r *http.Request)
+	// Resolve the route for a destination
+	// (GET /routing/resolve)
+	ResolveRoute(w http.ResponseWriter, r *http.Request, params ResolveRouteParams)
+	// List requests recorded in sandbox mode
+	// (GET /sandbox/requests)
+	GetSandboxRequests(w http.ResponseWriter, r *http.Request, params GetSandboxRequestsParams)
 	// Start automatic message sending
 	// (POST /scheduler/start)
 	StartScheduler(w http.ResponseWriter, r *http.Request)
 	// Stop automatic message sending
 	// (POST /scheduler/stop)
 	StopScheduler(w http.ResponseWriter, r *http.Request)
+	// List subscriptions
+	// (GET /subscriptions)
+	ListSubscriptions(w http.ResponseWriter, r *http.Request)
+	// Subscribe to events
+	// (POST /subscriptions)
+	CreateSubscription(w http.ResponseWriter, r *http.Request)
+	// Delete a subscription
+	// (DELETE /subscriptions/{id})
+	DeleteSubscription(w http.ResponseWriter, r *http.Request, id int64)
+	// Get dead-lettered events of a subscription
+	// (GET /subscriptions/{id}/dead-letters)
+	GetDeadLetters(w http.ResponseWriter, r *http.Request, id int64, params GetDeadLettersParams)
 }
 
 // Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
 
 type Unimplemented struct{}
 
+// Receive a delivery receipt
+// (POST /callbacks/delivery)
+func (_ Unimplemented) HandleDeliveryReceipt(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Stream live events
+// (GET /events/stream)
+func (_ Unimplemented) StreamEvents(w http.ResponseWriter, r *http.Request, params StreamEventsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Stream live events over WebSocket
+// (GET /events/ws)
+func (_ Unimplemented) StreamEventsWebSocket(w http.ResponseWriter, r *http.Request, params StreamEventsWebSocketParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Health check endpoint
 // (GET /health)
 func (_ Unimplemented) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// Queue a message
+// (POST /messages)
+func (_ Unimplemented) CreateMessage(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Find a message by provider message ID
+// (GET /messages/by-external-id/{messageId})
+func (_ Unimplemented) GetMessageByExternalId(w http.ResponseWriter, r *http.Request, messageId string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Get list of sent messages
 // (GET /messages/sent)
 func (_ Unimplemented) GetSentMessages(w http.ResponseWriter, r *http.Request, params GetSentMessagesParams) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// Get delivery attempts of a message
+// (GET /messages/{id}/attempts)
+func (_ Unimplemented) GetMessageAttempts(w http.ResponseWriter, r *http.Request, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get status history of a message
+// (GET /messages/{id}/history)
+func (_ Unimplemented) GetMessageHistory(w http.ResponseWriter, r *http.Request, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get per-provider delivery statistics
+// (GET /providers/stats)
+func (_ Unimplemented) GetProviderStats(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Resolve the route for a destination
+// (GET /routing/resolve)
+func (_ Unimplemented) ResolveRoute(w http.ResponseWriter, r *http.Request, params ResolveRouteParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// List requests recorded in sandbox mode
+// (GET /sandbox/requests)
+func (_ Unimplemented) GetSandboxRequests(w http.ResponseWriter, r *http.Request, params GetSandboxRequestsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Start automatic message sending
 // (POST /scheduler/start)
 func (_ Unimplemented) StartScheduler(w http.ResponseWriter, r *http.Request) {
@@ -221,6 +731,30 @@ func (_ Unimplemented) StopScheduler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// List subscriptions
+// (GET /subscriptions)
+func (_ Unimplemented) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Subscribe to events
+// (POST /subscriptions)
+func (_ Unimplemented) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Delete a subscription
+// (DELETE /subscriptions/{id})
+func (_ Unimplemented) DeleteSubscription(w http.ResponseWriter, r *http.Request, id int64) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get dead-lettered events of a subscription
+// (GET /subscriptions/{id}/dead-letters)
+func (_ Unimplemented) GetDeadLetters(w http.ResponseWriter, r *http.Request, id int64, params GetDeadLettersParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // ServerInterfaceWrapper converts contexts to parameters.
 type ServerInterfaceWrapper struct {
 	Handler            ServerInterface
@@ -230,6 +764,148 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(http.Handler) http.Handler
 
+// HandleDeliveryReceipt operation middleware
+func (siw *ServerInterfaceWrapper) HandleDeliveryReceipt(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.HandleDeliveryReceipt(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// StreamEvents operation middleware
+func (siw *ServerInterfaceWrapper) StreamEvents(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params StreamEventsParams
+
+	// ------------- Optional query parameter "campaign" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "campaign", r.URL.Query(), &params.Campaign)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "campaign", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "last_event_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "last_event_id", r.URL.Query(), &params.LastEventId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "last_event_id", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Last-Event-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Last-Event-ID")]; found {
+		var LastEventID string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Last-Event-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Last-Event-ID", valueList[0], &LastEventID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Last-Event-ID", Err: err})
+			return
+		}
+
+		params.LastEventID = &LastEventID
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.StreamEvents(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// StreamEventsWebSocket operation middleware
+func (siw *ServerInterfaceWrapper) StreamEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params StreamEventsWebSocketParams
+
+	// ------------- Optional query parameter "campaign" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "campaign", r.URL.Query(), &params.Campaign)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "campaign", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "last_event_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "last_event_id", r.URL.Query(), &params.LastEventId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "last_event_id", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Last-Event-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Last-Event-ID")]; found {
+		var LastEventID string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Last-Event-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Last-Event-ID", valueList[0], &LastEventID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Last-Event-ID", Err: err})
+			return
+		}
+
+		params.LastEventID = &LastEventID
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.StreamEventsWebSocket(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // HealthCheck operation middleware
 func (siw *ServerInterfaceWrapper) HealthCheck(w http.ResponseWriter, r *http.Request) {
 
@@ -244,6 +920,45 @@ func (siw *ServerInterfaceWrapper) HealthCheck(w http.ResponseWriter, r *http.Re
 	handler.ServeHTTP(w, r)
 }
 
+// CreateMessage operation middleware
+func (siw *ServerInterfaceWrapper) CreateMessage(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateMessage(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetMessageByExternalId operation middleware
+func (siw *ServerInterfaceWrapper) GetMessageByExternalId(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "messageId" -------------
+	var messageId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "messageId", chi.URLParam(r, "messageId"), &messageId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "messageId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetMessageByExternalId(w, r, messageId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // GetSentMessages operation middleware
 func (siw *ServerInterfaceWrapper) GetSentMessages(w http.ResponseWriter, r *http.Request) {
 
@@ -279,6 +994,155 @@ func (siw *ServerInterfaceWrapper) GetSentMessages(w http.ResponseWriter, r *htt
 	handler.ServeHTTP(w, r)
 }
 
+// GetMessageAttempts operation middleware
+func (siw *ServerInterfaceWrapper) GetMessageAttempts(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetMessageAttempts(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetMessageHistory operation middleware
+func (siw *ServerInterfaceWrapper) GetMessageHistory(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetMessageHistory(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetProviderStats operation middleware
+func (siw *ServerInterfaceWrapper) GetProviderStats(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetProviderStats(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ResolveRoute operation middleware
+func (siw *ServerInterfaceWrapper) ResolveRoute(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ResolveRouteParams
+
+	// ------------- Required query parameter "phone_number" -------------
+
+	if paramValue := r.URL.Query().Get("phone_number"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "phone_number"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "phone_number", r.URL.Query(), &params.PhoneNumber)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "phone_number", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "priority" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "priority", r.URL.Query(), &params.Priority)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "priority", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "campaign" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "campaign", r.URL.Query(), &params.Campaign)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "campaign", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ResolveRoute(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetSandboxRequests operation middleware
+func (siw *ServerInterfaceWrapper) GetSandboxRequests(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetSandboxRequestsParams
+
+	// ------------- Optional query parameter "message_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "message_id", r.URL.Query(), &params.MessageId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "message_id", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetSandboxRequests(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // StartScheduler operation middleware
 func (siw *ServerInterfaceWrapper) StartScheduler(w http.ResponseWriter, r *http.Request) {
 
@@ -307,6 +1171,95 @@ func (siw *ServerInterfaceWrapper) StopScheduler(w http.ResponseWriter, r *http.
 	handler.ServeHTTP(w, r)
 }
 
+// ListSubscriptions operation middleware
+func (siw *ServerInterfaceWrapper) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListSubscriptions(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateSubscription operation middleware
+func (siw *ServerInterfaceWrapper) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateSubscription(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteSubscription operation middleware
+func (siw *ServerInterfaceWrapper) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteSubscription(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDeadLetters operation middleware
+func (siw *ServerInterfaceWrapper) GetDeadLetters(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetDeadLettersParams
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDeadLetters(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 type UnescapedCookieParamError struct {
 	ParamName string
 	Err       error
@@ -420,18 +1373,60 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/callbacks/delivery", wrapper.HandleDeliveryReceipt)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/events/stream", wrapper.StreamEvents)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/events/ws", wrapper.StreamEventsWebSocket)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/health", wrapper.HealthCheck)
 	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/messages", wrapper.CreateMessage)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/messages/by-external-id/{messageId}", wrapper.GetMessageByExternalId)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/messages/sent", wrapper.GetSentMessages)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/messages/{id}/attempts", wrapper.GetMessageAttempts)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/messages/{id}/history", wrapper.GetMessageHistory)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/providers/stats", wrapper.GetProviderStats)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/routing/resolve", wrapper.ResolveRoute)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/sandbox/requests", wrapper.GetSandboxRequests)
+	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/scheduler/start", wrapper.StartScheduler)
 	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/scheduler/stop", wrapper.StopScheduler)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/subscriptions", wrapper.ListSubscriptions)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/subscriptions", wrapper.CreateSubscription)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/subscriptions/{id}", wrapper.DeleteSubscription)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/subscriptions/{id}/dead-letters", wrapper.GetDeadLetters)
+	})
 
 	return r
 }
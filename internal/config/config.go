@@ -3,17 +3,30 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/spf13/viper"
 )
 
+// DefaultProviderName is the name and type of the provider derived from
+// the webhook section when no providers are configured explicitly.
+const DefaultProviderName = "webhook"
+
 type Config struct {
 	Server     ServerConfig     `mapstructure:"server"`
 	Database   DatabaseConfig   `mapstructure:"database"`
 	Redis      RedisConfig      `mapstructure:"redis"`
 	Webhook    WebhookConfig    `mapstructure:"webhook"`
+	Providers  []ProviderConfig `mapstructure:"providers"`
+	Routing    RoutingConfig    `mapstructure:"routing"`
 	Scheduler  SchedulerConfig  `mapstructure:"scheduler"`
 	Middleware MiddlewareConfig `mapstructure:"middleware"`
+	Callbacks  CallbacksConfig  `mapstructure:"callbacks"`
+	Events     EventsConfig     `mapstructure:"events"`
+	Stream     StreamConfig     `mapstructure:"stream"`
+	Queue      QueueConfig      `mapstructure:"queue"`
+	Sandbox    SandboxConfig    `mapstructure:"sandbox"`
 }
 
 type ServerConfig struct {
@@ -42,20 +55,234 @@ type WebhookConfig struct {
 	URL            string               `mapstructure:"url"`
 	AuthKey        string               `mapstructure:"auth_key"`
 	Timeout        int                  `mapstructure:"timeout"`
+	Provider       string               `mapstructure:"provider"`
+	Signing        SigningConfig        `mapstructure:"signing"`
+	Transport      TransportConfig      `mapstructure:"transport"`
 	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
 }
 
+// SigningConfig enables HMAC-SHA256 signing of outgoing requests. Every
+// secret produces a signature so receivers can rotate keys without downtime.
+type SigningConfig struct {
+	Secrets []string `mapstructure:"secrets"`
+}
+
+// TransportConfig configures the HTTP transport used to reach a gateway.
+// Zero values keep the Go defaults; certificate files are reloaded when
+// they change on disk.
+type TransportConfig struct {
+	// CAFile is a PEM bundle replacing the system roots.
+	CAFile string `mapstructure:"ca_file"`
+	// CertFile and KeyFile hold the client certificate for mutual TLS.
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// MinTLSVersion is one of "1.0", "1.1", "1.2" (default) or "1.3".
+	MinTLSVersion string `mapstructure:"min_tls_version"`
+	// ProxyURL overrides the HTTP(S)_PROXY environment variables.
+	ProxyURL            string `mapstructure:"proxy_url"`
+	MaxIdleConns        int    `mapstructure:"max_idle_conns"`
+	MaxIdleConnsPerHost int    `mapstructure:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int    `mapstructure:"max_conns_per_host"`
+	// IdleConnTimeout and KeepAlive are in seconds.
+	IdleConnTimeout   int  `mapstructure:"idle_conn_timeout"`
+	KeepAlive         int  `mapstructure:"keep_alive"`
+	DisableKeepAlives bool `mapstructure:"disable_keep_alives"`
+}
+
+// ProviderConfig describes a single delivery provider instance.
+// Type selects the implementation from the provider registry.
+type ProviderConfig struct {
+	Name    string        `mapstructure:"name"`
+	Type    string        `mapstructure:"type"`
+	URL     string        `mapstructure:"url"`
+	AuthKey string        `mapstructure:"auth_key"`
+	Timeout int           `mapstructure:"timeout"`
+	Signing SigningConfig `mapstructure:"signing"`
+	// Transport falls back to webhook.transport when not set.
+	Transport TransportConfig `mapstructure:"transport"`
+	// HTTP maps messages to requests for providers of type "http".
+	HTTP HTTPMappingConfig `mapstructure:"http"`
+}
+
+// HTTPMappingConfig describes the request and response format of a
+// generic HTTP gateway.
+type HTTPMappingConfig struct {
+	// Method defaults to POST.
+	Method string `mapstructure:"method"`
+	// Format is "json" (default) or "form" and selects the content type.
+	Format string `mapstructure:"format"`
+	// Body is a text/template rendered with the message fields To,
+	// Content, ID and Campaign. The json and urlquery functions escape
+	// values for the respective format.
+	Body string `mapstructure:"body"`
+	// Headers are sent with every request.
+	Headers map[string]string `mapstructure:"headers"`
+	// SuccessCodes lists the accepted status codes, 200, 201 and 202 by
+	// default.
+	SuccessCodes []int `mapstructure:"success_codes"`
+	// IDPath and ErrorPath are JSONPath expressions, e.g. "$.data.id",
+	// locating the external message ID and error text in responses.
+	IDPath    string `mapstructure:"id_path"`
+	ErrorPath string `mapstructure:"error_path"`
+	// Lookup enables status queries used to reconcile messages accepted
+	// without a usable ID.
+	Lookup HTTPLookupConfig `mapstructure:"lookup"`
+}
+
+// HTTPLookupConfig describes the status query of a generic HTTP gateway.
+// Lookups are disabled while URL is empty.
+type HTTPLookupConfig struct {
+	// URL is a text/template rendered with the same fields as the body.
+	URL string `mapstructure:"url"`
+	// Method defaults to GET.
+	Method string `mapstructure:"method"`
+	// IDPath, StatusPath and ErrorPath locate the external ID, the gateway
+	// status and the error text in the response.
+	IDPath     string `mapstructure:"id_path"`
+	StatusPath string `mapstructure:"status_path"`
+	ErrorPath  string `mapstructure:"error_path"`
+	// Statuses maps gateway statuses (case-insensitive) to sent, delivered,
+	// undelivered or failed. Unmapped statuses are treated as pending.
+	Statuses map[string]string `mapstructure:"statuses"`
+}
+
 type CircuitBreakerConfig struct {
 	MaxRequests      uint32  `mapstructure:"max_requests"`
 	Interval         int     `mapstructure:"interval"`
 	Timeout          int     `mapstructure:"timeout"`
 	FailureRatio     float64 `mapstructure:"failure_ratio"`
 	ConsecutiveFails uint32  `mapstructure:"consecutive_fails"`
+	// PerDestination keeps a separate breaker for every destination country
+	// of a provider instead of one breaker per provider.
+	PerDestination bool `mapstructure:"per_destination"`
+	// Overrides adjust the settings for specific providers or countries.
+	Overrides []CircuitBreakerOverride `mapstructure:"overrides"`
+}
+
+// CircuitBreakerOverride replaces the non-zero settings for breakers that
+// match Provider and Country. An empty Provider or Country matches any.
+type CircuitBreakerOverride struct {
+	Provider         string  `mapstructure:"provider"`
+	Country          string  `mapstructure:"country"`
+	MaxRequests      uint32  `mapstructure:"max_requests"`
+	Interval         int     `mapstructure:"interval"`
+	Timeout          int     `mapstructure:"timeout"`
+	FailureRatio     float64 `mapstructure:"failure_ratio"`
+	ConsecutiveFails uint32  `mapstructure:"consecutive_fails"`
+}
+
+func (o CircuitBreakerOverride) matches(provider, country string) bool {
+	return (o.Provider == "" || o.Provider == provider) &&
+		(o.Country == "" || strings.TrimPrefix(o.Country, "+") == country)
+}
+
+func (o CircuitBreakerOverride) specificity() int {
+	n := 0
+	if o.Provider != "" {
+		n++
+	}
+	if o.Country != "" {
+		n++
+	}
+	return n
+}
+
+// For returns the settings of the breaker guarding provider for the given
+// country code. Matching overrides are applied from the least to the most
+// specific, so a provider and country override wins over a provider one.
+func (c CircuitBreakerConfig) For(provider, country string) CircuitBreakerConfig {
+	result := c
+	result.Overrides = nil
+
+	for level := 0; level <= 2; level++ {
+		for _, o := range c.Overrides {
+			if o.specificity() != level || !o.matches(provider, country) {
+				continue
+			}
+			if o.MaxRequests != 0 {
+				result.MaxRequests = o.MaxRequests
+			}
+			if o.Interval != 0 {
+				result.Interval = o.Interval
+			}
+			if o.Timeout != 0 {
+				result.Timeout = o.Timeout
+			}
+			if o.FailureRatio != 0 {
+				result.FailureRatio = o.FailureRatio
+			}
+			if o.ConsecutiveFails != 0 {
+				result.ConsecutiveFails = o.ConsecutiveFails
+			}
+		}
+	}
+
+	return result
+}
+
+// RoutingConfig controls how messages are routed across providers.
+type RoutingConfig struct {
+	// Failover is the ordered list of provider names tried for each message.
+	Failover []string `mapstructure:"failover"`
+	// Split distributes traffic of the default route across providers.
+	Split []WeightedProvider `mapstructure:"split"`
+	// Routes override the failover list for matching destinations.
+	Routes []RouteConfig `mapstructure:"routes"`
+}
+
+// WeightedProvider assigns a share of a route's traffic to a provider.
+type WeightedProvider struct {
+	Provider string `mapstructure:"provider"`
+	Weight   int    `mapstructure:"weight"`
+}
+
+// RouteConfig maps destinations to an ordered list of providers. A route
+// matches numbers starting with one of its prefixes (longest prefix wins)
+// and, when set, only messages with the given priority or campaign.
+// With Split set, each recipient is pinned to one provider by weight and
+// the remaining providers are used for failover.
+type RouteConfig struct {
+	Name      string             `mapstructure:"name"`
+	Prefixes  []string           `mapstructure:"prefixes"`
+	Priority  *int               `mapstructure:"priority"`
+	Campaign  string             `mapstructure:"campaign"`
+	Providers []string           `mapstructure:"providers"`
+	Split     []WeightedProvider `mapstructure:"split"`
 }
 
 type SchedulerConfig struct {
 	IntervalMinutes int `mapstructure:"interval_minutes"`
 	BatchSize       int `mapstructure:"batch_size"`
+	// ReconcileIntervalMinutes controls how often unconfirmed messages are
+	// looked up at their providers; 0 disables reconciliation.
+	ReconcileIntervalMinutes int `mapstructure:"reconcile_interval_minutes"`
+	ReconcileBatchSize       int `mapstructure:"reconcile_batch_size"`
+	// Notify wakes the scheduler through Postgres notifications as soon as
+	// messages are queued; the interval remains as a fallback.
+	Notify bool `mapstructure:"notify"`
+	// DebounceMs is how long a woken scheduler waits for further messages
+	// before sending, in milliseconds.
+	DebounceMs int `mapstructure:"debounce_ms"`
+	// DrainTimeout is how long shutdown waits for in-flight sends, in
+	// seconds. Sends still running afterwards are cancelled.
+	DrainTimeout int `mapstructure:"drain_timeout"`
+	// FlushSize is how many send outcomes are written in one statement; 1
+	// or less writes every outcome on its own.
+	FlushSize int `mapstructure:"flush_size"`
+	// FlushIntervalMs bounds how long outcomes wait to be written while a
+	// batch is sent, in milliseconds.
+	FlushIntervalMs int `mapstructure:"flush_interval_ms"`
+	// AdaptiveBatch resizes batches between MinBatchSize and MaxBatchSize
+	// after every run, starting from BatchSize.
+	AdaptiveBatch bool `mapstructure:"adaptive_batch"`
+	MinBatchSize  int  `mapstructure:"min_batch_size"`
+	MaxBatchSize  int  `mapstructure:"max_batch_size"`
+	// TargetLatencyMs is the average send time of a batch above which
+	// batches shrink, in milliseconds.
+	TargetLatencyMs int `mapstructure:"target_latency_ms"`
+	// MaxErrorRate is the share of failed sends in a batch above which
+	// batches shrink.
+	MaxErrorRate float64 `mapstructure:"max_error_rate"`
 }
 
 type MiddlewareConfig struct {
@@ -65,6 +292,96 @@ type MiddlewareConfig struct {
 	AllowedOrigins []string `mapstructure:"allowed_origins"`
 }
 
+// CallbacksConfig controls verification of inbound provider callbacks.
+// While no providers are listed, callbacks are rejected unless
+// AllowUnverified is set.
+type CallbacksConfig struct {
+	// Tolerance is the maximum age of a callback timestamp in seconds.
+	Tolerance       int                      `mapstructure:"tolerance"`
+	AllowUnverified bool                     `mapstructure:"allow_unverified"`
+	Providers       []CallbackProviderConfig `mapstructure:"providers"`
+}
+
+// CallbackProviderConfig lists the verification schemes of a provider's
+// callbacks. Every configured scheme must pass.
+type CallbackProviderConfig struct {
+	Name       string   `mapstructure:"name"`
+	Secrets    []string `mapstructure:"secrets"`
+	Token      string   `mapstructure:"token"`
+	AllowedIPs []string `mapstructure:"allowed_ips"`
+}
+
+// EventsConfig controls delivery of events to subscriptions. Failed
+// deliveries are retried with exponential backoff until MaxAttempts is
+// reached and the event becomes a dead letter.
+type EventsConfig struct {
+	// DispatchInterval is the time between outbox polls in seconds.
+	DispatchInterval int `mapstructure:"dispatch_interval"`
+	BatchSize        int `mapstructure:"batch_size"`
+	// Timeout is the request timeout in seconds.
+	Timeout     int `mapstructure:"timeout"`
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// Backoff is the delay before the first retry and MaxBackoff the
+	// upper bound of the delay, both in seconds.
+	Backoff    int `mapstructure:"backoff"`
+	MaxBackoff int `mapstructure:"max_backoff"`
+}
+
+// StreamConfig controls the live event stream.
+type StreamConfig struct {
+	// MaxLen is the approximate number of events kept for clients
+	// resuming with a Last-Event-ID.
+	MaxLen int64 `mapstructure:"max_len"`
+	// AllowedOrigins are the browser origins besides the API's own that
+	// may open WebSocket streams; "*" allows any.
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+}
+
+// Queue backends.
+const (
+	QueueBackendPostgres = "postgres-poll"
+	QueueBackendRedis    = "redis-stream"
+)
+
+// QueueConfig selects how queued messages reach the senders.
+type QueueConfig struct {
+	// Backend is "postgres-poll" (default), claiming queued rows directly,
+	// or "redis-stream", reading message IDs from a Redis stream with a
+	// consumer group.
+	Backend string `mapstructure:"backend"`
+	// Stream and Group name the Redis stream and its consumer group.
+	Stream string `mapstructure:"stream"`
+	Group  string `mapstructure:"group"`
+	// Consumer names this instance in the group, the host name by default.
+	Consumer string `mapstructure:"consumer"`
+	// ClaimIdle is the time in seconds after which claims of a sender
+	// that stopped are taken over: processing rows on postgres-poll,
+	// pending entries on redis-stream. Queued messages missing from the
+	// stream are added again after it. It has to outlast a batch, that is
+	// the scheduler interval; LoadConfig rejects shorter values.
+	ClaimIdle int `mapstructure:"claim_idle"`
+	// MaxLen caps the entries of the redis-stream backend. Messages
+	// queued while it is full are added by the sweep after ClaimIdle.
+	// Zero means no limit.
+	MaxLen int64 `mapstructure:"max_len"`
+}
+
+// ConsumerName returns Consumer, or the host name when it is empty.
+func (c QueueConfig) ConsumerName() (string, error) {
+	if c.Consumer != "" {
+		return c.Consumer, nil
+	}
+	return os.Hostname()
+}
+
+// SandboxConfig switches delivery to sandbox mode.
+type SandboxConfig struct {
+	// Enabled replaces sending with recording: every provider builds its
+	// request as usual, stores it and reports a synthetic message ID.
+	// Routing, circuit breakers and status transitions are unchanged.
+	Enabled bool `mapstructure:"enabled"`
+}
+
 func LoadConfig(configPath string) (*Config, error) {
 	viper.SetConfigFile(configPath)
 	viper.SetConfigType("yaml")
@@ -82,10 +399,37 @@ func LoadConfig(configPath string) (*Config, error) {
 	viper.SetDefault("webhook.circuit_breaker.consecutive_fails", 5)
 	viper.SetDefault("scheduler.interval_minutes", 2)
 	viper.SetDefault("scheduler.batch_size", 2)
+	viper.SetDefault("scheduler.reconcile_interval_minutes", 5)
+	viper.SetDefault("scheduler.reconcile_batch_size", 50)
+	viper.SetDefault("scheduler.notify", true)
+	viper.SetDefault("scheduler.debounce_ms", 200)
+	viper.SetDefault("scheduler.drain_timeout", 30)
+	viper.SetDefault("scheduler.flush_size", 50)
+	viper.SetDefault("scheduler.flush_interval_ms", 1000)
+	viper.SetDefault("scheduler.adaptive_batch", true)
+	viper.SetDefault("scheduler.min_batch_size", 1)
+	viper.SetDefault("scheduler.max_batch_size", 100)
+	viper.SetDefault("scheduler.target_latency_ms", 500)
+	viper.SetDefault("scheduler.max_error_rate", 0.1)
 	viper.SetDefault("middleware.rate_limit", 100)
 	viper.SetDefault("middleware.rate_limit_burst", 1000)
 	viper.SetDefault("middleware.enable_cors", true)
 	viper.SetDefault("middleware.allowed_origins", []string{"*"})
+	viper.SetDefault("callbacks.tolerance", 300)
+	viper.SetDefault("callbacks.allow_unverified", false)
+	viper.SetDefault("events.dispatch_interval", 5)
+	viper.SetDefault("events.batch_size", 50)
+	viper.SetDefault("events.timeout", 10)
+	viper.SetDefault("events.max_attempts", 10)
+	viper.SetDefault("events.backoff", 10)
+	viper.SetDefault("events.max_backoff", 3600)
+	viper.SetDefault("stream.max_len", 10000)
+	viper.SetDefault("queue.backend", QueueBackendPostgres)
+	viper.SetDefault("queue.stream", "queue:messages")
+	viper.SetDefault("queue.group", "senders")
+	viper.SetDefault("queue.claim_idle", 300)
+	viper.SetDefault("queue.max_len", 100000)
+	viper.SetDefault("sandbox.enabled", false)
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
@@ -98,9 +442,65 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	// A lease ending within a batch lets another sender claim and send
+	// the messages of a sender that is still running.
+	if config.Queue.ClaimIdle <= config.Scheduler.IntervalMinutes*60 {
+		return nil, fmt.Errorf("queue.claim_idle (%ds) must exceed scheduler.interval_minutes (%dm)",
+			config.Queue.ClaimIdle, config.Scheduler.IntervalMinutes)
+	}
+
 	return &config, nil
 }
 
+// ProviderConfigs returns the configured delivery providers.
+// When no providers are listed, a single webhook provider is derived
+// from the webhook section so existing configurations keep working.
+func (c *Config) ProviderConfigs() []ProviderConfig {
+	if len(c.Providers) == 0 {
+		return []ProviderConfig{{
+			Name:      DefaultProviderName,
+			Type:      DefaultProviderName,
+			URL:       c.Webhook.URL,
+			AuthKey:   c.Webhook.AuthKey,
+			Timeout:   c.Webhook.Timeout,
+			Signing:   c.Webhook.Signing,
+			Transport: c.Webhook.Transport,
+		}}
+	}
+
+	providers := make([]ProviderConfig, len(c.Providers))
+	for i, p := range c.Providers {
+		if p.Timeout == 0 {
+			p.Timeout = c.Webhook.Timeout
+		}
+		if p.Transport == (TransportConfig{}) {
+			p.Transport = c.Webhook.Transport
+		}
+		providers[i] = p
+	}
+	return providers
+}
+
+// FailoverOrder returns the ordered provider names used for delivery.
+// It prefers routing.failover, then webhook.provider, and finally falls
+// back to every configured provider in declaration order.
+func (c *Config) FailoverOrder() []string {
+	if len(c.Routing.Failover) > 0 {
+		return c.Routing.Failover
+	}
+
+	if c.Webhook.Provider != "" {
+		return []string{c.Webhook.Provider}
+	}
+
+	configs := c.ProviderConfigs()
+	names := make([]string, len(configs))
+	for i, pc := range configs {
+		names[i] = pc.Name
+	}
+	return names
+}
+
 // GetDSN returns PostgreSQL connection string.
 func (d *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
@@ -2,22 +2,40 @@
 package handler
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-chi/render"
+	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 
 	"github.com/popeskul/insdr-messenger/internal/api"
 	"github.com/popeskul/insdr-messenger/internal/middleware"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/routing"
 	"github.com/popeskul/insdr-messenger/internal/scheduler"
 	"github.com/popeskul/insdr-messenger/internal/service"
+	"github.com/popeskul/insdr-messenger/internal/stream"
 )
 
 const (
 	errorCodeSchedulerAlreadyRunning = "SCHEDULER_ALREADY_RUNNING"
 	errorCodeSchedulerNotRunning     = "SCHEDULER_NOT_RUNNING"
+	errorCodeInvalidPhoneNumber      = "INVALID_PHONE_NUMBER"
+	errorCodeInvalidReceipt          = "INVALID_RECEIPT"
+	errorCodeMessageNotFound         = "MESSAGE_NOT_FOUND"
+	errorCodeInvalidTransition       = "INVALID_STATUS_TRANSITION"
+	errorCodeInvalidSubscription     = "INVALID_SUBSCRIPTION"
+	errorCodeSubscriptionNotFound    = "SUBSCRIPTION_NOT_FOUND"
+	errorCodeInvalidStreamRequest    = "INVALID_STREAM_REQUEST"
+	errorCodeInvalidMessage          = "INVALID_MESSAGE"
 )
 
 const (
@@ -26,23 +44,83 @@ const (
 	errorMessageFailedToStartScheduler   = "Failed to start scheduler"
 	errorMessageFailedToStopScheduler    = "Failed to stop scheduler"
 	errorMessageFailedToRetrieveMessages = "Failed to retrieve sent messages"
+	errorMessageInvalidPhoneNumber       = "Phone number must contain digits"
+	errorMessageInvalidReceipt           = "Receipt must contain a messageId and a delivered or undelivered status"
+	errorMessageMessageNotFound          = "Message not found"
+	errorMessageInvalidTransition        = "Message status does not accept this receipt"
+	errorMessageFailedToApplyReceipt     = "Failed to apply delivery receipt"
+	errorMessageFailedToRetrieveMessage  = "Failed to retrieve message"
+	errorMessageFailedToRetrieveHistory  = "Failed to retrieve message history"
+	errorMessageFailedToRetrieveAttempts = "Failed to retrieve delivery attempts"
+	errorMessageInvalidSubscription      = "Subscription must have an http or https url, known event types and a secret of at least 16 characters"
+	errorMessageSubscriptionNotFound     = "Subscription not found"
+	errorMessageFailedToCreateSub        = "Failed to create subscription"
+	errorMessageFailedToRetrieveSubs     = "Failed to retrieve subscriptions"
+	errorMessageFailedToDeleteSub        = "Failed to delete subscription"
+	errorMessageFailedToRetrieveDead     = "Failed to retrieve dead letters"
+	errorMessageInvalidStreamRequest     = "Statuses must be message statuses and the last event ID a stream event ID"
+	errorMessageFailedToOpenStream       = "Failed to open event stream"
+	errorMessageInvalidMessage           = "Message must have a phone number with digits and content of 1 to 160 characters"
+	errorMessageFailedToCreateMessage    = "Failed to create message"
+	errorMessageFailedToRetrieveSandbox  = "Failed to retrieve sandbox requests"
 )
 
+const (
+	// streamHeartbeat is the interval of keep-alive messages, below the
+	// idle timeout of common proxies.
+	streamHeartbeat = 15 * time.Second
+	// streamWriteWait bounds a single write to a WebSocket client.
+	streamWriteWait = 10 * time.Second
+)
+
+// checkOrigin accepts WebSocket connections from the origins in allowed,
+// "*" allowing any, and from the origin the API is served from. Clients
+// that send no Origin header are not browsers and are accepted.
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, allowedOrigin := range allowed {
+			if allowedOrigin == "*" || strings.EqualFold(allowedOrigin, origin) {
+				return true
+			}
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		return strings.EqualFold(u.Host, r.Host)
+	}
+}
+
+// streamMessage is a live event sent to WebSocket clients.
+type streamMessage struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
 const (
 	schedulerMessageStarted = "Scheduler started successfully"
 	schedulerMessageStopped = "Scheduler stopped successfully"
 )
 
 type Handler struct {
-	service *service.Service
-	logger  *zap.Logger
+	service  *service.Service
+	logger   *zap.Logger
+	upgrader websocket.Upgrader
 }
 
 // NewHandler creates a new handler instance that implements api.ServerInterface.
-func NewHandler(service *service.Service, logger *zap.Logger) api.ServerInterface {
+// WebSocket clients are accepted from allowedOrigins and the same origin.
+func NewHandler(service *service.Service, logger *zap.Logger, allowedOrigins []string) api.ServerInterface {
 	return &Handler{
-		service: service,
-		logger:  logger,
+		service:  service,
+		logger:   logger,
+		upgrader: websocket.Upgrader{CheckOrigin: checkOrigin(allowedOrigins)},
 	}
 }
 
@@ -64,9 +142,11 @@ func (h *Handler) StartScheduler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	batchSize := h.service.Message.BatchSize()
 	render.JSON(w, r, api.SchedulerResponse{
-		Status:  api.SchedulerResponseStatusStarted,
-		Message: schedulerMessageStarted,
+		Status:    api.SchedulerResponseStatusStarted,
+		Message:   schedulerMessageStarted,
+		BatchSize: &batchSize,
 	})
 }
 
@@ -88,12 +168,41 @@ func (h *Handler) StopScheduler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	batchSize := h.service.Message.BatchSize()
 	render.JSON(w, r, api.SchedulerResponse{
-		Status:  api.SchedulerResponseStatusStopped,
-		Message: schedulerMessageStopped,
+		Status:    api.SchedulerResponseStatusStopped,
+		Message:   schedulerMessageStopped,
+		BatchSize: &batchSize,
 	})
 }
 
+// CreateMessage implements api.ServerInterface.
+func (h *Handler) CreateMessage(w http.ResponseWriter, r *http.Request) {
+	var body api.CreateMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, errorCodeInvalidMessage, errorMessageInvalidMessage)
+		return
+	}
+
+	result, err := h.service.Message.CreateMessage(r.Context(), body)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMessage) {
+			h.sendError(w, r, http.StatusBadRequest, errorCodeInvalidMessage, errorMessageInvalidMessage)
+			return
+		}
+
+		requestID := middleware.GetRequestID(r.Context())
+		h.logger.Error("Failed to create message",
+			zap.String("request_id", requestID),
+			zap.Error(err))
+		h.sendError(w, r, http.StatusInternalServerError, middleware.ErrorCodeInternal, errorMessageFailedToCreateMessage)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, result)
+}
+
 // GetSentMessages implements api.ServerInterface.
 func (h *Handler) GetSentMessages(w http.ResponseWriter, r *http.Request, params api.GetSentMessagesParams) {
 	page := 1
@@ -107,7 +216,7 @@ func (h *Handler) GetSentMessages(w http.ResponseWriter, r *http.Request, params
 		limit = *params.Limit
 	}
 
-	result, err := h.service.Message.GetSentMessages(page, limit)
+	result, err := h.service.Message.GetSentMessages(r.Context(), page, limit)
 	if err != nil {
 		requestID := middleware.GetRequestID(r.Context())
 		h.logger.Error("Failed to get sent messages",
@@ -120,9 +229,433 @@ func (h *Handler) GetSentMessages(w http.ResponseWriter, r *http.Request, params
 	render.JSON(w, r, result)
 }
 
+// GetMessageByExternalId implements api.ServerInterface.
+func (h *Handler) GetMessageByExternalId(w http.ResponseWriter, r *http.Request, messageId string) {
+	result, err := h.service.Message.GetMessageByExternalID(r.Context(), messageId)
+	if err != nil {
+		if errors.Is(err, service.ErrMessageNotFound) {
+			h.sendError(w, r, http.StatusNotFound, errorCodeMessageNotFound, errorMessageMessageNotFound)
+			return
+		}
+
+		requestID := middleware.GetRequestID(r.Context())
+		h.logger.Error("Failed to get message by external ID",
+			zap.String("request_id", requestID),
+			zap.String("externalMessageID", messageId),
+			zap.Error(err))
+		h.sendError(w, r, http.StatusInternalServerError, middleware.ErrorCodeInternal, errorMessageFailedToRetrieveMessage)
+		return
+	}
+
+	render.JSON(w, r, result)
+}
+
+// GetMessageHistory implements api.ServerInterface.
+func (h *Handler) GetMessageHistory(w http.ResponseWriter, r *http.Request, id int64) {
+	result, err := h.service.Message.GetMessageHistory(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrMessageNotFound) {
+			h.sendError(w, r, http.StatusNotFound, errorCodeMessageNotFound, errorMessageMessageNotFound)
+			return
+		}
+
+		requestID := middleware.GetRequestID(r.Context())
+		h.logger.Error("Failed to get message history",
+			zap.String("request_id", requestID),
+			zap.Int64("messageID", id),
+			zap.Error(err))
+		h.sendError(w, r, http.StatusInternalServerError, middleware.ErrorCodeInternal, errorMessageFailedToRetrieveHistory)
+		return
+	}
+
+	render.JSON(w, r, result)
+}
+
+// GetMessageAttempts implements api.ServerInterface.
+func (h *Handler) GetMessageAttempts(w http.ResponseWriter, r *http.Request, id int64) {
+	result, err := h.service.Message.GetMessageAttempts(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrMessageNotFound) {
+			h.sendError(w, r, http.StatusNotFound, errorCodeMessageNotFound, errorMessageMessageNotFound)
+			return
+		}
+
+		requestID := middleware.GetRequestID(r.Context())
+		h.logger.Error("Failed to get delivery attempts",
+			zap.String("request_id", requestID),
+			zap.Int64("messageID", id),
+			zap.Error(err))
+		h.sendError(w, r, http.StatusInternalServerError, middleware.ErrorCodeInternal, errorMessageFailedToRetrieveAttempts)
+		return
+	}
+
+	render.JSON(w, r, result)
+}
+
+// ResolveRoute implements api.ServerInterface.
+func (h *Handler) ResolveRoute(w http.ResponseWriter, r *http.Request, params api.ResolveRouteParams) {
+	if routing.NormalizeNumber(params.PhoneNumber) == "" {
+		h.sendError(w, r, http.StatusBadRequest, errorCodeInvalidPhoneNumber, errorMessageInvalidPhoneNumber)
+		return
+	}
+
+	dest := routing.Destination{PhoneNumber: params.PhoneNumber}
+	if params.Priority != nil {
+		dest.Priority = *params.Priority
+	}
+	if params.Campaign != nil {
+		dest.Campaign = *params.Campaign
+	}
+
+	route := h.service.Message.ResolveRoute(dest)
+
+	response := api.RouteResolution{
+		PhoneNumber: params.PhoneNumber,
+		Route:       route.Name,
+		Providers:   route.Providers,
+	}
+	if route.Prefix != "" {
+		response.Prefix = &route.Prefix
+	}
+
+	render.JSON(w, r, response)
+}
+
+// HandleDeliveryReceipt implements api.ServerInterface.
+func (h *Handler) HandleDeliveryReceipt(w http.ResponseWriter, r *http.Request) {
+	var body api.DeliveryReceipt
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, errorCodeInvalidReceipt, errorMessageInvalidReceipt)
+		return
+	}
+
+	if body.MessageId == "" ||
+		(body.Status != api.DeliveryReceiptStatusDelivered && body.Status != api.DeliveryReceiptStatusUndelivered) {
+		h.sendError(w, r, http.StatusBadRequest, errorCodeInvalidReceipt, errorMessageInvalidReceipt)
+		return
+	}
+
+	receipt := service.DeliveryReceipt{
+		MessageID: body.MessageId,
+		Status:    api.MessageStatus(body.Status),
+		Error:     body.Error,
+	}
+	if body.Timestamp != nil {
+		receipt.Timestamp = *body.Timestamp
+	}
+
+	err := h.service.Message.HandleDeliveryReceipt(r.Context(), receipt)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMessageNotFound):
+			h.sendError(w, r, http.StatusNotFound, errorCodeMessageNotFound, errorMessageMessageNotFound)
+		case errors.Is(err, service.ErrInvalidStatusTransition):
+			h.sendError(w, r, http.StatusConflict, errorCodeInvalidTransition, errorMessageInvalidTransition)
+		default:
+			requestID := middleware.GetRequestID(r.Context())
+			h.logger.Error("Failed to apply delivery receipt",
+				zap.String("request_id", requestID),
+				zap.String("externalMessageID", body.MessageId),
+				zap.Error(err))
+			h.sendError(w, r, http.StatusInternalServerError, middleware.ErrorCodeInternal, errorMessageFailedToApplyReceipt)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateSubscription implements api.ServerInterface.
+func (h *Handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var body api.CreateSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, errorCodeInvalidSubscription, errorMessageInvalidSubscription)
+		return
+	}
+
+	result, err := h.service.Events.CreateSubscription(r.Context(), body)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSubscription) {
+			h.sendError(w, r, http.StatusBadRequest, errorCodeInvalidSubscription, errorMessageInvalidSubscription)
+			return
+		}
+
+		requestID := middleware.GetRequestID(r.Context())
+		h.logger.Error("Failed to create subscription",
+			zap.String("request_id", requestID),
+			zap.Error(err))
+		h.sendError(w, r, http.StatusInternalServerError, middleware.ErrorCodeInternal, errorMessageFailedToCreateSub)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, result)
+}
+
+// ListSubscriptions implements api.ServerInterface.
+func (h *Handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.Events.ListSubscriptions(r.Context())
+	if err != nil {
+		requestID := middleware.GetRequestID(r.Context())
+		h.logger.Error("Failed to list subscriptions",
+			zap.String("request_id", requestID),
+			zap.Error(err))
+		h.sendError(w, r, http.StatusInternalServerError, middleware.ErrorCodeInternal, errorMessageFailedToRetrieveSubs)
+		return
+	}
+
+	render.JSON(w, r, result)
+}
+
+// DeleteSubscription implements api.ServerInterface.
+func (h *Handler) DeleteSubscription(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := h.service.Events.DeleteSubscription(r.Context(), id); err != nil {
+		if errors.Is(err, service.ErrSubscriptionNotFound) {
+			h.sendError(w, r, http.StatusNotFound, errorCodeSubscriptionNotFound, errorMessageSubscriptionNotFound)
+			return
+		}
+
+		requestID := middleware.GetRequestID(r.Context())
+		h.logger.Error("Failed to delete subscription",
+			zap.String("request_id", requestID),
+			zap.Int64("subscriptionID", id),
+			zap.Error(err))
+		h.sendError(w, r, http.StatusInternalServerError, middleware.ErrorCodeInternal, errorMessageFailedToDeleteSub)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSandboxRequests implements api.ServerInterface.
+func (h *Handler) GetSandboxRequests(w http.ResponseWriter, r *http.Request, params api.GetSandboxRequestsParams) {
+	limit := 50
+	if params.Limit != nil && *params.Limit >= 1 && *params.Limit <= 500 {
+		limit = *params.Limit
+	}
+
+	var messageID int64
+	if params.MessageId != nil {
+		messageID = *params.MessageId
+	}
+
+	result, err := h.service.Message.GetSandboxRequests(r.Context(), messageID, limit)
+	if err != nil {
+		requestID := middleware.GetRequestID(r.Context())
+		h.logger.Error("Failed to get sandbox requests",
+			zap.String("request_id", requestID),
+			zap.Error(err))
+		h.sendError(w, r, http.StatusInternalServerError, middleware.ErrorCodeInternal, errorMessageFailedToRetrieveSandbox)
+		return
+	}
+
+	render.JSON(w, r, result)
+}
+
+// GetDeadLetters implements api.ServerInterface.
+func (h *Handler) GetDeadLetters(w http.ResponseWriter, r *http.Request, id int64, params api.GetDeadLettersParams) {
+	limit := 100
+	if params.Limit != nil && *params.Limit >= 1 && *params.Limit <= 500 {
+		limit = *params.Limit
+	}
+
+	result, err := h.service.Events.GetDeadLetters(r.Context(), id, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrSubscriptionNotFound) {
+			h.sendError(w, r, http.StatusNotFound, errorCodeSubscriptionNotFound, errorMessageSubscriptionNotFound)
+			return
+		}
+
+		requestID := middleware.GetRequestID(r.Context())
+		h.logger.Error("Failed to get dead letters",
+			zap.String("request_id", requestID),
+			zap.Int64("subscriptionID", id),
+			zap.Error(err))
+		h.sendError(w, r, http.StatusInternalServerError, middleware.ErrorCodeInternal, errorMessageFailedToRetrieveDead)
+		return
+	}
+
+	render.JSON(w, r, result)
+}
+
+// StreamEvents implements api.ServerInterface.
+func (h *Handler) StreamEvents(w http.ResponseWriter, r *http.Request, params api.StreamEventsParams) {
+	sub, ok := h.subscribe(w, r, params.Campaign, params.Status, lastEventID(params.LastEventID, params.LastEventId))
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	// Streams outlive the server's write timeout.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Warn("Failed to clear write deadline of event stream", zap.Error(err))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.logger.Error("Event stream does not support flushing", zap.Error(err))
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case ev := <-sub.Events():
+			_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, ev.Data)
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": ping\n\n")
+		case <-sub.Done():
+			return
+		}
+
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// StreamEventsWebSocket implements api.ServerInterface.
+func (h *Handler) StreamEventsWebSocket(w http.ResponseWriter, r *http.Request, params api.StreamEventsWebSocketParams) {
+	sub, ok := h.subscribe(w, r, params.Campaign, params.Status, lastEventID(params.LastEventID, params.LastEventId))
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	// Deadlines set by the server survive the upgrade, the WebSocket
+	// connection manages its own.
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Warn("Failed to clear read deadline of event stream", zap.Error(err))
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already responded with an error.
+		return
+	}
+	defer conn.Close()
+
+	// Clients answer pings; a client that stops answering is gone.
+	_ = conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeat))
+	})
+
+	go func() {
+		defer sub.Close()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case ev := <-sub.Events():
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			err = conn.WriteJSON(streamMessage{ID: ev.ID, Type: ev.Type, Data: ev.Data})
+		case <-heartbeat.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait))
+		case <-sub.Done():
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+				time.Now().Add(streamWriteWait))
+			return
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// subscribe validates the stream parameters and subscribes to the live
+// events. It responds with an error itself when it fails.
+func (h *Handler) subscribe(w http.ResponseWriter, r *http.Request, campaigns, statuses *[]string, lastEventID string) (*stream.Subscription, bool) {
+	var filter stream.Filter
+	if campaigns != nil {
+		filter.Campaigns = *campaigns
+	}
+	if statuses != nil {
+		for _, status := range *statuses {
+			if !slices.Contains(models.Statuses(), models.MessageStatus(status)) {
+				h.sendError(w, r, http.StatusBadRequest, errorCodeInvalidStreamRequest, errorMessageInvalidStreamRequest)
+				return nil, false
+			}
+		}
+		filter.Statuses = *statuses
+	}
+
+	sub, err := h.service.Stream.Subscribe(r.Context(), lastEventID, filter)
+	if err != nil {
+		if errors.Is(err, stream.ErrInvalidEventID) {
+			h.sendError(w, r, http.StatusBadRequest, errorCodeInvalidStreamRequest, errorMessageInvalidStreamRequest)
+			return nil, false
+		}
+
+		requestID := middleware.GetRequestID(r.Context())
+		h.logger.Error("Failed to subscribe to event stream",
+			zap.String("request_id", requestID),
+			zap.Error(err))
+		h.sendError(w, r, http.StatusInternalServerError, middleware.ErrorCodeInternal, errorMessageFailedToOpenStream)
+		return nil, false
+	}
+
+	return sub, true
+}
+
+// lastEventID prefers the Last-Event-ID header, which browsers send when
+// reconnecting, over the query parameter.
+func lastEventID(header, query *string) string {
+	if header != nil && *header != "" {
+		return *header
+	}
+	if query != nil {
+		return *query
+	}
+	return ""
+}
+
+// GetProviderStats implements api.ServerInterface.
+func (h *Handler) GetProviderStats(w http.ResponseWriter, r *http.Request) {
+	stats := h.service.Message.GetProviderStats()
+
+	providers := make([]api.ProviderStats, 0, len(stats))
+	for _, st := range stats {
+		providers = append(providers, api.ProviderStats{
+			Name:         st.Name,
+			Requests:     int64(st.Requests),
+			Successes:    int64(st.Successes),
+			Failures:     int64(st.Failures),
+			SuccessRate:  st.SuccessRate,
+			AvgLatencyMs: milliseconds(st.AvgLatency),
+			P50LatencyMs: milliseconds(st.P50Latency),
+			P95LatencyMs: milliseconds(st.P95Latency),
+			P99LatencyMs: milliseconds(st.P99Latency),
+		})
+	}
+
+	render.JSON(w, r, api.ProviderStatsResponse{Providers: providers})
+}
+
 // HealthCheck implements api.ServerInterface.
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	health := h.service.Health.GetHealth()
+	health := h.service.Health.GetHealth(r.Context())
 
 	response := api.HealthResponse{
 		Status:    health.Status,
@@ -134,6 +667,11 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 		response.SchedulerStatus = &status
 	}
 
+	if health.SchedulerBatchSize > 0 {
+		batchSize := health.SchedulerBatchSize
+		response.SchedulerBatchSize = &batchSize
+	}
+
 	if health.DatabaseStatus != "" {
 		status := health.DatabaseStatus
 		response.DatabaseStatus = &status
@@ -153,6 +691,39 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 		response.CircuitBreakerState = &state
 	}
 
+	if len(health.Providers) > 0 {
+		providers := make([]api.ProviderHealth, 0, len(health.Providers))
+		for _, p := range health.Providers {
+			provider := api.ProviderHealth{
+				Name:     p.Name,
+				State:    p.State,
+				Requests: int64(p.Requests),
+				Failures: int64(p.Failures),
+			}
+
+			if len(p.Breakers) > 0 {
+				breakers := make([]api.CircuitBreakerHealth, 0, len(p.Breakers))
+				for _, b := range p.Breakers {
+					breaker := api.CircuitBreakerHealth{
+						Name:     b.Name,
+						State:    b.State,
+						Requests: int64(b.Requests),
+						Failures: int64(b.Failures),
+					}
+					if b.Country != "" {
+						country := b.Country
+						breaker.Country = &country
+					}
+					breakers = append(breakers, breaker)
+				}
+				provider.Breakers = &breakers
+			}
+
+			providers = append(providers, provider)
+		}
+		response.Providers = &providers
+	}
+
 	switch health.Status {
 	case api.Unhealthy:
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -165,6 +736,11 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, response)
 }
 
+// milliseconds converts a duration to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
 func (h *Handler) sendError(w http.ResponseWriter, r *http.Request, statusCode int, errorCode, message string) {
 	render.Status(r, statusCode)
 	render.JSON(w, r, api.ErrorResponse{
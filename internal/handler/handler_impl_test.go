@@ -1,20 +1,27 @@
 package handler_test
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/popeskul/insdr-messenger/internal/api"
 	"github.com/popeskul/insdr-messenger/internal/handler"
 	"github.com/popeskul/insdr-messenger/internal/middleware"
+	"github.com/popeskul/insdr-messenger/internal/routing"
 	"github.com/popeskul/insdr-messenger/internal/scheduler"
 	"github.com/popeskul/insdr-messenger/internal/service"
 	"github.com/popeskul/insdr-messenger/internal/service/mocks"
+	"github.com/popeskul/insdr-messenger/internal/stream"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
@@ -38,6 +45,7 @@ func TestHandler_StartScheduler(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, api.SchedulerResponseStatusStarted, resp.Status)
 				assert.Equal(t, "Scheduler started successfully", resp.Message)
+				assert.Equal(t, ptr(8), resp.BatchSize)
 			},
 		},
 		{
@@ -78,11 +86,15 @@ func TestHandler_StartScheduler(t *testing.T) {
 			mockScheduler := mocks.NewMockSchedulerService(ctrl)
 			tt.setupMocks(mockScheduler)
 
+			mockMessage := mocks.NewMockMessageService(ctrl)
+			mockMessage.EXPECT().BatchSize().Return(8).AnyTimes()
+
 			svc := &service.Service{
+				Message:   mockMessage,
 				Scheduler: mockScheduler,
 			}
 
-			h := handler.NewHandler(svc, zap.NewNop())
+			h := handler.NewHandler(svc, zap.NewNop(), nil)
 
 			req := httptest.NewRequest(http.MethodPost, "/scheduler/start", nil)
 			req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "test-request-id"))
@@ -115,6 +127,7 @@ func TestHandler_StopScheduler(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, api.SchedulerResponseStatusStopped, resp.Status)
 				assert.Equal(t, "Scheduler stopped successfully", resp.Message)
+				assert.Equal(t, ptr(8), resp.BatchSize)
 			},
 		},
 		{
@@ -154,11 +167,15 @@ func TestHandler_StopScheduler(t *testing.T) {
 			mockScheduler := mocks.NewMockSchedulerService(ctrl)
 			tt.setupMocks(mockScheduler)
 
+			mockMessage := mocks.NewMockMessageService(ctrl)
+			mockMessage.EXPECT().BatchSize().Return(8).AnyTimes()
+
 			svc := &service.Service{
+				Message:   mockMessage,
 				Scheduler: mockScheduler,
 			}
 
-			h := handler.NewHandler(svc, zap.NewNop())
+			h := handler.NewHandler(svc, zap.NewNop(), nil)
 
 			req := httptest.NewRequest(http.MethodPost, "/scheduler/stop", nil)
 			req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "test-request-id"))
@@ -183,7 +200,7 @@ func TestHandler_GetSentMessages(t *testing.T) {
 		{
 			name:   "success with defaults",
 			params: api.GetSentMessagesParams{}, setupMocks: func(m *mocks.MockMessageService) {
-				m.EXPECT().GetSentMessages(1, 20).Return(&api.MessageListResponse{
+				m.EXPECT().GetSentMessages(gomock.Any(), 1, 20).Return(&api.MessageListResponse{
 					Messages: []api.Message{
 						{
 							Id:          1,
@@ -218,7 +235,7 @@ func TestHandler_GetSentMessages(t *testing.T) {
 				Limit: ptr(50),
 			},
 			setupMocks: func(m *mocks.MockMessageService) {
-				m.EXPECT().GetSentMessages(2, 50).Return(&api.MessageListResponse{
+				m.EXPECT().GetSentMessages(gomock.Any(), 2, 50).Return(&api.MessageListResponse{
 					Messages: []api.Message{},
 					Pagination: api.Pagination{
 						CurrentPage:  2,
@@ -241,7 +258,7 @@ func TestHandler_GetSentMessages(t *testing.T) {
 		{
 			name:   "internal error",
 			params: api.GetSentMessagesParams{}, setupMocks: func(m *mocks.MockMessageService) {
-				m.EXPECT().GetSentMessages(1, 20).Return(nil, errors.New("database error"))
+				m.EXPECT().GetSentMessages(gomock.Any(), 1, 20).Return(nil, errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: func(t *testing.T, body []byte) {
@@ -266,7 +283,7 @@ func TestHandler_GetSentMessages(t *testing.T) {
 				Message: mockMessage,
 			}
 
-			h := handler.NewHandler(svc, zap.NewNop())
+			h := handler.NewHandler(svc, zap.NewNop(), nil)
 
 			req := httptest.NewRequest(http.MethodGet, "/messages", nil)
 			req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "test-request-id"))
@@ -280,6 +297,748 @@ func TestHandler_GetSentMessages(t *testing.T) {
 	}
 }
 
+func TestHandler_GetMessageByExternalId(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupMocks     func(*mocks.MockMessageService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "success",
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().GetMessageByExternalID(gomock.Any(), "ext-1").Return(&api.Message{
+					Id:          7,
+					PhoneNumber: "+905551111111",
+					MessageId:   ptr("ext-1"),
+					Status:      api.MessageStatusSent,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "message not found",
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().GetMessageByExternalID(gomock.Any(), "ext-1").
+					Return(nil, fmt.Errorf("failed to find message ext-1: %w", service.ErrMessageNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "MESSAGE_NOT_FOUND",
+		},
+		{
+			name: "internal error",
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().GetMessageByExternalID(gomock.Any(), "ext-1").Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   middleware.ErrorCodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockMessage := mocks.NewMockMessageService(ctrl)
+			tt.setupMocks(mockMessage)
+
+			h := handler.NewHandler(&service.Service{Message: mockMessage}, zap.NewNop(), nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/messages/by-external-id/ext-1", nil)
+			w := httptest.NewRecorder()
+
+			h.GetMessageByExternalId(w, req, "ext-1")
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode == "" {
+				var resp api.Message
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, int64(7), resp.Id)
+				assert.Equal(t, "ext-1", *resp.MessageId)
+				return
+			}
+
+			var resp api.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedCode, resp.Error)
+		})
+	}
+}
+
+func TestHandler_GetMessageHistory(t *testing.T) {
+	changedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		setupMocks     func(*mocks.MockMessageService)
+		expectedStatus int
+		expectedBody   func(*testing.T, []byte)
+	}{
+		{
+			name: "success",
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().GetMessageHistory(gomock.Any(), int64(1)).Return(&api.MessageHistoryResponse{
+					MessageId: 1,
+					History: []api.StatusChange{
+						{ToStatus: api.MessageStatusQueued, ChangedAt: changedAt},
+						{FromStatus: ptr(api.MessageStatusQueued), ToStatus: api.MessageStatusProcessing, ChangedAt: changedAt},
+					},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: func(t *testing.T, body []byte) {
+				var resp api.MessageHistoryResponse
+				err := json.Unmarshal(body, &resp)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), resp.MessageId)
+				assert.Len(t, resp.History, 2)
+				assert.Nil(t, resp.History[0].FromStatus)
+				assert.Equal(t, api.MessageStatusProcessing, resp.History[1].ToStatus)
+			},
+		},
+		{
+			name: "message not found",
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().GetMessageHistory(gomock.Any(), int64(1)).
+					Return(nil, fmt.Errorf("failed to get message history: %w", service.ErrMessageNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody: func(t *testing.T, body []byte) {
+				var resp api.ErrorResponse
+				err := json.Unmarshal(body, &resp)
+				assert.NoError(t, err)
+				assert.Equal(t, "MESSAGE_NOT_FOUND", resp.Error)
+			},
+		},
+		{
+			name: "internal error",
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().GetMessageHistory(gomock.Any(), int64(1)).Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: func(t *testing.T, body []byte) {
+				var resp api.ErrorResponse
+				err := json.Unmarshal(body, &resp)
+				assert.NoError(t, err)
+				assert.Equal(t, middleware.ErrorCodeInternal, resp.Error)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockMessage := mocks.NewMockMessageService(ctrl)
+			tt.setupMocks(mockMessage)
+
+			svc := &service.Service{
+				Message: mockMessage,
+			}
+
+			h := handler.NewHandler(svc, zap.NewNop(), nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/messages/1/history", nil)
+			w := httptest.NewRecorder()
+
+			h.GetMessageHistory(w, req, 1)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			tt.expectedBody(t, w.Body.Bytes())
+		})
+	}
+}
+
+func TestHandler_GetMessageAttempts(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupMocks     func(*mocks.MockMessageService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "success",
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().GetMessageAttempts(gomock.Any(), int64(1)).Return(&api.MessageAttemptsResponse{
+					MessageId: 1,
+					Attempts: []api.DeliveryAttempt{
+						{Provider: "primary", Route: "default", LatencyMs: 10, CircuitBreakerState: api.Closed},
+					},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "message not found",
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().GetMessageAttempts(gomock.Any(), int64(1)).
+					Return(nil, fmt.Errorf("failed to get message: %w", service.ErrMessageNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "MESSAGE_NOT_FOUND",
+		},
+		{
+			name: "internal error",
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().GetMessageAttempts(gomock.Any(), int64(1)).Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   middleware.ErrorCodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockMessage := mocks.NewMockMessageService(ctrl)
+			tt.setupMocks(mockMessage)
+
+			svc := &service.Service{
+				Message: mockMessage,
+			}
+
+			h := handler.NewHandler(svc, zap.NewNop(), nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/messages/1/attempts", nil)
+			w := httptest.NewRecorder()
+
+			h.GetMessageAttempts(w, req, 1)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var resp api.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &resp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedCode, resp.Error)
+				return
+			}
+
+			var resp api.MessageAttemptsResponse
+			err := json.Unmarshal(w.Body.Bytes(), &resp)
+			assert.NoError(t, err)
+			assert.Len(t, resp.Attempts, 1)
+			assert.Equal(t, "primary", resp.Attempts[0].Provider)
+		})
+	}
+}
+
+func TestHandler_HandleDeliveryReceipt(t *testing.T) {
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(*mocks.MockMessageService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "delivered",
+			body: `{"messageId":"ext-1","status":"delivered","timestamp":"2024-01-02T03:04:05Z"}`,
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().HandleDeliveryReceipt(gomock.Any(), service.DeliveryReceipt{
+					MessageID: "ext-1",
+					Status:    api.MessageStatusDelivered,
+					Timestamp: timestamp,
+				}).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "undelivered with reason",
+			body: `{"messageId":"ext-1","status":"undelivered","error":"handset unreachable"}`,
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().HandleDeliveryReceipt(gomock.Any(), service.DeliveryReceipt{
+					MessageID: "ext-1",
+					Status:    api.MessageStatusUndelivered,
+					Error:     ptr("handset unreachable"),
+				}).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "malformed body",
+			body:           `{`,
+			setupMocks:     func(m *mocks.MockMessageService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_RECEIPT",
+		},
+		{
+			name:           "unknown status",
+			body:           `{"messageId":"ext-1","status":"sent"}`,
+			setupMocks:     func(m *mocks.MockMessageService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_RECEIPT",
+		},
+		{
+			name: "unknown message",
+			body: `{"messageId":"missing","status":"delivered"}`,
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().HandleDeliveryReceipt(gomock.Any(), gomock.Any()).
+					Return(fmt.Errorf("failed to find message missing: %w", service.ErrMessageNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "MESSAGE_NOT_FOUND",
+		},
+		{
+			name: "conflicting status",
+			body: `{"messageId":"ext-1","status":"delivered"}`,
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().HandleDeliveryReceipt(gomock.Any(), gomock.Any()).
+					Return(fmt.Errorf("failed to apply delivery receipt: %w", service.ErrInvalidStatusTransition))
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "INVALID_STATUS_TRANSITION",
+		},
+		{
+			name: "internal error",
+			body: `{"messageId":"ext-1","status":"delivered"}`,
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().HandleDeliveryReceipt(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   middleware.ErrorCodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockMessage := mocks.NewMockMessageService(ctrl)
+			tt.setupMocks(mockMessage)
+
+			svc := &service.Service{
+				Message: mockMessage,
+			}
+
+			h := handler.NewHandler(svc, zap.NewNop(), nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/callbacks/delivery", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			h.HandleDeliveryReceipt(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var resp api.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &resp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedCode, resp.Error)
+			}
+		})
+	}
+}
+
+func TestHandler_ResolveRoute(t *testing.T) {
+	tests := []struct {
+		name           string
+		params         api.ResolveRouteParams
+		setupMocks     func(*mocks.MockMessageService)
+		expectedStatus int
+		expectedBody   func(*testing.T, []byte)
+	}{
+		{
+			name: "success",
+			params: api.ResolveRouteParams{
+				PhoneNumber: "+905551111111",
+				Priority:    ptr(1),
+				Campaign:    ptr("spring"),
+			},
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().ResolveRoute(routing.Destination{
+					PhoneNumber: "+905551111111",
+					Priority:    1,
+					Campaign:    "spring",
+				}).Return(routing.Route{Name: "turkey", Prefix: "90", Providers: []string{"tr-gateway", "webhook"}})
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: func(t *testing.T, body []byte) {
+				var resp api.RouteResolution
+				err := json.Unmarshal(body, &resp)
+				assert.NoError(t, err)
+				assert.Equal(t, "+905551111111", resp.PhoneNumber)
+				assert.Equal(t, "turkey", resp.Route)
+				assert.Equal(t, "90", *resp.Prefix)
+				assert.Equal(t, []string{"tr-gateway", "webhook"}, resp.Providers)
+			},
+		},
+		{
+			name:           "invalid phone number",
+			params:         api.ResolveRouteParams{PhoneNumber: "abc"},
+			setupMocks:     func(m *mocks.MockMessageService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: func(t *testing.T, body []byte) {
+				var resp api.ErrorResponse
+				err := json.Unmarshal(body, &resp)
+				assert.NoError(t, err)
+				assert.Equal(t, "INVALID_PHONE_NUMBER", resp.Error)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockMessage := mocks.NewMockMessageService(ctrl)
+			tt.setupMocks(mockMessage)
+
+			svc := &service.Service{
+				Message: mockMessage,
+			}
+
+			h := handler.NewHandler(svc, zap.NewNop(), nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/routing/resolve", nil)
+			w := httptest.NewRecorder()
+
+			h.ResolveRoute(w, req, tt.params)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			tt.expectedBody(t, w.Body.Bytes())
+		})
+	}
+}
+
+func TestHandler_CreateMessage(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(*mocks.MockMessageService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "created",
+			body: `{"phone_number":"+905551111111","content":"Hello","campaign":"spring"}`,
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().CreateMessage(gomock.Any(), api.CreateMessageRequest{
+					PhoneNumber: "+905551111111",
+					Content:     "Hello",
+					Campaign:    ptr("spring"),
+				}).Return(&api.Message{
+					Id:          1,
+					PhoneNumber: "+905551111111",
+					Content:     ptr("Hello"),
+					Status:      api.MessageStatusQueued,
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "malformed body",
+			body:           `{"phone_number":`,
+			setupMocks:     func(m *mocks.MockMessageService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_MESSAGE",
+		},
+		{
+			name: "invalid message",
+			body: `{"phone_number":"+905551111111","content":""}`,
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().CreateMessage(gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("%w: content must be 1 to 160 characters", service.ErrInvalidMessage))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_MESSAGE",
+		},
+		{
+			name: "internal error",
+			body: `{"phone_number":"+905551111111","content":"Hello"}`,
+			setupMocks: func(m *mocks.MockMessageService) {
+				m.EXPECT().CreateMessage(gomock.Any(), gomock.Any()).Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   middleware.ErrorCodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockMessages := mocks.NewMockMessageService(ctrl)
+			tt.setupMocks(mockMessages)
+
+			h := handler.NewHandler(&service.Service{Message: mockMessages}, zap.NewNop(), nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/messages", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			h.CreateMessage(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode == "" {
+				var resp api.Message
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, int64(1), resp.Id)
+				assert.Equal(t, api.MessageStatusQueued, resp.Status)
+				return
+			}
+
+			var resp api.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedCode, resp.Error)
+		})
+	}
+}
+
+func TestHandler_CreateSubscription(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(*mocks.MockEventService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "created",
+			body: `{"url":"https://orders.example.com/hooks","event_types":["message.sent"]}`,
+			setupMocks: func(m *mocks.MockEventService) {
+				m.EXPECT().CreateSubscription(gomock.Any(), api.CreateSubscriptionRequest{
+					Url:        "https://orders.example.com/hooks",
+					EventTypes: []api.EventType{api.MessageSent},
+				}).Return(&api.Subscription{
+					Id:         1,
+					Url:        "https://orders.example.com/hooks",
+					EventTypes: []api.EventType{api.MessageSent},
+					Secret:     ptr("0123456789abcdef"),
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "malformed body",
+			body:           `{"url":`,
+			setupMocks:     func(m *mocks.MockEventService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_SUBSCRIPTION",
+		},
+		{
+			name: "invalid subscription",
+			body: `{"url":"/hooks","event_types":["message.sent"]}`,
+			setupMocks: func(m *mocks.MockEventService) {
+				m.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("%w: url must be an absolute http or https url", service.ErrInvalidSubscription))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_SUBSCRIPTION",
+		},
+		{
+			name: "internal error",
+			body: `{"url":"https://orders.example.com/hooks","event_types":["message.sent"]}`,
+			setupMocks: func(m *mocks.MockEventService) {
+				m.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   middleware.ErrorCodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockEvents := mocks.NewMockEventService(ctrl)
+			tt.setupMocks(mockEvents)
+
+			h := handler.NewHandler(&service.Service{Events: mockEvents}, zap.NewNop(), nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			h.CreateSubscription(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode == "" {
+				var resp api.Subscription
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, int64(1), resp.Id)
+				assert.Equal(t, "0123456789abcdef", *resp.Secret)
+				return
+			}
+
+			var resp api.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedCode, resp.Error)
+		})
+	}
+}
+
+func TestHandler_DeleteSubscription(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "deleted", expectedStatus: http.StatusNoContent},
+		{name: "not found", err: service.ErrSubscriptionNotFound, expectedStatus: http.StatusNotFound},
+		{name: "internal error", err: errors.New("database error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockEvents := mocks.NewMockEventService(ctrl)
+			mockEvents.EXPECT().DeleteSubscription(gomock.Any(), int64(1)).Return(tt.err)
+
+			h := handler.NewHandler(&service.Service{Events: mockEvents}, zap.NewNop(), nil)
+
+			req := httptest.NewRequest(http.MethodDelete, "/subscriptions/1", nil)
+			w := httptest.NewRecorder()
+
+			h.DeleteSubscription(w, req, 1)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestHandler_GetDeadLetters(t *testing.T) {
+	tests := []struct {
+		name           string
+		limit          *int
+		expectedLimit  int
+		err            error
+		expectedStatus int
+	}{
+		{name: "default limit", expectedLimit: 100, expectedStatus: http.StatusOK},
+		{name: "custom limit", limit: ptr(5), expectedLimit: 5, expectedStatus: http.StatusOK},
+		{name: "limit out of range", limit: ptr(1000), expectedLimit: 100, expectedStatus: http.StatusOK},
+		{name: "not found", expectedLimit: 100, err: service.ErrSubscriptionNotFound, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockEvents := mocks.NewMockEventService(ctrl)
+			if tt.err != nil {
+				mockEvents.EXPECT().GetDeadLetters(gomock.Any(), int64(1), tt.expectedLimit).Return(nil, tt.err)
+			} else {
+				mockEvents.EXPECT().GetDeadLetters(gomock.Any(), int64(1), tt.expectedLimit).Return(&api.DeadLetterListResponse{
+					SubscriptionId: 1,
+					DeadLetters:    []api.DeadLetter{},
+				}, nil)
+			}
+
+			h := handler.NewHandler(&service.Service{Events: mockEvents}, zap.NewNop(), nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/subscriptions/1/dead-letters", nil)
+			w := httptest.NewRecorder()
+
+			h.GetDeadLetters(w, req, 1, api.GetDeadLettersParams{Limit: tt.limit})
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestHandler_GetSandboxRequests(t *testing.T) {
+	tests := []struct {
+		name              string
+		params            api.GetSandboxRequestsParams
+		expectedMessageID int64
+		expectedLimit     int
+		err               error
+		expectedStatus    int
+	}{
+		{name: "defaults", expectedLimit: 50, expectedStatus: http.StatusOK},
+		{name: "message filter", params: api.GetSandboxRequestsParams{MessageId: ptr(int64(7)), Limit: ptr(5)}, expectedMessageID: 7, expectedLimit: 5, expectedStatus: http.StatusOK},
+		{name: "limit out of range", params: api.GetSandboxRequestsParams{Limit: ptr(1000)}, expectedLimit: 50, expectedStatus: http.StatusOK},
+		{name: "internal error", expectedLimit: 50, err: errors.New("database error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockMessage := mocks.NewMockMessageService(ctrl)
+			if tt.err != nil {
+				mockMessage.EXPECT().GetSandboxRequests(gomock.Any(), tt.expectedMessageID, tt.expectedLimit).Return(nil, tt.err)
+			} else {
+				mockMessage.EXPECT().GetSandboxRequests(gomock.Any(), tt.expectedMessageID, tt.expectedLimit).Return(&api.SandboxRequestListResponse{
+					Requests: []api.SandboxRequest{{Id: 1, MessageId: 7, Provider: "primary", ExternalId: "sandbox-1"}},
+				}, nil)
+			}
+
+			h := handler.NewHandler(&service.Service{Message: mockMessage}, zap.NewNop(), nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/sandbox/requests", nil)
+			w := httptest.NewRecorder()
+
+			h.GetSandboxRequests(w, req, tt.params)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.err == nil {
+				var resp api.SandboxRequestListResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Len(t, resp.Requests, 1)
+			}
+		})
+	}
+}
+
+func TestHandler_GetProviderStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessage := mocks.NewMockMessageService(ctrl)
+	mockMessage.EXPECT().GetProviderStats().Return([]service.ProviderStats{
+		{
+			Name:        "current",
+			Requests:    90,
+			Successes:   88,
+			Failures:    2,
+			SuccessRate: 88.0 / 90.0,
+			AvgLatency:  120 * time.Millisecond,
+			P95Latency:  1500 * time.Microsecond,
+		},
+		{
+			Name:        "trial",
+			Requests:    10,
+			Successes:   10,
+			SuccessRate: 1,
+		},
+	})
+
+	svc := &service.Service{
+		Message: mockMessage,
+	}
+
+	h := handler.NewHandler(svc, zap.NewNop(), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/providers/stats", nil)
+	w := httptest.NewRecorder()
+
+	h.GetProviderStats(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp api.ProviderStatsResponse
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Providers, 2)
+	assert.Equal(t, "current", resp.Providers[0].Name)
+	assert.Equal(t, int64(90), resp.Providers[0].Requests)
+	assert.Equal(t, float64(120), resp.Providers[0].AvgLatencyMs)
+	assert.Equal(t, 1.5, resp.Providers[0].P95LatencyMs)
+	assert.Equal(t, float64(1), resp.Providers[1].SuccessRate)
+}
+
 func TestHandler_HealthCheck(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -290,9 +1049,10 @@ func TestHandler_HealthCheck(t *testing.T) {
 		{
 			name: "healthy status",
 			setupMocks: func(m *mocks.MockHealthService) {
-				m.EXPECT().GetHealth().Return(&service.HealthStatus{
+				m.EXPECT().GetHealth(gomock.Any()).Return(&service.HealthStatus{
 					Status:               api.Healthy,
 					SchedulerStatus:      api.HealthResponseSchedulerStatus("running"),
+					SchedulerBatchSize:   16,
 					DatabaseStatus:       api.HealthResponseDatabaseStatus("connected"),
 					RedisStatus:          api.HealthResponseRedisStatus("connected"),
 					CircuitBreakerStatus: "closed",
@@ -305,6 +1065,7 @@ func TestHandler_HealthCheck(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, api.Healthy, resp.Status)
 				assert.Equal(t, api.HealthResponseSchedulerStatus("running"), *resp.SchedulerStatus)
+				assert.Equal(t, ptr(16), resp.SchedulerBatchSize)
 				assert.Equal(t, api.HealthResponseDatabaseStatus("connected"), *resp.DatabaseStatus)
 				assert.Equal(t, api.HealthResponseRedisStatus("connected"), *resp.RedisStatus)
 				assert.Equal(t, "closed", *resp.CircuitBreakerStatus)
@@ -313,7 +1074,7 @@ func TestHandler_HealthCheck(t *testing.T) {
 		{
 			name: "unhealthy status",
 			setupMocks: func(m *mocks.MockHealthService) {
-				m.EXPECT().GetHealth().Return(&service.HealthStatus{
+				m.EXPECT().GetHealth(gomock.Any()).Return(&service.HealthStatus{
 					Status:               api.Unhealthy,
 					SchedulerStatus:      api.HealthResponseSchedulerStatus("stopped"),
 					DatabaseStatus:       api.HealthResponseDatabaseStatus("disconnected"),
@@ -333,7 +1094,7 @@ func TestHandler_HealthCheck(t *testing.T) {
 		{
 			name: "degraded status",
 			setupMocks: func(m *mocks.MockHealthService) {
-				m.EXPECT().GetHealth().Return(&service.HealthStatus{
+				m.EXPECT().GetHealth(gomock.Any()).Return(&service.HealthStatus{
 					Status:               api.Degraded,
 					SchedulerStatus:      api.HealthResponseSchedulerStatus("running"),
 					DatabaseStatus:       api.HealthResponseDatabaseStatus("connected"),
@@ -364,7 +1125,7 @@ func TestHandler_HealthCheck(t *testing.T) {
 				Health: mockHealth,
 			}
 
-			h := handler.NewHandler(svc, zap.NewNop())
+			h := handler.NewHandler(svc, zap.NewNop(), nil)
 
 			req := httptest.NewRequest(http.MethodGet, "/health", nil)
 			w := httptest.NewRecorder()
@@ -377,6 +1138,234 @@ func TestHandler_HealthCheck(t *testing.T) {
 	}
 }
 
+func TestHandler_StreamEvents_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		params        api.StreamEventsParams
+		setupMocks    func(*mocks.MockStreamService)
+		expectedCode  int
+		expectedError string
+	}{
+		{
+			name:          "unknown status",
+			params:        api.StreamEventsParams{Status: &[]string{"sent", "read"}},
+			setupMocks:    func(m *mocks.MockStreamService) {},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "INVALID_STREAM_REQUEST",
+		},
+		{
+			name:   "invalid last event id",
+			params: api.StreamEventsParams{LastEventID: ptr("latest")},
+			setupMocks: func(m *mocks.MockStreamService) {
+				m.EXPECT().Subscribe(gomock.Any(), "latest", stream.Filter{}).Return(nil, stream.ErrInvalidEventID)
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "INVALID_STREAM_REQUEST",
+		},
+		{
+			name:   "header takes precedence",
+			params: api.StreamEventsParams{LastEventID: ptr("1-2"), LastEventId: ptr("1-1"), Campaign: &[]string{"spring"}},
+			setupMocks: func(m *mocks.MockStreamService) {
+				m.EXPECT().Subscribe(gomock.Any(), "1-2", stream.Filter{Campaigns: []string{"spring"}}).Return(nil, errors.New("redis down"))
+			},
+			expectedCode:  http.StatusInternalServerError,
+			expectedError: middleware.ErrorCodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStream := mocks.NewMockStreamService(ctrl)
+			tt.setupMocks(mockStream)
+
+			h := handler.NewHandler(&service.Service{Stream: mockStream}, zap.NewNop(), nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/events/stream", nil)
+			w := httptest.NewRecorder()
+
+			h.StreamEvents(w, req, tt.params)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			var resp api.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedError, resp.Error)
+		})
+	}
+}
+
+func TestHandler_StreamEvents(t *testing.T) {
+	hub, server := newStreamServer(t)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/events/stream?status=sent", nil)
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	publishStreamEvents(t, hub)
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		if !assert.NoError(t, err) {
+			return
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	assert.Equal(t, []string{"id: 1-2", "event: message.status", `data: {"id":2,"status":"sent"}`}, lines)
+}
+
+func TestHandler_StreamEventsWebSocket(t *testing.T) {
+	hub, server := newStreamServer(t)
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/events/ws?status=sent", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	publishStreamEvents(t, hub)
+
+	var msg struct {
+		ID   string          `json:"id"`
+		Type string          `json:"type"`
+		Data json.RawMessage `json:"data"`
+	}
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	assert.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, "1-2", msg.ID)
+	assert.Equal(t, "message.status", msg.Type)
+	assert.JSONEq(t, `{"id":2,"status":"sent"}`, string(msg.Data))
+}
+
+func TestHandler_StreamEventsWebSocket_Origin(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowedOrigins []string
+		origin         string
+		wantErr        bool
+	}{
+		{name: "no origin", origin: ""},
+		{name: "same origin", origin: "same"},
+		{name: "other origin", origin: "https://evil.example", wantErr: true},
+		{name: "allowed origin", allowedOrigins: []string{"https://app.example"}, origin: "https://app.example"},
+		{name: "origin not in allowlist", allowedOrigins: []string{"https://app.example"}, origin: "https://evil.example", wantErr: true},
+		{name: "any origin", allowedOrigins: []string{"*"}, origin: "https://evil.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, server := newStreamServer(t, tt.allowedOrigins...)
+
+			header := http.Header{}
+			switch tt.origin {
+			case "":
+			case "same":
+				header.Set("Origin", server.URL)
+			default:
+				header.Set("Origin", tt.origin)
+			}
+
+			conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/events/ws", header)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, websocket.ErrBadHandshake)
+				if assert.NotNil(t, resp) {
+					assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+			assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		})
+	}
+}
+
+// newStreamServer serves the API with a stream hub on an in-memory log,
+// accepting WebSocket clients from allowedOrigins.
+func newStreamServer(t *testing.T, allowedOrigins ...string) (*stream.Hub, *httptest.Server) {
+	hub := stream.NewHub(&memLog{}, zap.NewNop())
+	assert.NoError(t, hub.Start())
+	t.Cleanup(func() { _ = hub.Stop() })
+
+	h := handler.NewHandler(&service.Service{Stream: hub}, zap.NewNop(), allowedOrigins)
+	server := httptest.NewServer(api.Handler(h))
+	t.Cleanup(server.Close)
+
+	return hub, server
+}
+
+// publishStreamEvents publishes a processing and a sent event once the
+// client is subscribed.
+func publishStreamEvents(t *testing.T, hub *stream.Hub) {
+	time.Sleep(100 * time.Millisecond)
+
+	for i, status := range []string{"processing", "sent"} {
+		_, err := hub.Publish(context.Background(), stream.Event{
+			Type:   stream.TypeMessageStatus,
+			Status: status,
+			Data:   []byte(fmt.Sprintf(`{"id":%d,"status":"%s"}`, i+1, status)),
+		})
+		assert.NoError(t, err)
+	}
+}
+
+// memLog is an in-memory stream.Log.
+type memLog struct {
+	mu     sync.Mutex
+	events []stream.Event
+}
+
+func (l *memLog) Append(_ context.Context, ev stream.Event) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ev.ID = fmt.Sprintf("1-%d", len(l.events)+1)
+	l.events = append(l.events, ev)
+	return ev.ID, nil
+}
+
+func (l *memLog) Read(ctx context.Context, afterID string, count int64, block time.Duration) ([]stream.Event, error) {
+	var seq int
+	_, _ = fmt.Sscanf(afterID, "1-%d", &seq)
+
+	deadline := time.Now().Add(block)
+	for {
+		l.mu.Lock()
+		var events []stream.Event
+		for i := seq; i < len(l.events) && int64(len(events)) < count; i++ {
+			events = append(events, l.events[i])
+		}
+		l.mu.Unlock()
+
+		if len(events) > 0 || time.Now().After(deadline) || ctx.Err() != nil {
+			return events, nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (l *memLog) LastID(context.Context) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return fmt.Sprintf("1-%d", len(l.events)), nil
+}
+
 func ptr[T any](v T) *T {
 	return &v
 }
@@ -0,0 +1,170 @@
+package loadtest_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/insdr-messenger/internal/loadtest"
+)
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      loadtest.Summary
+	}{
+		{
+			name: "empty",
+			want: loadtest.Summary{},
+		},
+		{
+			name:      "single",
+			durations: []time.Duration{1500 * time.Microsecond},
+			want:      loadtest.Summary{Count: 1, MeanMs: 1.5, MinMs: 1.5, P50Ms: 1.5, P90Ms: 1.5, P95Ms: 1.5, P99Ms: 1.5, MaxMs: 1.5},
+		},
+		{
+			name:      "nearest rank",
+			durations: millis(10, 1, 9, 2, 8, 3, 7, 4, 6, 5),
+			want:      loadtest.Summary{Count: 10, MeanMs: 5.5, MinMs: 1, P50Ms: 5, P90Ms: 9, P95Ms: 10, P99Ms: 10, MaxMs: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, loadtest.Summarize(tt.durations))
+		})
+	}
+}
+
+func TestSummarize_DoesNotReorder(t *testing.T) {
+	durations := millis(3, 1, 2)
+	loadtest.Summarize(durations)
+	assert.Equal(t, millis(3, 1, 2), durations)
+}
+
+func TestOptions_Validate(t *testing.T) {
+	valid := loadtest.Options{
+		Messages:     10,
+		Mode:         loadtest.ModeSeed,
+		Concurrency:  1,
+		Scheduler:    true,
+		Interval:     time.Second,
+		Timeout:      time.Minute,
+		PollInterval: time.Second,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*loadtest.Options)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(*loadtest.Options) {}},
+		{name: "no messages", modify: func(o *loadtest.Options) { o.Messages = 0 }, wantErr: true},
+		{name: "unknown mode", modify: func(o *loadtest.Options) { o.Mode = "replay" }, wantErr: true},
+		{name: "api without URL", modify: func(o *loadtest.Options) { o.Mode = loadtest.ModeAPI }, wantErr: true},
+		{name: "no interval", modify: func(o *loadtest.Options) { o.Interval = 0 }, wantErr: true},
+		{name: "no interval without scheduler", modify: func(o *loadtest.Options) { o.Interval, o.Scheduler = 0, false }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := valid
+			tt.modify(&opts)
+			err := opts.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestQueryRecorder(t *testing.T) {
+	recorder := loadtest.NewQueryRecorder()
+	db := sql.OpenDB(recorder.Wrap(fakeConnector{}))
+	defer func() { _ = db.Close() }()
+
+	_, err := db.Exec("UPDATE messages\n\t\tSET status = $1")
+	require.NoError(t, err)
+	_, err = db.Exec("UPDATE messages SET status = $1")
+	require.NoError(t, err)
+	_, err = db.Exec("DELETE FROM messages")
+	require.Error(t, err)
+
+	rows, err := db.Query("SELECT id FROM messages")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	stmt, err := db.Prepare("SELECT 1")
+	require.NoError(t, err)
+	_, err = stmt.Exec()
+	require.NoError(t, err)
+	require.NoError(t, stmt.Close())
+
+	stats := make(map[string]loadtest.QueryStats)
+	for _, s := range recorder.Stats() {
+		stats[s.Query] = s
+	}
+
+	require.Len(t, stats, 4)
+	assert.Equal(t, 2, stats["UPDATE messages SET status = $1"].Count)
+	assert.Equal(t, 1, stats["DELETE FROM messages"].Errors)
+	assert.Equal(t, 1, stats["SELECT id FROM messages"].Count)
+	assert.Equal(t, 1, stats["SELECT 1"].Count)
+
+	recorder.Reset()
+	assert.Empty(t, recorder.Stats())
+}
+
+func millis(values ...int) []time.Duration {
+	durations := make([]time.Duration, len(values))
+	for i, v := range values {
+		durations[i] = time.Duration(v) * time.Millisecond
+	}
+	return durations
+}
+
+// fakeConnector hands out connections failing every DELETE.
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if query == "DELETE FROM messages" {
+		return nil, errors.New("permission denied")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error                               { return nil }
+func (fakeStmt) NumInput() int                              { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(0), nil }
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error)  { return fakeRows{}, nil }
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return []string{"id"} }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
@@ -0,0 +1,268 @@
+package loadtest
+
+import (
+	"context"
+	"database/sql/driver"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxQueryKeyLength bounds the query text used to group timings.
+const maxQueryKeyLength = 160
+
+// QueryRecorder collects the duration of every database query made
+// through a connector returned by Wrap, grouped by query text. Queries
+// returning rows are timed until the first row is available.
+type QueryRecorder struct {
+	mu      sync.Mutex
+	queries map[string]*queryTimings
+}
+
+type queryTimings struct {
+	durations []time.Duration
+	errors    int
+}
+
+// NewQueryRecorder creates an empty recorder.
+func NewQueryRecorder() *QueryRecorder {
+	return &QueryRecorder{queries: make(map[string]*queryTimings)}
+}
+
+// QueryStats summarises the timings of one query.
+type QueryStats struct {
+	Query  string `json:"query"`
+	Errors int    `json:"errors"`
+	// TotalMs is the time spent in the query over the whole run.
+	TotalMs float64 `json:"total_ms"`
+	Summary
+}
+
+// Reset discards everything recorded so far.
+func (r *QueryRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = make(map[string]*queryTimings)
+}
+
+// Stats returns the timings per query, most expensive first.
+func (r *QueryRecorder) Stats() []QueryStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]QueryStats, 0, len(r.queries))
+	for query, t := range r.queries {
+		var total time.Duration
+		for _, d := range t.durations {
+			total += d
+		}
+		stats = append(stats, QueryStats{
+			Query:   query,
+			Errors:  t.errors,
+			TotalMs: milliseconds(total),
+			Summary: Summarize(t.durations),
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TotalMs != stats[j].TotalMs {
+			return stats[i].TotalMs > stats[j].TotalMs
+		}
+		return stats[i].Query < stats[j].Query
+	})
+	return stats
+}
+
+func (r *QueryRecorder) record(query string, d time.Duration, err error) {
+	key := queryKey(query)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.queries[key]
+	if !ok {
+		t = &queryTimings{}
+		r.queries[key] = t
+	}
+	t.durations = append(t.durations, d)
+	if err != nil && err != driver.ErrSkip {
+		t.errors++
+	}
+}
+
+// queryKey collapses whitespace so the same statement groups together
+// however it is indented.
+func queryKey(query string) string {
+	key := strings.Join(strings.Fields(query), " ")
+	if len(key) > maxQueryKeyLength {
+		key = key[:maxQueryKeyLength] + "..."
+	}
+	return key
+}
+
+// Wrap returns a connector timing the queries and statements executed on
+// connections of c. Use it with sql.OpenDB.
+func (r *QueryRecorder) Wrap(c driver.Connector) driver.Connector {
+	return &timedConnector{Connector: c, recorder: r}
+}
+
+type timedConnector struct {
+	driver.Connector
+	recorder *QueryRecorder
+}
+
+// Connect implements driver.Connector.
+func (c *timedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{Conn: conn, recorder: c.recorder}, nil
+}
+
+// timedConn times queries and exposes the optional interfaces database/sql
+// relies on when the wrapped connection implements them.
+type timedConn struct {
+	driver.Conn
+	recorder *QueryRecorder
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.recorder.record(query, time.Since(start), err)
+	}
+	return rows, err
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.recorder.record(query, time.Since(start), err)
+	}
+	return result, err
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &timedStmt{Stmt: stmt, query: query, recorder: c.recorder}, nil
+}
+
+// Prepare implements driver.Conn.
+func (c *timedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (c *timedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck // fallback for drivers without BeginTx
+}
+
+// Ping implements driver.Pinger.
+func (c *timedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// CheckNamedValue implements driver.NamedValueChecker, which lib/pq uses
+// to pass arrays and byte slices through unconverted.
+func (c *timedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// ResetSession implements driver.SessionResetter.
+func (c *timedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator.
+func (c *timedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+type timedStmt struct {
+	driver.Stmt
+	query    string
+	recorder *QueryRecorder
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *timedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var (
+		result driver.Result
+		err    error
+	)
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(namedToValues(args)) //nolint:staticcheck // fallback for old drivers
+	}
+	s.recorder.record(s.query, time.Since(start), err)
+	return result, err
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *timedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedToValues(args)) //nolint:staticcheck // fallback for old drivers
+	}
+	s.recorder.record(s.query, time.Since(start), err)
+	return rows, err
+}
+
+func namedToValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
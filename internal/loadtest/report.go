@@ -0,0 +1,68 @@
+package loadtest
+
+import (
+	"time"
+
+	"github.com/popeskul/insdr-messenger/internal/mockgateway"
+)
+
+// Report is the machine-readable result of a run. Durations are in
+// milliseconds and rates per second unless named otherwise.
+type Report struct {
+	// Campaign tags the messages of the run in the messages table.
+	Campaign     string    `json:"campaign"`
+	Mode         string    `json:"mode"`
+	QueueBackend string    `json:"queue_backend"`
+	Messages     int       `json:"messages"`
+	Concurrency  int       `json:"concurrency"`
+	Scheduler    bool      `json:"scheduler"`
+	BatchSize    int       `json:"batch_size"`
+	IntervalMs   float64   `json:"interval_ms"`
+	StartedAt    time.Time `json:"started_at"`
+	DurationMs   float64   `json:"duration_ms"`
+	// TimedOut is set when messages were still queued or processing at the
+	// end of the run.
+	TimedOut bool `json:"timed_out"`
+
+	Enqueue    EnqueueStats `json:"enqueue"`
+	Throughput Throughput   `json:"throughput"`
+	// Latency is the time from created_at to sent_at of sent messages.
+	Latency Summary `json:"end_to_end_latency"`
+	// Statuses counts the messages of the run by final status.
+	Statuses map[string]int `json:"statuses"`
+	// ErrorRate is the share of messages that failed or could not be
+	// created.
+	ErrorRate float64 `json:"error_rate"`
+	// Gateway holds the counters of the in-process mock gateway.
+	Gateway *mockgateway.Stats `json:"gateway,omitempty"`
+	// Queries holds the timings of the queries of the sending side, most
+	// expensive first. They are only recorded when the scheduler runs in
+	// process.
+	Queries []QueryStats `json:"db_queries"`
+}
+
+// EnqueueStats describes how messages were created.
+type EnqueueStats struct {
+	Succeeded  int     `json:"succeeded"`
+	Failed     int     `json:"failed"`
+	DurationMs float64 `json:"duration_ms"`
+	PerSecond  float64 `json:"per_second"`
+	Latency    Summary `json:"latency"`
+}
+
+// Throughput is the rate of sent messages between the creation of the
+// first message and the sending of the last one.
+type Throughput struct {
+	Sent      int     `json:"sent"`
+	WindowMs  float64 `json:"window_ms"`
+	PerSecond float64 `json:"per_second"`
+	PerMinute float64 `json:"per_minute"`
+}
+
+// rate returns n per second over d.
+func rate(n int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
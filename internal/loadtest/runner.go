@@ -0,0 +1,414 @@
+// Package loadtest measures the throughput of the sending pipeline: it
+// creates messages, sends them with the scheduler against a mock gateway
+// and reports rates, latencies and database timings.
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/api"
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/mockgateway"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/repository"
+	"github.com/popeskul/insdr-messenger/internal/scheduler"
+	"github.com/popeskul/insdr-messenger/internal/service"
+)
+
+// Ways of creating messages.
+const (
+	// ModeSeed creates messages in process, through the message service.
+	ModeSeed = "seed"
+	// ModeAPI creates messages with POST /messages against a running server.
+	ModeAPI = "api"
+)
+
+// Options configures a run.
+type Options struct {
+	Messages    int
+	Mode        string
+	Concurrency int
+	// APIURL is the base URL of the server in ModeAPI.
+	APIURL string
+	// Scheduler runs the send task in process every Interval. Without it
+	// the messages are left to the schedulers of running servers.
+	Scheduler bool
+	Interval  time.Duration
+	// BatchSize overrides scheduler.batch_size when positive and turns off
+	// adaptive batching, so every batch has that size.
+	BatchSize int
+	// GatewayURL is the gateway the in-process scheduler sends to. When
+	// empty, a mock gateway with the Gateway faults is started in process.
+	GatewayURL string
+	Gateway    mockgateway.Config
+	// Timeout bounds the wait for all messages to be processed.
+	Timeout      time.Duration
+	PollInterval time.Duration
+}
+
+// Validate reports the first invalid option.
+func (o Options) Validate() error {
+	switch {
+	case o.Messages <= 0:
+		return errors.New("messages must be positive")
+	case o.Mode != ModeSeed && o.Mode != ModeAPI:
+		return fmt.Errorf("unknown mode %q", o.Mode)
+	case o.Mode == ModeAPI && o.APIURL == "":
+		return errors.New("api mode requires the API URL")
+	case o.Concurrency <= 0:
+		return errors.New("concurrency must be positive")
+	case o.Scheduler && o.Interval <= 0:
+		return errors.New("scheduler interval must be positive")
+	case o.Timeout <= 0 || o.PollInterval <= 0:
+		return errors.New("timeout and poll interval must be positive")
+	}
+	return nil
+}
+
+// Runner executes load test runs against a database and Redis.
+type Runner struct {
+	cfg     config.Config
+	db      *sqlx.DB
+	monitor *sqlx.DB
+	redis   *redis.Client
+	queries *QueryRecorder
+	client  *http.Client
+	logger  *zap.Logger
+}
+
+// NewRunner creates a runner. db is used by the message service and
+// should be opened through queries; monitor observes progress and is kept
+// separate so its queries do not show up in the timings.
+func NewRunner(cfg *config.Config, db, monitor *sqlx.DB, redisClient *redis.Client, queries *QueryRecorder, logger *zap.Logger) *Runner {
+	return &Runner{
+		cfg:     *cfg,
+		db:      db,
+		monitor: monitor,
+		redis:   redisClient,
+		queries: queries,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		logger:  logger,
+	}
+}
+
+// Run creates opts.Messages messages, waits until none of them is queued
+// or processing any more and reports on the run.
+func (r *Runner) Run(ctx context.Context, opts Options) (*Report, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	cfg := r.cfg
+	if opts.BatchSize > 0 {
+		cfg.Scheduler.BatchSize = opts.BatchSize
+		cfg.Scheduler.AdaptiveBatch = false
+	}
+
+	report := &Report{
+		Campaign:     fmt.Sprintf("loadtest-%d", time.Now().UnixNano()),
+		Mode:         opts.Mode,
+		QueueBackend: cfg.Queue.Backend,
+		Messages:     opts.Messages,
+		Concurrency:  opts.Concurrency,
+		Scheduler:    opts.Scheduler,
+		BatchSize:    cfg.Scheduler.BatchSize,
+		StartedAt:    time.Now().UTC(),
+	}
+	if opts.Scheduler {
+		report.IntervalMs = milliseconds(opts.Interval)
+	}
+
+	var gateway *mockgateway.Gateway
+	if opts.Scheduler {
+		gatewayURL := opts.GatewayURL
+		if gatewayURL == "" {
+			var stop func()
+			var err error
+			gateway, gatewayURL, stop, err = r.startGateway(opts.Gateway)
+			if err != nil {
+				return nil, err
+			}
+			defer stop()
+		}
+
+		// Send everything to the gateway through the default provider.
+		cfg.Webhook.URL = gatewayURL
+		cfg.Webhook.Provider = ""
+		cfg.Providers = nil
+		cfg.Routing = config.RoutingConfig{}
+		cfg.Sandbox.Enabled = false
+	}
+
+	var messages service.MessageService
+	if opts.Scheduler || opts.Mode == ModeSeed {
+		var err error
+		messages, err = service.NewMessageService(&cfg, repository.NewRepository(r.db), r.redis, r.logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create message service: %w", err)
+		}
+	}
+
+	r.queries.Reset()
+	start := time.Now()
+
+	if opts.Scheduler {
+		sched := scheduler.NewScheduler(r.logger, opts.Interval, func(ctx context.Context) error {
+			return messages.SendPendingMessages(ctx)
+		})
+		if err := sched.Start(ctx); err != nil {
+			return nil, fmt.Errorf("failed to start scheduler: %w", err)
+		}
+		defer func() {
+			if err := sched.Stop(); err != nil && !errors.Is(err, scheduler.ErrSchedulerNotRunning) {
+				r.logger.Warn("Failed to stop scheduler", zap.Error(err))
+			}
+		}()
+	}
+
+	report.Enqueue = r.enqueue(ctx, opts, report.Campaign, messages)
+
+	timedOut, err := r.wait(ctx, opts, report.Campaign)
+	if err != nil {
+		return nil, err
+	}
+	report.TimedOut = timedOut
+	report.DurationMs = milliseconds(time.Since(start))
+
+	if opts.Scheduler {
+		report.Queries = r.queries.Stats()
+	}
+	if gateway != nil {
+		stats := gateway.Stats()
+		report.Gateway = &stats
+	}
+
+	if err := r.collect(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// startGateway serves a mock gateway on a random local port.
+func (r *Runner) startGateway(cfg mockgateway.Config) (*mockgateway.Gateway, string, func(), error) {
+	gateway, err := mockgateway.New(cfg, mockgateway.Options{Logger: r.logger})
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("invalid gateway configuration: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		gateway.Close()
+		return nil, "", nil, fmt.Errorf("failed to listen for the mock gateway: %w", err)
+	}
+
+	srv := &http.Server{Handler: gateway, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			r.logger.Error("Mock gateway failed", zap.Error(err))
+		}
+	}()
+
+	stop := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			r.logger.Warn("Failed to stop mock gateway", zap.Error(err))
+		}
+		gateway.Close()
+	}
+
+	return gateway, "http://" + listener.Addr().String() + "/send", stop, nil
+}
+
+// enqueue creates the messages of the run with opts.Concurrency workers.
+func (r *Runner) enqueue(ctx context.Context, opts Options, campaign string, messages service.MessageService) EnqueueStats {
+	var (
+		mu        sync.Mutex
+		latencies = make([]time.Duration, 0, opts.Messages)
+		failed    int
+		wg        sync.WaitGroup
+	)
+
+	jobs := make(chan int)
+	start := time.Now()
+
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				req := api.CreateMessageRequest{
+					PhoneNumber: fmt.Sprintf("+90555%07d", i),
+					Content:     fmt.Sprintf("Load test message %d", i),
+					Campaign:    &campaign,
+				}
+
+				begin := time.Now()
+				var err error
+				if opts.Mode == ModeAPI {
+					err = r.post(ctx, opts.APIURL, req)
+				} else {
+					_, err = messages.CreateMessage(ctx, req)
+				}
+				took := time.Since(begin)
+
+				mu.Lock()
+				if err != nil {
+					failed++
+					// Log the first failures only, they tend to repeat.
+					if failed <= 5 {
+						r.logger.Warn("Failed to create message", zap.Int("index", i), zap.Error(err))
+					}
+				} else {
+					latencies = append(latencies, took)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < opts.Messages; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	duration := time.Since(start)
+	return EnqueueStats{
+		Succeeded:  len(latencies),
+		Failed:     failed,
+		DurationMs: milliseconds(duration),
+		PerSecond:  rate(len(latencies), duration),
+		Latency:    Summarize(latencies),
+	}
+}
+
+// post creates a message with POST /messages.
+func (r *Runner) post(ctx context.Context, baseURL string, msg api.CreateMessageRequest) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(baseURL, "/")+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// wait polls until no message of the campaign is queued or processing and
+// reports whether opts.Timeout passed first.
+func (r *Runner) wait(ctx context.Context, opts Options, campaign string) (bool, error) {
+	query := `
+		SELECT COUNT(*) FROM messages
+		WHERE campaign = $1 AND status IN ($2, $3)
+	`
+
+	deadline := time.NewTimer(opts.Timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		var pending int
+		err := r.monitor.GetContext(ctx, &pending, query, campaign,
+			models.MessageStatusQueued, models.MessageStatusProcessing)
+		if err != nil {
+			return false, fmt.Errorf("failed to count pending messages: %w", err)
+		}
+		if pending == 0 {
+			return false, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			r.logger.Warn("Timed out waiting for messages", zap.Int("pending", pending))
+			return true, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// collect fills in statuses, throughput and end-to-end latency from the
+// messages of the run.
+func (r *Runner) collect(report *Report) error {
+	var rows []struct {
+		Status    models.MessageStatus `db:"status"`
+		CreatedAt time.Time            `db:"created_at"`
+		SentAt    *time.Time           `db:"sent_at"`
+	}
+	query := `SELECT status, created_at, sent_at FROM messages WHERE campaign = $1`
+	if err := r.monitor.Select(&rows, query, report.Campaign); err != nil {
+		return fmt.Errorf("failed to load messages of the run: %w", err)
+	}
+
+	report.Statuses = make(map[string]int)
+	latencies := make([]time.Duration, 0, len(rows))
+	var first, last time.Time
+	for _, row := range rows {
+		report.Statuses[string(row.Status)]++
+		if first.IsZero() || row.CreatedAt.Before(first) {
+			first = row.CreatedAt
+		}
+		if row.SentAt == nil {
+			continue
+		}
+		latencies = append(latencies, row.SentAt.Sub(row.CreatedAt))
+		if row.SentAt.After(last) {
+			last = *row.SentAt
+		}
+	}
+
+	report.Latency = Summarize(latencies)
+
+	var window time.Duration
+	if len(latencies) > 0 {
+		window = last.Sub(first)
+	}
+	report.Throughput = Throughput{
+		Sent:      len(latencies),
+		WindowMs:  milliseconds(window),
+		PerSecond: rate(len(latencies), window),
+		PerMinute: rate(len(latencies), window) * 60,
+	}
+
+	failed := report.Statuses[string(models.MessageStatusFailed)] + report.Enqueue.Failed
+	report.ErrorRate = float64(failed) / float64(report.Messages)
+	return nil
+}
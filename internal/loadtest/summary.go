@@ -0,0 +1,61 @@
+package loadtest
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Summary describes a distribution of durations in milliseconds.
+type Summary struct {
+	Count  int     `json:"count"`
+	MeanMs float64 `json:"mean_ms"`
+	MinMs  float64 `json:"min_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P90Ms  float64 `json:"p90_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+// Summarize computes the summary of durations using nearest-rank
+// percentiles. durations is not modified.
+func Summarize(durations []time.Duration) Summary {
+	if len(durations) == 0 {
+		return Summary{}
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	return Summary{
+		Count:  len(sorted),
+		MeanMs: milliseconds(total / time.Duration(len(sorted))),
+		MinMs:  milliseconds(sorted[0]),
+		P50Ms:  milliseconds(percentile(sorted, 50)),
+		P90Ms:  milliseconds(percentile(sorted, 90)),
+		P95Ms:  milliseconds(percentile(sorted, 95)),
+		P99Ms:  milliseconds(percentile(sorted, 99)),
+		MaxMs:  milliseconds(sorted[len(sorted)-1]),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// milliseconds converts d to milliseconds rounded to microseconds.
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
@@ -0,0 +1,384 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/signing"
+)
+
+const (
+	// CallbackProviderHeader names the provider sending a callback. The
+	// "provider" query parameter is accepted as well.
+	CallbackProviderHeader = "X-Callback-Provider"
+	// CallbackTokenHeader carries the shared token of the token scheme.
+	CallbackTokenHeader = "X-Callback-Token"
+	// CallbackNonceHeader carries a unique id of the callback. It is
+	// required for providers without secrets and ignored for signed
+	// callbacks, which are identified by their signed timestamp and body.
+	CallbackNonceHeader = "X-Callback-Nonce"
+
+	defaultCallbackTolerance = 5 * time.Minute
+	maxCallbackBody          = 1 << 20
+	callbackNoncePrefix      = "callback:nonce:"
+)
+
+var (
+	errUnknownProvider = errors.New("unknown callback provider")
+	errIPNotAllowed    = errors.New("source address not allowed")
+	errInvalidToken    = errors.New("invalid callback token")
+	errReplayed        = errors.New("callback replayed")
+	errNoProviders     = errors.New("no callback providers configured")
+	errMissingNonce    = errors.New("missing callback nonce")
+	errMissingTime     = errors.New("missing callback timestamp")
+)
+
+// NonceStore remembers callback nonces to detect replays.
+type NonceStore interface {
+	// Remember stores nonce for ttl and reports whether it was unseen.
+	Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+	// Forget removes nonce so that the callback can be retried.
+	Forget(ctx context.Context, nonce string) error
+}
+
+// RedisNonceStore keeps nonces in Redis using SETNX.
+type RedisNonceStore struct {
+	client *redis.Client
+}
+
+// NewRedisNonceStore creates a nonce store backed by client.
+func NewRedisNonceStore(client *redis.Client) *RedisNonceStore {
+	return &RedisNonceStore{client: client}
+}
+
+// Remember implements NonceStore.
+func (s *RedisNonceStore) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, callbackNoncePrefix+nonce, 1, ttl).Result()
+}
+
+// Forget implements NonceStore.
+func (s *RedisNonceStore) Forget(ctx context.Context, nonce string) error {
+	return s.client.Del(ctx, callbackNoncePrefix+nonce).Err()
+}
+
+// CallbackProvider describes how callbacks of a provider are verified.
+// Every configured check must pass.
+type CallbackProvider struct {
+	Name string
+	// Secrets verify HMAC signatures made with the signing package.
+	Secrets []string
+	// Token is compared with the CallbackTokenHeader.
+	Token string
+	// AllowedIPs lists addresses or CIDR ranges callbacks may come from.
+	AllowedIPs []string
+}
+
+// CallbackConfig holds callback verification configuration.
+type CallbackConfig struct {
+	Providers []CallbackProvider
+	// Tolerance is the maximum age of a callback timestamp.
+	Tolerance time.Duration
+	Nonces    NonceStore
+	// AllowUnverified accepts all callbacks while no providers are
+	// configured; otherwise they are rejected.
+	AllowUnverified bool
+	Logger          *zap.Logger
+}
+
+type callbackProvider struct {
+	CallbackProvider
+	networks []*net.IPNet
+}
+
+// CallbackVerifier authenticates inbound provider callbacks and rejects
+// replays.
+type CallbackVerifier struct {
+	providers       map[string]*callbackProvider
+	tolerance       time.Duration
+	nonces          NonceStore
+	allowUnverified bool
+	logger          *zap.Logger
+	now             func() time.Time
+}
+
+// NewCallbackVerifier creates a verifier for the configured providers.
+func NewCallbackVerifier(config *CallbackConfig) (*CallbackVerifier, error) {
+	v := &CallbackVerifier{
+		providers:       make(map[string]*callbackProvider, len(config.Providers)),
+		tolerance:       config.Tolerance,
+		nonces:          config.Nonces,
+		allowUnverified: config.AllowUnverified,
+		logger:          config.Logger,
+		now:             time.Now,
+	}
+	if v.tolerance <= 0 {
+		v.tolerance = defaultCallbackTolerance
+	}
+	if v.logger == nil {
+		v.logger = zap.NewNop()
+	}
+
+	for _, p := range config.Providers {
+		if p.Name == "" {
+			return nil, fmt.Errorf("callback provider name is required")
+		}
+		if _, ok := v.providers[p.Name]; ok {
+			return nil, fmt.Errorf("duplicate callback provider %q", p.Name)
+		}
+		if len(p.Secrets) == 0 && p.Token == "" && len(p.AllowedIPs) == 0 {
+			return nil, fmt.Errorf("callback provider %q has no verification scheme", p.Name)
+		}
+
+		cp := &callbackProvider{CallbackProvider: p}
+		for _, entry := range p.AllowedIPs {
+			network, err := parseNetwork(entry)
+			if err != nil {
+				return nil, fmt.Errorf("callback provider %q: %w", p.Name, err)
+			}
+			cp.networks = append(cp.networks, network)
+		}
+		v.providers[p.Name] = cp
+	}
+
+	return v, nil
+}
+
+// parseNetwork parses a CIDR range or a single address.
+func parseNetwork(entry string) (*net.IPNet, error) {
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed ip %q: %w", entry, err)
+		}
+		return network, nil
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid allowed ip %q", entry)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// Middleware returns a middleware that rejects unverified callbacks.
+// With no providers configured all callbacks are rejected, unless
+// unverified callbacks are allowed. The nonce of a callback is only kept
+// when the handler succeeds, so that the provider can retry a callback we
+// failed to process.
+func (v *CallbackVerifier) Middleware() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(v.providers) == 0 {
+				if v.allowUnverified {
+					next.ServeHTTP(w, r)
+					return
+				}
+				v.reject(w, r, "", errNoProviders)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBody))
+			if err != nil {
+				v.reject(w, r, "", err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			name, nonce, err := v.verify(r, body)
+			if err != nil {
+				v.reject(w, r, name, err)
+				return
+			}
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			succeeded := false
+			defer func() {
+				if !succeeded {
+					v.forget(r, name, nonce)
+				}
+			}()
+
+			next.ServeHTTP(wrapped, r)
+			succeeded = wrapped.statusCode >= 200 && wrapped.statusCode < 300
+		})
+	}
+}
+
+// verify authenticates r and returns the name of the calling provider and
+// the nonce it stored, empty when none was stored.
+func (v *CallbackVerifier) verify(r *http.Request, body []byte) (string, string, error) {
+	name := r.Header.Get(CallbackProviderHeader)
+	if name == "" {
+		name = r.URL.Query().Get("provider")
+	}
+
+	p, ok := v.providers[name]
+	if !ok {
+		return name, "", errUnknownProvider
+	}
+
+	if len(p.networks) > 0 && !p.allows(r.RemoteAddr) {
+		return name, "", errIPNotAllowed
+	}
+
+	if p.Token != "" {
+		token := r.Header.Get(CallbackTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(p.Token)) != 1 {
+			return name, "", errInvalidToken
+		}
+	}
+
+	signature := r.Header.Get(signing.SignatureHeader)
+	timestamp := r.Header.Get(signing.TimestampHeader)
+	nonce := r.Header.Get(CallbackNonceHeader)
+	if len(p.Secrets) > 0 {
+		if err := signing.Verify(p.Secrets, signature, timestamp, body, v.now(), v.tolerance); err != nil {
+			return name, "", err
+		}
+		// Only the timestamp and body are signed, so they identify the
+		// callback; the nonce header and the signature text can be changed
+		// by whoever replays it.
+		sum := sha256.Sum256(body)
+		nonce = timestamp + ":" + hex.EncodeToString(sum[:])
+	} else {
+		// Unsigned callbacks need a fresh timestamp and a nonce, so that
+		// replays are detected within the tolerance and rejected after it.
+		if timestamp == "" {
+			return name, "", errMissingTime
+		}
+		if err := v.checkTimestamp(timestamp); err != nil {
+			return name, "", err
+		}
+		if nonce == "" {
+			return name, "", errMissingNonce
+		}
+	}
+
+	if v.nonces == nil {
+		return name, "", nil
+	}
+	key := name + ":" + nonce
+	fresh, err := v.nonces.Remember(r.Context(), key, 2*v.tolerance)
+	if err != nil {
+		return name, "", fmt.Errorf("failed to check nonce: %w", err)
+	}
+	if !fresh {
+		return name, "", errReplayed
+	}
+
+	return name, key, nil
+}
+
+// forget removes the nonce of a callback that was not processed.
+func (v *CallbackVerifier) forget(r *http.Request, provider, nonce string) {
+	if nonce == "" {
+		return
+	}
+	if err := v.nonces.Forget(context.WithoutCancel(r.Context()), nonce); err != nil {
+		v.logger.Warn("Failed to forget callback nonce, retries are rejected as replays",
+			zap.String("request_id", GetRequestID(r.Context())),
+			zap.String("provider", provider),
+			zap.Error(err))
+	}
+}
+
+// checkTimestamp validates an unsigned callback timestamp against the
+// tolerance.
+func (v *CallbackVerifier) checkTimestamp(timestamp string) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return signing.ErrInvalidTimestamp
+	}
+
+	age := v.now().Sub(time.Unix(ts, 0))
+	if age > v.tolerance || age < -v.tolerance {
+		return signing.ErrExpiredTimestamp
+	}
+	return nil
+}
+
+// allows reports whether remoteAddr is in one of the allowed networks.
+func (p *callbackProvider) allows(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// reject logs the failed verification and writes the error response.
+func (v *CallbackVerifier) reject(w http.ResponseWriter, r *http.Request, provider string, err error) {
+	v.logger.Warn("Rejected callback",
+		zap.String("request_id", GetRequestID(r.Context())),
+		zap.String("provider", provider),
+		zap.String("path", r.URL.Path),
+		zap.String("remote_addr", r.RemoteAddr),
+		zap.Error(err))
+
+	status, code, message := http.StatusUnauthorized, ErrorCodeCallbackUnauthorized, ErrorMessageCallbackUnauthorized
+	switch {
+	case errors.Is(err, errReplayed):
+		status, code, message = http.StatusConflict, ErrorCodeCallbackReplayed, ErrorMessageCallbackReplayed
+	case errors.Is(err, errIPNotAllowed):
+		status = http.StatusForbidden
+	case !isVerificationError(err):
+		status, code, message = http.StatusInternalServerError, ErrorCodeInternal, ErrorMessageInternal
+	}
+
+	w.WriteHeader(status)
+	render.JSON(w, r, map[string]interface{}{
+		"error":   code,
+		"message": message,
+	})
+}
+
+// isVerificationError reports whether err was caused by the request rather
+// than by the verifier itself.
+func isVerificationError(err error) bool {
+	for _, target := range []error{
+		errUnknownProvider,
+		errNoProviders,
+		errInvalidToken,
+		errMissingNonce,
+		errMissingTime,
+		signing.ErrMissingSignature,
+		signing.ErrInvalidTimestamp,
+		signing.ErrExpiredTimestamp,
+		signing.ErrInvalidSignature,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
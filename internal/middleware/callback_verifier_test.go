@@ -0,0 +1,435 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/insdr-messenger/internal/middleware"
+	"github.com/popeskul/insdr-messenger/internal/signing"
+)
+
+type memoryNonceStore struct {
+	mu   sync.Mutex
+	seen map[string]bool
+	err  error
+}
+
+func (s *memoryNonceStore) Remember(_ context.Context, nonce string, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return false, s.err
+	}
+	if s.seen == nil {
+		s.seen = make(map[string]bool)
+	}
+	if s.seen[nonce] {
+		return false, nil
+	}
+	s.seen[nonce] = true
+	return true, nil
+}
+
+func (s *memoryNonceStore) Forget(_ context.Context, nonce string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.seen, nonce)
+	return nil
+}
+
+const callbackBody = `{"messageId":"abc-123","status":"delivered"}`
+
+func signedCallback(provider, secret string, ts time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/callbacks/delivery", strings.NewReader(callbackBody))
+	req.Header.Set(middleware.CallbackProviderHeader, provider)
+	req.Header.Set(signing.TimestampHeader, strconv.FormatInt(ts.Unix(), 10))
+	req.Header.Set(signing.SignatureHeader, signing.Version+"="+signing.Compute(secret, ts.Unix(), []byte(callbackBody)))
+	return req
+}
+
+// unsignedCallback builds a callback with a timestamp and a nonce, as
+// required from providers without secrets.
+func unsignedCallback(target, provider, nonce string, ts time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(callbackBody))
+	if provider != "" {
+		req.Header.Set(middleware.CallbackProviderHeader, provider)
+	}
+	req.Header.Set(signing.TimestampHeader, strconv.FormatInt(ts.Unix(), 10))
+	req.Header.Set(middleware.CallbackNonceHeader, nonce)
+	return req
+}
+
+func newVerifiedHandler(t *testing.T, store middleware.NonceStore) http.Handler {
+	t.Helper()
+
+	verifier, err := middleware.NewCallbackVerifier(&middleware.CallbackConfig{
+		Providers: []middleware.CallbackProvider{
+			{Name: "hmac", Secrets: []string{"new-secret", "old-secret"}},
+			{Name: "token", Token: "s3cret"},
+			{Name: "ip", AllowedIPs: []string{"10.0.0.0/8", "192.168.1.5"}},
+		},
+		Tolerance: time.Minute,
+		Nonces:    store,
+	})
+	require.NoError(t, err)
+
+	return verifier.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, callbackBody, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func TestCallbackVerifier(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		request    func() *http.Request
+		wantStatus int
+	}{
+		{
+			name: "valid signature",
+			request: func() *http.Request {
+				return signedCallback("hmac", "new-secret", now)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "signature with rotated secret",
+			request: func() *http.Request {
+				return signedCallback("hmac", "old-secret", now)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "provider from query parameter",
+			request: func() *http.Request {
+				req := signedCallback("", "new-secret", now)
+				req.URL.RawQuery = "provider=hmac"
+				return req
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "wrong secret",
+			request: func() *http.Request {
+				return signedCallback("hmac", "other", now)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "expired timestamp",
+			request: func() *http.Request {
+				return signedCallback("hmac", "new-secret", now.Add(-2*time.Minute))
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "missing signature",
+			request: func() *http.Request {
+				req := signedCallback("hmac", "new-secret", now)
+				req.Header.Del(signing.SignatureHeader)
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "unknown provider",
+			request: func() *http.Request {
+				return signedCallback("other", "new-secret", now)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "valid token",
+			request: func() *http.Request {
+				req := unsignedCallback("/callbacks/delivery", "token", "n-1", now)
+				req.Header.Set(middleware.CallbackTokenHeader, "s3cret")
+				return req
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "wrong token",
+			request: func() *http.Request {
+				req := unsignedCallback("/callbacks/delivery", "token", "n-1", now)
+				req.Header.Set(middleware.CallbackTokenHeader, "guess")
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "token with expired timestamp",
+			request: func() *http.Request {
+				req := unsignedCallback("/callbacks/delivery", "token", "n-1", now.Add(-time.Hour))
+				req.Header.Set(middleware.CallbackTokenHeader, "s3cret")
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "token without timestamp",
+			request: func() *http.Request {
+				req := unsignedCallback("/callbacks/delivery", "token", "n-1", now)
+				req.Header.Set(middleware.CallbackTokenHeader, "s3cret")
+				req.Header.Del(signing.TimestampHeader)
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "token without nonce",
+			request: func() *http.Request {
+				req := unsignedCallback("/callbacks/delivery", "token", "", now)
+				req.Header.Set(middleware.CallbackTokenHeader, "s3cret")
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "allowed network",
+			request: func() *http.Request {
+				req := unsignedCallback("/callbacks/delivery?provider=ip", "", "n-1", now)
+				req.RemoteAddr = "10.1.2.3:4567"
+				return req
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "allowed address",
+			request: func() *http.Request {
+				req := unsignedCallback("/callbacks/delivery?provider=ip", "", "n-1", now)
+				req.RemoteAddr = "192.168.1.5:4567"
+				return req
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "allowed address without timestamp",
+			request: func() *http.Request {
+				req := unsignedCallback("/callbacks/delivery?provider=ip", "", "n-1", now)
+				req.Header.Del(signing.TimestampHeader)
+				req.RemoteAddr = "192.168.1.5:4567"
+				return req
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "address not allowed",
+			request: func() *http.Request {
+				req := unsignedCallback("/callbacks/delivery?provider=ip", "", "n-1", now)
+				req.RemoteAddr = "192.168.1.6:4567"
+				return req
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newVerifiedHandler(t, &memoryNonceStore{})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, tt.request())
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestCallbackVerifier_Replay(t *testing.T) {
+	handler := newVerifiedHandler(t, &memoryNonceStore{})
+	now := time.Now()
+
+	first := signedCallback("hmac", "new-secret", now)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, first)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	replay := signedCallback("hmac", "new-secret", now)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, replay)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), middleware.ErrorCodeCallbackReplayed)
+
+	nonced := unsignedCallback("/callbacks/delivery", "token", "n-1", now)
+	nonced.Header.Set(middleware.CallbackTokenHeader, "s3cret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, nonced)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	nonced = unsignedCallback("/callbacks/delivery", "token", "n-1", now)
+	nonced.Header.Set(middleware.CallbackTokenHeader, "s3cret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, nonced)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestCallbackVerifier_SignedReplay(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(r *http.Request)
+	}{
+		{
+			name:   "new nonce",
+			tamper: func(r *http.Request) { r.Header.Set(middleware.CallbackNonceHeader, "fresh") },
+		},
+		{
+			name: "extra signature entry",
+			tamper: func(r *http.Request) {
+				r.Header.Set(signing.SignatureHeader, r.Header.Get(signing.SignatureHeader)+","+signing.Version+"=00")
+			},
+		},
+		{
+			name: "upper case signature",
+			tamper: func(r *http.Request) {
+				r.Header.Set(signing.SignatureHeader, signing.Version+"="+
+					strings.ToUpper(strings.TrimPrefix(r.Header.Get(signing.SignatureHeader), signing.Version+"=")))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newVerifiedHandler(t, &memoryNonceStore{})
+			now := time.Now()
+
+			first := signedCallback("hmac", "new-secret", now)
+			first.Header.Set(middleware.CallbackNonceHeader, "original")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, first)
+			assert.Equal(t, http.StatusNoContent, w.Code)
+
+			replay := signedCallback("hmac", "new-secret", now)
+			tt.tamper(replay)
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, replay)
+			assert.Equal(t, http.StatusConflict, w.Code)
+			assert.Contains(t, w.Body.String(), middleware.ErrorCodeCallbackReplayed)
+		})
+	}
+}
+
+func TestCallbackVerifier_NonceStoreError(t *testing.T) {
+	handler := newVerifiedHandler(t, &memoryNonceStore{err: errors.New("redis down")})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, signedCallback("hmac", "new-secret", time.Now()))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestCallbackVerifier_RetryAfterFailure(t *testing.T) {
+	verifier, err := middleware.NewCallbackVerifier(&middleware.CallbackConfig{
+		Providers: []middleware.CallbackProvider{{Name: "hmac", Secrets: []string{"new-secret"}}},
+		Tolerance: time.Minute,
+		Nonces:    &memoryNonceStore{},
+	})
+	require.NoError(t, err)
+
+	status := http.StatusInternalServerError
+	handler := verifier.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	now := time.Now()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, signedCallback("hmac", "new-secret", now))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// The failed callback is accepted again when the provider retries it.
+	status = http.StatusNoContent
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, signedCallback("hmac", "new-secret", now))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, signedCallback("hmac", "new-secret", now))
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestCallbackVerifier_NoProviders(t *testing.T) {
+	tests := []struct {
+		name            string
+		allowUnverified bool
+		wantStatus      int
+	}{
+		{
+			name:       "rejected by default",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:            "accepted when allowed",
+			allowUnverified: true,
+			wantStatus:      http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := middleware.NewCallbackVerifier(&middleware.CallbackConfig{AllowUnverified: tt.allowUnverified})
+			require.NoError(t, err)
+
+			handler := verifier.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/callbacks/delivery", strings.NewReader(callbackBody)))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestNewCallbackVerifier_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []middleware.CallbackProvider
+		wantErr   string
+	}{
+		{
+			name:      "missing name",
+			providers: []middleware.CallbackProvider{{Token: "t"}},
+			wantErr:   "name is required",
+		},
+		{
+			name:      "no scheme",
+			providers: []middleware.CallbackProvider{{Name: "p"}},
+			wantErr:   "no verification scheme",
+		},
+		{
+			name:      "duplicate provider",
+			providers: []middleware.CallbackProvider{{Name: "p", Token: "a"}, {Name: "p", Token: "b"}},
+			wantErr:   "duplicate callback provider",
+		},
+		{
+			name:      "invalid address",
+			providers: []middleware.CallbackProvider{{Name: "p", AllowedIPs: []string{"10.0.0.300"}}},
+			wantErr:   "invalid allowed ip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := middleware.NewCallbackVerifier(&middleware.CallbackConfig{Providers: tt.providers})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
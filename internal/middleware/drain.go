@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-chi/render"
+)
+
+// drainRetryAfter is the Retry-After sent with rejected writes, in seconds.
+// Clients retrying after it reach another instance.
+const drainRetryAfter = "5"
+
+// Drainer rejects API writes with 503 once the server starts draining
+// before shutdown. Reads keep working until the server stops.
+type Drainer struct {
+	draining atomic.Bool
+	exempt   []string
+}
+
+// NewDrainer creates a drainer. Requests to paths starting with one of
+// exemptPrefixes, such as provider callbacks that only finish work already
+// done, are still accepted while draining.
+func NewDrainer(exemptPrefixes ...string) *Drainer {
+	return &Drainer{exempt: exemptPrefixes}
+}
+
+// Start begins draining. It cannot be undone.
+func (d *Drainer) Start() {
+	d.draining.Store(true)
+}
+
+// Draining reports whether Start was called.
+func (d *Drainer) Draining() bool {
+	return d.draining.Load()
+}
+
+// Middleware returns a middleware rejecting writes while draining.
+func (d *Drainer) Middleware() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if d.Draining() && isWrite(r.Method) && !d.isExempt(r.URL.Path) {
+				w.Header().Set("Retry-After", drainRetryAfter)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				render.JSON(w, r, map[string]interface{}{
+					"error":   ErrorCodeDraining,
+					"message": ErrorMessageDraining,
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (d *Drainer) isExempt(path string) bool {
+	for _, prefix := range d.exempt {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
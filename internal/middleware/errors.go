@@ -2,14 +2,20 @@ package middleware
 
 // Common error codes used by middleware
 const (
-	ErrorCodeInternal          = "INTERNAL_ERROR"
-	ErrorCodeRateLimitExceeded = "RATE_LIMIT_EXCEEDED"
-	ErrorCodeRequestTimeout    = "REQUEST_TIMEOUT"
+	ErrorCodeInternal             = "INTERNAL_ERROR"
+	ErrorCodeRateLimitExceeded    = "RATE_LIMIT_EXCEEDED"
+	ErrorCodeRequestTimeout       = "REQUEST_TIMEOUT"
+	ErrorCodeCallbackUnauthorized = "CALLBACK_UNAUTHORIZED"
+	ErrorCodeCallbackReplayed     = "CALLBACK_REPLAYED"
+	ErrorCodeDraining             = "SERVICE_DRAINING"
 )
 
 // Common error messages used by middleware
 const (
-	ErrorMessageInternal          = "An internal error occurred"
-	ErrorMessageRateLimitExceeded = "Too many requests"
-	ErrorMessageRequestTimeout    = "Request timeout"
+	ErrorMessageInternal             = "An internal error occurred"
+	ErrorMessageRateLimitExceeded    = "Too many requests"
+	ErrorMessageRequestTimeout       = "Request timeout"
+	ErrorMessageCallbackUnauthorized = "Callback verification failed"
+	ErrorMessageCallbackReplayed     = "Callback was already received"
+	ErrorMessageDraining             = "Service is shutting down, retry later"
 )
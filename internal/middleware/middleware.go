@@ -18,6 +18,12 @@ type Config struct {
 	RateLimitBurst int
 
 	RequestTimeout time.Duration
+	// StreamPaths serve long-lived responses and are exempt from
+	// RequestTimeout.
+	StreamPaths []string
+
+	// Drainer, when set, rejects writes while the server drains.
+	Drainer *Drainer
 }
 
 // Chain creates a middleware chain with all configured middleware.
@@ -28,7 +34,11 @@ func Chain(config *Config) func(http.Handler) http.Handler {
 		// Apply middleware in order (outer to inner)
 		h := handler
 
-		h = Timeout(config.RequestTimeout)(h)
+		if config.Drainer != nil {
+			h = config.Drainer.Middleware()(h)
+		}
+
+		h = Timeout(config.RequestTimeout, config.StreamPaths...)(h)
 
 		h = rateLimiter.Middleware()(h)
 
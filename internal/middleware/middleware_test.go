@@ -105,3 +105,86 @@ func TestRecovery(t *testing.T) {
 		t.Errorf("Expected status 500 after panic, got %d", w.Code)
 	}
 }
+
+func TestTimeout(t *testing.T) {
+	handler := middleware.Timeout(10*time.Millisecond, "/events/stream")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		if r.Context().Err() == nil {
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	// Regular requests time out
+	req := httptest.NewRequest("GET", "/messages/sent", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestTimeout {
+		t.Errorf("Expected status 408, got %d", w.Code)
+	}
+
+	// Streaming paths are exempt
+	req = httptest.NewRequest("GET", "/events/stream", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for stream path, got %d", w.Code)
+	}
+}
+
+func TestLogger_Flush(t *testing.T) {
+	handler := middleware.Logger(zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Expected flush support, got %v", err)
+		}
+	}))
+
+	req := httptest.NewRequest("GET", "/events/stream", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if !w.Flushed {
+		t.Error("Expected response to be flushed")
+	}
+}
+
+func TestDrainer(t *testing.T) {
+	drainer := middleware.NewDrainer("/callbacks/")
+	handler := drainer.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		draining   bool
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{name: "write before draining", method: http.MethodPost, path: "/messages", wantStatus: http.StatusOK},
+		{name: "read while draining", draining: true, method: http.MethodGet, path: "/messages/sent", wantStatus: http.StatusOK},
+		{name: "write while draining", draining: true, method: http.MethodPost, path: "/messages", wantStatus: http.StatusServiceUnavailable},
+		{name: "delete while draining", draining: true, method: http.MethodDelete, path: "/subscriptions/1", wantStatus: http.StatusServiceUnavailable},
+		{name: "exempt write while draining", draining: true, method: http.MethodPost, path: "/callbacks/delivery", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.draining {
+				drainer.Start()
+			}
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusServiceUnavailable && w.Header().Get("Retry-After") == "" {
+				t.Error("Expected Retry-After header")
+			}
+		})
+	}
+}
@@ -9,10 +9,21 @@ import (
 	"github.com/go-chi/render"
 )
 
-// Timeout middleware adds a timeout to requests.
-func Timeout(timeout time.Duration) func(next http.Handler) http.Handler {
+// Timeout middleware adds a timeout to requests. Requests to skipPaths,
+// such as long-lived streaming responses, are passed through unchanged.
+func Timeout(timeout time.Duration, skipPaths ...string) func(next http.Handler) http.Handler {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = true
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			ctx, cancel := context.WithTimeout(r.Context(), timeout)
 			defer cancel()
 
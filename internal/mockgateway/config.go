@@ -0,0 +1,166 @@
+// Package mockgateway implements a local SMS gateway speaking the webhook
+// protocol, with injectable faults, for development and CI.
+package mockgateway
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Latency distributions.
+const (
+	LatencyFixed       = "fixed"
+	LatencyUniform     = "uniform"
+	LatencyNormal      = "normal"
+	LatencyExponential = "exponential"
+)
+
+// Config holds the faults injected into send requests. Rates are
+// probabilities between 0 and 1, evaluated per request in the order
+// outage, throttling, errors, malformed responses.
+type Config struct {
+	// ErrorRate is the share of requests failing with ErrorStatus.
+	ErrorRate   float64 `json:"error_rate"`
+	ErrorStatus int     `json:"error_status"`
+	// ThrottleRate is the share of requests answered with 429 and a
+	// Retry-After of RetryAfter seconds.
+	ThrottleRate float64 `json:"throttle_rate"`
+	RetryAfter   int     `json:"retry_after"`
+	// MalformedRate is the share of accepted requests answered with a body
+	// that is not valid JSON.
+	MalformedRate float64  `json:"malformed_rate"`
+	Latency       Latency  `json:"latency"`
+	Outage        Outage   `json:"outage"`
+	Receipts      Receipts `json:"receipts"`
+}
+
+// Latency describes the response delay in milliseconds.
+//
+//   - fixed: Mean.
+//   - uniform: between Min and Max.
+//   - normal: Mean with standard deviation StdDev.
+//   - exponential: Min plus an exponential delay averaging Mean.
+//
+// Delays are clamped to Min and, when set, Max.
+type Latency struct {
+	Distribution string `json:"distribution"`
+	Mean         int    `json:"mean_ms"`
+	StdDev       int    `json:"stddev_ms"`
+	Min          int    `json:"min_ms"`
+	Max          int    `json:"max_ms"`
+}
+
+// Outage makes the gateway answer 503 during the last Duration seconds of
+// every Every seconds since it started.
+type Outage struct {
+	Every    int `json:"every"`
+	Duration int `json:"duration"`
+}
+
+// Receipts configures delivery receipts sent for accepted messages.
+// Receipts are disabled while URL is empty.
+type Receipts struct {
+	// URL receives the receipts, e.g. http://localhost:8080/callbacks/delivery.
+	URL string `json:"url"`
+	// Delay is the time in milliseconds between acceptance and receipt.
+	Delay int `json:"delay_ms"`
+	// DeliveredRate is the share of receipts reporting delivered; the rest
+	// report undelivered.
+	DeliveredRate float64 `json:"delivered_rate"`
+	// Provider, Token and Secret identify and authenticate receipts
+	// towards the callback verification of the messenger.
+	Provider string `json:"provider"`
+	Token    string `json:"token"`
+	Secret   string `json:"secret"`
+}
+
+// DefaultConfig returns a gateway that accepts everything at once.
+func DefaultConfig() Config {
+	return Config{
+		ErrorStatus: http.StatusInternalServerError,
+		RetryAfter:  1,
+		Latency:     Latency{Distribution: LatencyFixed},
+		Receipts:    Receipts{DeliveredRate: 1},
+	}
+}
+
+// Validate reports the first invalid setting.
+func (c Config) Validate() error {
+	rates := map[string]float64{
+		"error_rate":              c.ErrorRate,
+		"throttle_rate":           c.ThrottleRate,
+		"malformed_rate":          c.MalformedRate,
+		"receipts.delivered_rate": c.Receipts.DeliveredRate,
+	}
+	for name, rate := range rates {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1", name)
+		}
+	}
+
+	if c.ErrorStatus < 400 || c.ErrorStatus > 599 {
+		return fmt.Errorf("error_status must be a 4xx or 5xx status, got %d", c.ErrorStatus)
+	}
+	if c.RetryAfter < 0 {
+		return errors.New("retry_after must not be negative")
+	}
+
+	l := c.Latency
+	switch l.Distribution {
+	case LatencyFixed, LatencyUniform, LatencyNormal, LatencyExponential:
+	default:
+		return fmt.Errorf("unknown latency distribution %q", l.Distribution)
+	}
+	if l.Mean < 0 || l.StdDev < 0 || l.Min < 0 || l.Max < 0 {
+		return errors.New("latency must not be negative")
+	}
+	if l.Max > 0 && l.Max < l.Min {
+		return errors.New("latency max_ms must not be below min_ms")
+	}
+
+	if c.Outage.Every < 0 || c.Outage.Duration < 0 || c.Outage.Duration > c.Outage.Every {
+		return errors.New("outage duration must be between 0 and every")
+	}
+	if c.Receipts.Delay < 0 {
+		return errors.New("receipts delay_ms must not be negative")
+	}
+
+	return nil
+}
+
+// sample draws a delay from the distribution.
+func (l Latency) sample(rnd *rand.Rand) time.Duration {
+	var ms float64
+	switch l.Distribution {
+	case LatencyUniform:
+		ms = float64(l.Min)
+		if l.Max > l.Min {
+			ms += rnd.Float64() * float64(l.Max-l.Min)
+		}
+	case LatencyNormal:
+		ms = float64(l.Mean) + rnd.NormFloat64()*float64(l.StdDev)
+	case LatencyExponential:
+		ms = float64(l.Min) + rnd.ExpFloat64()*float64(l.Mean)
+	default:
+		ms = float64(l.Mean)
+	}
+
+	ms = math.Max(ms, float64(l.Min))
+	if l.Max > 0 {
+		ms = math.Min(ms, float64(l.Max))
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// inOutage reports whether the gateway is down elapsed after its start.
+func (o Outage) inOutage(elapsed time.Duration) bool {
+	if o.Every == 0 || o.Duration == 0 {
+		return false
+	}
+	every := time.Duration(o.Every) * time.Second
+	return elapsed%every >= every-time.Duration(o.Duration)*time.Second
+}
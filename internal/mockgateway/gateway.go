@@ -0,0 +1,353 @@
+package mockgateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
+
+// AuthKeyHeader carries the static key the webhook provider authenticates
+// with.
+const AuthKeyHeader = "x-ins-auth-key"
+
+// adminPrefix is the path prefix of the admin API. Every other path
+// accepts messages.
+const adminPrefix = "/admin/"
+
+// Options configures a Gateway.
+type Options struct {
+	// AuthKey, when set, must match the AuthKeyHeader of send requests.
+	AuthKey string
+	// Seed seeds fault injection. Zero seeds from the clock.
+	Seed   int64
+	Logger *zap.Logger
+	// Client sends delivery receipts. Defaults to a client with a 10s
+	// timeout.
+	Client *http.Client
+}
+
+// Stats counts send requests by outcome.
+type Stats struct {
+	Requests       int64 `json:"requests"`
+	Accepted       int64 `json:"accepted"`
+	Unauthorized   int64 `json:"unauthorized"`
+	BadRequests    int64 `json:"bad_requests"`
+	Outages        int64 `json:"outages"`
+	Throttled      int64 `json:"throttled"`
+	Errors         int64 `json:"errors"`
+	Malformed      int64 `json:"malformed"`
+	ReceiptsSent   int64 `json:"receipts_sent"`
+	ReceiptsFailed int64 `json:"receipts_failed"`
+}
+
+// Gateway is an http.Handler simulating an SMS gateway.
+type Gateway struct {
+	authKey string
+	logger  *zap.Logger
+	client  *http.Client
+	started time.Time
+
+	mu          sync.Mutex
+	cfg         Config
+	rnd         *rand.Rand
+	outageUntil time.Time
+
+	requests       atomic.Int64
+	accepted       atomic.Int64
+	unauthorized   atomic.Int64
+	badRequests    atomic.Int64
+	outages        atomic.Int64
+	throttled      atomic.Int64
+	failed         atomic.Int64
+	malformed      atomic.Int64
+	receiptsSent   atomic.Int64
+	receiptsFailed atomic.Int64
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	pending sync.WaitGroup
+	mux     *http.ServeMux
+}
+
+// New creates a gateway injecting the faults of cfg.
+func New(cfg Config, opts Options) (*Gateway, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &Gateway{
+		authKey: opts.AuthKey,
+		logger:  logger,
+		client:  client,
+		started: time.Now(),
+		cfg:     cfg,
+		rnd:     rand.New(rand.NewSource(seed)),
+		ctx:     ctx,
+		cancel:  cancel,
+		mux:     http.NewServeMux(),
+	}
+
+	g.mux.HandleFunc(adminPrefix+"faults", g.handleFaults)
+	g.mux.HandleFunc(adminPrefix+"outage", g.handleOutage)
+	g.mux.HandleFunc(adminPrefix+"stats", g.handleStats)
+	g.mux.HandleFunc("/", g.handleSend)
+
+	return g, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// Close cancels pending delivery receipts and waits for those in flight.
+func (g *Gateway) Close() {
+	g.cancel()
+	g.pending.Wait()
+}
+
+// Config returns the current fault configuration.
+func (g *Gateway) Config() Config {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.cfg
+}
+
+// SetConfig replaces the fault configuration.
+func (g *Gateway) SetConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cfg = cfg
+	return nil
+}
+
+// StartOutage makes the gateway answer 503 for d, in addition to the
+// configured outage windows. A zero d ends a running outage.
+func (g *Gateway) StartOutage(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.outageUntil = time.Now().Add(d)
+}
+
+// Stats returns the request counters.
+func (g *Gateway) Stats() Stats {
+	return Stats{
+		Requests:       g.requests.Load(),
+		Accepted:       g.accepted.Load(),
+		Unauthorized:   g.unauthorized.Load(),
+		BadRequests:    g.badRequests.Load(),
+		Outages:        g.outages.Load(),
+		Throttled:      g.throttled.Load(),
+		Errors:         g.failed.Load(),
+		Malformed:      g.malformed.Load(),
+		ReceiptsSent:   g.receiptsSent.Load(),
+		ReceiptsFailed: g.receiptsFailed.Load(),
+	}
+}
+
+// outcome is the fault drawn for a single send request.
+type outcome int
+
+const (
+	outcomeAccept outcome = iota
+	outcomeOutage
+	outcomeThrottle
+	outcomeError
+	outcomeMalformed
+)
+
+// draw picks the outcome and latency of a request under a single lock so
+// that a seeded gateway is reproducible for sequential clients.
+func (g *Gateway) draw(now time.Time) (Config, outcome, time.Duration, string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	cfg := g.cfg
+	id := g.messageID()
+	if now.Before(g.outageUntil) || cfg.Outage.inOutage(now.Sub(g.started)) {
+		return cfg, outcomeOutage, 0, id
+	}
+
+	delay := cfg.Latency.sample(g.rnd)
+	switch {
+	case g.rnd.Float64() < cfg.ThrottleRate:
+		return cfg, outcomeThrottle, delay, id
+	case g.rnd.Float64() < cfg.ErrorRate:
+		return cfg, outcomeError, delay, id
+	case g.rnd.Float64() < cfg.MalformedRate:
+		return cfg, outcomeMalformed, delay, id
+	}
+	return cfg, outcomeAccept, delay, id
+}
+
+// messageID returns a random gateway message ID. Callers hold g.mu.
+func (g *Gateway) messageID() string {
+	b := make([]byte, 16)
+	g.rnd.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (g *Gateway) handleSend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, models.WebhookResponse{Message: "Method not allowed"})
+		return
+	}
+	g.requests.Add(1)
+
+	if g.authKey != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(AuthKeyHeader)), []byte(g.authKey)) != 1 {
+		g.unauthorized.Add(1)
+		writeJSON(w, http.StatusUnauthorized, models.WebhookResponse{Message: "Invalid auth key"})
+		return
+	}
+
+	var req models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.To == "" || req.Content == "" {
+		g.badRequests.Add(1)
+		writeJSON(w, http.StatusBadRequest, models.WebhookResponse{Message: "Invalid request"})
+		return
+	}
+
+	cfg, result, delay, id := g.draw(time.Now())
+	if result == outcomeOutage {
+		g.outages.Add(1)
+		writeJSON(w, http.StatusServiceUnavailable, models.WebhookResponse{Message: "Service unavailable"})
+		return
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+			return
+		}
+	}
+
+	switch result {
+	case outcomeThrottle:
+		g.throttled.Add(1)
+		w.Header().Set("Retry-After", strconv.Itoa(cfg.RetryAfter))
+		writeJSON(w, http.StatusTooManyRequests, models.WebhookResponse{Message: "Too many requests"})
+	case outcomeError:
+		g.failed.Add(1)
+		writeJSON(w, cfg.ErrorStatus, models.WebhookResponse{Message: http.StatusText(cfg.ErrorStatus)})
+	case outcomeMalformed:
+		g.malformed.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"message":"Accepted","messageId":"` + id))
+		g.scheduleReceipt(cfg.Receipts, id)
+	default:
+		g.accepted.Add(1)
+		writeJSON(w, http.StatusAccepted, models.WebhookResponse{Message: "Accepted", MessageID: id})
+		g.scheduleReceipt(cfg.Receipts, id)
+	}
+
+	g.logger.Debug("Handled send request",
+		zap.String("to", req.To),
+		zap.String("message_id", id),
+		zap.Int("outcome", int(result)),
+		zap.Duration("latency", delay))
+}
+
+func (g *Gateway) handleFaults(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, g.Config())
+	case http.MethodPut:
+		cfg := DefaultConfig()
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		if err := g.SetConfig(cfg); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		g.logger.Info("Fault configuration updated")
+		writeJSON(w, http.StatusOK, cfg)
+	default:
+		w.Header().Set("Allow", strings.Join([]string{http.MethodGet, http.MethodPut}, ", "))
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// outageRequest is the body of POST /admin/outage.
+type outageRequest struct {
+	// Duration is a Go duration such as "30s"; "0s" ends the outage.
+	Duration string `json:"duration"`
+}
+
+func (g *Gateway) handleOutage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var body outageRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	d, err := time.ParseDuration(body.Duration)
+	if err != nil || d < 0 {
+		writeError(w, http.StatusBadRequest, "duration must be a non-negative Go duration")
+		return
+	}
+
+	g.StartOutage(d)
+	g.logger.Info("Outage started", zap.Duration("duration", d))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (g *Gateway) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, g.Stats())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,309 @@
+package mockgateway_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/insdr-messenger/internal/api"
+	"github.com/popeskul/insdr-messenger/internal/middleware"
+	"github.com/popeskul/insdr-messenger/internal/mockgateway"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/signing"
+)
+
+const sendBody = `{"to":"+905551111111","content":"Hello"}`
+
+func newGateway(t *testing.T, cfg mockgateway.Config, opts mockgateway.Options) *mockgateway.Gateway {
+	t.Helper()
+
+	opts.Seed = 1
+	g, err := mockgateway.New(cfg, opts)
+	require.NoError(t, err)
+	t.Cleanup(g.Close)
+	return g
+}
+
+func send(g http.Handler, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	return w
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*mockgateway.Config)
+		wantErr string
+	}{
+		{
+			name:   "default",
+			modify: func(*mockgateway.Config) {},
+		},
+		{
+			name:    "rate above one",
+			modify:  func(c *mockgateway.Config) { c.ErrorRate = 1.5 },
+			wantErr: "error_rate",
+		},
+		{
+			name:    "negative rate",
+			modify:  func(c *mockgateway.Config) { c.Receipts.DeliveredRate = -0.1 },
+			wantErr: "receipts.delivered_rate",
+		},
+		{
+			name:    "success error status",
+			modify:  func(c *mockgateway.Config) { c.ErrorStatus = http.StatusOK },
+			wantErr: "error_status",
+		},
+		{
+			name:    "unknown distribution",
+			modify:  func(c *mockgateway.Config) { c.Latency.Distribution = "pareto" },
+			wantErr: "distribution",
+		},
+		{
+			name:    "max below min",
+			modify:  func(c *mockgateway.Config) { c.Latency.Min, c.Latency.Max = 100, 50 },
+			wantErr: "max_ms",
+		},
+		{
+			name:    "outage longer than period",
+			modify:  func(c *mockgateway.Config) { c.Outage = mockgateway.Outage{Every: 10, Duration: 20} },
+			wantErr: "outage",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := mockgateway.DefaultConfig()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestGateway_Send(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(*mockgateway.Config)
+		authKey    string
+		header     http.Header
+		body       string
+		wantStatus int
+		check      func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "accepted",
+			modify:     func(*mockgateway.Config) {},
+			body:       sendBody,
+			wantStatus: http.StatusAccepted,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp models.WebhookResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, "Accepted", resp.Message)
+				assert.Len(t, resp.MessageID, 32)
+			},
+		},
+		{
+			name:       "invalid body",
+			modify:     func(*mockgateway.Config) {},
+			body:       `{"to":""}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing auth key",
+			modify:     func(*mockgateway.Config) {},
+			authKey:    "secret",
+			body:       sendBody,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "valid auth key",
+			modify:     func(*mockgateway.Config) {},
+			authKey:    "secret",
+			header:     http.Header{http.CanonicalHeaderKey(mockgateway.AuthKeyHeader): {"secret"}},
+			body:       sendBody,
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name: "throttled",
+			modify: func(c *mockgateway.Config) {
+				c.ThrottleRate = 1
+				c.RetryAfter = 7
+			},
+			body:       sendBody,
+			wantStatus: http.StatusTooManyRequests,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, "7", w.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name: "error",
+			modify: func(c *mockgateway.Config) {
+				c.ErrorRate = 1
+				c.ErrorStatus = http.StatusBadGateway
+			},
+			body:       sendBody,
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:       "malformed",
+			modify:     func(c *mockgateway.Config) { c.MalformedRate = 1 },
+			body:       sendBody,
+			wantStatus: http.StatusAccepted,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp models.WebhookResponse
+				assert.Error(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			},
+		},
+		{
+			name: "latency",
+			modify: func(c *mockgateway.Config) {
+				c.Latency = mockgateway.Latency{Distribution: mockgateway.LatencyUniform, Min: 20, Max: 30}
+			},
+			body:       sendBody,
+			wantStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := mockgateway.DefaultConfig()
+			tt.modify(&cfg)
+			g := newGateway(t, cfg, mockgateway.Options{AuthKey: tt.authKey})
+
+			start := time.Now()
+			w := send(g, tt.body, tt.header)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.GreaterOrEqual(t, time.Since(start), time.Duration(cfg.Latency.Min)*time.Millisecond)
+			if tt.check != nil {
+				tt.check(t, w)
+			}
+		})
+	}
+}
+
+func TestGateway_Outage(t *testing.T) {
+	g := newGateway(t, mockgateway.DefaultConfig(), mockgateway.Options{})
+
+	g.StartOutage(time.Minute)
+	assert.Equal(t, http.StatusServiceUnavailable, send(g, sendBody, nil).Code)
+
+	g.StartOutage(0)
+	assert.Equal(t, http.StatusAccepted, send(g, sendBody, nil).Code)
+
+	stats := g.Stats()
+	assert.Equal(t, int64(2), stats.Requests)
+	assert.Equal(t, int64(1), stats.Outages)
+	assert.Equal(t, int64(1), stats.Accepted)
+}
+
+func TestGateway_OutageWindow(t *testing.T) {
+	cfg := mockgateway.DefaultConfig()
+	cfg.Outage = mockgateway.Outage{Every: 60, Duration: 60}
+	g := newGateway(t, cfg, mockgateway.Options{})
+
+	assert.Equal(t, http.StatusServiceUnavailable, send(g, sendBody, nil).Code)
+}
+
+func TestGateway_Admin(t *testing.T) {
+	g := newGateway(t, mockgateway.DefaultConfig(), mockgateway.Options{})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "get faults", method: http.MethodGet, path: "/admin/faults", wantStatus: http.StatusOK},
+		{name: "invalid faults", method: http.MethodPut, path: "/admin/faults", body: `{"error_rate":2}`, wantStatus: http.StatusBadRequest},
+		{name: "update faults", method: http.MethodPut, path: "/admin/faults", body: `{"throttle_rate":1}`, wantStatus: http.StatusOK},
+		{name: "invalid outage", method: http.MethodPost, path: "/admin/outage", body: `{"duration":"soon"}`, wantStatus: http.StatusBadRequest},
+		{name: "start outage", method: http.MethodPost, path: "/admin/outage", body: `{"duration":"0s"}`, wantStatus: http.StatusNoContent},
+		{name: "stats", method: http.MethodGet, path: "/admin/stats", wantStatus: http.StatusOK},
+		{name: "wrong method", method: http.MethodDelete, path: "/admin/stats", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+
+	// The update keeps defaults for omitted fields.
+	cfg := g.Config()
+	assert.Equal(t, 1.0, cfg.ThrottleRate)
+	assert.Equal(t, http.StatusInternalServerError, cfg.ErrorStatus)
+	assert.Equal(t, http.StatusTooManyRequests, send(g, sendBody, nil).Code)
+}
+
+func TestGateway_Receipts(t *testing.T) {
+	type received struct {
+		header  http.Header
+		body    []byte
+		receipt api.DeliveryReceipt
+	}
+	receipts := make(chan received, 1)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var receipt api.DeliveryReceipt
+		_ = json.Unmarshal(body, &receipt)
+		receipts <- received{header: r.Header, body: body, receipt: receipt}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer callback.Close()
+
+	cfg := mockgateway.DefaultConfig()
+	cfg.Receipts = mockgateway.Receipts{
+		URL:           callback.URL,
+		DeliveredRate: 0,
+		Provider:      "primary",
+		Token:         "callback-token",
+		Secret:        "callback-secret",
+	}
+	g := newGateway(t, cfg, mockgateway.Options{})
+
+	w := send(g, sendBody, nil)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var resp models.WebhookResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	select {
+	case got := <-receipts:
+		assert.Equal(t, resp.MessageID, got.receipt.MessageId)
+		assert.Equal(t, api.DeliveryReceiptStatusUndelivered, got.receipt.Status)
+		assert.NotNil(t, got.receipt.Error)
+		assert.Equal(t, "primary", got.header.Get(middleware.CallbackProviderHeader))
+		assert.Equal(t, "callback-token", got.header.Get(middleware.CallbackTokenHeader))
+		assert.NoError(t, signing.Verify([]string{"callback-secret"},
+			got.header.Get(signing.SignatureHeader), got.header.Get(signing.TimestampHeader),
+			got.body, time.Now(), time.Minute))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery receipt received")
+	}
+
+	require.Eventually(t, func() bool { return g.Stats().ReceiptsSent == 1 }, time.Second, 10*time.Millisecond)
+}
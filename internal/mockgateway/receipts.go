@@ -0,0 +1,105 @@
+package mockgateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/api"
+	"github.com/popeskul/insdr-messenger/internal/middleware"
+	"github.com/popeskul/insdr-messenger/internal/signing"
+)
+
+// scheduleReceipt sends a delivery receipt for the message after the
+// configured delay. The outcome is drawn when the message is accepted.
+func (g *Gateway) scheduleReceipt(cfg Receipts, externalID string) {
+	if cfg.URL == "" {
+		return
+	}
+
+	g.mu.Lock()
+	delivered := g.rnd.Float64() < cfg.DeliveredRate
+	g.mu.Unlock()
+
+	g.pending.Add(1)
+	go func() {
+		defer g.pending.Done()
+
+		timer := time.NewTimer(time.Duration(cfg.Delay) * time.Millisecond)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-g.ctx.Done():
+			return
+		}
+
+		if err := g.sendReceipt(cfg, externalID, delivered); err != nil {
+			g.receiptsFailed.Add(1)
+			g.logger.Warn("Failed to send delivery receipt",
+				zap.String("message_id", externalID),
+				zap.Error(err))
+			return
+		}
+		g.receiptsSent.Add(1)
+	}()
+}
+
+func (g *Gateway) sendReceipt(cfg Receipts, externalID string, delivered bool) error {
+	now := time.Now().UTC()
+	receipt := api.DeliveryReceipt{
+		MessageId: externalID,
+		Status:    api.DeliveryReceiptStatusDelivered,
+		Timestamp: &now,
+	}
+	if !delivered {
+		reason := "handset unreachable"
+		receipt.Status = api.DeliveryReceiptStatusUndelivered
+		receipt.Error = &reason
+	}
+
+	body, err := json.Marshal(receipt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal receipt: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(g.ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.Provider != "" {
+		req.Header.Set(middleware.CallbackProviderHeader, cfg.Provider)
+	}
+	if cfg.Token != "" {
+		req.Header.Set(middleware.CallbackTokenHeader, cfg.Token)
+	}
+	if cfg.Secret != "" {
+		signing.NewSigner([]string{cfg.Secret}).Sign(req, body)
+	} else {
+		// Every message gets a single receipt, so its ID is a unique nonce.
+		req.Header.Set(middleware.CallbackNonceHeader, externalID)
+		req.Header.Set(signing.TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			g.logger.Warn("Failed to close response body", zap.Error(err))
+		}
+	}()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/popeskul/insdr-messenger/internal/api"
+)
+
+type EventType = api.EventType
+
+const (
+	EventMessageSent        = api.MessageSent
+	EventMessageDelivered   = api.MessageDelivered
+	EventMessageUndelivered = api.MessageUndelivered
+	EventMessageFailed      = api.MessageFailed
+	EventSchedulerStarted   = api.SchedulerStarted
+	EventSchedulerStopped   = api.SchedulerStopped
+)
+
+// EventTypes returns every event type subscriptions may select.
+func EventTypes() []EventType {
+	return []EventType{
+		EventMessageSent,
+		EventMessageDelivered,
+		EventMessageUndelivered,
+		EventMessageFailed,
+		EventSchedulerStarted,
+		EventSchedulerStopped,
+	}
+}
+
+// IsValidEventType reports whether t is a known event type.
+func IsValidEventType(t EventType) bool {
+	for _, known := range EventTypes() {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery states of an outbox event.
+const (
+	EventStatusPending   = "pending"
+	EventStatusDelivered = "delivered"
+	EventStatusDead      = "dead"
+)
+
+// Subscription is a URL receiving events of the selected types.
+type Subscription struct {
+	ID         int64          `db:"id" json:"id"`
+	URL        string         `db:"url" json:"url"`
+	EventTypes pq.StringArray `db:"event_types" json:"event_types"`
+	Secret     string         `db:"secret" json:"-"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+}
+
+// OutboxEvent is an event waiting for, or done with, delivery to one
+// subscription. URL and Secret are read from the subscription when the
+// event is claimed for delivery.
+type OutboxEvent struct {
+	ID             int64          `db:"id" json:"id"`
+	SubscriptionID int64          `db:"subscription_id" json:"subscription_id"`
+	EventType      EventType      `db:"event_type" json:"event_type"`
+	Payload        []byte         `db:"payload" json:"payload"`
+	Status         string         `db:"status" json:"status"`
+	Attempts       int            `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time      `db:"next_attempt_at" json:"next_attempt_at"`
+	LastError      sql.NullString `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at" json:"updated_at"`
+	URL            string         `db:"url" json:"-"`
+	Secret         string         `db:"secret" json:"-"`
+}
@@ -3,6 +3,7 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/popeskul/insdr-messenger/internal/api"
@@ -11,22 +12,88 @@ import (
 type MessageStatus = api.MessageStatus
 
 const (
-	MessageStatusPending = api.Pending
-	MessageStatusSent    = api.Sent
-	MessageStatusFailed  = api.Failed
+	MessageStatusQueued              = api.MessageStatusQueued
+	MessageStatusProcessing          = api.MessageStatusProcessing
+	MessageStatusAcceptedUnconfirmed = api.MessageStatusAcceptedUnconfirmed
+	MessageStatusSent                = api.MessageStatusSent
+	MessageStatusDelivered           = api.MessageStatusDelivered
+	MessageStatusUndelivered         = api.MessageStatusUndelivered
+	MessageStatusFailed              = api.MessageStatusFailed
+	MessageStatusCancelled           = api.MessageStatusCancelled
+	MessageStatusExpired             = api.MessageStatusExpired
 )
 
 // Message represents a message in the database.
 type Message struct {
-	ID          int64          `db:"id" json:"id"`
-	PhoneNumber string         `db:"phone_number" json:"phone_number"`
-	Content     string         `db:"content" json:"content"`
-	Status      MessageStatus  `db:"status" json:"status"`
-	MessageID   sql.NullString `db:"message_id" json:"message_id,omitempty"`
-	Error       sql.NullString `db:"error" json:"error,omitempty"`
-	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
-	SentAt      sql.NullTime   `db:"sent_at" json:"sent_at,omitempty"`
-	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+	ID               int64          `db:"id" json:"id"`
+	PhoneNumber      string         `db:"phone_number" json:"phone_number"`
+	Content          string         `db:"content" json:"content"`
+	Status           MessageStatus  `db:"status" json:"status"`
+	MessageID        sql.NullString `db:"message_id" json:"message_id,omitempty"`
+	Provider         sql.NullString `db:"provider" json:"provider,omitempty"`
+	ProviderResponse sql.NullString `db:"provider_response" json:"provider_response,omitempty"`
+	Error            sql.NullString `db:"error" json:"error,omitempty"`
+	Priority         int            `db:"priority" json:"priority"`
+	Campaign         sql.NullString `db:"campaign" json:"campaign,omitempty"`
+	CreatedAt        time.Time      `db:"created_at" json:"created_at"`
+	SentAt           sql.NullTime   `db:"sent_at" json:"sent_at,omitempty"`
+	DeliveredAt      sql.NullTime   `db:"delivered_at" json:"delivered_at,omitempty"`
+	UpdatedAt        time.Time      `db:"updated_at" json:"updated_at"`
+	// ClaimToken identifies the claim that moved the message to
+	// processing. Outcomes are only written with the current token.
+	ClaimToken sql.NullString `db:"claim_token" json:"-"`
+}
+
+// StatusUpdate is the outcome of a send, written together with the
+// outcomes of other sends. Empty strings are stored as NULL. ClaimToken is
+// the token of the claim the send was made under.
+type StatusUpdate struct {
+	ID         int64         `json:"id"`
+	Status     MessageStatus `json:"status"`
+	ExternalID string        `json:"external_id,omitempty"`
+	Provider   string        `json:"provider,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	At         time.Time     `json:"at"`
+	ClaimToken string        `json:"claim_token,omitempty"`
+}
+
+// StatusChange is a single entry of a message's status history.
+type StatusChange struct {
+	ID         int64          `db:"id" json:"id"`
+	MessageID  int64          `db:"message_id" json:"message_id"`
+	FromStatus sql.NullString `db:"from_status" json:"from_status,omitempty"`
+	ToStatus   MessageStatus  `db:"to_status" json:"to_status"`
+	Error      sql.NullString `db:"error" json:"error,omitempty"`
+	ChangedAt  time.Time      `db:"changed_at" json:"changed_at"`
+}
+
+// DeliveryAttempt records a single call to a delivery provider.
+type DeliveryAttempt struct {
+	ID           int64          `db:"id" json:"id"`
+	MessageID    int64          `db:"message_id" json:"message_id"`
+	Provider     string         `db:"provider" json:"provider"`
+	Route        string         `db:"route" json:"route"`
+	RequestedAt  time.Time      `db:"requested_at" json:"requested_at"`
+	LatencyMs    int64          `db:"latency_ms" json:"latency_ms"`
+	StatusCode   sql.NullInt32  `db:"status_code" json:"status_code,omitempty"`
+	ResponseBody sql.NullString `db:"response_body" json:"response_body,omitempty"`
+	Error        sql.NullString `db:"error" json:"error,omitempty"`
+	ErrorClass   sql.NullString `db:"error_class" json:"error_class,omitempty"`
+	BreakerState string         `db:"breaker_state" json:"breaker_state"`
+}
+
+// SandboxRequest is a provider request recorded in sandbox mode instead
+// of being sent. Headers holds a JSON object of header values.
+type SandboxRequest struct {
+	ID         int64           `db:"id" json:"id"`
+	MessageID  int64           `db:"message_id" json:"message_id"`
+	Provider   string          `db:"provider" json:"provider"`
+	ExternalID string          `db:"external_id" json:"external_id"`
+	Method     string          `db:"method" json:"method"`
+	URL        string          `db:"url" json:"url"`
+	Headers    json.RawMessage `db:"headers" json:"headers"`
+	Body       string          `db:"body" json:"body"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
 }
 
 type WebhookRequest struct {
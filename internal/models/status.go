@@ -0,0 +1,61 @@
+package models
+
+// transitions lists the statuses a message may move to from each status.
+// Statuses without an entry are final.
+//
+//	queued -> processing -> sent -> delivered | undelivered
+//	   |          |
+//	   |          +-> accepted_unconfirmed -> sent | delivered | undelivered | failed
+//	   |          +-> failed, or back to queued when a claim is released
+//	   +-> cancelled | expired
+var transitions = map[MessageStatus][]MessageStatus{
+	MessageStatusQueued:     {MessageStatusProcessing, MessageStatusCancelled, MessageStatusExpired},
+	MessageStatusProcessing: {MessageStatusSent, MessageStatusAcceptedUnconfirmed, MessageStatusFailed, MessageStatusQueued},
+	MessageStatusAcceptedUnconfirmed: {
+		MessageStatusSent, MessageStatusDelivered, MessageStatusUndelivered, MessageStatusFailed,
+	},
+	MessageStatusSent: {MessageStatusDelivered, MessageStatusUndelivered},
+}
+
+// CanTransition reports whether a message may move from one status to
+// another.
+func CanTransition(from, to MessageStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionSources returns the statuses a message may move to the given
+// status from.
+func TransitionSources(to MessageStatus) []MessageStatus {
+	var sources []MessageStatus
+	for _, from := range Statuses() {
+		if CanTransition(from, to) {
+			sources = append(sources, from)
+		}
+	}
+	return sources
+}
+
+// IsFinal reports whether no further transitions are allowed from status.
+func IsFinal(status MessageStatus) bool {
+	return len(transitions[status]) == 0
+}
+
+// Statuses returns every message status in lifecycle order.
+func Statuses() []MessageStatus {
+	return []MessageStatus{
+		MessageStatusQueued,
+		MessageStatusProcessing,
+		MessageStatusAcceptedUnconfirmed,
+		MessageStatusSent,
+		MessageStatusDelivered,
+		MessageStatusUndelivered,
+		MessageStatusFailed,
+		MessageStatusCancelled,
+		MessageStatusExpired,
+	}
+}
@@ -0,0 +1,60 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from     models.MessageStatus
+		to       models.MessageStatus
+		expected bool
+	}{
+		{from: models.MessageStatusQueued, to: models.MessageStatusProcessing, expected: true},
+		{from: models.MessageStatusQueued, to: models.MessageStatusCancelled, expected: true},
+		{from: models.MessageStatusQueued, to: models.MessageStatusExpired, expected: true},
+		{from: models.MessageStatusQueued, to: models.MessageStatusSent, expected: false},
+		{from: models.MessageStatusProcessing, to: models.MessageStatusSent, expected: true},
+		{from: models.MessageStatusProcessing, to: models.MessageStatusFailed, expected: true},
+		{from: models.MessageStatusProcessing, to: models.MessageStatusQueued, expected: true},
+		{from: models.MessageStatusProcessing, to: models.MessageStatusAcceptedUnconfirmed, expected: true},
+		{from: models.MessageStatusAcceptedUnconfirmed, to: models.MessageStatusSent, expected: true},
+		{from: models.MessageStatusAcceptedUnconfirmed, to: models.MessageStatusDelivered, expected: true},
+		{from: models.MessageStatusAcceptedUnconfirmed, to: models.MessageStatusFailed, expected: true},
+		{from: models.MessageStatusAcceptedUnconfirmed, to: models.MessageStatusQueued, expected: false},
+		{from: models.MessageStatusSent, to: models.MessageStatusDelivered, expected: true},
+		{from: models.MessageStatusSent, to: models.MessageStatusFailed, expected: false},
+		{from: models.MessageStatusDelivered, to: models.MessageStatusUndelivered, expected: false},
+		{from: models.MessageStatusFailed, to: models.MessageStatusQueued, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.expected, models.CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestTransitionSources(t *testing.T) {
+	assert.Equal(t, []models.MessageStatus{models.MessageStatusProcessing, models.MessageStatusAcceptedUnconfirmed}, models.TransitionSources(models.MessageStatusSent))
+	assert.Equal(t, []models.MessageStatus{models.MessageStatusProcessing}, models.TransitionSources(models.MessageStatusQueued))
+	assert.Empty(t, models.TransitionSources("unknown"))
+}
+
+func TestIsFinal(t *testing.T) {
+	final := map[models.MessageStatus]bool{
+		models.MessageStatusDelivered:   true,
+		models.MessageStatusUndelivered: true,
+		models.MessageStatusFailed:      true,
+		models.MessageStatusCancelled:   true,
+		models.MessageStatusExpired:     true,
+	}
+
+	for _, status := range models.Statuses() {
+		assert.Equal(t, final[status], models.IsFinal(status), status)
+	}
+}
@@ -0,0 +1,256 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/signing"
+)
+
+// TypeHTTP is the registry type of the config driven HTTP provider.
+const TypeHTTP = "http"
+
+const (
+	formatJSON = "json"
+	formatForm = "form"
+)
+
+var defaultSuccessCodes = []int{http.StatusOK, http.StatusCreated, http.StatusAccepted}
+
+// templateData is the message view available to body templates.
+type templateData struct {
+	ID       int64
+	To       string
+	Content  string
+	Campaign string
+}
+
+// sampleMessage is rendered at startup to validate templates.
+var sampleMessage = &models.Message{ID: 1, PhoneNumber: "+905551111111", Content: `Sample "content" & more`}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+type httpProvider struct {
+	name         string
+	url          string
+	method       string
+	contentType  string
+	body         *template.Template
+	headers      map[string]string
+	successCodes map[int]bool
+	idPath       jsonPath
+	errorPath    jsonPath
+	signer       *signing.Signer
+	httpClient   *http.Client
+	logger       *zap.Logger
+}
+
+// NewHTTPProvider creates a provider whose request body, headers and
+// response parsing are defined by cfg.HTTP. The mapping is validated by
+// rendering the body for a sample message.
+func NewHTTPProvider(cfg config.ProviderConfig, opts Options) (Provider, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	mapping := cfg.HTTP
+	if cfg.URL == "" {
+		return nil, errors.New("url is required")
+	}
+	if mapping.Body == "" {
+		return nil, errors.New("http.body is required")
+	}
+
+	p := &httpProvider{
+		name:         cfg.Name,
+		url:          cfg.URL,
+		method:       strings.ToUpper(mapping.Method),
+		headers:      mapping.Headers,
+		successCodes: make(map[int]bool),
+		signer:       signing.NewSigner(cfg.Signing.Secrets),
+		logger:       logger,
+	}
+	if p.method == "" {
+		p.method = http.MethodPost
+	}
+
+	format := mapping.Format
+	if format == "" {
+		format = formatJSON
+	}
+	switch format {
+	case formatJSON:
+		p.contentType = "application/json"
+	case formatForm:
+		p.contentType = "application/x-www-form-urlencoded"
+	default:
+		return nil, fmt.Errorf("unsupported http.format %q", mapping.Format)
+	}
+
+	body, err := template.New(cfg.Name).Funcs(templateFuncs).Option("missingkey=error").Parse(mapping.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http.body: %w", err)
+	}
+	p.body = body
+
+	codes := mapping.SuccessCodes
+	if len(codes) == 0 {
+		codes = defaultSuccessCodes
+	}
+	for _, code := range codes {
+		if code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid http.success_codes entry %d", code)
+		}
+		p.successCodes[code] = true
+	}
+
+	if mapping.IDPath != "" {
+		if p.idPath, err = parseJSONPath(mapping.IDPath); err != nil {
+			return nil, fmt.Errorf("invalid http.id_path: %w", err)
+		}
+	}
+	if mapping.ErrorPath != "" {
+		if p.errorPath, err = parseJSONPath(mapping.ErrorPath); err != nil {
+			return nil, fmt.Errorf("invalid http.error_path: %w", err)
+		}
+	}
+
+	sample, err := renderTemplate(p.body, sampleMessage)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http.body: %w", err)
+	}
+	if err := validateBody(format, sample); err != nil {
+		return nil, fmt.Errorf("invalid http.body: %w", err)
+	}
+
+	if p.httpClient, err = NewHTTPClient(cfg, logger); err != nil {
+		return nil, err
+	}
+
+	if mapping.Lookup.URL != "" {
+		lookup, err := newHTTPLookup(cfg.Name, mapping.Lookup)
+		if err != nil {
+			return nil, err
+		}
+		return &lookupHTTPProvider{httpProvider: p, lookup: lookup}, nil
+	}
+
+	return p, nil
+}
+
+// validateBody checks that a rendered body is well formed for format.
+func validateBody(format string, body []byte) error {
+	if format == formatJSON {
+		if !json.Valid(body) {
+			return fmt.Errorf("rendered body is not valid JSON: %s", body)
+		}
+		return nil
+	}
+
+	if _, err := url.ParseQuery(string(body)); err != nil {
+		return fmt.Errorf("rendered body is not valid form data: %w", err)
+	}
+	return nil
+}
+
+// Name implements Provider.
+func (p *httpProvider) Name() string {
+	return p.name
+}
+
+// renderTemplate executes tmpl for msg.
+func renderTemplate(tmpl *template.Template, msg *models.Message) ([]byte, error) {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, templateData{
+		ID:       msg.ID,
+		To:       msg.PhoneNumber,
+		Content:  msg.Content,
+		Campaign: msg.Campaign.String,
+	})
+	return buf.Bytes(), err
+}
+
+// newRequest implements requestBuilder.
+func (p *httpProvider) newRequest(ctx context.Context, msg *models.Message) (*http.Request, []byte, error) {
+	body, err := renderTemplate(p.body, msg)
+	if err != nil {
+		return nil, nil, Permanent(0, "failed to render request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, p.method, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, Permanent(0, "failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", p.contentType)
+	for name, value := range p.headers {
+		req.Header.Set(name, value)
+	}
+	p.signer.Sign(req, body)
+
+	return req, body, nil
+}
+
+// Send implements Provider.
+func (p *httpProvider) Send(ctx context.Context, msg *models.Message) (*Result, error) {
+	req, _, err := p.newRequest(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, Transient(0, "failed to send request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			p.logger.Warn("Failed to close response body", zap.Error(err))
+		}
+	}()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return nil, Transient(resp.StatusCode, "failed to read response: %w", err)
+	}
+
+	if !p.successCodes[resp.StatusCode] {
+		providerErr := classifyStatus(resp.StatusCode, string(respBody))
+		if p.errorPath != nil {
+			if text, ok := p.errorPath.lookup(respBody); ok && text != "" {
+				providerErr.Err = fmt.Errorf("%w: %s", providerErr.Err, text)
+			}
+		}
+		return nil, providerErr
+	}
+
+	result := &Result{StatusCode: resp.StatusCode, Body: string(respBody)}
+	if p.idPath != nil {
+		id, ok := p.idPath.lookup(respBody)
+		if !ok {
+			p.logger.Warn("External message ID not found in response",
+				zap.String("provider", p.name),
+				zap.Int64("messageID", msg.ID))
+		}
+		result.ExternalID = id
+	}
+
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
+
+// lookupStatuses are the message statuses a status lookup may report.
+var lookupStatuses = map[models.MessageStatus]bool{
+	models.MessageStatusSent:        true,
+	models.MessageStatusDelivered:   true,
+	models.MessageStatusUndelivered: true,
+	models.MessageStatusFailed:      true,
+}
+
+// httpLookup queries a generic HTTP gateway for the state of a message.
+type httpLookup struct {
+	url        *template.Template
+	method     string
+	idPath     jsonPath
+	statusPath jsonPath
+	errorPath  jsonPath
+	statuses   map[string]models.MessageStatus
+}
+
+func newHTTPLookup(name string, cfg config.HTTPLookupConfig) (*httpLookup, error) {
+	l := &httpLookup{
+		method:   strings.ToUpper(cfg.Method),
+		statuses: make(map[string]models.MessageStatus, len(cfg.Statuses)),
+	}
+	if l.method == "" {
+		l.method = http.MethodGet
+	}
+
+	tmpl, err := template.New(name + "-lookup").Funcs(templateFuncs).Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http.lookup.url: %w", err)
+	}
+	sample, err := renderTemplate(tmpl, sampleMessage)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http.lookup.url: %w", err)
+	}
+	if u, err := url.Parse(string(sample)); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid http.lookup.url: rendered %q is not an absolute url", sample)
+	}
+	l.url = tmpl
+
+	if cfg.StatusPath == "" {
+		return nil, errors.New("http.lookup.status_path is required")
+	}
+	if l.statusPath, err = parseJSONPath(cfg.StatusPath); err != nil {
+		return nil, fmt.Errorf("invalid http.lookup.status_path: %w", err)
+	}
+	if cfg.IDPath != "" {
+		if l.idPath, err = parseJSONPath(cfg.IDPath); err != nil {
+			return nil, fmt.Errorf("invalid http.lookup.id_path: %w", err)
+		}
+	}
+	if cfg.ErrorPath != "" {
+		if l.errorPath, err = parseJSONPath(cfg.ErrorPath); err != nil {
+			return nil, fmt.Errorf("invalid http.lookup.error_path: %w", err)
+		}
+	}
+
+	if len(cfg.Statuses) == 0 {
+		return nil, errors.New("http.lookup.statuses is required")
+	}
+	for gatewayStatus, status := range cfg.Statuses {
+		if !lookupStatuses[models.MessageStatus(status)] {
+			return nil, fmt.Errorf("invalid http.lookup.statuses entry %q: %q is not sent, delivered, undelivered or failed",
+				gatewayStatus, status)
+		}
+		l.statuses[strings.ToLower(gatewayStatus)] = models.MessageStatus(status)
+	}
+
+	return l, nil
+}
+
+// lookupHTTPProvider is a generic HTTP provider with status lookups
+// configured.
+type lookupHTTPProvider struct {
+	*httpProvider
+	lookup *httpLookup
+}
+
+// LookupStatus implements StatusLookup.
+func (p *lookupHTTPProvider) LookupStatus(ctx context.Context, msg *models.Message) (*Status, error) {
+	target, err := renderTemplate(p.lookup.url, msg)
+	if err != nil {
+		return nil, Permanent(0, "failed to render lookup url: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, p.lookup.method, string(target), nil)
+	if err != nil {
+		return nil, Permanent(0, "failed to create lookup request: %w", err)
+	}
+	for name, value := range p.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, Transient(0, "failed to send lookup request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			p.logger.Warn("Failed to close response body", zap.Error(err))
+		}
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return nil, Transient(resp.StatusCode, "failed to read lookup response: %w", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, classifyStatus(resp.StatusCode, string(body))
+	}
+
+	status := &Status{}
+	if gatewayStatus, ok := p.lookup.statusPath.lookup(body); ok {
+		status.Status = p.lookup.statuses[strings.ToLower(gatewayStatus)]
+	}
+	if p.lookup.idPath != nil {
+		status.ExternalID, _ = p.lookup.idPath.lookup(body)
+	}
+	if p.lookup.errorPath != nil {
+		status.Error, _ = p.lookup.errorPath.lookup(body)
+	}
+
+	return status, nil
+}
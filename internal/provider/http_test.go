@@ -0,0 +1,406 @@
+package provider_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/provider"
+)
+
+func newHTTPProvider(t *testing.T, url string, mapping config.HTTPMappingConfig) provider.Provider {
+	t.Helper()
+
+	p, err := provider.New(config.ProviderConfig{
+		Name:    "generic",
+		Type:    provider.TypeHTTP,
+		URL:     url,
+		Timeout: 5,
+		HTTP:    mapping,
+	}, provider.Options{Logger: zap.NewNop()})
+	require.NoError(t, err)
+	return p
+}
+
+func TestHTTPProvider_Send_JSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		var req struct {
+			Destination struct {
+				Number string `json:"number"`
+			} `json:"destination"`
+			Text      string `json:"text"`
+			Reference int64  `json:"reference"`
+			Campaign  string `json:"campaign"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "+905551111111", req.Destination.Number)
+		assert.Equal(t, `Say "hi"`, req.Text)
+		assert.Equal(t, int64(7), req.Reference)
+		assert.Equal(t, "spring", req.Campaign)
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"data":{"messages":[{"id":"gw-42"}]}}`)
+	}))
+	defer server.Close()
+
+	p := newHTTPProvider(t, server.URL, config.HTTPMappingConfig{
+		Method:  "put",
+		Body:    `{"destination":{"number":{{json .To}}},"text":{{json .Content}},"reference":{{.ID}},"campaign":{{json .Campaign}}}`,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		IDPath:  "$.data.messages[0].id",
+	})
+
+	result, err := p.Send(context.Background(), &models.Message{
+		ID:          7,
+		PhoneNumber: "+905551111111",
+		Content:     `Say "hi"`,
+		Campaign:    sql.NullString{String: "spring", Valid: true},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "gw-42", result.ExternalID)
+	assert.Equal(t, http.StatusCreated, result.StatusCode)
+}
+
+func TestHTTPProvider_Send_Form(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "+905551111111", r.PostForm.Get("to"))
+		assert.Equal(t, "a&b=c", r.PostForm.Get("body"))
+
+		_, _ = io.WriteString(w, `{"sid":12345}`)
+	}))
+	defer server.Close()
+
+	p := newHTTPProvider(t, server.URL, config.HTTPMappingConfig{
+		Format: "form",
+		Body:   `to={{urlquery .To}}&body={{urlquery .Content}}`,
+		IDPath: "sid",
+	})
+
+	result, err := p.Send(context.Background(), &models.Message{ID: 1, PhoneNumber: "+905551111111", Content: "a&b=c"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "12345", result.ExternalID)
+}
+
+func TestHTTPProvider_Send_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		body          string
+		successCodes  []int
+		expectedClass provider.ErrorClass
+		expectedError string
+	}{
+		{
+			name:          "rejected with error text",
+			statusCode:    http.StatusBadRequest,
+			body:          `{"errors":[{"detail":"invalid number"}]}`,
+			expectedClass: provider.ErrorClassPermanent,
+			expectedError: "unexpected status code: 400: invalid number",
+		},
+		{
+			name:          "server error without error text",
+			statusCode:    http.StatusBadGateway,
+			body:          `upstream down`,
+			expectedClass: provider.ErrorClassTransient,
+			expectedError: "unexpected status code: 502",
+		},
+		{
+			name:          "status outside success codes",
+			statusCode:    http.StatusAccepted,
+			body:          `{}`,
+			successCodes:  []int{http.StatusOK},
+			expectedClass: provider.ErrorClassPermanent,
+			expectedError: "unexpected status code: 202",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			p := newHTTPProvider(t, server.URL, config.HTTPMappingConfig{
+				Body:         `{"to":{{json .To}}}`,
+				SuccessCodes: tt.successCodes,
+				ErrorPath:    "$.errors[0].detail",
+			})
+
+			result, err := p.Send(context.Background(), &models.Message{ID: 1, PhoneNumber: "+905551111111"})
+
+			require.Error(t, err)
+			assert.Nil(t, result)
+			assert.Equal(t, tt.expectedClass, provider.ClassOf(err))
+			assert.Equal(t, tt.expectedError, err.Error())
+
+			var providerErr *provider.Error
+			require.ErrorAs(t, err, &providerErr)
+			assert.Equal(t, tt.statusCode, providerErr.StatusCode)
+			assert.Equal(t, tt.body, providerErr.Body)
+		})
+	}
+}
+
+func TestHTTPProvider_IDPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		idPath   string
+		response string
+		expected string
+	}{
+		{name: "nested member", idPath: "$.result.id", response: `{"result":{"id":"a"}}`, expected: "a"},
+		{name: "bracket member", idPath: "$['message-id']", response: `{"message-id":"b"}`, expected: "b"},
+		{name: "array index", idPath: "$.ids[1]", response: `{"ids":["x","c"]}`, expected: "c"},
+		{name: "numeric id", idPath: "$.id", response: `{"id":9007199254740993}`, expected: "9007199254740993"},
+		{name: "missing member", idPath: "$.id", response: `{"other":"d"}`, expected: ""},
+		{name: "index out of range", idPath: "$.ids[3]", response: `{"ids":["x"]}`, expected: ""},
+		{name: "not json", idPath: "$.id", response: `OK`, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, tt.response)
+			}))
+			defer server.Close()
+
+			p := newHTTPProvider(t, server.URL, config.HTTPMappingConfig{
+				Body:   `{"to":{{json .To}}}`,
+				IDPath: tt.idPath,
+			})
+
+			result, err := p.Send(context.Background(), &models.Message{ID: 1, PhoneNumber: "+905551111111"})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.ExternalID)
+		})
+	}
+}
+
+func TestNewHTTPProvider_InvalidMapping(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		mapping       config.HTTPMappingConfig
+		expectedError string
+	}{
+		{
+			name:          "missing url",
+			mapping:       config.HTTPMappingConfig{Body: `{}`},
+			expectedError: "url is required",
+		},
+		{
+			name:          "missing body",
+			url:           "http://gateway",
+			expectedError: "http.body is required",
+		},
+		{
+			name:          "unknown format",
+			url:           "http://gateway",
+			mapping:       config.HTTPMappingConfig{Format: "xml", Body: `<to/>`},
+			expectedError: "unsupported http.format",
+		},
+		{
+			name:          "template syntax",
+			url:           "http://gateway",
+			mapping:       config.HTTPMappingConfig{Body: `{"to":{{json .To}`},
+			expectedError: "invalid http.body",
+		},
+		{
+			name:          "unknown field",
+			url:           "http://gateway",
+			mapping:       config.HTTPMappingConfig{Body: `{"to":{{json .Phone}}}`},
+			expectedError: "invalid http.body",
+		},
+		{
+			name:          "unescaped json",
+			url:           "http://gateway",
+			mapping:       config.HTTPMappingConfig{Body: `{"text":"{{.Content}}"}`},
+			expectedError: "not valid JSON",
+		},
+		{
+			name:          "invalid form",
+			url:           "http://gateway",
+			mapping:       config.HTTPMappingConfig{Format: "form", Body: `to=%zz`},
+			expectedError: "not valid form data",
+		},
+		{
+			name:          "invalid success code",
+			url:           "http://gateway",
+			mapping:       config.HTTPMappingConfig{Body: `{}`, SuccessCodes: []int{42}},
+			expectedError: "invalid http.success_codes",
+		},
+		{
+			name:          "invalid id path",
+			url:           "http://gateway",
+			mapping:       config.HTTPMappingConfig{Body: `{}`, IDPath: "$.ids[x]"},
+			expectedError: "invalid http.id_path",
+		},
+		{
+			name:          "invalid error path",
+			url:           "http://gateway",
+			mapping:       config.HTTPMappingConfig{Body: `{}`, ErrorPath: "$.error[0"},
+			expectedError: "invalid http.error_path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.New(config.ProviderConfig{
+				Name: "generic",
+				Type: provider.TypeHTTP,
+				URL:  tt.url,
+				HTTP: tt.mapping,
+			}, provider.Options{Logger: zap.NewNop()})
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestHTTPProvider_LookupStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected provider.Status
+	}{
+		{
+			name:     "delivered with id",
+			response: `{"message":{"id":"gw-1","state":"DELIVRD"}}`,
+			expected: provider.Status{ExternalID: "gw-1", Status: models.MessageStatusDelivered},
+		},
+		{
+			name:     "rejected with reason",
+			response: `{"message":{"id":"gw-2","state":"rejected","reason":"blocked"}}`,
+			expected: provider.Status{ExternalID: "gw-2", Status: models.MessageStatusFailed, Error: "blocked"},
+		},
+		{
+			name:     "unmapped status is pending",
+			response: `{"message":{"state":"enroute"}}`,
+			expected: provider.Status{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				assert.Equal(t, "/status", r.URL.Path)
+				assert.Equal(t, "7", r.URL.Query().Get("ref"))
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+				_, _ = io.WriteString(w, tt.response)
+			}))
+			defer server.Close()
+
+			p := newHTTPProvider(t, server.URL, config.HTTPMappingConfig{
+				Body:    `{"to":{{json .To}}}`,
+				Headers: map[string]string{"Authorization": "Bearer token"},
+				Lookup: config.HTTPLookupConfig{
+					URL:        server.URL + `/status?ref={{.ID}}`,
+					IDPath:     "$.message.id",
+					StatusPath: "$.message.state",
+					ErrorPath:  "$.message.reason",
+					Statuses: map[string]string{
+						"delivrd":  "delivered",
+						"rejected": "failed",
+					},
+				},
+			})
+
+			lookup, ok := p.(provider.StatusLookup)
+			require.True(t, ok)
+
+			status, err := lookup.LookupStatus(context.Background(), &models.Message{ID: 7})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *status)
+		})
+	}
+}
+
+func TestHTTPProvider_LookupStatus_NotConfigured(t *testing.T) {
+	p := newHTTPProvider(t, "http://gateway", config.HTTPMappingConfig{Body: `{}`})
+
+	_, ok := p.(provider.StatusLookup)
+	assert.False(t, ok)
+}
+
+func TestNewHTTPProvider_InvalidLookup(t *testing.T) {
+	valid := config.HTTPLookupConfig{
+		URL:        "http://gateway/status/{{.ID}}",
+		StatusPath: "$.state",
+		Statuses:   map[string]string{"ok": "delivered"},
+	}
+
+	tests := []struct {
+		name          string
+		modify        func(*config.HTTPLookupConfig)
+		expectedError string
+	}{
+		{
+			name:          "relative url",
+			modify:        func(c *config.HTTPLookupConfig) { c.URL = "/status/{{.ID}}" },
+			expectedError: "not an absolute url",
+		},
+		{
+			name:          "url template syntax",
+			modify:        func(c *config.HTTPLookupConfig) { c.URL = "http://gateway/{{.ID" },
+			expectedError: "invalid http.lookup.url",
+		},
+		{
+			name:          "missing status path",
+			modify:        func(c *config.HTTPLookupConfig) { c.StatusPath = "" },
+			expectedError: "status_path is required",
+		},
+		{
+			name:          "missing statuses",
+			modify:        func(c *config.HTTPLookupConfig) { c.Statuses = nil },
+			expectedError: "statuses is required",
+		},
+		{
+			name:          "unsupported status",
+			modify:        func(c *config.HTTPLookupConfig) { c.Statuses = map[string]string{"ok": "queued"} },
+			expectedError: "invalid http.lookup.statuses entry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := valid
+			tt.modify(&lookup)
+
+			_, err := provider.New(config.ProviderConfig{
+				Name: "generic",
+				Type: provider.TypeHTTP,
+				URL:  "http://gateway",
+				HTTP: config.HTTPMappingConfig{Body: `{}`, Lookup: lookup},
+			}, provider.Options{Logger: zap.NewNop()})
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
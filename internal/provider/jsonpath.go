@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a minimal JSONPath supporting member access and array
+// indexes, e.g. "$.data.messages[0].id" or "$['message-id']".
+type jsonPath []pathStep
+
+type pathStep struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// parseJSONPath compiles expr. The leading "$" is optional.
+func parseJSONPath(expr string) (jsonPath, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	if rest == "" {
+		return nil, fmt.Errorf("invalid json path %q: no members", expr)
+	}
+
+	var path jsonPath
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid json path %q: empty member", expr)
+			}
+			path = append(path, pathStep{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid json path %q: unclosed bracket", expr)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				path = append(path, pathStep{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid json path %q: bad index %q", expr, inner)
+			}
+			path = append(path, pathStep{index: index, isIdx: true})
+		default:
+			if len(path) == 0 && !strings.HasPrefix(strings.TrimSpace(expr), "$") {
+				// Allow "data.id" as a shorthand for "$.data.id".
+				rest = "." + rest
+				continue
+			}
+			return nil, fmt.Errorf("invalid json path %q: unexpected %q", expr, rest[0])
+		}
+	}
+
+	return path, nil
+}
+
+// lookup returns the string form of the value at p in the JSON document
+// body. Numbers and booleans are formatted as in JSON.
+func (p jsonPath) lookup(body []byte) (string, bool) {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return "", false
+	}
+
+	for _, step := range p {
+		if step.isIdx {
+			arr, ok := doc.([]interface{})
+			if !ok || step.index >= len(arr) {
+				return "", false
+			}
+			doc = arr[step.index]
+			continue
+		}
+
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if doc, ok = obj[step.key]; !ok {
+			return "", false
+		}
+	}
+
+	switch v := doc.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,109 @@
+// Package provider defines the delivery provider abstraction used to hand
+// messages over to external gateways.
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
+
+// Provider delivers a single message to an external gateway.
+type Provider interface {
+	// Name returns the configured name of the provider instance.
+	Name() string
+	// Send delivers the message and returns the gateway's result.
+	// Failures are reported as *Error so callers can tell transient
+	// problems from permanent rejections.
+	Send(ctx context.Context, msg *models.Message) (*Result, error)
+}
+
+// StatusLookup is implemented by providers that can report the state of a
+// message they accepted earlier. It is used to reconcile messages whose
+// send response carried no usable external ID.
+type StatusLookup interface {
+	LookupStatus(ctx context.Context, msg *models.Message) (*Status, error)
+}
+
+// Status is the state of a message as reported by its provider.
+type Status struct {
+	// ExternalID is the gateway's identifier of the message, if known.
+	ExternalID string
+	// Status is sent, delivered, undelivered or failed, and empty while the
+	// gateway has no final answer yet.
+	Status models.MessageStatus
+	// Error is the gateway's reason for a failed or undelivered message.
+	Error string
+}
+
+// Result describes a message accepted by a provider.
+type Result struct {
+	// ExternalID is the identifier assigned to the message by the gateway.
+	// It is empty when the response carried no usable identifier.
+	ExternalID string
+	// StatusCode is the protocol level status returned by the gateway.
+	StatusCode int
+	// Body is the raw response returned by the gateway.
+	Body string
+	// Request is set by sandbox providers to the request they recorded
+	// instead of sending it.
+	Request *Request
+}
+
+// ErrorClass classifies delivery failures.
+type ErrorClass string
+
+const (
+	// ErrorClassTransient marks failures that may succeed on retry
+	// (network errors, timeouts, throttling, 5xx responses).
+	ErrorClassTransient ErrorClass = "transient"
+	// ErrorClassPermanent marks failures that will not succeed on retry
+	// (invalid requests, rejected destinations).
+	ErrorClassPermanent ErrorClass = "permanent"
+)
+
+// Error is a classified delivery failure.
+type Error struct {
+	Class      ErrorClass
+	StatusCode int
+	// Body is the raw response returned by the gateway, if any.
+	Body string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Transient wraps err as a transient delivery failure.
+func Transient(statusCode int, format string, args ...interface{}) error {
+	return &Error{Class: ErrorClassTransient, StatusCode: statusCode, Err: fmt.Errorf(format, args...)}
+}
+
+// Permanent wraps err as a permanent delivery failure.
+func Permanent(statusCode int, format string, args ...interface{}) error {
+	return &Error{Class: ErrorClassPermanent, StatusCode: statusCode, Err: fmt.Errorf(format, args...)}
+}
+
+// ClassOf returns the class of err. Errors that were not classified by a
+// provider are treated as transient.
+func ClassOf(err error) ErrorClass {
+	var providerErr *Error
+	if errors.As(err, &providerErr) {
+		return providerErr.Class
+	}
+	return ErrorClassTransient
+}
+
+// IsTransient reports whether err may succeed on retry.
+func IsTransient(err error) bool {
+	return err != nil && ClassOf(err) == ErrorClassTransient
+}
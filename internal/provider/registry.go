@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+)
+
+// Options carries shared dependencies handed to provider factories.
+type Options struct {
+	Logger *zap.Logger
+}
+
+// Factory builds a provider instance from its configuration.
+type Factory func(cfg config.ProviderConfig, opts Options) (Provider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		TypeWebhook: NewWebhookProvider,
+		TypeHTTP:    NewHTTPProvider,
+	}
+)
+
+// Register makes a provider implementation available under the given type.
+// Registering the same type twice replaces the previous factory.
+func Register(providerType string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry[providerType] = factory
+}
+
+// Types returns the registered provider types in sorted order.
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New builds a provider from its configuration using the registered factory
+// for cfg.Type.
+func New(cfg config.ProviderConfig, opts Options) (Provider, error) {
+	registryMu.RLock()
+	factory, ok := registry[cfg.Type]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown provider type %q for provider %q", cfg.Type, cfg.Name)
+	}
+
+	p, err := factory(cfg, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider %q: %w", cfg.Name, err)
+	}
+	return p, nil
+}
+
+// Select builds the provider with the given name from the configuration.
+// An empty name selects the first configured provider.
+func Select(cfg *config.Config, name string, opts Options) (Provider, error) {
+	configs := cfg.ProviderConfigs()
+
+	for _, pc := range configs {
+		if name == "" || pc.Name == name {
+			return New(pc, opts)
+		}
+	}
+
+	return nil, fmt.Errorf("provider %q is not configured", name)
+}
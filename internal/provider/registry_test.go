@@ -0,0 +1,119 @@
+package provider_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/provider"
+)
+
+type fakeProvider struct {
+	name string
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Send(_ context.Context, _ *models.Message) (*provider.Result, error) {
+	return &provider.Result{ExternalID: "fake-id"}, nil
+}
+
+func TestRegistry_New(t *testing.T) {
+	provider.Register("fake", func(cfg config.ProviderConfig, _ provider.Options) (provider.Provider, error) {
+		return &fakeProvider{name: cfg.Name}, nil
+	})
+
+	tests := []struct {
+		name          string
+		cfg           config.ProviderConfig
+		expectedName  string
+		expectedError string
+	}{
+		{
+			name:         "registered fake provider",
+			cfg:          config.ProviderConfig{Name: "test", Type: "fake"},
+			expectedName: "test",
+		},
+		{
+			name:         "built-in webhook provider",
+			cfg:          config.ProviderConfig{Name: "primary", Type: provider.TypeWebhook},
+			expectedName: "primary",
+		},
+		{
+			name:          "unknown provider type",
+			cfg:           config.ProviderConfig{Name: "sms", Type: "unknown"},
+			expectedError: `unknown provider type "unknown"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := provider.New(tt.cfg, provider.Options{})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedName, p.Name())
+		})
+	}
+
+	assert.Contains(t, provider.Types(), "fake")
+}
+
+func TestRegistry_Select(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           *config.Config
+		providerName  string
+		expectedName  string
+		expectedError string
+	}{
+		{
+			name:         "falls back to webhook section",
+			cfg:          &config.Config{Webhook: config.WebhookConfig{URL: "http://localhost"}},
+			expectedName: config.DefaultProviderName,
+		},
+		{
+			name: "selects provider by name",
+			cfg: &config.Config{Providers: []config.ProviderConfig{
+				{Name: "primary", Type: provider.TypeWebhook},
+				{Name: "secondary", Type: provider.TypeWebhook},
+			}},
+			providerName: "secondary",
+			expectedName: "secondary",
+		},
+		{
+			name: "unknown provider name",
+			cfg: &config.Config{Providers: []config.ProviderConfig{
+				{Name: "primary", Type: provider.TypeWebhook},
+			}},
+			providerName:  "missing",
+			expectedError: `provider "missing" is not configured`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := provider.Select(tt.cfg, tt.providerName, provider.Options{})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedName, p.Name())
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
+
+// sandboxIDPrefix marks the synthetic external IDs of sandbox providers.
+const sandboxIDPrefix = "sandbox-"
+
+// redactedValue replaces the values of credential headers in recorded
+// requests.
+const redactedValue = "[redacted]"
+
+// Request is a gateway request recorded by a sandbox provider.
+type Request struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    string
+}
+
+// requestBuilder is implemented by providers that send a single HTTP
+// request per message.
+type requestBuilder interface {
+	newRequest(ctx context.Context, msg *models.Message) (*http.Request, []byte, error)
+}
+
+type sandboxProvider struct {
+	inner Provider
+}
+
+// NewSandbox wraps p so that messages are never sent. Send builds the
+// request p would send, including its validation and signing, and returns
+// it with a synthetic external ID. Providers that do not send HTTP
+// requests record the message content only.
+func NewSandbox(p Provider) Provider {
+	return &sandboxProvider{inner: p}
+}
+
+// Name implements Provider.
+func (p *sandboxProvider) Name() string {
+	return p.inner.Name()
+}
+
+// Send implements Provider.
+func (p *sandboxProvider) Send(ctx context.Context, msg *models.Message) (*Result, error) {
+	recorded := &Request{Body: msg.Content}
+	if builder, ok := p.inner.(requestBuilder); ok {
+		req, body, err := builder.newRequest(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+		recorded = &Request{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: redactHeaders(req.Header),
+			Body:    string(body),
+		}
+	}
+
+	externalID, err := sandboxID()
+	if err != nil {
+		return nil, Transient(0, "failed to generate sandbox message ID: %w", err)
+	}
+
+	return &Result{
+		ExternalID: externalID,
+		StatusCode: http.StatusAccepted,
+		Body:       fmt.Sprintf(`{"sandbox":true,"messageId":%q}`, externalID),
+		Request:    recorded,
+	}, nil
+}
+
+// redactHeaders flattens header and hides the values of headers that
+// look like credentials.
+func redactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		lower := strings.ToLower(name)
+		for _, word := range []string{"auth", "key", "token", "secret", "password"} {
+			if strings.Contains(lower, word) {
+				value = redactedValue
+				break
+			}
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+func sandboxID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return sandboxIDPrefix + hex.EncodeToString(b), nil
+}
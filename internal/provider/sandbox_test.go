@@ -0,0 +1,95 @@
+package provider_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/provider"
+	"github.com/popeskul/insdr-messenger/internal/signing"
+)
+
+func TestSandbox_WebhookProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("sandbox provider called the gateway")
+	}))
+	defer server.Close()
+
+	p, err := provider.NewWebhookProvider(config.ProviderConfig{
+		Name:    "primary",
+		Type:    provider.TypeWebhook,
+		URL:     server.URL,
+		AuthKey: "test-auth-key",
+		Timeout: 5,
+		Signing: config.SigningConfig{Secrets: []string{"signing-secret"}},
+	}, provider.Options{Logger: zap.NewNop()})
+	require.NoError(t, err)
+
+	sandbox := provider.NewSandbox(p)
+	result, err := sandbox.Send(context.Background(), &models.Message{ID: 1, PhoneNumber: "+905551111111", Content: "Hello"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "primary", sandbox.Name())
+	assert.True(t, strings.HasPrefix(result.ExternalID, "sandbox-"))
+	assert.Equal(t, http.StatusAccepted, result.StatusCode)
+	assert.Contains(t, result.Body, result.ExternalID)
+
+	require.NotNil(t, result.Request)
+	assert.Equal(t, http.MethodPost, result.Request.Method)
+	assert.Equal(t, server.URL, result.Request.URL)
+	assert.JSONEq(t, `{"to":"+905551111111","content":"Hello"}`, result.Request.Body)
+	assert.Equal(t, "application/json", result.Request.Headers["Content-Type"])
+	assert.Equal(t, "[redacted]", result.Request.Headers["X-Ins-Auth-Key"])
+	assert.NotEmpty(t, result.Request.Headers[signing.SignatureHeader])
+}
+
+func TestSandbox_HTTPProvider(t *testing.T) {
+	p, err := provider.NewHTTPProvider(config.ProviderConfig{
+		Name: "sms",
+		Type: provider.TypeHTTP,
+		URL:  "http://gateway.invalid/send",
+		HTTP: config.HTTPMappingConfig{
+			Format:  "form",
+			Body:    `to={{urlquery .To}}&text={{urlquery .Content}}`,
+			Headers: map[string]string{"Authorization": "Bearer token", "X-Campaign": "spring"},
+			IDPath:  "$.id",
+			Lookup: config.HTTPLookupConfig{
+				URL:        "http://gateway.invalid/status/{{.ID}}",
+				StatusPath: "$.status",
+				Statuses:   map[string]string{"DELIVRD": "delivered"},
+			},
+		},
+	}, provider.Options{Logger: zap.NewNop()})
+	require.NoError(t, err)
+
+	sandbox := provider.NewSandbox(p)
+	result, err := sandbox.Send(context.Background(), &models.Message{ID: 1, PhoneNumber: "+905551111111", Content: "Hi there"})
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Request)
+	assert.Equal(t, "to=%2B905551111111&text=Hi+there", result.Request.Body)
+	assert.Equal(t, "[redacted]", result.Request.Headers["Authorization"])
+	assert.Equal(t, "spring", result.Request.Headers["X-Campaign"])
+
+	// Sandboxed messages always get an ID, nothing is left to reconcile.
+	_, ok := sandbox.(provider.StatusLookup)
+	assert.False(t, ok)
+}
+
+func TestSandbox_OtherProvider(t *testing.T) {
+	sandbox := provider.NewSandbox(&fakeProvider{name: "fake"})
+
+	result, err := sandbox.Send(context.Background(), &models.Message{ID: 1, PhoneNumber: "+905551111111", Content: "Hello"})
+
+	require.NoError(t, err)
+	assert.NotEqual(t, "fake-id", result.ExternalID)
+	assert.Equal(t, &provider.Request{Body: "Hello"}, result.Request)
+}
@@ -0,0 +1,324 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// NewHTTPClient builds the HTTP client used to reach a provider's gateway
+// from its timeout and transport settings.
+func NewHTTPClient(cfg config.ProviderConfig, logger *zap.Logger) (*http.Client, error) {
+	transport, err := newTransport(cfg.Transport, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Timeout:   time.Duration(cfg.Timeout) * time.Second,
+		Transport: transport,
+	}, nil
+}
+
+func newTransport(cfg config.TransportConfig, logger *zap.Logger) (*http.Transport, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url %q", cfg.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.KeepAlive != 0 {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: time.Duration(cfg.KeepAlive) * time.Second,
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	if cfg.MaxIdleConns != 0 {
+		transport.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.MaxConnsPerHost != 0 {
+		transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	}
+	if cfg.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = time.Duration(cfg.IdleConnTimeout) * time.Second
+	}
+	transport.DisableKeepAlives = cfg.DisableKeepAlives
+
+	tlsConfig, roots, err := newTLSConfig(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+	if roots != nil {
+		transport.DialTLSContext = verifiedTLSDialer(transport, roots)
+	}
+
+	return transport, nil
+}
+
+// newTLSConfig builds the TLS settings of a transport. With a CA bundle it
+// also returns the bundle, which replaces the standard verification.
+func newTLSConfig(cfg config.TransportConfig, logger *zap.Logger) (*tls.Config, *caReloader, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.MinTLSVersion != "" {
+		version, ok := tlsVersions[cfg.MinTLSVersion]
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported min tls version %q", cfg.MinTLSVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, nil, errors.New("cert_file and key_file must be set together")
+	}
+
+	if cfg.CertFile != "" {
+		certs := &certReloader{certFile: cfg.CertFile, keyFile: cfg.KeyFile, logger: logger}
+		if _, err := certs.certificate(); err != nil {
+			return nil, nil, err
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return certs.certificate()
+		}
+	}
+
+	if cfg.CAFile == "" {
+		return tlsConfig, nil, nil
+	}
+
+	roots := &caReloader{caFile: cfg.CAFile, logger: logger}
+	if _, err := roots.pool(); err != nil {
+		return nil, nil, err
+	}
+	// The standard verification only supports a fixed root pool, so it is
+	// replaced by VerifyConnection, which reads the current bundle. Direct
+	// connections are verified against the dialed host by
+	// verifiedTLSDialer; connections through a proxy only know the server
+	// name sent in SNI, which is empty for IP addresses.
+	tlsConfig.InsecureSkipVerify = true
+	tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		if cs.ServerName == "" {
+			return errors.New("tls: no server name to verify the certificate against")
+		}
+		return roots.verify(cs, cs.ServerName)
+	}
+
+	return tlsConfig, roots, nil
+}
+
+// verifiedTLSDialer returns a TLS dialer for transport that verifies the
+// peer against roots and the host that was dialed, which is also checked
+// when it is an IP address and no SNI is sent.
+func verifiedTLSDialer(transport *http.Transport, roots *caReloader) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg := transport.TLSClientConfig.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName = host
+		}
+		name := cfg.ServerName
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return roots.verify(cs, name)
+		}
+
+		if timeout := transport.TLSHandshakeTimeout; timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+}
+
+// verify checks the peer of cs against the current bundle and name.
+func (r *caReloader) verify(cs tls.ConnectionState, name string) error {
+	pool, err := r.pool()
+	if err != nil {
+		return err
+	}
+	return verifyPeer(cs, pool, name)
+}
+
+// verifyPeer performs the checks of the default TLS verification against
+// the given root pool and host name or IP address.
+func verifyPeer(cs tls.ConnectionState, roots *x509.CertPool, name string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("tls: server presented no certificates")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       name,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// certReloader loads a client certificate and reloads it when the
+// certificate or key file changes on disk.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+}
+
+func (r *certReloader) certificate() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certMod, certErr := modTime(r.certFile)
+	keyMod, keyErr := modTime(r.keyFile)
+	if err := errors.Join(certErr, keyErr); err != nil {
+		if r.cert != nil {
+			r.logger.Warn("Failed to check client certificate, using loaded one", zap.Error(err))
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	if r.cert != nil && certMod.Equal(r.certMod) && keyMod.Equal(r.keyMod) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			r.logger.Warn("Failed to reload client certificate, using loaded one", zap.Error(err))
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	if r.cert != nil {
+		r.logger.Info("Reloaded client certificate", zap.String("cert_file", r.certFile))
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certMod, keyMod
+	return r.cert, nil
+}
+
+// caReloader loads a CA bundle and reloads it when the file changes on
+// disk.
+type caReloader struct {
+	caFile string
+	logger *zap.Logger
+
+	mu    sync.Mutex
+	roots *x509.CertPool
+	mod   time.Time
+}
+
+func (r *caReloader) pool() (*x509.CertPool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mod, err := modTime(r.caFile)
+	if err != nil {
+		if r.roots != nil {
+			r.logger.Warn("Failed to check CA bundle, using loaded one", zap.Error(err))
+			return r.roots, nil
+		}
+		return nil, fmt.Errorf("failed to load CA bundle: %w", err)
+	}
+
+	if r.roots != nil && mod.Equal(r.mod) {
+		return r.roots, nil
+	}
+
+	roots, err := loadPool(r.caFile)
+	if err != nil {
+		if r.roots != nil {
+			r.logger.Warn("Failed to reload CA bundle, using loaded one", zap.Error(err))
+			return r.roots, nil
+		}
+		return nil, err
+	}
+
+	if r.roots != nil {
+		r.logger.Info("Reloaded CA bundle", zap.String("ca_file", r.caFile))
+	}
+	r.roots, r.mod = roots, mod
+	return r.roots, nil
+}
+
+func loadPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA bundle: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("failed to load CA bundle: no certificates found in %s", path)
+	}
+	return roots, nil
+}
+
+func modTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
@@ -0,0 +1,331 @@
+package provider_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/provider"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM encoded certificate and key signed by the CA, valid
+// for ips or 127.0.0.1.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage, ips ...net.IP) ([]byte, []byte) {
+	t.Helper()
+
+	if len(ips) == 0 {
+		ips = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// newMTLSServer starts a server that requires client certificates issued
+// by clientCA and responds with the client certificate's common name.
+func newMTLSServer(t *testing.T, serverCA, clientCA *testCA) *httptest.Server {
+	t.Helper()
+
+	certPEM, keyPEM := serverCA.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	clientRoots := x509.NewCertPool()
+	clientRoots.AddCert(clientCA.cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientRoots,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// writeFile writes data to path and moves its modification time forward so
+// the change is noticed even within the file system's time resolution.
+func writeFile(t *testing.T, path string, data []byte, mod time.Time) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	require.NoError(t, os.Chtimes(path, mod, mod))
+}
+
+func get(t *testing.T, client *http.Client, url string) (string, error) {
+	t.Helper()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body), nil
+}
+
+func TestNewHTTPClient_MutualTLSReload(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	server := newMTLSServer(t, ca, ca)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client.key")
+
+	mod := time.Now().Add(-time.Minute)
+	writeFile(t, caFile, ca.pem, mod)
+	certPEM, keyPEM := ca.issue(t, "client-1", x509.ExtKeyUsageClientAuth)
+	writeFile(t, certFile, certPEM, mod)
+	writeFile(t, keyFile, keyPEM, mod)
+
+	client, err := provider.NewHTTPClient(config.ProviderConfig{
+		Timeout: 5,
+		Transport: config.TransportConfig{
+			CAFile:            caFile,
+			CertFile:          certFile,
+			KeyFile:           keyFile,
+			MinTLSVersion:     "1.2",
+			DisableKeepAlives: true,
+		},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	name, err := get(t, client, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "client-1", name)
+
+	certPEM, keyPEM = ca.issue(t, "client-2", x509.ExtKeyUsageClientAuth)
+	writeFile(t, certFile, certPEM, mod.Add(time.Second))
+	writeFile(t, keyFile, keyPEM, mod.Add(time.Second))
+
+	name, err = get(t, client, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "client-2", name)
+}
+
+func TestNewHTTPClient_CABundleReload(t *testing.T) {
+	oldCA := newTestCA(t, "old-ca")
+	newCA := newTestCA(t, "new-ca")
+	server := newMTLSServer(t, newCA, oldCA)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client.key")
+
+	mod := time.Now().Add(-time.Minute)
+	writeFile(t, caFile, oldCA.pem, mod)
+	certPEM, keyPEM := oldCA.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	writeFile(t, certFile, certPEM, mod)
+	writeFile(t, keyFile, keyPEM, mod)
+
+	client, err := provider.NewHTTPClient(config.ProviderConfig{
+		Timeout: 5,
+		Transport: config.TransportConfig{
+			CAFile:            caFile,
+			CertFile:          certFile,
+			KeyFile:           keyFile,
+			DisableKeepAlives: true,
+		},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	_, err = get(t, client, server.URL)
+	require.Error(t, err)
+
+	writeFile(t, caFile, newCA.pem, mod.Add(time.Second))
+
+	name, err := get(t, client, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "client", name)
+}
+
+func TestNewHTTPClient_CABundleChecksHost(t *testing.T) {
+	ca := newTestCA(t, "ca")
+
+	tests := []struct {
+		name    string
+		ip      string
+		wantErr bool
+	}{
+		{name: "certificate for dialed address", ip: "127.0.0.1"},
+		{name: "certificate for other address", ip: "10.0.0.1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certPEM, keyPEM := ca.issue(t, "server", x509.ExtKeyUsageServerAuth, net.ParseIP(tt.ip))
+			cert, err := tls.X509KeyPair(certPEM, keyPEM)
+			require.NoError(t, err)
+
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, "ok")
+			}))
+			server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+			server.StartTLS()
+			defer server.Close()
+
+			caFile := filepath.Join(t.TempDir(), "ca.pem")
+			writeFile(t, caFile, ca.pem, time.Now().Add(-time.Minute))
+
+			client, err := provider.NewHTTPClient(config.ProviderConfig{
+				Timeout:   5,
+				Transport: config.TransportConfig{CAFile: caFile},
+			}, zap.NewNop())
+			require.NoError(t, err)
+
+			body, err := get(t, client, server.URL)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "not 127.0.0.1")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "ok", body)
+		})
+	}
+}
+
+func TestNewHTTPClient_Proxy(t *testing.T) {
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	client, err := provider.NewHTTPClient(config.ProviderConfig{
+		Timeout: 5,
+		Transport: config.TransportConfig{
+			ProxyURL:            proxy.URL,
+			MaxIdleConns:        10,
+			MaxIdleConnsPerHost: 5,
+			MaxConnsPerHost:     5,
+			IdleConnTimeout:     30,
+			KeepAlive:           15,
+		},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	_, err = get(t, client, "http://gateway.example.com/messages")
+	require.NoError(t, err)
+	assert.Equal(t, "http://gateway.example.com/messages", <-proxied)
+}
+
+func TestNewHTTPClient_InvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	emptyFile := filepath.Join(dir, "empty.pem")
+	require.NoError(t, os.WriteFile(emptyFile, nil, 0o600))
+
+	tests := []struct {
+		name      string
+		transport config.TransportConfig
+		wantErr   string
+	}{
+		{
+			name:      "unsupported tls version",
+			transport: config.TransportConfig{MinTLSVersion: "1.4"},
+			wantErr:   "unsupported min tls version",
+		},
+		{
+			name:      "invalid proxy",
+			transport: config.TransportConfig{ProxyURL: "not a url"},
+			wantErr:   "invalid proxy url",
+		},
+		{
+			name:      "cert without key",
+			transport: config.TransportConfig{CertFile: emptyFile},
+			wantErr:   "must be set together",
+		},
+		{
+			name:      "missing client certificate",
+			transport: config.TransportConfig{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: emptyFile},
+			wantErr:   "failed to load client certificate",
+		},
+		{
+			name:      "empty ca bundle",
+			transport: config.TransportConfig{CAFile: emptyFile},
+			wantErr:   "no certificates found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.NewHTTPClient(config.ProviderConfig{Transport: tt.transport}, zap.NewNop())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
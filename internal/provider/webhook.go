@@ -0,0 +1,145 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/signing"
+)
+
+// TypeWebhook is the registry type of the JSON webhook provider.
+const TypeWebhook = "webhook"
+
+// authKeyHeader carries the static webhook authentication key.
+const authKeyHeader = "x-ins-auth-key"
+
+// maxResponseBody limits how much of a gateway response is read.
+const maxResponseBody = 64 << 10
+
+type webhookProvider struct {
+	name       string
+	url        string
+	authKey    string
+	signer     *signing.Signer
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+// NewWebhookProvider creates a provider that POSTs models.WebhookRequest
+// payloads to the configured URL.
+func NewWebhookProvider(cfg config.ProviderConfig, opts Options) (Provider, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	httpClient, err := NewHTTPClient(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhookProvider{
+		name:       cfg.Name,
+		url:        cfg.URL,
+		authKey:    cfg.AuthKey,
+		signer:     signing.NewSigner(cfg.Signing.Secrets),
+		httpClient: httpClient,
+		logger:     logger,
+	}, nil
+}
+
+// Name implements Provider.
+func (p *webhookProvider) Name() string {
+	return p.name
+}
+
+// newRequest implements requestBuilder.
+func (p *webhookProvider) newRequest(ctx context.Context, msg *models.Message) (*http.Request, []byte, error) {
+	reqBody := models.WebhookRequest{
+		To:      msg.PhoneNumber,
+		Content: msg.Content,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, nil, Permanent(0, "failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, nil, Permanent(0, "failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(authKeyHeader, p.authKey)
+	p.signer.Sign(req, jsonData)
+
+	return req, jsonData, nil
+}
+
+// Send implements Provider.
+func (p *webhookProvider) Send(ctx context.Context, msg *models.Message) (*Result, error) {
+	req, _, err := p.newRequest(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, Transient(0, "failed to send request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			p.logger.Warn("Failed to close response body", zap.Error(err))
+		}
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return nil, Transient(resp.StatusCode, "failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return nil, classifyStatus(resp.StatusCode, string(body))
+	}
+
+	var webhookResp models.WebhookResponse
+	if err := json.Unmarshal(body, &webhookResp); err != nil {
+		p.logger.Warn("Undecodable webhook response",
+			zap.String("provider", p.name),
+			zap.Int64("messageID", msg.ID),
+			zap.Error(err))
+	}
+
+	return &Result{
+		ExternalID: webhookResp.MessageID,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}, nil
+}
+
+// classifyStatus converts an unexpected HTTP status into a classified error.
+func classifyStatus(statusCode int, body string) *Error {
+	class := ErrorClassPermanent
+	switch {
+	case statusCode == http.StatusTooManyRequests,
+		statusCode == http.StatusRequestTimeout,
+		statusCode >= http.StatusInternalServerError:
+		class = ErrorClassTransient
+	}
+
+	return &Error{
+		Class:      class,
+		StatusCode: statusCode,
+		Body:       body,
+		Err:        fmt.Errorf("unexpected status code: %d", statusCode),
+	}
+}
@@ -0,0 +1,173 @@
+package provider_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/provider"
+	"github.com/popeskul/insdr-messenger/internal/signing"
+)
+
+func TestWebhookProvider_Send_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "test-auth-key", r.Header.Get("x-ins-auth-key"))
+
+		var req models.WebhookRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "+905551111111", req.To)
+		assert.Equal(t, "Hello", req.Content)
+
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(models.WebhookResponse{Message: "Accepted", MessageID: "ext-1"})
+	}))
+	defer server.Close()
+
+	p, err := provider.NewWebhookProvider(config.ProviderConfig{
+		Name:    "primary",
+		Type:    provider.TypeWebhook,
+		URL:     server.URL,
+		AuthKey: "test-auth-key",
+		Timeout: 5,
+	}, provider.Options{Logger: zap.NewNop()})
+	require.NoError(t, err)
+
+	result, err := p.Send(context.Background(), &models.Message{ID: 1, PhoneNumber: "+905551111111", Content: "Hello"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "primary", p.Name())
+	assert.Equal(t, "ext-1", result.ExternalID)
+	assert.Equal(t, http.StatusAccepted, result.StatusCode)
+	assert.Contains(t, result.Body, `"messageId":"ext-1"`)
+}
+
+func TestWebhookProvider_Send_UndecodableResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	p, err := provider.NewWebhookProvider(config.ProviderConfig{
+		Name:    "primary",
+		URL:     server.URL,
+		Timeout: 5,
+	}, provider.Options{})
+	require.NoError(t, err)
+
+	result, err := p.Send(context.Background(), &models.Message{ID: 1})
+
+	require.NoError(t, err)
+	assert.Empty(t, result.ExternalID)
+	assert.Equal(t, "OK", result.Body)
+}
+
+func TestWebhookProvider_Send_Signed(t *testing.T) {
+	secrets := []string{"current", "previous"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		for _, secret := range secrets {
+			err := signing.Verify([]string{secret},
+				r.Header.Get(signing.SignatureHeader),
+				r.Header.Get(signing.TimestampHeader),
+				body, time.Now(), time.Minute)
+			assert.NoError(t, err)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(models.WebhookResponse{MessageID: "ext-1"})
+	}))
+	defer server.Close()
+
+	p, err := provider.NewWebhookProvider(config.ProviderConfig{
+		Name:    "primary",
+		URL:     server.URL,
+		Timeout: 5,
+		Signing: config.SigningConfig{Secrets: secrets},
+	}, provider.Options{})
+	require.NoError(t, err)
+
+	_, err = p.Send(context.Background(), &models.Message{ID: 1, PhoneNumber: "+905551111111", Content: "Hello"})
+	require.NoError(t, err)
+}
+
+func TestWebhookProvider_Send_Failure(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		expectedClass provider.ErrorClass
+	}{
+		{
+			name:          "server error is transient",
+			statusCode:    http.StatusInternalServerError,
+			expectedClass: provider.ErrorClassTransient,
+		},
+		{
+			name:          "throttling is transient",
+			statusCode:    http.StatusTooManyRequests,
+			expectedClass: provider.ErrorClassTransient,
+		},
+		{
+			name:          "bad request is permanent",
+			statusCode:    http.StatusBadRequest,
+			expectedClass: provider.ErrorClassPermanent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(`{"error":"rejected"}`))
+			}))
+			defer server.Close()
+
+			p, err := provider.NewWebhookProvider(config.ProviderConfig{
+				Name:    "primary",
+				URL:     server.URL,
+				Timeout: 5,
+			}, provider.Options{})
+			require.NoError(t, err)
+
+			result, err := p.Send(context.Background(), &models.Message{ID: 1})
+
+			require.Error(t, err)
+			assert.Nil(t, result)
+			assert.Equal(t, tt.expectedClass, provider.ClassOf(err))
+			assert.Contains(t, err.Error(), "unexpected status code")
+
+			var providerErr *provider.Error
+			require.ErrorAs(t, err, &providerErr)
+			assert.Equal(t, tt.statusCode, providerErr.StatusCode)
+			assert.Equal(t, `{"error":"rejected"}`, providerErr.Body)
+		})
+	}
+}
+
+func TestWebhookProvider_Send_NetworkError(t *testing.T) {
+	p, err := provider.NewWebhookProvider(config.ProviderConfig{
+		Name:    "primary",
+		URL:     "http://localhost:1",
+		Timeout: 1,
+	}, provider.Options{})
+	require.NoError(t, err)
+
+	_, err = p.Send(context.Background(), &models.Message{ID: 1})
+
+	require.Error(t, err)
+	assert.True(t, provider.IsTransient(err))
+}
@@ -0,0 +1,90 @@
+// Package queue hands queued messages to the senders. Messages are always
+// stored in Postgres; the queue decides how senders learn about them.
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/repository"
+)
+
+// Queue delivers queued messages to senders.
+type Queue interface {
+	// Enqueue makes a newly queued message available to senders.
+	Enqueue(ctx context.Context, id int64) error
+	// Claim moves up to limit messages to processing and returns them.
+	// A message is claimed by one sender only.
+	Claim(ctx context.Context, limit int) ([]*models.Message, error)
+	// Ack reports that a claimed message reached its next status and is
+	// not to be sent again.
+	Ack(ctx context.Context, id int64) error
+	// Release hands claimed messages that were not sent back to the
+	// queue and returns the IDs of the released ones. Messages that left
+	// processing in the meantime are skipped.
+	Release(ctx context.Context, ids []int64) ([]int64, error)
+}
+
+// New returns the queue selected by cfg.Backend.
+func New(cfg config.QueueConfig, repo repository.Repository, client *redis.Client, logger *zap.Logger) (Queue, error) {
+	switch cfg.Backend {
+	case "", config.QueueBackendPostgres:
+		return NewPostgresQueue(repo, time.Duration(cfg.ClaimIdle)*time.Second), nil
+	case config.QueueBackendRedis:
+		consumer, err := cfg.ConsumerName()
+		if err != nil {
+			return nil, fmt.Errorf("failed to name queue consumer: %w", err)
+		}
+		return NewRedisQueue(client, repo, RedisOptions{
+			Stream:    cfg.Stream,
+			Group:     cfg.Group,
+			Consumer:  consumer,
+			ClaimIdle: time.Duration(cfg.ClaimIdle) * time.Second,
+			MaxLen:    cfg.MaxLen,
+		}, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q", cfg.Backend)
+	}
+}
+
+// PostgresQueue claims queued rows directly from the messages table.
+// Claimed rows are leased; rows still processing when their lease ends
+// are claimed again.
+type PostgresQueue struct {
+	repo  repository.Repository
+	lease time.Duration
+}
+
+// NewPostgresQueue creates a queue polling the messages table. The lease
+// has to outlast a batch.
+func NewPostgresQueue(repo repository.Repository, lease time.Duration) *PostgresQueue {
+	return &PostgresQueue{repo: repo, lease: lease}
+}
+
+// Enqueue implements Queue. Queued rows are found by Claim, so there is
+// nothing to do.
+func (q *PostgresQueue) Enqueue(context.Context, int64) error {
+	return nil
+}
+
+// Claim implements Queue.
+func (q *PostgresQueue) Claim(ctx context.Context, limit int) ([]*models.Message, error) {
+	return q.repo.Message().ClaimMessages(ctx, limit, q.lease)
+}
+
+// Ack implements Queue. The status of a message is its queue state, so
+// there is nothing to do.
+func (q *PostgresQueue) Ack(context.Context, int64) error {
+	return nil
+}
+
+// Release implements Queue.
+func (q *PostgresQueue) Release(ctx context.Context, ids []int64) ([]int64, error) {
+	return q.repo.Message().ReleaseMessages(ctx, ids)
+}
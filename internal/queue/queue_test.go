@@ -0,0 +1,73 @@
+package queue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/queue"
+	"github.com/popeskul/insdr-messenger/internal/repository/mocks"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "default", backend: "", want: &queue.PostgresQueue{}},
+		{name: "postgres", backend: config.QueueBackendPostgres, want: &queue.PostgresQueue{}},
+		{name: "redis", backend: config.QueueBackendRedis, want: &queue.RedisQueue{}},
+		{name: "unknown", backend: "kafka", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.QueueConfig{Backend: tt.backend, Stream: "queue:messages", Group: "senders"}
+
+			q, err := queue.New(cfg, nil, redis.NewClient(&redis.Options{}), zap.NewNop())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.IsType(t, tt.want, q)
+		})
+	}
+}
+
+func TestPostgresQueue_Claim(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+
+	messages := []*models.Message{{ID: 1, Status: models.MessageStatusProcessing}}
+	mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 10, 5*time.Minute).Return(messages, nil)
+	mockMessageRepo.EXPECT().ReleaseMessages(gomock.Any(), []int64{1}).Return([]int64{1}, nil)
+
+	q := queue.NewPostgresQueue(mockRepo, 5*time.Minute)
+
+	require.NoError(t, q.Enqueue(context.Background(), 1))
+
+	claimed, err := q.Claim(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, messages, claimed)
+
+	released, err := q.Release(context.Background(), []int64{1})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, released)
+
+	assert.NoError(t, q.Ack(context.Background(), 1))
+}
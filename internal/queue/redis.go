@@ -0,0 +1,380 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/repository"
+)
+
+// sweepLimit bounds the queued messages added back to the stream per sweep.
+const sweepLimit = 1000
+
+// ErrQueueFull is returned by Enqueue when the stream holds MaxLen entries.
+var ErrQueueFull = errors.New("queue is full")
+
+// enqueueScript adds an entry unless the stream holds ARGV[1] entries.
+var enqueueScript = redis.NewScript(`
+if redis.call('XLEN', KEYS[1]) >= tonumber(ARGV[1]) then
+	return false
+end
+return redis.call('XADD', KEYS[1], '*', 'id', ARGV[2])
+`)
+
+// RedisOptions configures a RedisQueue.
+type RedisOptions struct {
+	Stream    string
+	Group     string
+	Consumer  string
+	ClaimIdle time.Duration
+	// MaxLen caps the entries of the stream, zero means no limit. Entries
+	// are deleted once acknowledged, so it bounds the backlog of unread
+	// and pending entries.
+	MaxLen int64
+}
+
+// RedisQueue passes message IDs through a Redis stream read by a consumer
+// group. An entry is acknowledged and deleted once its message left
+// processing; entries left pending by a consumer that stopped are taken
+// over after ClaimIdle.
+//
+// The stream only carries IDs, the message itself is claimed in Postgres,
+// so duplicate entries and entries of messages that are no longer queued
+// are harmless and acknowledged on sight.
+type RedisQueue struct {
+	client *redis.Client
+	repo   repository.Repository
+	opts   RedisOptions
+	logger *zap.Logger
+
+	mu        sync.Mutex
+	groupOK   bool
+	lastSweep time.Time
+	// entries maps claimed messages to their unacknowledged stream entries.
+	entries map[int64][]string
+}
+
+// NewRedisQueue creates a queue on the stream and group named in opts.
+func NewRedisQueue(client *redis.Client, repo repository.Repository, opts RedisOptions, logger *zap.Logger) *RedisQueue {
+	return &RedisQueue{
+		client:  client,
+		repo:    repo,
+		opts:    opts,
+		logger:  logger,
+		entries: make(map[int64][]string),
+	}
+}
+
+// Enqueue implements Queue. It returns ErrQueueFull when the stream holds
+// MaxLen entries; the message is added again by the sweep later.
+func (q *RedisQueue) Enqueue(ctx context.Context, id int64) error {
+	var err error
+	if q.opts.MaxLen > 0 {
+		err = enqueueScript.Run(ctx, q.client, []string{q.opts.Stream}, q.opts.MaxLen, id).Err()
+		if errors.Is(err, redis.Nil) {
+			err = ErrQueueFull
+		}
+	} else {
+		err = q.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: q.opts.Stream,
+			Values: map[string]interface{}{"id": id},
+		}).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to enqueue message %d: %w", id, err)
+	}
+	return nil
+}
+
+// Claim implements Queue. Entries taken over from stopped consumers come
+// first, then new entries; it never blocks.
+func (q *RedisQueue) Claim(ctx context.Context, limit int) ([]*models.Message, error) {
+	if err := q.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+	q.sweep(ctx)
+
+	// A consumer that stopped may have claimed its messages in Postgres
+	// already, so taken over entries may be processing as well.
+	stale, err := q.autoClaim(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := q.claim(ctx, stale, []models.MessageStatus{models.MessageStatusQueued, models.MessageStatusProcessing})
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := limit - len(messages)
+	if remaining <= 0 {
+		return messages, nil
+	}
+
+	fresh, err := q.readNew(ctx, remaining)
+	if err == nil {
+		var claimed []*models.Message
+		claimed, err = q.claim(ctx, fresh, []models.MessageStatus{models.MessageStatusQueued})
+		messages = append(messages, claimed...)
+	}
+	if err != nil {
+		if len(messages) == 0 {
+			return nil, err
+		}
+		// The messages taken over are processing already and must be sent.
+		q.logger.Warn("Failed to read new queue entries", zap.Error(err))
+	}
+
+	return messages, nil
+}
+
+// Ack implements Queue.
+func (q *RedisQueue) Ack(ctx context.Context, id int64) error {
+	q.mu.Lock()
+	entryIDs := q.entries[id]
+	delete(q.entries, id)
+	q.mu.Unlock()
+
+	if len(entryIDs) == 0 {
+		return nil
+	}
+	if err := q.done(ctx, entryIDs); err != nil {
+		return fmt.Errorf("failed to acknowledge message %d: %w", id, err)
+	}
+	return nil
+}
+
+// done acknowledges entries and deletes them from the stream, which would
+// grow without bound otherwise.
+func (q *RedisQueue) done(ctx context.Context, entryIDs []string) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, q.opts.Stream, q.opts.Group, entryIDs...)
+		pipe.XDel(ctx, q.opts.Stream, entryIDs...)
+		return nil
+	})
+	return err
+}
+
+// Release implements Queue. Released messages get a new entry so that any
+// consumer can read them right away; their old entries are acknowledged.
+func (q *RedisQueue) Release(ctx context.Context, ids []int64) ([]int64, error) {
+	released, err := q.repo.Message().ReleaseMessages(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range released {
+		if err := q.Enqueue(ctx, id); err != nil {
+			// The old entry is left pending on purpose: without a new
+			// entry it is what brings the message back, taken over by any
+			// consumer after ClaimIdle. It is no longer ours to acknowledge.
+			q.mu.Lock()
+			delete(q.entries, id)
+			q.mu.Unlock()
+			q.logger.Warn("Failed to enqueue released message", zap.Int64("messageID", id), zap.Error(err))
+			continue
+		}
+		if err := q.Ack(ctx, id); err != nil {
+			q.logger.Warn("Failed to acknowledge released message", zap.Int64("messageID", id), zap.Error(err))
+		}
+	}
+
+	return released, nil
+}
+
+// entry is a stream entry carrying a message ID; id is zero if the entry
+// could not be parsed.
+type entry struct {
+	streamID string
+	id       int64
+}
+
+// claim claims the messages of entries in Postgres. Entries of claimed
+// messages are kept for Ack, all others are acknowledged and deleted right
+// away.
+func (q *RedisQueue) claim(ctx context.Context, entries []entry, from []models.MessageStatus) ([]*models.Message, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int64, 0, len(entries))
+	seen := make(map[int64]bool, len(entries))
+	for _, e := range entries {
+		if e.id != 0 && !seen[e.id] {
+			seen[e.id] = true
+			ids = append(ids, e.id)
+		}
+	}
+
+	var messages []*models.Message
+	if len(ids) > 0 {
+		var err error
+		messages, err = q.repo.Message().ClaimMessagesByID(ctx, ids, from)
+		if err != nil {
+			// The entries stay pending and are taken over after ClaimIdle.
+			return nil, err
+		}
+	}
+
+	claimed := make(map[int64]bool, len(messages))
+	for _, msg := range messages {
+		claimed[msg.ID] = true
+	}
+
+	var skipped []string
+	q.mu.Lock()
+	for _, e := range entries {
+		if claimed[e.id] {
+			q.entries[e.id] = append(q.entries[e.id], e.streamID)
+		} else {
+			skipped = append(skipped, e.streamID)
+		}
+	}
+	q.mu.Unlock()
+
+	if len(skipped) > 0 {
+		if err := q.done(ctx, skipped); err != nil {
+			q.logger.Warn("Failed to acknowledge skipped queue entries",
+				zap.Int("count", len(skipped)),
+				zap.Error(err))
+		}
+	}
+
+	return messages, nil
+}
+
+// readNew reads up to count entries never delivered to the group.
+func (q *RedisQueue) readNew(ctx context.Context, count int) ([]entry, error) {
+	streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    q.opts.Group,
+		Consumer: q.opts.Consumer,
+		Streams:  []string{q.opts.Stream, ">"},
+		Count:    int64(count),
+		Block:    -1,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue: %w", err)
+	}
+
+	var entries []entry
+	for _, s := range streams {
+		for _, msg := range s.Messages {
+			entries = append(entries, entry{streamID: msg.ID, id: parseMessageID(msg.Values["id"])})
+		}
+	}
+	return entries, nil
+}
+
+// autoClaim takes over up to count entries pending for longer than
+// ClaimIdle. The reply is parsed by hand as go-redis v8 does not know the
+// three element reply of Redis 7.
+func (q *RedisQueue) autoClaim(ctx context.Context, count int) ([]entry, error) {
+	reply, err := q.client.Do(ctx, "XAUTOCLAIM", q.opts.Stream, q.opts.Group, q.opts.Consumer,
+		q.opts.ClaimIdle.Milliseconds(), "0-0", "COUNT", count).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to take over queue entries: %w", err)
+	}
+	if len(reply) < 2 {
+		return nil, fmt.Errorf("unexpected XAUTOCLAIM reply of %d elements", len(reply))
+	}
+
+	items, _ := reply[1].([]interface{})
+	entries := make([]entry, 0, len(items))
+	for _, item := range items {
+		fields, _ := item.([]interface{})
+		if len(fields) == 0 {
+			continue
+		}
+		streamID, _ := fields[0].(string)
+		e := entry{streamID: streamID}
+		// Entries trimmed from the stream come without values.
+		if len(fields) > 1 {
+			values, _ := fields[1].([]interface{})
+			for i := 0; i+1 < len(values); i += 2 {
+				if values[i] == "id" {
+					e.id = parseMessageID(values[i+1])
+				}
+			}
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) > 0 {
+		q.logger.Info("Took over pending queue entries", zap.Int("count", len(entries)))
+	}
+	return entries, nil
+}
+
+// ensureGroup creates the stream and consumer group on first use.
+func (q *RedisQueue) ensureGroup(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.groupOK {
+		return nil
+	}
+
+	err := q.client.XGroupCreateMkStream(ctx, q.opts.Stream, q.opts.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create queue consumer group: %w", err)
+	}
+	q.groupOK = true
+	return nil
+}
+
+// sweep adds queued messages that waited longer than ClaimIdle to the
+// stream again, at most once per ClaimIdle. It covers messages whose
+// Enqueue failed and messages queued by other means than the API.
+func (q *RedisQueue) sweep(ctx context.Context) {
+	now := time.Now()
+
+	q.mu.Lock()
+	if now.Sub(q.lastSweep) < q.opts.ClaimIdle {
+		q.mu.Unlock()
+		return
+	}
+	q.lastSweep = now
+	q.mu.Unlock()
+
+	messages, err := q.repo.Message().GetUnsentMessages(ctx, sweepLimit)
+	if err != nil {
+		q.logger.Warn("Failed to sweep queued messages", zap.Error(err))
+		return
+	}
+
+	var added int
+	for _, msg := range messages {
+		if now.Sub(msg.UpdatedAt) < q.opts.ClaimIdle {
+			continue
+		}
+		if err := q.Enqueue(ctx, msg.ID); err != nil {
+			q.logger.Warn("Failed to sweep queued messages", zap.Error(err))
+			return
+		}
+		added++
+	}
+
+	if added > 0 {
+		q.logger.Info("Added waiting messages to the queue again", zap.Int("count", added))
+	}
+}
+
+func parseMessageID(v interface{}) int64 {
+	s, _ := v.(string)
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,323 @@
+package queue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/queue"
+	"github.com/popeskul/insdr-messenger/internal/repository/mocks"
+)
+
+const (
+	testStream    = "queue:messages"
+	testGroup     = "senders"
+	testClaimIdle = time.Minute
+)
+
+var (
+	fromQueued     = []models.MessageStatus{models.MessageStatusQueued}
+	fromProcessing = []models.MessageStatus{models.MessageStatusQueued, models.MessageStatusProcessing}
+)
+
+type redisFixture struct {
+	server      *miniredis.Miniredis
+	client      *redis.Client
+	repo        *mocks.MockRepository
+	messageRepo *mocks.MockMessageRepository
+}
+
+func newRedisFixture(t *testing.T) *redisFixture {
+	ctrl := gomock.NewController(t)
+
+	server := miniredis.RunT(t)
+	server.SetTime(time.Now())
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	repo := mocks.NewMockRepository(ctrl)
+	messageRepo := mocks.NewMockMessageRepository(ctrl)
+	repo.EXPECT().Message().Return(messageRepo).AnyTimes()
+
+	return &redisFixture{server: server, client: client, repo: repo, messageRepo: messageRepo}
+}
+
+func (f *redisFixture) queue(consumer string) *queue.RedisQueue {
+	return queue.NewRedisQueue(f.client, f.repo, queue.RedisOptions{
+		Stream:    testStream,
+		Group:     testGroup,
+		Consumer:  consumer,
+		ClaimIdle: testClaimIdle,
+	}, zap.NewNop())
+}
+
+func (f *redisFixture) pending(t *testing.T) []redis.XPendingExt {
+	pending, err := f.client.XPendingExt(context.Background(), &redis.XPendingExtArgs{
+		Stream: testStream,
+		Group:  testGroup,
+		Start:  "-",
+		End:    "+",
+		Count:  100,
+	}).Result()
+	require.NoError(t, err)
+	return pending
+}
+
+func processing(ids ...int64) []*models.Message {
+	messages := make([]*models.Message, 0, len(ids))
+	for _, id := range ids {
+		messages = append(messages, &models.Message{ID: id, Status: models.MessageStatusProcessing})
+	}
+	return messages
+}
+
+func TestRedisQueue_ClaimAndAck(t *testing.T) {
+	f := newRedisFixture(t)
+	q := f.queue("worker-1")
+	ctx := context.Background()
+
+	f.messageRepo.EXPECT().GetUnsentMessages(gomock.Any(), gomock.Any()).Return(nil, nil)
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{1, 2}, fromQueued).Return(processing(1, 2), nil)
+
+	require.NoError(t, q.Enqueue(ctx, 1))
+	require.NoError(t, q.Enqueue(ctx, 2))
+
+	messages, err := q.Claim(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, processing(1, 2), messages)
+	assert.Len(t, f.pending(t), 2)
+
+	require.NoError(t, q.Ack(ctx, 1))
+	require.NoError(t, q.Ack(ctx, 2))
+	assert.Empty(t, f.pending(t))
+
+	// Nothing new to read.
+	messages, err = q.Claim(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestRedisQueue_ClaimRespectsLimit(t *testing.T) {
+	f := newRedisFixture(t)
+	q := f.queue("worker-1")
+	ctx := context.Background()
+
+	f.messageRepo.EXPECT().GetUnsentMessages(gomock.Any(), gomock.Any()).Return(nil, nil)
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{1, 2}, fromQueued).Return(processing(1, 2), nil)
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{3}, fromQueued).Return(processing(3), nil)
+
+	for id := int64(1); id <= 3; id++ {
+		require.NoError(t, q.Enqueue(ctx, id))
+	}
+
+	messages, err := q.Claim(ctx, 2)
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+
+	messages, err = q.Claim(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, processing(3), messages)
+}
+
+func TestRedisQueue_AcknowledgesUnclaimableEntries(t *testing.T) {
+	f := newRedisFixture(t)
+	q := f.queue("worker-1")
+	ctx := context.Background()
+
+	f.messageRepo.EXPECT().GetUnsentMessages(gomock.Any(), gomock.Any()).Return(nil, nil)
+	// Message 1 is queued twice, message 2 was sent meanwhile.
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{1, 2}, fromQueued).Return(processing(1), nil)
+
+	require.NoError(t, q.Enqueue(ctx, 1))
+	require.NoError(t, q.Enqueue(ctx, 1))
+	require.NoError(t, q.Enqueue(ctx, 2))
+	require.NoError(t, f.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: testStream,
+		Values: map[string]interface{}{"id": "not-a-number"},
+	}).Err())
+
+	messages, err := q.Claim(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, processing(1), messages)
+	assert.Len(t, f.pending(t), 2)
+
+	require.NoError(t, q.Ack(ctx, 1))
+	assert.Empty(t, f.pending(t))
+}
+
+func TestRedisQueue_DeletesAcknowledgedEntries(t *testing.T) {
+	f := newRedisFixture(t)
+	q := f.queue("worker-1")
+	ctx := context.Background()
+
+	f.messageRepo.EXPECT().GetUnsentMessages(gomock.Any(), gomock.Any()).Return(nil, nil)
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{1, 2}, fromQueued).Return(processing(1), nil)
+
+	require.NoError(t, q.Enqueue(ctx, 1))
+	require.NoError(t, q.Enqueue(ctx, 2))
+
+	_, err := q.Claim(ctx, 10)
+	require.NoError(t, err)
+
+	// The entry of message 2 is skipped and gone, message 1 is pending.
+	length, err := f.client.XLen(ctx, testStream).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), length)
+
+	require.NoError(t, q.Ack(ctx, 1))
+	length, err = f.client.XLen(ctx, testStream).Result()
+	require.NoError(t, err)
+	assert.Zero(t, length)
+}
+
+func TestRedisQueue_MaxLen(t *testing.T) {
+	f := newRedisFixture(t)
+	q := queue.NewRedisQueue(f.client, f.repo, queue.RedisOptions{
+		Stream:    testStream,
+		Group:     testGroup,
+		Consumer:  "worker-1",
+		ClaimIdle: testClaimIdle,
+		MaxLen:    2,
+	}, zap.NewNop())
+	ctx := context.Background()
+
+	require.NoError(t, q.Enqueue(ctx, 1))
+	require.NoError(t, q.Enqueue(ctx, 2))
+	assert.ErrorIs(t, q.Enqueue(ctx, 3), queue.ErrQueueFull)
+
+	f.messageRepo.EXPECT().GetUnsentMessages(gomock.Any(), gomock.Any()).Return(nil, nil)
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{1, 2}, fromQueued).Return(processing(1, 2), nil)
+
+	_, err := q.Claim(ctx, 10)
+	require.NoError(t, err)
+	require.NoError(t, q.Ack(ctx, 1))
+
+	assert.NoError(t, q.Enqueue(ctx, 3))
+}
+
+func TestRedisQueue_TakesOverStaleEntries(t *testing.T) {
+	f := newRedisFixture(t)
+	stopped := f.queue("worker-1")
+	q := f.queue("worker-2")
+	ctx := context.Background()
+
+	f.messageRepo.EXPECT().GetUnsentMessages(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{1}, fromQueued).Return(processing(1), nil)
+
+	require.NoError(t, stopped.Enqueue(ctx, 1))
+	_, err := stopped.Claim(ctx, 10)
+	require.NoError(t, err)
+
+	// Not idle for long enough yet.
+	messages, err := q.Claim(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	f.server.SetTime(time.Now().Add(testClaimIdle + time.Second))
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{1}, fromProcessing).Return(processing(1), nil)
+
+	messages, err = q.Claim(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, processing(1), messages)
+
+	pending := f.pending(t)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "worker-2", pending[0].Consumer)
+
+	require.NoError(t, q.Ack(ctx, 1))
+	assert.Empty(t, f.pending(t))
+}
+
+func TestRedisQueue_SweepsWaitingMessages(t *testing.T) {
+	f := newRedisFixture(t)
+	q := f.queue("worker-1")
+	ctx := context.Background()
+
+	// Message 1 was never added to the stream, message 2 was queued just
+	// now and is left to Enqueue.
+	f.messageRepo.EXPECT().GetUnsentMessages(gomock.Any(), gomock.Any()).Return([]*models.Message{
+		{ID: 1, Status: models.MessageStatusQueued, UpdatedAt: time.Now().Add(-2 * testClaimIdle)},
+		{ID: 2, Status: models.MessageStatusQueued, UpdatedAt: time.Now()},
+	}, nil)
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{1}, fromQueued).Return(processing(1), nil)
+
+	messages, err := q.Claim(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, processing(1), messages)
+}
+
+func TestRedisQueue_Release(t *testing.T) {
+	f := newRedisFixture(t)
+	q := f.queue("worker-1")
+	ctx := context.Background()
+
+	// Both consumers sweep on their first claim.
+	f.messageRepo.EXPECT().GetUnsentMessages(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{1, 2}, fromQueued).Return(processing(1, 2), nil)
+	f.messageRepo.EXPECT().ReleaseMessages(gomock.Any(), []int64{1, 2}).Return([]int64{2}, nil)
+
+	require.NoError(t, q.Enqueue(ctx, 1))
+	require.NoError(t, q.Enqueue(ctx, 2))
+
+	_, err := q.Claim(ctx, 10)
+	require.NoError(t, err)
+
+	released, err := q.Release(ctx, []int64{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, released)
+
+	// The old entry of the released message is acknowledged and a new one
+	// is ready for any consumer; message 1 stays pending until acknowledged.
+	pending := f.pending(t)
+	require.Len(t, pending, 1)
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{2}, fromQueued).Return(processing(2), nil)
+
+	messages, err := f.queue("worker-2").Claim(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, processing(2), messages)
+}
+
+func TestRedisQueue_ReleaseWhenFull(t *testing.T) {
+	f := newRedisFixture(t)
+	q := queue.NewRedisQueue(f.client, f.repo, queue.RedisOptions{
+		Stream:    testStream,
+		Group:     testGroup,
+		Consumer:  "worker-1",
+		ClaimIdle: testClaimIdle,
+		MaxLen:    1,
+	}, zap.NewNop())
+	ctx := context.Background()
+
+	f.messageRepo.EXPECT().GetUnsentMessages(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{1}, fromQueued).Return(processing(1), nil)
+	f.messageRepo.EXPECT().ReleaseMessages(gomock.Any(), []int64{1}).Return([]int64{1}, nil)
+
+	require.NoError(t, q.Enqueue(ctx, 1))
+	_, err := q.Claim(ctx, 10)
+	require.NoError(t, err)
+
+	// The stream is full, so the old entry stays pending and is no longer
+	// acknowledged by this consumer.
+	released, err := q.Release(ctx, []int64{1})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, released)
+	require.NoError(t, q.Ack(ctx, 1))
+	require.Len(t, f.pending(t), 1)
+
+	// Another consumer takes it over once it is idle.
+	f.server.SetTime(time.Now().Add(testClaimIdle + time.Second))
+	f.messageRepo.EXPECT().ClaimMessagesByID(gomock.Any(), []int64{1}, fromProcessing).Return(processing(1), nil)
+
+	messages, err := f.queue("worker-2").Claim(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, processing(1), messages)
+}
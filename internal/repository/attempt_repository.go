@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
+
+type attemptRepository struct {
+	db *sqlx.DB
+}
+
+func NewAttemptRepository(db *sqlx.DB) AttemptRepository {
+	return &attemptRepository{
+		db: db,
+	}
+}
+
+// CreateAttempts stores the delivery attempts of a message.
+func (r *attemptRepository) CreateAttempts(ctx context.Context, attempts []*models.DeliveryAttempt) error {
+	if len(attempts) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO delivery_attempts (message_id, provider, route, requested_at, latency_ms, status_code, response_body, error, error_class, breaker_state)
+		VALUES (:message_id, :provider, :route, :requested_at, :latency_ms, :status_code, :response_body, :error, :error_class, :breaker_state)
+	`
+
+	_, err := r.db.NamedExecContext(ctx, query, attempts)
+	if err != nil {
+		return fmt.Errorf("failed to create delivery attempts: %w", err)
+	}
+
+	return nil
+}
+
+// GetAttempts returns the delivery attempts of a message, oldest first.
+func (r *attemptRepository) GetAttempts(ctx context.Context, messageID int64) ([]*models.DeliveryAttempt, error) {
+	query := `
+		SELECT id, message_id, provider, route, requested_at, latency_ms, status_code, response_body, error, error_class, breaker_state
+		FROM delivery_attempts
+		WHERE message_id = $1
+		ORDER BY requested_at ASC, id ASC
+	`
+
+	attempts := []*models.DeliveryAttempt{}
+	err := r.db.SelectContext(ctx, &attempts, query, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery attempts: %w", err)
+	}
+
+	return attempts, nil
+}
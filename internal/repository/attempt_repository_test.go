@@ -0,0 +1,67 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttemptRepository_CreateAndGetAttempts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewAttemptRepository(db)
+
+	messageID, err := insertTestMessage(db.DB, "+1234567890", "Test", string(models.MessageStatusProcessing), nil)
+	require.NoError(t, err)
+
+	requestedAt := time.Now().Truncate(time.Microsecond)
+	err = repo.CreateAttempts(context.Background(), []*models.DeliveryAttempt{
+		{
+			MessageID:    messageID,
+			Provider:     "primary",
+			Route:        "default",
+			RequestedAt:  requestedAt,
+			LatencyMs:    30,
+			StatusCode:   sql.NullInt32{Int32: 503, Valid: true},
+			ResponseBody: sql.NullString{String: "unavailable", Valid: true},
+			Error:        sql.NullString{String: "unexpected status code: 503", Valid: true},
+			ErrorClass:   sql.NullString{String: "transient", Valid: true},
+			BreakerState: "closed",
+		},
+		{
+			MessageID:    messageID,
+			Provider:     "secondary",
+			Route:        "default",
+			RequestedAt:  requestedAt.Add(40 * time.Millisecond),
+			LatencyMs:    12,
+			StatusCode:   sql.NullInt32{Int32: 202, Valid: true},
+			BreakerState: "closed",
+		},
+	})
+	require.NoError(t, err)
+
+	attempts, err := repo.GetAttempts(context.Background(), messageID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+
+	assert.Equal(t, "primary", attempts[0].Provider)
+	assert.True(t, requestedAt.Equal(attempts[0].RequestedAt))
+	assert.Equal(t, int32(503), attempts[0].StatusCode.Int32)
+	assert.Equal(t, "transient", attempts[0].ErrorClass.String)
+	assert.Equal(t, "secondary", attempts[1].Provider)
+	assert.False(t, attempts[1].Error.Valid)
+
+	assert.NoError(t, repo.CreateAttempts(context.Background(), nil))
+
+	attempts, err = repo.GetAttempts(context.Background(), 99999)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+}
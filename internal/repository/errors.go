@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
+
+var (
+	ErrMessageNotFound         = errors.New("message not found")
+	ErrInvalidStatusTransition = errors.New("invalid message status transition")
+	ErrSubscriptionNotFound    = errors.New("subscription not found")
+	// ErrClaimLost is returned when the outcome of a send is written after
+	// the message was claimed again by another sender.
+	ErrClaimLost = errors.New("message claimed by another sender")
+)
+
+// TransitionError is returned when a status update is not allowed from the
+// current status of the message. It matches ErrInvalidStatusTransition.
+type TransitionError struct {
+	MessageID int64
+	From      models.MessageStatus
+	To        models.MessageStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s: message %d cannot move from %s to %s", ErrInvalidStatusTransition, e.MessageID, e.From, e.To)
+}
+
+// Is reports whether target is ErrInvalidStatusTransition.
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidStatusTransition
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
+
+type eventRepository struct {
+	db *sqlx.DB
+}
+
+func NewEventRepository(db *sqlx.DB) EventRepository {
+	return &eventRepository{
+		db: db,
+	}
+}
+
+// CreateSubscription stores a subscription and sets its ID and creation
+// time.
+func (r *eventRepository) CreateSubscription(ctx context.Context, sub *models.Subscription) error {
+	query := `
+		INSERT INTO event_subscriptions (url, event_types, secret)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowxContext(ctx, query, sub.URL, sub.EventTypes, sub.Secret).Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	return nil
+}
+
+// GetSubscriptions returns all subscriptions, oldest first.
+func (r *eventRepository) GetSubscriptions(ctx context.Context) ([]*models.Subscription, error) {
+	query := `
+		SELECT id, url, event_types, secret, created_at
+		FROM event_subscriptions
+		ORDER BY id ASC
+	`
+
+	subscriptions := []*models.Subscription{}
+	err := r.db.SelectContext(ctx, &subscriptions, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// GetSubscription retrieves a subscription by ID.
+func (r *eventRepository) GetSubscription(ctx context.Context, id int64) (*models.Subscription, error) {
+	query := `
+		SELECT id, url, event_types, secret, created_at
+		FROM event_subscriptions
+		WHERE id = $1
+	`
+
+	var sub models.Subscription
+	err := r.db.GetContext(ctx, &sub, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	return &sub, nil
+}
+
+// DeleteSubscription removes a subscription together with its events.
+func (r *eventRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM event_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ErrSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// PublishEvent queues an event for every subscription of its type.
+// Message status events are queued by a database trigger instead.
+func (r *eventRepository) PublishEvent(ctx context.Context, eventType models.EventType, payload []byte) error {
+	query := `
+		INSERT INTO event_outbox (subscription_id, event_type, payload)
+		SELECT id, $1, $2::jsonb
+		FROM event_subscriptions
+		WHERE $1 = ANY(event_types)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, string(eventType), string(payload))
+	if err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimEvents returns up to limit events that are due for delivery and
+// postpones their next attempt by lease, so concurrent dispatchers skip
+// them. Events of a dispatcher that dies are retried once the lease ends.
+func (r *eventRepository) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboxEvent, error) {
+	query := `
+		UPDATE event_outbox o
+		SET next_attempt_at = $1,
+		    updated_at = $2
+		FROM event_subscriptions s
+		WHERE s.id = o.subscription_id
+		  AND o.id IN (
+			SELECT id
+			FROM event_outbox
+			WHERE status = $3 AND next_attempt_at <= $2
+			ORDER BY next_attempt_at ASC, id ASC
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING o.id, o.subscription_id, o.event_type, o.payload, o.status, o.attempts, o.next_attempt_at,
+		          o.last_error, o.created_at, o.updated_at, s.url, s.secret
+	`
+
+	now := time.Now()
+	var events []*models.OutboxEvent
+	err := r.db.SelectContext(ctx, &events, query, now.Add(lease), now, models.EventStatusPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim events: %w", err)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+
+	return events, nil
+}
+
+// MarkEventDelivered records a successful delivery attempt.
+func (r *eventRepository) MarkEventDelivered(ctx context.Context, id int64) error {
+	query := `
+		UPDATE event_outbox
+		SET status = $2,
+		    attempts = attempts + 1,
+		    last_error = NULL,
+		    updated_at = $3
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, models.EventStatusDelivered, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mark event delivered: %w", err)
+	}
+
+	return nil
+}
+
+// RetryEvent records a failed delivery attempt and schedules the next one.
+func (r *eventRepository) RetryEvent(ctx context.Context, id int64, errorMsg string, nextAttemptAt time.Time) error {
+	query := `
+		UPDATE event_outbox
+		SET attempts = attempts + 1,
+		    last_error = $2,
+		    next_attempt_at = $3,
+		    updated_at = $4
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, errorMsg, nextAttemptAt, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to reschedule event: %w", err)
+	}
+
+	return nil
+}
+
+// MarkEventDead records the last failed delivery attempt and moves the
+// event to the dead letters of its subscription.
+func (r *eventRepository) MarkEventDead(ctx context.Context, id int64, errorMsg string) error {
+	query := `
+		UPDATE event_outbox
+		SET status = $2,
+		    attempts = attempts + 1,
+		    last_error = $3,
+		    updated_at = $4
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, models.EventStatusDead, errorMsg, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mark event dead: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeadLetters returns the dead events of a subscription, most recent
+// first.
+func (r *eventRepository) GetDeadLetters(ctx context.Context, subscriptionID int64, limit int) ([]*models.OutboxEvent, error) {
+	query := `
+		SELECT id, subscription_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at
+		FROM event_outbox
+		WHERE subscription_id = $1 AND status = $2
+		ORDER BY updated_at DESC, id DESC
+		LIMIT $3
+	`
+
+	events := []*models.OutboxEvent{}
+	err := r.db.SelectContext(ctx, &events, query, subscriptionID, models.EventStatusDead, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letters: %w", err)
+	}
+
+	return events, nil
+}
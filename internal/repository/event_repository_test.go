@@ -0,0 +1,111 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventRepository_Subscriptions(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewEventRepository(db)
+
+	sub := &models.Subscription{
+		URL:        "https://orders.example.com/hooks",
+		EventTypes: []string{string(models.EventMessageSent), string(models.EventMessageFailed)},
+		Secret:     "0123456789abcdef",
+	}
+	require.NoError(t, repo.CreateSubscription(context.Background(), sub))
+	assert.NotZero(t, sub.ID)
+	assert.False(t, sub.CreatedAt.IsZero())
+
+	subscriptions, err := repo.GetSubscriptions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, sub.URL, subscriptions[0].URL)
+	assert.Equal(t, []string(sub.EventTypes), []string(subscriptions[0].EventTypes))
+
+	found, err := repo.GetSubscription(context.Background(), sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, sub.Secret, found.Secret)
+
+	require.NoError(t, repo.DeleteSubscription(context.Background(), sub.ID))
+	assert.ErrorIs(t, repo.DeleteSubscription(context.Background(), sub.ID), repository.ErrSubscriptionNotFound)
+
+	_, err = repo.GetSubscription(context.Background(), sub.ID)
+	assert.ErrorIs(t, err, repository.ErrSubscriptionNotFound)
+}
+
+func TestEventRepository_Outbox(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewEventRepository(db)
+	messages := repository.NewMessageRepository(db)
+
+	sent := &models.Subscription{
+		URL:        "https://orders.example.com/hooks",
+		EventTypes: []string{string(models.EventMessageSent), string(models.EventSchedulerStopped)},
+		Secret:     "0123456789abcdef",
+	}
+	require.NoError(t, repo.CreateSubscription(context.Background(), sent))
+	failed := &models.Subscription{
+		URL:        "https://alerts.example.com/hooks",
+		EventTypes: []string{string(models.EventMessageFailed)},
+		Secret:     "fedcba9876543210",
+	}
+	require.NoError(t, repo.CreateSubscription(context.Background(), failed))
+
+	// Status changes are queued by the database for matching subscriptions.
+	messageID, err := insertTestMessage(db.DB, "+1234567890", "Test", string(models.MessageStatusProcessing), nil)
+	require.NoError(t, err)
+	require.NoError(t, messages.UpdateMessageStatus(context.Background(), messageID, "", models.MessageStatusSent, ptr("ext-1"), ptr("primary"), nil))
+
+	require.NoError(t, repo.PublishEvent(context.Background(), models.EventSchedulerStopped, []byte(`{"at":"2024-01-02T03:04:05Z"}`)))
+
+	events, err := repo.ClaimEvents(context.Background(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, models.EventMessageSent, events[0].EventType)
+	assert.Equal(t, sent.ID, events[0].SubscriptionID)
+	assert.Equal(t, sent.URL, events[0].URL)
+	assert.Equal(t, sent.Secret, events[0].Secret)
+	assert.Contains(t, string(events[0].Payload), `"message_id": "ext-1"`)
+	assert.Equal(t, models.EventSchedulerStopped, events[1].EventType)
+
+	// Claimed events are leased.
+	claimed, err := repo.ClaimEvents(context.Background(), 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	require.NoError(t, repo.MarkEventDelivered(context.Background(), events[0].ID))
+	require.NoError(t, repo.RetryEvent(context.Background(), events[1].ID, "status 503", time.Now().Add(-time.Second)))
+
+	claimed, err = repo.ClaimEvents(context.Background(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	assert.Equal(t, "status 503", claimed[0].LastError.String)
+
+	require.NoError(t, repo.MarkEventDead(context.Background(), claimed[0].ID, "status 500"))
+
+	deadLetters, err := repo.GetDeadLetters(context.Background(), sent.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, models.EventStatusDead, deadLetters[0].Status)
+	assert.Equal(t, 2, deadLetters[0].Attempts)
+	assert.Equal(t, "status 500", deadLetters[0].LastError.String)
+
+	deadLetters, err = repo.GetDeadLetters(context.Background(), failed.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deadLetters)
+}
@@ -1,21 +1,76 @@
 package repository
 
-import "github.com/popeskul/insdr-messenger/internal/models"
+import (
+	"context"
+	"time"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
 
 // Repository interface defines all repository operations.
 type Repository interface {
 	// Ping checks database connectivity
-	Ping() error
+	Ping(ctx context.Context) error
 
 	// Message returns message repository
 	Message() MessageRepository
+
+	// Attempt returns delivery attempt repository
+	Attempt() AttemptRepository
+
+	// Event returns event subscription repository
+	Event() EventRepository
+
+	// Sandbox returns sandbox request repository
+	Sandbox() SandboxRepository
 }
 
 // MessageRepository interface defines message operations.
 type MessageRepository interface {
-	GetUnsentMessages(limit int) ([]*models.Message, error)
-	UpdateMessageStatus(id int64, status models.MessageStatus, messageID *string, errorMsg *string) error
-	GetSentMessages(offset, limit int) ([]*models.Message, error)
-	GetTotalSentCount() (int64, error)
-	CreateMessage(phoneNumber, content string) error
+	GetUnsentMessages(ctx context.Context, limit int) ([]*models.Message, error)
+	ClaimMessages(ctx context.Context, limit int, lease time.Duration) ([]*models.Message, error)
+	ClaimMessagesByID(ctx context.Context, ids []int64, from []models.MessageStatus) ([]*models.Message, error)
+	ReleaseMessages(ctx context.Context, ids []int64) ([]int64, error)
+	UpdateMessageStatus(ctx context.Context, id int64, claimToken string, status models.MessageStatus, messageID *string, provider *string, errorMsg *string) error
+	UpdateMessageStatuses(ctx context.Context, updates []models.StatusUpdate) ([]int64, error)
+	GetSentMessages(ctx context.Context, offset, limit int) ([]*models.Message, error)
+	GetTotalSentCount(ctx context.Context) (int64, error)
+	GetQueuedCount(ctx context.Context) (int64, error)
+	GetMessage(ctx context.Context, id int64) (*models.Message, error)
+	GetMessageByExternalID(ctx context.Context, messageID string) (*models.Message, error)
+	UpdateDeliveryStatus(ctx context.Context, id int64, status models.MessageStatus, deliveredAt time.Time, errorMsg *string) error
+	GetStatusHistory(ctx context.Context, id int64) ([]*models.StatusChange, error)
+	MarkAcceptedUnconfirmed(ctx context.Context, id int64, claimToken string, provider string, response string) error
+	GetUnconfirmedMessages(ctx context.Context, limit int) ([]*models.Message, error)
+	ResolveUnconfirmed(ctx context.Context, id int64, externalID string, status models.MessageStatus, errorMsg *string) error
+	CreateMessage(ctx context.Context, phoneNumber, content string) error
+	InsertMessage(ctx context.Context, msg *models.Message) error
+}
+
+// AttemptRepository interface defines delivery attempt operations.
+type AttemptRepository interface {
+	CreateAttempts(ctx context.Context, attempts []*models.DeliveryAttempt) error
+	GetAttempts(ctx context.Context, messageID int64) ([]*models.DeliveryAttempt, error)
+}
+
+// EventRepository interface defines event subscription and outbox
+// operations.
+type EventRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.Subscription) error
+	GetSubscriptions(ctx context.Context) ([]*models.Subscription, error)
+	GetSubscription(ctx context.Context, id int64) (*models.Subscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	PublishEvent(ctx context.Context, eventType models.EventType, payload []byte) error
+	ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboxEvent, error)
+	MarkEventDelivered(ctx context.Context, id int64) error
+	RetryEvent(ctx context.Context, id int64, errorMsg string, nextAttemptAt time.Time) error
+	MarkEventDead(ctx context.Context, id int64, errorMsg string) error
+	GetDeadLetters(ctx context.Context, subscriptionID int64, limit int) ([]*models.OutboxEvent, error)
+}
+
+// SandboxRepository interface defines operations on requests recorded in
+// sandbox mode.
+type SandboxRepository interface {
+	CreateSandboxRequest(ctx context.Context, req *models.SandboxRequest) error
+	GetSandboxRequests(ctx context.Context, messageID int64, limit int) ([]*models.SandboxRequest, error)
 }
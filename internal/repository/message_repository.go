@@ -1,11 +1,17 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"github.com/popeskul/insdr-messenger/internal/api"
 	"github.com/popeskul/insdr-messenger/internal/models"
@@ -21,10 +27,11 @@ func NewMessageRepository(db *sqlx.DB) MessageRepository {
 	}
 }
 
-// GetUnsentMessages retrieves unsent messages from the database.
-func (r *messageRepository) GetUnsentMessages(limit int) ([]*models.Message, error) {
+// GetUnsentMessages retrieves queued messages from the database without
+// claiming them.
+func (r *messageRepository) GetUnsentMessages(ctx context.Context, limit int) ([]*models.Message, error) {
 	query := `
-		SELECT id, phone_number, content, status, message_id, error, created_at, sent_at, updated_at
+		SELECT id, phone_number, content, status, message_id, provider, provider_response, error, priority, campaign, created_at, sent_at, delivered_at, updated_at
 		FROM messages
 		WHERE status = $1
 		ORDER BY created_at ASC
@@ -32,7 +39,7 @@ func (r *messageRepository) GetUnsentMessages(limit int) ([]*models.Message, err
 	`
 
 	var messages []*models.Message
-	err := r.db.Select(&messages, query, models.MessageStatusPending, limit)
+	err := r.db.SelectContext(ctx, &messages, query, models.MessageStatusQueued, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get unsent messages: %w", err)
 	}
@@ -40,16 +47,127 @@ func (r *messageRepository) GetUnsentMessages(limit int) ([]*models.Message, err
 	return messages, nil
 }
 
-// UpdateMessageStatus updates the status of a message.
-func (r *messageRepository) UpdateMessageStatus(id int64, status api.MessageStatus, messageID *string, errorMsg *string) error {
+// ClaimMessages moves up to limit of the oldest queued messages to
+// processing for lease and returns them. Messages whose lease expired
+// while processing are claimed again, so that messages of a sender that
+// stopped are not stuck. Rows locked by another claimer are skipped, so
+// concurrent senders never receive the same message. Every claim stores a
+// new claim token; outcomes written with an older token are rejected.
+func (r *messageRepository) ClaimMessages(ctx context.Context, limit int, lease time.Duration) ([]*models.Message, error) {
+	query := `
+		UPDATE messages
+		SET status = $1,
+		    claimed_until = $2,
+		    updated_at = $3,
+		    claim_token = $6
+		WHERE id IN (
+			SELECT id
+			FROM messages
+			WHERE status = $4
+			   OR (status = $1 AND claimed_until <= $3)
+			ORDER BY created_at ASC
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, phone_number, content, status, message_id, provider, provider_response, error, priority, campaign, created_at, sent_at, delivered_at, updated_at, claim_token
+	`
+
+	now := time.Now()
+	var messages []*models.Message
+	err := r.db.SelectContext(ctx, &messages, query, models.MessageStatusProcessing, now.Add(lease), now, models.MessageStatusQueued, limit, uuid.NewString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim messages: %w", err)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		if !messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
+			return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+		}
+		return messages[i].ID < messages[j].ID
+	})
+
+	return messages, nil
+}
+
+// ClaimMessagesByID moves the given messages to processing under a new
+// claim token and returns them, skipping messages whose status is not one
+// of from.
+func (r *messageRepository) ClaimMessagesByID(ctx context.Context, ids []int64, from []models.MessageStatus) ([]*models.Message, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		UPDATE messages
+		SET status = $1,
+		    updated_at = $2,
+		    claim_token = $5
+		WHERE id IN (
+			SELECT id
+			FROM messages
+			WHERE id = ANY($3) AND status = ANY($4)
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, phone_number, content, status, message_id, provider, provider_response, error, priority, campaign, created_at, sent_at, delivered_at, updated_at, claim_token
+	`
+
+	statuses := make([]string, len(from))
+	for i, status := range from {
+		statuses[i] = string(status)
+	}
+
+	var messages []*models.Message
+	err := r.db.SelectContext(ctx, &messages, query, models.MessageStatusProcessing, time.Now(), pq.Array(ids), pq.Array(statuses), uuid.NewString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim messages: %w", err)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].ID < messages[j].ID
+	})
+
+	return messages, nil
+}
+
+// ReleaseMessages moves claimed messages that are still processing back to
+// queued and returns the IDs of the released ones.
+func (r *messageRepository) ReleaseMessages(ctx context.Context, ids []int64) ([]int64, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		UPDATE messages
+		SET status = $1,
+		    updated_at = $2
+		WHERE id = ANY($3) AND status = $4
+		RETURNING id
+	`
+
+	var released []int64
+	err := r.db.SelectContext(ctx, &released, query, models.MessageStatusQueued, time.Now(), pq.Array(ids), models.MessageStatusProcessing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release messages: %w", err)
+	}
+
+	sort.Slice(released, func(i, j int) bool { return released[i] < released[j] })
+	return released, nil
+}
+
+// UpdateMessageStatus updates the status of a message. The update only
+// applies when the transition from the current status is allowed and the
+// message still holds claimToken, empty for an unclaimed message;
+// otherwise a *TransitionError or ErrClaimLost is returned.
+func (r *messageRepository) UpdateMessageStatus(ctx context.Context, id int64, claimToken string, status api.MessageStatus, messageID *string, provider *string, errorMsg *string) error {
 	query := `
 		UPDATE messages
 		SET status = $2, 
 		    message_id = $3, 
 		    error = $4, 
 		    sent_at = $5,
-		    updated_at = $6
-		WHERE id = $1
+		    updated_at = $6,
+		    provider = $7
+		WHERE id = $1 AND status = ANY($8) AND claim_token IS NOT DISTINCT FROM $9
 	`
 
 	var sentAt sql.NullTime
@@ -68,6 +186,14 @@ func (r *messageRepository) UpdateMessageStatus(id int64, status api.MessageStat
 		}
 	}
 
+	var providerName sql.NullString
+	if provider != nil {
+		providerName = sql.NullString{
+			String: *provider,
+			Valid:  true,
+		}
+	}
+
 	var errMsg sql.NullString
 	if errorMsg != nil {
 		errMsg = sql.NullString{
@@ -76,25 +202,125 @@ func (r *messageRepository) UpdateMessageStatus(id int64, status api.MessageStat
 		}
 	}
 
-	_, err := r.db.Exec(query, id, status, msgID, errMsg, sentAt, time.Now())
+	sources := statusArray(models.TransitionSources(status))
+	result, err := r.db.ExecContext(ctx, query, id, status, msgID, errMsg, sentAt, time.Now(), providerName, sources, nullString(claimToken))
 	if err != nil {
 		return fmt.Errorf("failed to update message status: %w", err)
 	}
 
-	return nil
+	return r.checkTransition(ctx, result, id, status)
 }
 
-// GetSentMessages retrieves sent messages with pagination.
-func (r *messageRepository) GetSentMessages(offset, limit int) ([]*models.Message, error) {
+// maxStatusUpdates bounds the rows of a single bulk update, keeping it
+// below the parameter limit of Postgres.
+const maxStatusUpdates = 1000
+
+// UpdateMessageStatuses applies many status updates with one statement per
+// thousand updates, all in one transaction, and returns the IDs of the
+// updated messages. Updates of messages that are missing, whose transition
+// is not allowed or that were claimed again since are skipped. Messages moving to sent get the
+// time of their update as sent_at.
+func (r *messageRepository) UpdateMessageStatuses(ctx context.Context, updates []models.StatusUpdate) ([]int64, error) {
+	if len(updates) == 0 {
+		return nil, nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var updated []int64
+	now := time.Now()
+	for start := 0; start < len(updates); start += maxStatusUpdates {
+		end := start + maxStatusUpdates
+		if end > len(updates) {
+			end = len(updates)
+		}
+
+		ids, err := updateStatuses(ctx, tx, updates[start:end], now)
+		if err != nil {
+			return nil, err
+		}
+		updated = append(updated, ids...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit message statuses: %w", err)
+	}
+
+	sort.Slice(updated, func(i, j int) bool { return updated[i] < updated[j] })
+	return updated, nil
+}
+
+// updateStatuses applies updates with a single UPDATE ... FROM (VALUES ...).
+func updateStatuses(ctx context.Context, tx *sqlx.Tx, updates []models.StatusUpdate, now time.Time) ([]int64, error) {
+	const columns = 8
+
+	rows := make([]string, len(updates))
+	args := make([]interface{}, 0, 1+len(updates)*columns)
+	args = append(args, now)
+	for i, u := range updates {
+		n := len(args)
+		rows[i] = fmt.Sprintf("($%d::bigint, $%d::text, $%d::text, $%d::text, $%d::text, $%d::timestamptz, $%d::text[], $%d::text)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8)
+
+		var sentAt sql.NullTime
+		if u.Status == models.MessageStatusSent {
+			sentAt = sql.NullTime{Time: u.At, Valid: true}
+		}
+		args = append(args, u.ID, string(u.Status), nullString(u.ExternalID), nullString(u.Provider),
+			nullString(u.Error), sentAt, statusArray(models.TransitionSources(u.Status)), nullString(u.ClaimToken))
+	}
+
+	query := `
+		UPDATE messages AS m
+		SET status = v.status,
+		    message_id = v.message_id,
+		    provider = v.provider,
+		    error = v.error,
+		    sent_at = v.sent_at,
+		    updated_at = $1
+		FROM (VALUES ` + strings.Join(rows, ", ") + `) AS v(id, status, message_id, provider, error, sent_at, sources, claim_token)
+		WHERE m.id = v.id AND m.status = ANY(v.sources) AND m.claim_token IS NOT DISTINCT FROM v.claim_token
+		RETURNING m.id
+	`
+
+	var ids []int64
+	if err := tx.SelectContext(ctx, &ids, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to update message statuses: %w", err)
+	}
+	return ids, nil
+}
+
+// nullString converts an empty string to NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// sentStatuses are the statuses of messages accepted by a provider.
+var sentStatuses = []models.MessageStatus{
+	models.MessageStatusAcceptedUnconfirmed,
+	models.MessageStatusSent,
+	models.MessageStatusDelivered,
+	models.MessageStatusUndelivered,
+}
+
+// GetSentMessages retrieves sent messages with pagination, including the
+// ones that already have a delivery receipt or await confirmation.
+func (r *messageRepository) GetSentMessages(ctx context.Context, offset, limit int) ([]*models.Message, error) {
 	query := `
-		SELECT id, phone_number, content, status, message_id, error, created_at, sent_at, updated_at
+		SELECT id, phone_number, content, status, message_id, provider, provider_response, error, priority, campaign, created_at, sent_at, delivered_at, updated_at
 		FROM messages
-		WHERE status = $1
+		WHERE status = ANY($1)
 		ORDER BY sent_at DESC
 		LIMIT $2 OFFSET $3	`
 
 	var messages []*models.Message
-	err := r.db.Select(&messages, query, models.MessageStatusSent, limit, offset)
+	err := r.db.SelectContext(ctx, &messages, query, statusArray(sentStatuses), limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sent messages: %w", err)
 	}
@@ -102,12 +328,13 @@ func (r *messageRepository) GetSentMessages(offset, limit int) ([]*models.Messag
 	return messages, nil
 }
 
-// GetTotalSentCount returns the total count of sent messages.
-func (r *messageRepository) GetTotalSentCount() (int64, error) {
+// GetTotalSentCount returns the total count of sent messages, including the
+// ones that already have a delivery receipt or await confirmation.
+func (r *messageRepository) GetTotalSentCount(ctx context.Context) (int64, error) {
 	var count int64
-	query := `SELECT COUNT(*) FROM messages WHERE status = $1`
+	query := `SELECT COUNT(*) FROM messages WHERE status = ANY($1)`
 
-	err := r.db.Get(&count, query, models.MessageStatusSent)
+	err := r.db.GetContext(ctx, &count, query, statusArray(sentStatuses))
 	if err != nil {
 		return 0, fmt.Errorf("failed to get total sent count: %w", err)
 	}
@@ -115,14 +342,268 @@ func (r *messageRepository) GetTotalSentCount() (int64, error) {
 	return count, nil
 }
 
+// GetQueuedCount returns the number of messages waiting to be sent.
+func (r *messageRepository) GetQueuedCount(ctx context.Context) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*) FROM messages WHERE status = $1`
+
+	err := r.db.GetContext(ctx, &count, query, models.MessageStatusQueued)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queued count: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetMessage retrieves a message by ID.
+func (r *messageRepository) GetMessage(ctx context.Context, id int64) (*models.Message, error) {
+	query := `
+		SELECT id, phone_number, content, status, message_id, provider, provider_response, error, priority, campaign, created_at, sent_at, delivered_at, updated_at
+		FROM messages
+		WHERE id = $1
+	`
+
+	var msg models.Message
+	err := r.db.GetContext(ctx, &msg, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	return &msg, nil
+}
+
+// GetMessageByExternalID retrieves a message by the ID assigned by its provider.
+func (r *messageRepository) GetMessageByExternalID(ctx context.Context, messageID string) (*models.Message, error) {
+	query := `
+		SELECT id, phone_number, content, status, message_id, provider, provider_response, error, priority, campaign, created_at, sent_at, delivered_at, updated_at
+		FROM messages
+		WHERE message_id = $1
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var msg models.Message
+	err := r.db.GetContext(ctx, &msg, query, messageID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, fmt.Errorf("failed to get message by external id: %w", err)
+	}
+
+	return &msg, nil
+}
+
+// UpdateDeliveryStatus records the delivery receipt of a sent message.
+// Repeating the current receipt status is a no-op that keeps the original
+// receipt time.
+func (r *messageRepository) UpdateDeliveryStatus(ctx context.Context, id int64, status models.MessageStatus, deliveredAt time.Time, errorMsg *string) error {
+	query := `
+		UPDATE messages
+		SET status = $2,
+		    delivered_at = COALESCE(delivered_at, $3),
+		    error = COALESCE($4, error),
+		    updated_at = $5
+		WHERE id = $1 AND (status = ANY($6) OR status = $2)
+	`
+
+	var errMsg sql.NullString
+	if errorMsg != nil {
+		errMsg = sql.NullString{
+			String: *errorMsg,
+			Valid:  true,
+		}
+	}
+
+	sources := statusArray(models.TransitionSources(status))
+	result, err := r.db.ExecContext(ctx, query, id, status, deliveredAt, errMsg, time.Now(), sources)
+	if err != nil {
+		return fmt.Errorf("failed to update delivery status: %w", err)
+	}
+
+	return r.checkTransition(ctx, result, id, status)
+}
+
+// MarkAcceptedUnconfirmed records a message the provider accepted without
+// returning a usable message ID, keeping the raw response for
+// reconciliation. Like UpdateMessageStatus it requires the message to still
+// hold claimToken.
+func (r *messageRepository) MarkAcceptedUnconfirmed(ctx context.Context, id int64, claimToken string, provider string, response string) error {
+	query := `
+		UPDATE messages
+		SET status = $2,
+		    provider = $3,
+		    provider_response = $4,
+		    sent_at = $5,
+		    updated_at = $5
+		WHERE id = $1 AND status = ANY($6) AND claim_token IS NOT DISTINCT FROM $7
+	`
+
+	status := models.MessageStatusAcceptedUnconfirmed
+	sources := statusArray(models.TransitionSources(status))
+	result, err := r.db.ExecContext(ctx, query, id, status, provider, response, time.Now(), sources, nullString(claimToken))
+	if err != nil {
+		return fmt.Errorf("failed to mark message unconfirmed: %w", err)
+	}
+
+	return r.checkTransition(ctx, result, id, status)
+}
+
+// GetUnconfirmedMessages returns up to limit accepted but unconfirmed
+// messages, least recently checked first.
+func (r *messageRepository) GetUnconfirmedMessages(ctx context.Context, limit int) ([]*models.Message, error) {
+	query := `
+		SELECT id, phone_number, content, status, message_id, provider, provider_response, error, priority, campaign, created_at, sent_at, delivered_at, updated_at
+		FROM messages
+		WHERE status = $1
+		ORDER BY updated_at ASC, id ASC
+		LIMIT $2
+	`
+
+	var messages []*models.Message
+	err := r.db.SelectContext(ctx, &messages, query, models.MessageStatusAcceptedUnconfirmed, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unconfirmed messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// ResolveUnconfirmed applies the status a provider reported for an
+// unconfirmed message. An empty status only marks the message as checked so
+// other messages are looked at first in the next round.
+func (r *messageRepository) ResolveUnconfirmed(ctx context.Context, id int64, externalID string, status models.MessageStatus, errorMsg *string) error {
+	if status == "" {
+		_, err := r.db.ExecContext(ctx, `UPDATE messages SET updated_at = $2 WHERE id = $1 AND status = $3`,
+			id, time.Now(), models.MessageStatusAcceptedUnconfirmed)
+		if err != nil {
+			return fmt.Errorf("failed to update unconfirmed message: %w", err)
+		}
+		return nil
+	}
+
+	if !models.CanTransition(models.MessageStatusAcceptedUnconfirmed, status) {
+		return &TransitionError{MessageID: id, From: models.MessageStatusAcceptedUnconfirmed, To: status}
+	}
+
+	query := `
+		UPDATE messages
+		SET status = $2,
+		    message_id = COALESCE($3, message_id),
+		    error = COALESCE($4, error),
+		    delivered_at = CASE WHEN $2 IN ($5, $6) THEN $7 ELSE delivered_at END,
+		    updated_at = $7
+		WHERE id = $1 AND status = $8
+	`
+
+	var msgID sql.NullString
+	if externalID != "" {
+		msgID = sql.NullString{String: externalID, Valid: true}
+	}
+
+	var errMsg sql.NullString
+	if errorMsg != nil {
+		errMsg = sql.NullString{String: *errorMsg, Valid: true}
+	}
+
+	result, err := r.db.ExecContext(ctx, query, id, status, msgID, errMsg,
+		models.MessageStatusDelivered, models.MessageStatusUndelivered, time.Now(),
+		models.MessageStatusAcceptedUnconfirmed)
+	if err != nil {
+		return fmt.Errorf("failed to resolve unconfirmed message: %w", err)
+	}
+
+	return r.checkTransition(ctx, result, id, status)
+}
+
+// GetStatusHistory returns the status changes of a message, oldest first.
+func (r *messageRepository) GetStatusHistory(ctx context.Context, id int64) ([]*models.StatusChange, error) {
+	query := `
+		SELECT id, message_id, from_status, to_status, error, changed_at
+		FROM message_status_history
+		WHERE message_id = $1
+		ORDER BY changed_at ASC, id ASC
+	`
+
+	var history []*models.StatusChange
+	err := r.db.SelectContext(ctx, &history, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+
+	// Every message has at least the entry of its initial status.
+	if len(history) == 0 {
+		return nil, ErrMessageNotFound
+	}
+
+	return history, nil
+}
+
+// checkTransition turns a conditional status update that matched no rows
+// into ErrMessageNotFound, a *TransitionError, or ErrClaimLost when the
+// transition is allowed but the message was claimed again.
+func (r *messageRepository) checkTransition(ctx context.Context, result sql.Result, id int64, status models.MessageStatus) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update message status: %w", err)
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var current models.MessageStatus
+	err = r.db.GetContext(ctx, &current, `SELECT status FROM messages WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrMessageNotFound
+		}
+		return fmt.Errorf("failed to get message status: %w", err)
+	}
+	if models.CanTransition(current, status) {
+		return ErrClaimLost
+	}
+
+	return &TransitionError{MessageID: id, From: current, To: status}
+}
+
+// statusArray converts statuses to a Postgres text array parameter.
+func statusArray(statuses []models.MessageStatus) pq.StringArray {
+	arr := make(pq.StringArray, len(statuses))
+	for i, status := range statuses {
+		arr[i] = string(status)
+	}
+	return arr
+}
+
+// InsertMessage stores a queued message and sets its ID, status and
+// timestamps.
+func (r *messageRepository) InsertMessage(ctx context.Context, msg *models.Message) error {
+	query := `
+		INSERT INTO messages (phone_number, content, status, priority, campaign, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING id, status, created_at, updated_at
+	`
+
+	err := r.db.QueryRowxContext(ctx, query, msg.PhoneNumber, msg.Content, models.MessageStatusQueued, msg.Priority, msg.Campaign, time.Now()).
+		Scan(&msg.ID, &msg.Status, &msg.CreatedAt, &msg.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert message: %w", err)
+	}
+
+	return nil
+}
+
 // CreateMessage creates a new message in the database.
-func (r *messageRepository) CreateMessage(phoneNumber, content string) error {
+func (r *messageRepository) CreateMessage(ctx context.Context, phoneNumber, content string) error {
 	query := `
 		INSERT INTO messages (phone_number, content, status, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5)	`
 
 	now := time.Now()
-	_, err := r.db.Exec(query, phoneNumber, content, models.MessageStatusPending, now, now)
+	_, err := r.db.ExecContext(ctx, query, phoneNumber, content, models.MessageStatusQueued, now, now)
 	if err != nil {
 		return fmt.Errorf("failed to create message: %w", err)
 	}
@@ -1,6 +1,8 @@
 package repository_test
 
 import (
+	"context"
+	"database/sql"
 	"strings"
 	"testing"
 	"time"
@@ -35,7 +37,7 @@ func TestMessageRepository_GetSentMessages_Success(t *testing.T) {
 				if err != nil {
 					return err
 				}
-				err = insertBulkTestMessages(db.DB, 3, "+0987654321", "Pending message", string(models.MessageStatusPending), nil, 0)
+				err = insertBulkTestMessages(db.DB, 3, "+0987654321", "Pending message", string(models.MessageStatusQueued), nil, 0)
 				if err != nil {
 					return err
 				}
@@ -77,7 +79,7 @@ func TestMessageRepository_GetSentMessages_Success(t *testing.T) {
 		{
 			name: "Get sent messages - empty result",
 			setupData: func() error {
-				_, err := insertTestMessage(db.DB, "+3334445555", "Pending", string(models.MessageStatusPending), nil)
+				_, err := insertTestMessage(db.DB, "+3334445555", "Pending", string(models.MessageStatusQueued), nil)
 				if err != nil {
 					return err
 				}
@@ -172,7 +174,7 @@ func TestMessageRepository_GetSentMessages_Success(t *testing.T) {
 			err := tt.setupData()
 			require.NoError(t, err)
 
-			messages, err := repo.GetSentMessages(tt.offset, tt.limit)
+			messages, err := repo.GetSentMessages(context.Background(), tt.offset, tt.limit)
 
 			assert.NoError(t, err)
 			assert.Len(t, messages, tt.expectedCount)
@@ -229,7 +231,7 @@ func TestMessageRepository_GetSentMessages_Failure(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := tt.setupRepo()
-			messages, err := repo.GetSentMessages(tt.offset, tt.limit)
+			messages, err := repo.GetSentMessages(context.Background(), tt.offset, tt.limit)
 
 			if tt.name == "Zero limit returns empty result" {
 				assert.NoError(t, err)
@@ -264,7 +266,7 @@ func TestMessageRepository_GetUnsentMessages_Success(t *testing.T) {
 		{
 			name: "Get unsent messages with limit",
 			setupData: func() error {
-				err := insertBulkTestMessages(db.DB, 5, "+1234567890", "Pending message", string(models.MessageStatusPending), nil, 0)
+				err := insertBulkTestMessages(db.DB, 5, "+1234567890", "Pending message", string(models.MessageStatusQueued), nil, 0)
 				if err != nil {
 					return err
 				}
@@ -290,7 +292,7 @@ func TestMessageRepository_GetUnsentMessages_Success(t *testing.T) {
 			expectedCount: 3,
 			validateResult: func(t *testing.T, messages []*models.Message) {
 				for _, msg := range messages {
-					assert.Equal(t, models.MessageStatusPending, msg.Status)
+					assert.Equal(t, models.MessageStatusQueued, msg.Status)
 					assert.False(t, msg.SentAt.Valid, "SentAt should be null for pending messages")
 					assert.False(t, msg.MessageID.Valid, "MessageID should be null for pending messages")
 					assert.False(t, msg.Error.Valid, "Error should be null for pending messages")
@@ -306,14 +308,14 @@ func TestMessageRepository_GetUnsentMessages_Success(t *testing.T) {
 		{
 			name: "Get all unsent messages when limit exceeds available",
 			setupData: func() error {
-				return insertBulkTestMessages(db.DB, 3, "+2234567890", "Pending msg", string(models.MessageStatusPending), nil, 0)
+				return insertBulkTestMessages(db.DB, 3, "+2234567890", "Pending msg", string(models.MessageStatusQueued), nil, 0)
 			},
 			limit:         10,
 			expectedCount: 3,
 			validateResult: func(t *testing.T, messages []*models.Message) {
 				assert.Len(t, messages, 3)
 				for _, msg := range messages {
-					assert.Equal(t, models.MessageStatusPending, msg.Status)
+					assert.Equal(t, models.MessageStatusQueued, msg.Status)
 				}
 			},
 		},
@@ -355,7 +357,7 @@ func TestMessageRepository_GetUnsentMessages_Success(t *testing.T) {
 				_, err := insertTestMessage(db.DB,
 					"+9998887777",
 					"Test pending message with all fields",
-					string(models.MessageStatusPending),
+					string(models.MessageStatusQueued),
 					nil)
 				return err
 			},
@@ -368,7 +370,7 @@ func TestMessageRepository_GetUnsentMessages_Success(t *testing.T) {
 				assert.NotZero(t, msg.ID)
 				assert.Equal(t, "+9998887777", msg.PhoneNumber)
 				assert.Equal(t, "Test pending message with all fields", msg.Content)
-				assert.Equal(t, models.MessageStatusPending, msg.Status)
+				assert.Equal(t, models.MessageStatusQueued, msg.Status)
 				assert.False(t, msg.MessageID.Valid)
 				assert.False(t, msg.Error.Valid)
 				assert.False(t, msg.SentAt.Valid)
@@ -379,7 +381,7 @@ func TestMessageRepository_GetUnsentMessages_Success(t *testing.T) {
 		{
 			name: "Get unsent messages with exact limit",
 			setupData: func() error {
-				return insertBulkTestMessages(db.DB, 5, "+5556667777", "Message", string(models.MessageStatusPending), nil, 0)
+				return insertBulkTestMessages(db.DB, 5, "+5556667777", "Message", string(models.MessageStatusQueued), nil, 0)
 			},
 			limit:         5,
 			expectedCount: 5,
@@ -412,7 +414,7 @@ func TestMessageRepository_GetUnsentMessages_Success(t *testing.T) {
 					_, err := db.DB.Exec(query,
 						"+7778889999"+string(rune('0'+i)),
 						"Message created at "+createdAt.Format("15:04:05"),
-						string(models.MessageStatusPending),
+						string(models.MessageStatusQueued),
 						createdAt,
 						createdAt)
 					if err != nil {
@@ -443,7 +445,7 @@ func TestMessageRepository_GetUnsentMessages_Success(t *testing.T) {
 			err := tt.setupData()
 			require.NoError(t, err)
 
-			messages, err := repo.GetUnsentMessages(tt.limit)
+			messages, err := repo.GetUnsentMessages(context.Background(), tt.limit)
 
 			assert.NoError(t, err)
 			assert.Len(t, messages, tt.expectedCount)
@@ -483,7 +485,7 @@ func TestMessageRepository_GetUnsentMessages_Failure(t *testing.T) {
 			setupRepo: func() repository.MessageRepository {
 				db, _ := setupTestDB(t)
 				cleanupTestData(db)
-				_, err := insertTestMessage(db.DB, "+1234567890", "Test message", string(models.MessageStatusPending), nil)
+				_, err := insertTestMessage(db.DB, "+1234567890", "Test message", string(models.MessageStatusQueued), nil)
 				require.NoError(t, err)
 				return repository.NewMessageRepository(db)
 			},
@@ -495,7 +497,7 @@ func TestMessageRepository_GetUnsentMessages_Failure(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := tt.setupRepo()
-			messages, err := repo.GetUnsentMessages(tt.limit)
+			messages, err := repo.GetUnsentMessages(context.Background(), tt.limit)
 
 			if tt.name == "Zero limit returns empty result" {
 				assert.NoError(t, err)
@@ -525,16 +527,18 @@ func TestMessageRepository_UpdateMessageStatus_Success(t *testing.T) {
 		setupData      func() (int64, error)
 		status         models.MessageStatus
 		messageID      *string
+		provider       *string
 		errorMsg       *string
 		validateResult func(t *testing.T, messageID int64)
 	}{
 		{
-			name: "Update pending message to sent with message_id",
+			name: "Update processing message to sent with message_id",
 			setupData: func() (int64, error) {
-				return insertTestMessage(db.DB, "+1234567890", "Test message", string(models.MessageStatusPending), nil)
+				return insertTestMessage(db.DB, "+1234567890", "Test message", string(models.MessageStatusProcessing), nil)
 			},
 			status:    models.MessageStatusSent,
 			messageID: ptr("webhook_msg_123"),
+			provider:  ptr("primary"),
 			errorMsg:  nil,
 			validateResult: func(t *testing.T, messageID int64) {
 				var msg models.Message
@@ -544,15 +548,17 @@ func TestMessageRepository_UpdateMessageStatus_Success(t *testing.T) {
 				assert.Equal(t, models.MessageStatusSent, msg.Status)
 				assert.True(t, msg.MessageID.Valid)
 				assert.Equal(t, "webhook_msg_123", msg.MessageID.String)
+				assert.True(t, msg.Provider.Valid)
+				assert.Equal(t, "primary", msg.Provider.String)
 				assert.False(t, msg.Error.Valid)
 				assert.True(t, msg.SentAt.Valid)
 				assert.False(t, msg.SentAt.Time.IsZero())
 			},
 		},
 		{
-			name: "Update pending message to failed with error",
+			name: "Update processing message to failed with error",
 			setupData: func() (int64, error) {
-				return insertTestMessage(db.DB, "+0987654321", "Failed message", string(models.MessageStatusPending), nil)
+				return insertTestMessage(db.DB, "+0987654321", "Failed message", string(models.MessageStatusProcessing), nil)
 			},
 			status:    models.MessageStatusFailed,
 			messageID: nil,
@@ -569,32 +575,10 @@ func TestMessageRepository_UpdateMessageStatus_Success(t *testing.T) {
 				assert.False(t, msg.SentAt.Valid)
 			},
 		},
-		{
-			name: "Update sent message to failed",
-			setupData: func() (int64, error) {
-				sentAt := time.Now()
-				messageID := "original_msg_id"
-				return insertTestMessageWithDetails(db.DB, "+1111111111", "Retry message", string(models.MessageStatusSent), &messageID, nil, &sentAt)
-			},
-			status:    models.MessageStatusFailed,
-			messageID: nil,
-			errorMsg:  ptr("Webhook error: 503"),
-			validateResult: func(t *testing.T, messageID int64) {
-				var msg models.Message
-				err := db.Get(&msg, "SELECT * FROM messages WHERE id = $1", messageID)
-				require.NoError(t, err)
-
-				assert.Equal(t, models.MessageStatusFailed, msg.Status)
-				assert.False(t, msg.MessageID.Valid)
-				assert.True(t, msg.Error.Valid)
-				assert.Equal(t, "Webhook error: 503", msg.Error.String)
-				assert.False(t, msg.SentAt.Valid)
-			},
-		},
 		{
 			name: "Update message status without changing message_id or error",
 			setupData: func() (int64, error) {
-				return insertTestMessage(db.DB, "+2223334444", "Status change only", string(models.MessageStatusPending), nil)
+				return insertTestMessage(db.DB, "+2223334444", "Status change only", string(models.MessageStatusProcessing), nil)
 			},
 			status:    models.MessageStatusSent,
 			messageID: nil,
@@ -610,27 +594,6 @@ func TestMessageRepository_UpdateMessageStatus_Success(t *testing.T) {
 				assert.True(t, msg.SentAt.Valid)
 			},
 		},
-		{
-			name: "Update failed message to sent clears error",
-			setupData: func() (int64, error) {
-				errorMsg := "Previous error"
-				return insertTestMessageWithDetails(db.DB, "+5556667777", "Retry success", string(models.MessageStatusFailed), nil, &errorMsg, nil)
-			},
-			status:    models.MessageStatusSent,
-			messageID: ptr("new_msg_id"),
-			errorMsg:  nil,
-			validateResult: func(t *testing.T, messageID int64) {
-				var msg models.Message
-				err := db.Get(&msg, "SELECT * FROM messages WHERE id = $1", messageID)
-				require.NoError(t, err)
-
-				assert.Equal(t, models.MessageStatusSent, msg.Status)
-				assert.True(t, msg.MessageID.Valid)
-				assert.Equal(t, "new_msg_id", msg.MessageID.String)
-				assert.False(t, msg.Error.Valid)
-				assert.True(t, msg.SentAt.Valid)
-			},
-		},
 	}
 
 	for _, tt := range tests {
@@ -640,7 +603,7 @@ func TestMessageRepository_UpdateMessageStatus_Success(t *testing.T) {
 			messageID, err := tt.setupData()
 			require.NoError(t, err)
 
-			err = repo.UpdateMessageStatus(messageID, tt.status, tt.messageID, tt.errorMsg)
+			err = repo.UpdateMessageStatus(context.Background(), messageID, "", tt.status, tt.messageID, tt.provider, tt.errorMsg)
 			assert.NoError(t, err)
 
 			tt.validateResult(t, messageID)
@@ -654,6 +617,7 @@ func TestMessageRepository_UpdateMessageStatus_Failure(t *testing.T) {
 		setupRepo     func() (repository.MessageRepository, int64)
 		status        models.MessageStatus
 		messageID     *string
+		provider      *string
 		errorMsg      *string
 		expectedError string
 	}{
@@ -666,13 +630,47 @@ func TestMessageRepository_UpdateMessageStatus_Failure(t *testing.T) {
 			status:        models.MessageStatusSent,
 			messageID:     ptr("msg_123"),
 			errorMsg:      nil,
-			expectedError: "",
+			expectedError: "message not found",
+		},
+		{
+			name: "Sent message cannot fail",
+			setupRepo: func() (repository.MessageRepository, int64) {
+				db, _ := setupTestDB(t)
+				sentAt := time.Now()
+				messageID, _ := insertTestMessageWithDetails(db.DB, "+1111111111", "Retry message", string(models.MessageStatusSent), ptr("original_msg_id"), nil, &sentAt)
+				return repository.NewMessageRepository(db), messageID
+			},
+			status:        models.MessageStatusFailed,
+			errorMsg:      ptr("Webhook error: 503"),
+			expectedError: "cannot move from sent to failed",
+		},
+		{
+			name: "Failed message cannot be sent",
+			setupRepo: func() (repository.MessageRepository, int64) {
+				db, _ := setupTestDB(t)
+				messageID, _ := insertTestMessageWithDetails(db.DB, "+5556667777", "Retry success", string(models.MessageStatusFailed), nil, ptr("Previous error"), nil)
+				return repository.NewMessageRepository(db), messageID
+			},
+			status:        models.MessageStatusSent,
+			messageID:     ptr("new_msg_id"),
+			expectedError: "cannot move from failed to sent",
+		},
+		{
+			name: "Queued message must be claimed before it is sent",
+			setupRepo: func() (repository.MessageRepository, int64) {
+				db, _ := setupTestDB(t)
+				messageID, _ := insertTestMessage(db.DB, "+1234567890", "Test", string(models.MessageStatusQueued), nil)
+				return repository.NewMessageRepository(db), messageID
+			},
+			status:        models.MessageStatusSent,
+			messageID:     ptr("msg_123"),
+			expectedError: "cannot move from queued to sent",
 		},
 		{
 			name: "Database connection closed",
 			setupRepo: func() (repository.MessageRepository, int64) {
 				db, cleanup := setupTestDB(t)
-				messageID, _ := insertTestMessage(db.DB, "+1234567890", "Test", string(models.MessageStatusPending), nil)
+				messageID, _ := insertTestMessage(db.DB, "+1234567890", "Test", string(models.MessageStatusQueued), nil)
 				cleanup()
 				return repository.NewMessageRepository(db), messageID
 			},
@@ -685,13 +683,13 @@ func TestMessageRepository_UpdateMessageStatus_Failure(t *testing.T) {
 			name: "Invalid status value",
 			setupRepo: func() (repository.MessageRepository, int64) {
 				db, _ := setupTestDB(t)
-				messageID, _ := insertTestMessage(db.DB, "+1234567890", "Test", string(models.MessageStatusPending), nil)
+				messageID, _ := insertTestMessage(db.DB, "+1234567890", "Test", string(models.MessageStatusQueued), nil)
 				return repository.NewMessageRepository(db), messageID
 			},
 			status:        "invalid_status",
 			messageID:     nil,
 			errorMsg:      nil,
-			expectedError: "new row for relation \"messages\" violates check constraint",
+			expectedError: "invalid message status transition",
 		},
 	}
 
@@ -699,16 +697,10 @@ func TestMessageRepository_UpdateMessageStatus_Failure(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo, messageID := tt.setupRepo()
 
-			err := repo.UpdateMessageStatus(messageID, tt.status, tt.messageID, tt.errorMsg)
+			err := repo.UpdateMessageStatus(context.Background(), messageID, "", tt.status, tt.messageID, tt.provider, tt.errorMsg)
 
-			if tt.expectedError != "" {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tt.expectedError)
-			} else {
-				if tt.name == "Update non-existent message" {
-					assert.NoError(t, err)
-				}
-			}
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
 		})
 	}
 }
@@ -727,7 +719,7 @@ func TestMessageRepository_GetTotalSentCount_Success(t *testing.T) {
 		{
 			name: "Count with no sent messages",
 			setupData: func() error {
-				err := insertBulkTestMessages(db.DB, 3, "+1234567890", "Pending", string(models.MessageStatusPending), nil, 0)
+				err := insertBulkTestMessages(db.DB, 3, "+1234567890", "Pending", string(models.MessageStatusQueued), nil, 0)
 				if err != nil {
 					return err
 				}
@@ -751,7 +743,7 @@ func TestMessageRepository_GetTotalSentCount_Success(t *testing.T) {
 				if err != nil {
 					return err
 				}
-				err = insertBulkTestMessages(db.DB, 5, "+2222222222", "Pending", string(models.MessageStatusPending), nil, 0)
+				err = insertBulkTestMessages(db.DB, 5, "+2222222222", "Pending", string(models.MessageStatusQueued), nil, 0)
 				if err != nil {
 					return err
 				}
@@ -781,7 +773,55 @@ func TestMessageRepository_GetTotalSentCount_Success(t *testing.T) {
 			err := tt.setupData()
 			require.NoError(t, err)
 
-			count, err := repo.GetTotalSentCount()
+			count, err := repo.GetTotalSentCount(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCount, count)
+		})
+	}
+}
+
+func TestMessageRepository_GetQueuedCount(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewMessageRepository(db)
+
+	tests := []struct {
+		name          string
+		setupData     func() error
+		expectedCount int64
+	}{
+		{
+			name: "Count with mixed statuses",
+			setupData: func() error {
+				now := time.Now()
+				err := insertBulkTestMessages(db.DB, 4, "+1111111111", "Sent", string(models.MessageStatusSent), &now, time.Minute)
+				if err != nil {
+					return err
+				}
+				err = insertBulkTestMessages(db.DB, 2, "+2222222222", "Processing", string(models.MessageStatusProcessing), nil, 0)
+				if err != nil {
+					return err
+				}
+				return insertBulkTestMessages(db.DB, 7, "+3333333333", "Pending", string(models.MessageStatusQueued), nil, 0)
+			},
+			expectedCount: 7,
+		},
+		{
+			name:          "Count with empty table",
+			setupData:     func() error { return nil },
+			expectedCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleanupTestData(db)
+
+			err := tt.setupData()
+			require.NoError(t, err)
+
+			count, err := repo.GetQueuedCount(context.Background())
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expectedCount, count)
 		})
@@ -809,7 +849,7 @@ func TestMessageRepository_GetTotalSentCount_Failure(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := tt.setupRepo()
 
-			count, err := repo.GetTotalSentCount()
+			count, err := repo.GetTotalSentCount(context.Background())
 
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), tt.expectedError)
@@ -841,7 +881,7 @@ func TestMessageRepository_CreateMessage_Success(t *testing.T) {
 
 				assert.Equal(t, "+1234567890", msg.PhoneNumber)
 				assert.Equal(t, "Hello, this is a test message", msg.Content)
-				assert.Equal(t, models.MessageStatusPending, msg.Status)
+				assert.Equal(t, models.MessageStatusQueued, msg.Status)
 				assert.False(t, msg.MessageID.Valid)
 				assert.False(t, msg.Error.Valid)
 				assert.False(t, msg.SentAt.Valid)
@@ -860,7 +900,7 @@ func TestMessageRepository_CreateMessage_Success(t *testing.T) {
 
 				assert.Equal(t, "+44-789-012-3456", msg.PhoneNumber)
 				assert.Equal(t, "Special chars: @#$%^&*()_+-=[]{}|;':\",./<>?", msg.Content)
-				assert.Equal(t, models.MessageStatusPending, msg.Status)
+				assert.Equal(t, models.MessageStatusQueued, msg.Status)
 			},
 		},
 		{
@@ -891,10 +931,10 @@ func TestMessageRepository_CreateMessage_Success(t *testing.T) {
 			phoneNumber: "+1111111111",
 			content:     "Message 1",
 			validate: func(t *testing.T) {
-				err := repo.CreateMessage("+1111111111", "Message 2")
+				err := repo.CreateMessage(context.Background(), "+1111111111", "Message 2")
 				require.NoError(t, err)
 
-				err = repo.CreateMessage("+1111111111", "Message 3")
+				err = repo.CreateMessage(context.Background(), "+1111111111", "Message 3")
 				require.NoError(t, err)
 
 				var count int
@@ -909,7 +949,7 @@ func TestMessageRepository_CreateMessage_Success(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			cleanupTestData(db)
 
-			err := repo.CreateMessage(tt.phoneNumber, tt.content)
+			err := repo.CreateMessage(context.Background(), tt.phoneNumber, tt.content)
 			assert.NoError(t, err)
 
 			tt.validate(t)
@@ -954,10 +994,412 @@ func TestMessageRepository_CreateMessage_Failure(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := tt.setupRepo()
 
-			err := repo.CreateMessage(tt.phoneNumber, tt.content)
+			err := repo.CreateMessage(context.Background(), tt.phoneNumber, tt.content)
 
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), tt.expectedError)
 		})
 	}
 }
+
+func TestMessageRepository_GetMessageByExternalID(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewMessageRepository(db)
+
+	sentAt := time.Now()
+	id, err := insertTestMessageWithDetails(db.DB, "+1234567890", "Sent", string(models.MessageStatusSent), ptr("ext-123"), nil, &sentAt)
+	require.NoError(t, err)
+
+	msg, err := repo.GetMessageByExternalID(context.Background(), "ext-123")
+	require.NoError(t, err)
+	assert.Equal(t, id, msg.ID)
+	assert.Equal(t, "ext-123", msg.MessageID.String)
+
+	_, err = repo.GetMessageByExternalID(context.Background(), "missing")
+	assert.ErrorIs(t, err, repository.ErrMessageNotFound)
+}
+
+func TestMessageRepository_UpdateDeliveryStatus(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewMessageRepository(db)
+
+	deliveredAt := time.Now().Add(-time.Minute).Truncate(time.Microsecond)
+
+	tests := []struct {
+		name          string
+		initialStatus models.MessageStatus
+		status        models.MessageStatus
+		errorMsg      *string
+		expectedError error
+	}{
+		{
+			name:          "Sent message is delivered",
+			initialStatus: models.MessageStatusSent,
+			status:        models.MessageStatusDelivered,
+		},
+		{
+			name:          "Sent message is undelivered with reason",
+			initialStatus: models.MessageStatusSent,
+			status:        models.MessageStatusUndelivered,
+			errorMsg:      ptr("handset unreachable"),
+		},
+		{
+			name:          "Repeated receipt is accepted",
+			initialStatus: models.MessageStatusDelivered,
+			status:        models.MessageStatusDelivered,
+		},
+		{
+			name:          "Receipt for a queued message is rejected",
+			initialStatus: models.MessageStatusQueued,
+			status:        models.MessageStatusDelivered,
+			expectedError: repository.ErrInvalidStatusTransition,
+		},
+		{
+			name:          "Conflicting receipt is rejected",
+			initialStatus: models.MessageStatusDelivered,
+			status:        models.MessageStatusUndelivered,
+			expectedError: repository.ErrInvalidStatusTransition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleanupTestData(db)
+
+			id, err := insertTestMessage(db.DB, "+1234567890", "Test", string(tt.initialStatus), nil)
+			require.NoError(t, err)
+
+			err = repo.UpdateDeliveryStatus(context.Background(), id, tt.status, deliveredAt, tt.errorMsg)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			var msg models.Message
+			err = db.Get(&msg, "SELECT * FROM messages WHERE id = $1", id)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.status, msg.Status)
+			assert.True(t, msg.DeliveredAt.Valid)
+			assert.True(t, deliveredAt.Equal(msg.DeliveredAt.Time))
+			if tt.errorMsg != nil {
+				assert.Equal(t, *tt.errorMsg, msg.Error.String)
+			}
+		})
+	}
+
+	err := repo.UpdateDeliveryStatus(context.Background(), 99999, models.MessageStatusDelivered, deliveredAt, nil)
+	assert.ErrorIs(t, err, repository.ErrMessageNotFound)
+}
+
+func TestMessageRepository_UnconfirmedMessages(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewMessageRepository(db)
+
+	id, err := insertTestMessage(db.DB, "+1234567890", "Test", string(models.MessageStatusProcessing), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.MarkAcceptedUnconfirmed(context.Background(), id, "", "webhook", "OK"))
+
+	unconfirmed, err := repo.GetUnconfirmedMessages(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, unconfirmed, 1)
+	assert.Equal(t, id, unconfirmed[0].ID)
+	assert.Equal(t, models.MessageStatusAcceptedUnconfirmed, unconfirmed[0].Status)
+	assert.Equal(t, "webhook", unconfirmed[0].Provider.String)
+	assert.Equal(t, "OK", unconfirmed[0].ProviderResponse.String)
+	assert.True(t, unconfirmed[0].SentAt.Valid)
+
+	// A pending lookup leaves the status untouched
+	require.NoError(t, repo.ResolveUnconfirmed(context.Background(), id, "", "", nil))
+	msg, err := repo.GetMessage(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, models.MessageStatusAcceptedUnconfirmed, msg.Status)
+
+	err = repo.ResolveUnconfirmed(context.Background(), id, "ext-1", models.MessageStatusQueued, nil)
+	assert.ErrorIs(t, err, repository.ErrInvalidStatusTransition)
+
+	require.NoError(t, repo.ResolveUnconfirmed(context.Background(), id, "ext-1", models.MessageStatusDelivered, nil))
+	msg, err = repo.GetMessage(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, models.MessageStatusDelivered, msg.Status)
+	assert.Equal(t, "ext-1", msg.MessageID.String)
+	assert.True(t, msg.DeliveredAt.Valid)
+
+	err = repo.ResolveUnconfirmed(context.Background(), id, "ext-1", models.MessageStatusSent, nil)
+	assert.ErrorIs(t, err, repository.ErrInvalidStatusTransition)
+
+	err = repo.MarkAcceptedUnconfirmed(context.Background(), 99999, "", "webhook", "OK")
+	assert.ErrorIs(t, err, repository.ErrMessageNotFound)
+}
+
+func TestMessageRepository_ClaimMessages(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewMessageRepository(db)
+
+	err := insertBulkTestMessages(db.DB, 5, "+1234567890", "Queued", string(models.MessageStatusQueued), nil, 0)
+	require.NoError(t, err)
+	_, err = insertTestMessage(db.DB, "+1234567890", "Sent", string(models.MessageStatusSent), nil)
+	require.NoError(t, err)
+
+	first, err := repo.ClaimMessages(context.Background(), 3, time.Minute)
+	require.NoError(t, err)
+	assert.Len(t, first, 3)
+	for _, msg := range first {
+		assert.Equal(t, models.MessageStatusProcessing, msg.Status)
+	}
+
+	second, err := repo.ClaimMessages(context.Background(), 3, time.Minute)
+	require.NoError(t, err)
+	assert.Len(t, second, 2)
+
+	claimed := make(map[int64]bool)
+	for _, msg := range append(first, second...) {
+		assert.False(t, claimed[msg.ID], "message %d claimed twice", msg.ID)
+		claimed[msg.ID] = true
+	}
+
+	third, err := repo.ClaimMessages(context.Background(), 3, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, third)
+}
+
+func TestMessageRepository_ClaimMessages_ExpiredLease(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewMessageRepository(db)
+
+	require.NoError(t, repo.CreateMessage(context.Background(), "+1234567890", "Stale"))
+
+	// A claim whose lease already ended, as left behind by a sender that
+	// stopped mid-batch.
+	stale, err := repo.ClaimMessages(context.Background(), 1, -time.Second)
+	require.NoError(t, err)
+	require.Len(t, stale, 1)
+
+	reclaimed, err := repo.ClaimMessages(context.Background(), 1, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, reclaimed, 1)
+	assert.Equal(t, stale[0].ID, reclaimed[0].ID)
+	assert.Equal(t, models.MessageStatusProcessing, reclaimed[0].Status)
+
+	// The new lease holds.
+	again, err := repo.ClaimMessages(context.Background(), 1, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+}
+
+func TestMessageRepository_ClaimMessages_StaleClaim(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewMessageRepository(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateMessage(ctx, "+1234567890", "Slow"))
+
+	// A sender still running after its lease ended.
+	stale, err := repo.ClaimMessages(ctx, 1, -time.Second)
+	require.NoError(t, err)
+	require.Len(t, stale, 1)
+	require.True(t, stale[0].ClaimToken.Valid)
+
+	current, err := repo.ClaimMessages(ctx, 1, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, current, 1)
+	assert.NotEqual(t, stale[0].ClaimToken, current[0].ClaimToken)
+
+	id := stale[0].ID
+	err = repo.UpdateMessageStatus(ctx, id, stale[0].ClaimToken.String, models.MessageStatusSent, ptr("ext-stale"), ptr("webhook"), nil)
+	assert.ErrorIs(t, err, repository.ErrClaimLost)
+	err = repo.MarkAcceptedUnconfirmed(ctx, id, stale[0].ClaimToken.String, "webhook", "OK")
+	assert.ErrorIs(t, err, repository.ErrClaimLost)
+
+	updated, err := repo.UpdateMessageStatuses(ctx, []models.StatusUpdate{
+		{ID: id, Status: models.MessageStatusSent, ExternalID: "ext-stale", Provider: "webhook", At: time.Now(), ClaimToken: stale[0].ClaimToken.String},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, updated)
+
+	// The current claim still writes its outcome.
+	updated, err = repo.UpdateMessageStatuses(ctx, []models.StatusUpdate{
+		{ID: id, Status: models.MessageStatusSent, ExternalID: "ext-1", Provider: "webhook", At: time.Now(), ClaimToken: current[0].ClaimToken.String},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{id}, updated)
+
+	msg, err := repo.GetMessage(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "ext-1", msg.MessageID.String)
+}
+
+func TestMessageRepository_ClaimMessagesByID(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewMessageRepository(db)
+
+	queued, err := insertTestMessage(db.DB, "+1234567890", "Queued", string(models.MessageStatusQueued), nil)
+	require.NoError(t, err)
+	processing, err := insertTestMessage(db.DB, "+1234567890", "Processing", string(models.MessageStatusProcessing), nil)
+	require.NoError(t, err)
+	sent, err := insertTestMessage(db.DB, "+1234567890", "Sent", string(models.MessageStatusSent), nil)
+	require.NoError(t, err)
+
+	claimed, err := repo.ClaimMessagesByID(context.Background(), []int64{queued, processing, sent}, []models.MessageStatus{models.MessageStatusQueued})
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, queued, claimed[0].ID)
+	assert.Equal(t, models.MessageStatusProcessing, claimed[0].Status)
+
+	// Reclaiming includes messages left in processing.
+	claimed, err = repo.ClaimMessagesByID(context.Background(), []int64{queued, processing, sent},
+		[]models.MessageStatus{models.MessageStatusQueued, models.MessageStatusProcessing})
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, queued, claimed[0].ID)
+	assert.Equal(t, processing, claimed[1].ID)
+}
+
+func TestMessageRepository_ReleaseMessages(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewMessageRepository(db)
+
+	processing, err := insertTestMessage(db.DB, "+1234567890", "Processing", string(models.MessageStatusProcessing), nil)
+	require.NoError(t, err)
+	sent, err := insertTestMessage(db.DB, "+1234567890", "Sent", string(models.MessageStatusSent), nil)
+	require.NoError(t, err)
+
+	released, err := repo.ReleaseMessages(context.Background(), []int64{sent, processing})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{processing}, released)
+
+	msg, err := repo.GetMessage(context.Background(), processing)
+	require.NoError(t, err)
+	assert.Equal(t, models.MessageStatusQueued, msg.Status)
+
+	msg, err = repo.GetMessage(context.Background(), sent)
+	require.NoError(t, err)
+	assert.Equal(t, models.MessageStatusSent, msg.Status)
+}
+
+func TestMessageRepository_UpdateMessageStatuses(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewMessageRepository(db)
+	ctx := context.Background()
+
+	sent, err := insertTestMessage(db.DB, "+1234567890", "Sent", string(models.MessageStatusProcessing), nil)
+	require.NoError(t, err)
+	failed, err := insertTestMessage(db.DB, "+1234567890", "Failed", string(models.MessageStatusProcessing), nil)
+	require.NoError(t, err)
+	queued, err := insertTestMessage(db.DB, "+1234567890", "Queued", string(models.MessageStatusQueued), nil)
+	require.NoError(t, err)
+
+	at := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	updated, err := repo.UpdateMessageStatuses(ctx, []models.StatusUpdate{
+		{ID: failed, Status: models.MessageStatusFailed, Error: "unexpected status code: 500", At: at},
+		{ID: sent, Status: models.MessageStatusSent, ExternalID: "ext-1", Provider: "webhook", At: at},
+		{ID: queued, Status: models.MessageStatusSent, ExternalID: "ext-2", Provider: "webhook", At: at},
+		{ID: 999999, Status: models.MessageStatusSent, ExternalID: "ext-3", Provider: "webhook", At: at},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{sent, failed}, updated)
+
+	msg, err := repo.GetMessage(ctx, sent)
+	require.NoError(t, err)
+	assert.Equal(t, models.MessageStatusSent, msg.Status)
+	assert.Equal(t, "ext-1", msg.MessageID.String)
+	assert.Equal(t, "webhook", msg.Provider.String)
+	assert.False(t, msg.Error.Valid)
+	assert.True(t, msg.SentAt.Time.Equal(at))
+
+	msg, err = repo.GetMessage(ctx, failed)
+	require.NoError(t, err)
+	assert.Equal(t, models.MessageStatusFailed, msg.Status)
+	assert.Equal(t, "unexpected status code: 500", msg.Error.String)
+	assert.False(t, msg.SentAt.Valid)
+
+	// Transitions that are not allowed are skipped.
+	msg, err = repo.GetMessage(ctx, queued)
+	require.NoError(t, err)
+	assert.Equal(t, models.MessageStatusQueued, msg.Status)
+
+	history, err := repo.GetStatusHistory(ctx, sent)
+	require.NoError(t, err)
+	assert.Equal(t, models.MessageStatusSent, history[len(history)-1].ToStatus)
+
+	// Applying the same updates again changes nothing.
+	updated, err = repo.UpdateMessageStatuses(ctx, []models.StatusUpdate{
+		{ID: sent, Status: models.MessageStatusSent, ExternalID: "ext-1", Provider: "webhook", At: at},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, updated)
+}
+
+func TestMessageRepository_InsertMessage(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewMessageRepository(db)
+
+	msg := &models.Message{
+		PhoneNumber: "+1234567890",
+		Content:     "Your code is 1234",
+		Priority:    5,
+		Campaign:    sql.NullString{String: "otp", Valid: true},
+	}
+	require.NoError(t, repo.InsertMessage(context.Background(), msg))
+	assert.NotZero(t, msg.ID)
+	assert.Equal(t, models.MessageStatusQueued, msg.Status)
+	assert.False(t, msg.CreatedAt.IsZero())
+
+	stored, err := repo.GetMessage(context.Background(), msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stored.Priority)
+	assert.Equal(t, "otp", stored.Campaign.String)
+}
+
+func TestMessageRepository_GetStatusHistory(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewMessageRepository(db)
+
+	require.NoError(t, repo.CreateMessage(context.Background(), "+1234567890", "Timeline"))
+	claimed, err := repo.ClaimMessages(context.Background(), 1, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	id := claimed[0].ID
+
+	require.NoError(t, repo.UpdateMessageStatus(context.Background(), id, "", models.MessageStatusSent, ptr("ext-1"), ptr("primary"), nil))
+	require.NoError(t, repo.UpdateDeliveryStatus(context.Background(), id, models.MessageStatusDelivered, time.Now(), nil))
+
+	history, err := repo.GetStatusHistory(context.Background(), id)
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+
+	assert.False(t, history[0].FromStatus.Valid)
+	assert.Equal(t, models.MessageStatusQueued, history[0].ToStatus)
+	assert.Equal(t, string(models.MessageStatusQueued), history[1].FromStatus.String)
+	assert.Equal(t, models.MessageStatusProcessing, history[1].ToStatus)
+	assert.Equal(t, models.MessageStatusSent, history[2].ToStatus)
+	assert.Equal(t, models.MessageStatusDelivered, history[3].ToStatus)
+
+	_, err = repo.GetStatusHistory(context.Background(), 99999)
+	assert.ErrorIs(t, err, repository.ErrMessageNotFound)
+}
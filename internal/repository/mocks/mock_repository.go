@@ -1,16 +1,18 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/popeskul/insdr-messenger/internal/repository (interfaces: Repository,MessageRepository)
+// Source: github.com/popeskul/insdr-messenger/internal/repository (interfaces: Repository,MessageRepository,AttemptRepository,EventRepository,SandboxRepository)
 //
 // Generated by this command:
 //
-//	mockgen -destination=mocks/mock_repository.go -package=mocks github.com/popeskul/insdr-messenger/internal/repository Repository,MessageRepository
+//	mockgen -destination=mocks/mock_repository.go -package=mocks github.com/popeskul/insdr-messenger/internal/repository Repository,MessageRepository,AttemptRepository,EventRepository,SandboxRepository
 //
 
 // Package mocks is a generated GoMock package.
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/popeskul/insdr-messenger/internal/models"
 	repository "github.com/popeskul/insdr-messenger/internal/repository"
@@ -41,6 +43,34 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// Attempt mocks base method.
+func (m *MockRepository) Attempt() repository.AttemptRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Attempt")
+	ret0, _ := ret[0].(repository.AttemptRepository)
+	return ret0
+}
+
+// Attempt indicates an expected call of Attempt.
+func (mr *MockRepositoryMockRecorder) Attempt() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Attempt", reflect.TypeOf((*MockRepository)(nil).Attempt))
+}
+
+// Event mocks base method.
+func (m *MockRepository) Event() repository.EventRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Event")
+	ret0, _ := ret[0].(repository.EventRepository)
+	return ret0
+}
+
+// Event indicates an expected call of Event.
+func (mr *MockRepositoryMockRecorder) Event() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Event", reflect.TypeOf((*MockRepository)(nil).Event))
+}
+
 // Message mocks base method.
 func (m *MockRepository) Message() repository.MessageRepository {
 	m.ctrl.T.Helper()
@@ -56,17 +86,31 @@ func (mr *MockRepositoryMockRecorder) Message() *gomock.Call {
 }
 
 // Ping mocks base method.
-func (m *MockRepository) Ping() error {
+func (m *MockRepository) Ping(ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Ping")
+	ret := m.ctrl.Call(m, "Ping", ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Ping indicates an expected call of Ping.
-func (mr *MockRepositoryMockRecorder) Ping() *gomock.Call {
+func (mr *MockRepositoryMockRecorder) Ping(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockRepository)(nil).Ping))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockRepository)(nil).Ping), ctx)
+}
+
+// Sandbox mocks base method.
+func (m *MockRepository) Sandbox() repository.SandboxRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sandbox")
+	ret0, _ := ret[0].(repository.SandboxRepository)
+	return ret0
+}
+
+// Sandbox indicates an expected call of Sandbox.
+func (mr *MockRepositoryMockRecorder) Sandbox() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sandbox", reflect.TypeOf((*MockRepository)(nil).Sandbox))
 }
 
 // MockMessageRepository is a mock of MessageRepository interface.
@@ -93,75 +137,540 @@ func (m *MockMessageRepository) EXPECT() *MockMessageRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ClaimMessages mocks base method.
+func (m *MockMessageRepository) ClaimMessages(ctx context.Context, limit int, lease time.Duration) ([]*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimMessages", ctx, limit, lease)
+	ret0, _ := ret[0].([]*models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimMessages indicates an expected call of ClaimMessages.
+func (mr *MockMessageRepositoryMockRecorder) ClaimMessages(ctx, limit, lease any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimMessages", reflect.TypeOf((*MockMessageRepository)(nil).ClaimMessages), ctx, limit, lease)
+}
+
+// ClaimMessagesByID mocks base method.
+func (m *MockMessageRepository) ClaimMessagesByID(ctx context.Context, ids []int64, from []models.MessageStatus) ([]*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimMessagesByID", ctx, ids, from)
+	ret0, _ := ret[0].([]*models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimMessagesByID indicates an expected call of ClaimMessagesByID.
+func (mr *MockMessageRepositoryMockRecorder) ClaimMessagesByID(ctx, ids, from any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimMessagesByID", reflect.TypeOf((*MockMessageRepository)(nil).ClaimMessagesByID), ctx, ids, from)
+}
+
 // CreateMessage mocks base method.
-func (m *MockMessageRepository) CreateMessage(phoneNumber, content string) error {
+func (m *MockMessageRepository) CreateMessage(ctx context.Context, phoneNumber, content string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateMessage", phoneNumber, content)
+	ret := m.ctrl.Call(m, "CreateMessage", ctx, phoneNumber, content)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateMessage indicates an expected call of CreateMessage.
-func (mr *MockMessageRepositoryMockRecorder) CreateMessage(phoneNumber, content any) *gomock.Call {
+func (mr *MockMessageRepositoryMockRecorder) CreateMessage(ctx, phoneNumber, content any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockMessageRepository)(nil).CreateMessage), ctx, phoneNumber, content)
+}
+
+// GetMessage mocks base method.
+func (m *MockMessageRepository) GetMessage(ctx context.Context, id int64) (*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessage", ctx, id)
+	ret0, _ := ret[0].(*models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessage indicates an expected call of GetMessage.
+func (mr *MockMessageRepositoryMockRecorder) GetMessage(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockMessageRepository)(nil).CreateMessage), phoneNumber, content)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessage", reflect.TypeOf((*MockMessageRepository)(nil).GetMessage), ctx, id)
+}
+
+// GetMessageByExternalID mocks base method.
+func (m *MockMessageRepository) GetMessageByExternalID(ctx context.Context, messageID string) (*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessageByExternalID", ctx, messageID)
+	ret0, _ := ret[0].(*models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessageByExternalID indicates an expected call of GetMessageByExternalID.
+func (mr *MockMessageRepositoryMockRecorder) GetMessageByExternalID(ctx, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessageByExternalID", reflect.TypeOf((*MockMessageRepository)(nil).GetMessageByExternalID), ctx, messageID)
+}
+
+// GetQueuedCount mocks base method.
+func (m *MockMessageRepository) GetQueuedCount(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQueuedCount", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQueuedCount indicates an expected call of GetQueuedCount.
+func (mr *MockMessageRepositoryMockRecorder) GetQueuedCount(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueuedCount", reflect.TypeOf((*MockMessageRepository)(nil).GetQueuedCount), ctx)
 }
 
 // GetSentMessages mocks base method.
-func (m *MockMessageRepository) GetSentMessages(offset, limit int) ([]*models.Message, error) {
+func (m *MockMessageRepository) GetSentMessages(ctx context.Context, offset, limit int) ([]*models.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSentMessages", offset, limit)
+	ret := m.ctrl.Call(m, "GetSentMessages", ctx, offset, limit)
 	ret0, _ := ret[0].([]*models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetSentMessages indicates an expected call of GetSentMessages.
-func (mr *MockMessageRepositoryMockRecorder) GetSentMessages(offset, limit any) *gomock.Call {
+func (mr *MockMessageRepositoryMockRecorder) GetSentMessages(ctx, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSentMessages", reflect.TypeOf((*MockMessageRepository)(nil).GetSentMessages), ctx, offset, limit)
+}
+
+// GetStatusHistory mocks base method.
+func (m *MockMessageRepository) GetStatusHistory(ctx context.Context, id int64) ([]*models.StatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatusHistory", ctx, id)
+	ret0, _ := ret[0].([]*models.StatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatusHistory indicates an expected call of GetStatusHistory.
+func (mr *MockMessageRepositoryMockRecorder) GetStatusHistory(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSentMessages", reflect.TypeOf((*MockMessageRepository)(nil).GetSentMessages), offset, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatusHistory", reflect.TypeOf((*MockMessageRepository)(nil).GetStatusHistory), ctx, id)
 }
 
 // GetTotalSentCount mocks base method.
-func (m *MockMessageRepository) GetTotalSentCount() (int64, error) {
+func (m *MockMessageRepository) GetTotalSentCount(ctx context.Context) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTotalSentCount")
+	ret := m.ctrl.Call(m, "GetTotalSentCount", ctx)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTotalSentCount indicates an expected call of GetTotalSentCount.
-func (mr *MockMessageRepositoryMockRecorder) GetTotalSentCount() *gomock.Call {
+func (mr *MockMessageRepositoryMockRecorder) GetTotalSentCount(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalSentCount", reflect.TypeOf((*MockMessageRepository)(nil).GetTotalSentCount), ctx)
+}
+
+// GetUnconfirmedMessages mocks base method.
+func (m *MockMessageRepository) GetUnconfirmedMessages(ctx context.Context, limit int) ([]*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnconfirmedMessages", ctx, limit)
+	ret0, _ := ret[0].([]*models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnconfirmedMessages indicates an expected call of GetUnconfirmedMessages.
+func (mr *MockMessageRepositoryMockRecorder) GetUnconfirmedMessages(ctx, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalSentCount", reflect.TypeOf((*MockMessageRepository)(nil).GetTotalSentCount))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnconfirmedMessages", reflect.TypeOf((*MockMessageRepository)(nil).GetUnconfirmedMessages), ctx, limit)
 }
 
 // GetUnsentMessages mocks base method.
-func (m *MockMessageRepository) GetUnsentMessages(limit int) ([]*models.Message, error) {
+func (m *MockMessageRepository) GetUnsentMessages(ctx context.Context, limit int) ([]*models.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUnsentMessages", limit)
+	ret := m.ctrl.Call(m, "GetUnsentMessages", ctx, limit)
 	ret0, _ := ret[0].([]*models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUnsentMessages indicates an expected call of GetUnsentMessages.
-func (mr *MockMessageRepositoryMockRecorder) GetUnsentMessages(limit any) *gomock.Call {
+func (mr *MockMessageRepositoryMockRecorder) GetUnsentMessages(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnsentMessages", reflect.TypeOf((*MockMessageRepository)(nil).GetUnsentMessages), ctx, limit)
+}
+
+// InsertMessage mocks base method.
+func (m *MockMessageRepository) InsertMessage(ctx context.Context, msg *models.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertMessage", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertMessage indicates an expected call of InsertMessage.
+func (mr *MockMessageRepositoryMockRecorder) InsertMessage(ctx, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertMessage", reflect.TypeOf((*MockMessageRepository)(nil).InsertMessage), ctx, msg)
+}
+
+// MarkAcceptedUnconfirmed mocks base method.
+func (m *MockMessageRepository) MarkAcceptedUnconfirmed(ctx context.Context, id int64, claimToken, provider, response string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAcceptedUnconfirmed", ctx, id, claimToken, provider, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkAcceptedUnconfirmed indicates an expected call of MarkAcceptedUnconfirmed.
+func (mr *MockMessageRepositoryMockRecorder) MarkAcceptedUnconfirmed(ctx, id, claimToken, provider, response any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAcceptedUnconfirmed", reflect.TypeOf((*MockMessageRepository)(nil).MarkAcceptedUnconfirmed), ctx, id, claimToken, provider, response)
+}
+
+// ReleaseMessages mocks base method.
+func (m *MockMessageRepository) ReleaseMessages(ctx context.Context, ids []int64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseMessages", ctx, ids)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseMessages indicates an expected call of ReleaseMessages.
+func (mr *MockMessageRepositoryMockRecorder) ReleaseMessages(ctx, ids any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnsentMessages", reflect.TypeOf((*MockMessageRepository)(nil).GetUnsentMessages), limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseMessages", reflect.TypeOf((*MockMessageRepository)(nil).ReleaseMessages), ctx, ids)
+}
+
+// ResolveUnconfirmed mocks base method.
+func (m *MockMessageRepository) ResolveUnconfirmed(ctx context.Context, id int64, externalID string, status models.MessageStatus, errorMsg *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveUnconfirmed", ctx, id, externalID, status, errorMsg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolveUnconfirmed indicates an expected call of ResolveUnconfirmed.
+func (mr *MockMessageRepositoryMockRecorder) ResolveUnconfirmed(ctx, id, externalID, status, errorMsg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveUnconfirmed", reflect.TypeOf((*MockMessageRepository)(nil).ResolveUnconfirmed), ctx, id, externalID, status, errorMsg)
+}
+
+// UpdateDeliveryStatus mocks base method.
+func (m *MockMessageRepository) UpdateDeliveryStatus(ctx context.Context, id int64, status models.MessageStatus, deliveredAt time.Time, errorMsg *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDeliveryStatus", ctx, id, status, deliveredAt, errorMsg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDeliveryStatus indicates an expected call of UpdateDeliveryStatus.
+func (mr *MockMessageRepositoryMockRecorder) UpdateDeliveryStatus(ctx, id, status, deliveredAt, errorMsg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeliveryStatus", reflect.TypeOf((*MockMessageRepository)(nil).UpdateDeliveryStatus), ctx, id, status, deliveredAt, errorMsg)
 }
 
 // UpdateMessageStatus mocks base method.
-func (m *MockMessageRepository) UpdateMessageStatus(id int64, status models.MessageStatus, messageID, errorMsg *string) error {
+func (m *MockMessageRepository) UpdateMessageStatus(ctx context.Context, id int64, claimToken string, status models.MessageStatus, messageID, provider, errorMsg *string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateMessageStatus", id, status, messageID, errorMsg)
+	ret := m.ctrl.Call(m, "UpdateMessageStatus", ctx, id, claimToken, status, messageID, provider, errorMsg)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateMessageStatus indicates an expected call of UpdateMessageStatus.
-func (mr *MockMessageRepositoryMockRecorder) UpdateMessageStatus(id, status, messageID, errorMsg any) *gomock.Call {
+func (mr *MockMessageRepositoryMockRecorder) UpdateMessageStatus(ctx, id, claimToken, status, messageID, provider, errorMsg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessageStatus", reflect.TypeOf((*MockMessageRepository)(nil).UpdateMessageStatus), ctx, id, claimToken, status, messageID, provider, errorMsg)
+}
+
+// UpdateMessageStatuses mocks base method.
+func (m *MockMessageRepository) UpdateMessageStatuses(ctx context.Context, updates []models.StatusUpdate) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMessageStatuses", ctx, updates)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateMessageStatuses indicates an expected call of UpdateMessageStatuses.
+func (mr *MockMessageRepositoryMockRecorder) UpdateMessageStatuses(ctx, updates any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessageStatuses", reflect.TypeOf((*MockMessageRepository)(nil).UpdateMessageStatuses), ctx, updates)
+}
+
+// MockAttemptRepository is a mock of AttemptRepository interface.
+type MockAttemptRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttemptRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockAttemptRepositoryMockRecorder is the mock recorder for MockAttemptRepository.
+type MockAttemptRepositoryMockRecorder struct {
+	mock *MockAttemptRepository
+}
+
+// NewMockAttemptRepository creates a new mock instance.
+func NewMockAttemptRepository(ctrl *gomock.Controller) *MockAttemptRepository {
+	mock := &MockAttemptRepository{ctrl: ctrl}
+	mock.recorder = &MockAttemptRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttemptRepository) EXPECT() *MockAttemptRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateAttempts mocks base method.
+func (m *MockAttemptRepository) CreateAttempts(ctx context.Context, attempts []*models.DeliveryAttempt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAttempts", ctx, attempts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAttempts indicates an expected call of CreateAttempts.
+func (mr *MockAttemptRepositoryMockRecorder) CreateAttempts(ctx, attempts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAttempts", reflect.TypeOf((*MockAttemptRepository)(nil).CreateAttempts), ctx, attempts)
+}
+
+// GetAttempts mocks base method.
+func (m *MockAttemptRepository) GetAttempts(ctx context.Context, messageID int64) ([]*models.DeliveryAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttempts", ctx, messageID)
+	ret0, _ := ret[0].([]*models.DeliveryAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttempts indicates an expected call of GetAttempts.
+func (mr *MockAttemptRepositoryMockRecorder) GetAttempts(ctx, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttempts", reflect.TypeOf((*MockAttemptRepository)(nil).GetAttempts), ctx, messageID)
+}
+
+// MockEventRepository is a mock of EventRepository interface.
+type MockEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockEventRepositoryMockRecorder is the mock recorder for MockEventRepository.
+type MockEventRepositoryMockRecorder struct {
+	mock *MockEventRepository
+}
+
+// NewMockEventRepository creates a new mock instance.
+func NewMockEventRepository(ctrl *gomock.Controller) *MockEventRepository {
+	mock := &MockEventRepository{ctrl: ctrl}
+	mock.recorder = &MockEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventRepository) EXPECT() *MockEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimEvents mocks base method.
+func (m *MockEventRepository) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimEvents", ctx, limit, lease)
+	ret0, _ := ret[0].([]*models.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimEvents indicates an expected call of ClaimEvents.
+func (mr *MockEventRepositoryMockRecorder) ClaimEvents(ctx, limit, lease any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimEvents", reflect.TypeOf((*MockEventRepository)(nil).ClaimEvents), ctx, limit, lease)
+}
+
+// CreateSubscription mocks base method.
+func (m *MockEventRepository) CreateSubscription(ctx context.Context, sub *models.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubscription", ctx, sub)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSubscription indicates an expected call of CreateSubscription.
+func (mr *MockEventRepositoryMockRecorder) CreateSubscription(ctx, sub any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubscription", reflect.TypeOf((*MockEventRepository)(nil).CreateSubscription), ctx, sub)
+}
+
+// DeleteSubscription mocks base method.
+func (m *MockEventRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubscription", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSubscription indicates an expected call of DeleteSubscription.
+func (mr *MockEventRepositoryMockRecorder) DeleteSubscription(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscription", reflect.TypeOf((*MockEventRepository)(nil).DeleteSubscription), ctx, id)
+}
+
+// GetDeadLetters mocks base method.
+func (m *MockEventRepository) GetDeadLetters(ctx context.Context, subscriptionID int64, limit int) ([]*models.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadLetters", ctx, subscriptionID, limit)
+	ret0, _ := ret[0].([]*models.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetters indicates an expected call of GetDeadLetters.
+func (mr *MockEventRepositoryMockRecorder) GetDeadLetters(ctx, subscriptionID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLetters", reflect.TypeOf((*MockEventRepository)(nil).GetDeadLetters), ctx, subscriptionID, limit)
+}
+
+// GetSubscription mocks base method.
+func (m *MockEventRepository) GetSubscription(ctx context.Context, id int64) (*models.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscription", ctx, id)
+	ret0, _ := ret[0].(*models.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscription indicates an expected call of GetSubscription.
+func (mr *MockEventRepositoryMockRecorder) GetSubscription(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscription", reflect.TypeOf((*MockEventRepository)(nil).GetSubscription), ctx, id)
+}
+
+// GetSubscriptions mocks base method.
+func (m *MockEventRepository) GetSubscriptions(ctx context.Context) ([]*models.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptions", ctx)
+	ret0, _ := ret[0].([]*models.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptions indicates an expected call of GetSubscriptions.
+func (mr *MockEventRepositoryMockRecorder) GetSubscriptions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptions", reflect.TypeOf((*MockEventRepository)(nil).GetSubscriptions), ctx)
+}
+
+// MarkEventDead mocks base method.
+func (m *MockEventRepository) MarkEventDead(ctx context.Context, id int64, errorMsg string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEventDead", ctx, id, errorMsg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEventDead indicates an expected call of MarkEventDead.
+func (mr *MockEventRepositoryMockRecorder) MarkEventDead(ctx, id, errorMsg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEventDead", reflect.TypeOf((*MockEventRepository)(nil).MarkEventDead), ctx, id, errorMsg)
+}
+
+// MarkEventDelivered mocks base method.
+func (m *MockEventRepository) MarkEventDelivered(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEventDelivered", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEventDelivered indicates an expected call of MarkEventDelivered.
+func (mr *MockEventRepositoryMockRecorder) MarkEventDelivered(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEventDelivered", reflect.TypeOf((*MockEventRepository)(nil).MarkEventDelivered), ctx, id)
+}
+
+// PublishEvent mocks base method.
+func (m *MockEventRepository) PublishEvent(ctx context.Context, eventType models.EventType, payload []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishEvent", ctx, eventType, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishEvent indicates an expected call of PublishEvent.
+func (mr *MockEventRepositoryMockRecorder) PublishEvent(ctx, eventType, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishEvent", reflect.TypeOf((*MockEventRepository)(nil).PublishEvent), ctx, eventType, payload)
+}
+
+// RetryEvent mocks base method.
+func (m *MockEventRepository) RetryEvent(ctx context.Context, id int64, errorMsg string, nextAttemptAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryEvent", ctx, id, errorMsg, nextAttemptAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryEvent indicates an expected call of RetryEvent.
+func (mr *MockEventRepositoryMockRecorder) RetryEvent(ctx, id, errorMsg, nextAttemptAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryEvent", reflect.TypeOf((*MockEventRepository)(nil).RetryEvent), ctx, id, errorMsg, nextAttemptAt)
+}
+
+// MockSandboxRepository is a mock of SandboxRepository interface.
+type MockSandboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSandboxRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSandboxRepositoryMockRecorder is the mock recorder for MockSandboxRepository.
+type MockSandboxRepositoryMockRecorder struct {
+	mock *MockSandboxRepository
+}
+
+// NewMockSandboxRepository creates a new mock instance.
+func NewMockSandboxRepository(ctrl *gomock.Controller) *MockSandboxRepository {
+	mock := &MockSandboxRepository{ctrl: ctrl}
+	mock.recorder = &MockSandboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSandboxRepository) EXPECT() *MockSandboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateSandboxRequest mocks base method.
+func (m *MockSandboxRepository) CreateSandboxRequest(ctx context.Context, req *models.SandboxRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSandboxRequest", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSandboxRequest indicates an expected call of CreateSandboxRequest.
+func (mr *MockSandboxRepositoryMockRecorder) CreateSandboxRequest(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSandboxRequest", reflect.TypeOf((*MockSandboxRepository)(nil).CreateSandboxRequest), ctx, req)
+}
+
+// GetSandboxRequests mocks base method.
+func (m *MockSandboxRepository) GetSandboxRequests(ctx context.Context, messageID int64, limit int) ([]*models.SandboxRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSandboxRequests", ctx, messageID, limit)
+	ret0, _ := ret[0].([]*models.SandboxRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSandboxRequests indicates an expected call of GetSandboxRequests.
+func (mr *MockSandboxRepositoryMockRecorder) GetSandboxRequests(ctx, messageID, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessageStatus", reflect.TypeOf((*MockMessageRepository)(nil).UpdateMessageStatus), id, status, messageID, errorMsg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSandboxRequests", reflect.TypeOf((*MockSandboxRepository)(nil).GetSandboxRequests), ctx, messageID, limit)
 }
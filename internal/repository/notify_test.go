@@ -0,0 +1,78 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMessagesQueuedNotification(t *testing.T) {
+	ctx := context.Background()
+	pgContainer, cleanup := setupTestContainer(t, ctx)
+	defer cleanup()
+
+	dsn, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	db, err := sqlx.Connect("postgres", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, applyMigrations(db))
+
+	notified := make(chan struct{}, 10)
+	listener := scheduler.NewListener(dsn, scheduler.MessagesQueuedChannel, func() {
+		notified <- struct{}{}
+	}, zap.NewNop())
+	require.NoError(t, listener.Start())
+	defer func() { _ = listener.Stop() }()
+
+	// Give the listener time to connect.
+	time.Sleep(500 * time.Millisecond)
+
+	sentID, err := insertTestMessage(db.DB, "+1234567890", "Sent", string(models.MessageStatusSent), nil)
+	require.NoError(t, err)
+
+	select {
+	case <-notified:
+		t.Fatal("unexpected notification for a sent message")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	queuedID, err := insertTestMessage(db.DB, "+1234567890", "Queued", string(models.MessageStatusQueued), nil)
+	require.NoError(t, err)
+
+	select {
+	case <-notified:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no notification for a queued message")
+	}
+
+	// Updates that leave the queue as it is stay quiet.
+	_, err = db.Exec(`UPDATE messages SET updated_at = NOW() WHERE id IN ($1, $2)`, sentID, queuedID)
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE messages SET status = $1 WHERE id = $2`, models.MessageStatusQueued, queuedID)
+	require.NoError(t, err)
+
+	select {
+	case <-notified:
+		t.Fatal("unexpected notification for an update that queues no message")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	_, err = db.Exec(`UPDATE messages SET status = $1 WHERE id = $2`, models.MessageStatusQueued, sentID)
+	require.NoError(t, err)
+
+	select {
+	case <-notified:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no notification for a re-queued message")
+	}
+}
@@ -11,6 +11,9 @@ import (
 type repositoryImpl struct {
 	db      *sqlx.DB
 	message MessageRepository
+	attempt AttemptRepository
+	event   EventRepository
+	sandbox SandboxRepository
 }
 
 // NewRepository creates a new repository instance.
@@ -18,6 +21,9 @@ func NewRepository(db *sqlx.DB) Repository {
 	return &repositoryImpl{
 		db:      db,
 		message: NewMessageRepository(db),
+		attempt: NewAttemptRepository(db),
+		event:   NewEventRepository(db),
+		sandbox: NewSandboxRepository(db),
 	}
 }
 
@@ -26,9 +32,25 @@ func (r *repositoryImpl) Message() MessageRepository {
 	return r.message
 }
 
-// Ping checks if the database connection is healthy.
-func (r *repositoryImpl) Ping() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+// Attempt returns the delivery attempt repository.
+func (r *repositoryImpl) Attempt() AttemptRepository {
+	return r.attempt
+}
+
+// Event returns the event subscription repository.
+func (r *repositoryImpl) Event() EventRepository {
+	return r.event
+}
+
+// Sandbox returns the sandbox request repository.
+func (r *repositoryImpl) Sandbox() SandboxRepository {
+	return r.sandbox
+}
+
+// Ping checks if the database connection is healthy, giving up after two
+// seconds at most.
+func (r *repositoryImpl) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
 	return r.db.PingContext(ctx)
@@ -1,6 +1,7 @@
 package repository_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -39,14 +40,14 @@ func TestRepositoryImpl_Message_Success(t *testing.T) {
 			validate: func(t *testing.T, repo repository.Repository) {
 				messageRepo := repo.Message()
 
-				_, err := messageRepo.GetUnsentMessages(10)
+				_, err := messageRepo.GetUnsentMessages(context.Background(), 10)
 				assert.NoError(t, err)
 
-				count, err := messageRepo.GetTotalSentCount()
+				count, err := messageRepo.GetTotalSentCount(context.Background())
 				assert.NoError(t, err)
 				assert.GreaterOrEqual(t, count, int64(0))
 
-				_, err = messageRepo.GetSentMessages(0, 10)
+				_, err = messageRepo.GetSentMessages(context.Background(), 0, 10)
 				assert.NoError(t, err)
 			},
 		},
@@ -55,10 +56,10 @@ func TestRepositoryImpl_Message_Success(t *testing.T) {
 			validate: func(t *testing.T, repo repository.Repository) {
 				messageRepo := repo.Message()
 
-				err := messageRepo.CreateMessage("+1234567890", "Test message from repository test")
+				err := messageRepo.CreateMessage(context.Background(), "+1234567890", "Test message from repository test")
 				assert.NoError(t, err)
 
-				messages, err := messageRepo.GetUnsentMessages(10)
+				messages, err := messageRepo.GetUnsentMessages(context.Background(), 10)
 				assert.NoError(t, err)
 				assert.Len(t, messages, 1)
 				assert.Equal(t, "+1234567890", messages[0].PhoneNumber)
@@ -100,7 +101,7 @@ func TestRepositoryImpl_Message_Failure(t *testing.T) {
 			messageRepo := repo.Message()
 			assert.NotNil(t, messageRepo)
 
-			_, err := messageRepo.GetUnsentMessages(10)
+			_, err := messageRepo.GetUnsentMessages(context.Background(), 10)
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), "database is closed")
 		})
@@ -148,12 +149,12 @@ func TestRepositoryImpl_Ping_Success(t *testing.T) {
 
 			tt.setup()
 
-			err := repo.Ping()
+			err := repo.Ping(context.Background())
 			tt.validate(t, err)
 
 			if tt.name == "Multiple pings in succession" {
 				for i := 0; i < 5; i++ {
-					err := repo.Ping()
+					err := repo.Ping(context.Background())
 					assert.NoError(t, err)
 				}
 			}
@@ -198,7 +199,7 @@ func TestRepositoryImpl_Ping_Failure(t *testing.T) {
 
 			done := make(chan bool)
 			go func() {
-				err := repo.Ping()
+				err := repo.Ping(context.Background())
 				assert.Error(t, err)
 				if tt.expectedError != "" {
 					assert.Contains(t, err.Error(), tt.expectedError)
@@ -224,7 +225,7 @@ func BenchmarkRepository_Ping(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		err := repo.Ping()
+		err := repo.Ping(context.Background())
 		if err != nil {
 			b.Fatal(err)
 		}
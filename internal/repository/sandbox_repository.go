@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
+
+type sandboxRepository struct {
+	db *sqlx.DB
+}
+
+func NewSandboxRepository(db *sqlx.DB) SandboxRepository {
+	return &sandboxRepository{
+		db: db,
+	}
+}
+
+// CreateSandboxRequest stores a recorded request and sets its ID and
+// creation time.
+func (r *sandboxRepository) CreateSandboxRequest(ctx context.Context, req *models.SandboxRequest) error {
+	query := `
+		INSERT INTO sandbox_requests (message_id, provider, external_id, method, url, headers, body, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	headers := req.Headers
+	if len(headers) == 0 {
+		headers = []byte(`{}`)
+	}
+
+	err := r.db.QueryRowxContext(ctx, query, req.MessageID, req.Provider, req.ExternalID, req.Method, req.URL, string(headers), req.Body, time.Now()).
+		Scan(&req.ID, &req.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create sandbox request: %w", err)
+	}
+
+	return nil
+}
+
+// GetSandboxRequests returns up to limit recorded requests, newest first.
+// A non-zero messageID restricts them to that message.
+func (r *sandboxRepository) GetSandboxRequests(ctx context.Context, messageID int64, limit int) ([]*models.SandboxRequest, error) {
+	query := `
+		SELECT id, message_id, provider, external_id, method, url, headers, body, created_at
+		FROM sandbox_requests
+		WHERE $1::bigint = 0 OR message_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`
+
+	requests := []*models.SandboxRequest{}
+	err := r.db.SelectContext(ctx, &requests, query, messageID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sandbox requests: %w", err)
+	}
+
+	return requests, nil
+}
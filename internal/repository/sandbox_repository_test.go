@@ -0,0 +1,60 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxRepository_CreateAndGetSandboxRequests(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewSandboxRepository(db)
+
+	firstID, err := insertTestMessage(db.DB, "+1234567890", "First", string(models.MessageStatusSent), nil)
+	require.NoError(t, err)
+	secondID, err := insertTestMessage(db.DB, "+1234567891", "Second", string(models.MessageStatusSent), nil)
+	require.NoError(t, err)
+
+	first := &models.SandboxRequest{
+		MessageID:  firstID,
+		Provider:   "primary",
+		ExternalID: "sandbox-1",
+		Method:     "POST",
+		URL:        "https://gateway.example.com/send",
+		Headers:    []byte(`{"Content-Type":"application/json"}`),
+		Body:       `{"to":"+1234567890","content":"First"}`,
+	}
+	require.NoError(t, repo.CreateSandboxRequest(context.Background(), first))
+	assert.NotZero(t, first.ID)
+	assert.False(t, first.CreatedAt.IsZero())
+
+	require.NoError(t, repo.CreateSandboxRequest(context.Background(), &models.SandboxRequest{
+		MessageID:  secondID,
+		Provider:   "custom",
+		ExternalID: "sandbox-2",
+		Body:       "Second",
+	}))
+
+	requests, err := repo.GetSandboxRequests(context.Background(), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	assert.Equal(t, "sandbox-2", requests[0].ExternalID)
+	assert.JSONEq(t, `{}`, string(requests[0].Headers))
+	assert.JSONEq(t, `{"Content-Type":"application/json"}`, string(requests[1].Headers))
+
+	requests, err = repo.GetSandboxRequests(context.Background(), firstID, 10)
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "https://gateway.example.com/send", requests[0].URL)
+
+	requests, err = repo.GetSandboxRequests(context.Background(), 0, 1)
+	require.NoError(t, err)
+	assert.Len(t, requests, 1)
+}
@@ -91,5 +91,5 @@ func applyMigrations(db *sqlx.DB) error {
 }
 
 func cleanupTestData(db *sqlx.DB) {
-	_, _ = db.Exec("TRUNCATE TABLE messages RESTART IDENTITY CASCADE")
+	_, _ = db.Exec("TRUNCATE TABLE messages, event_subscriptions RESTART IDENTITY CASCADE")
 }
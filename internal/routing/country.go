@@ -0,0 +1,36 @@
+package routing
+
+// twoDigitCodes lists the ITU-T E.164 country codes that are two digits
+// long. Codes starting with 1 or 7 are one digit; every other code is
+// three digits.
+var twoDigitCodes = map[string]struct{}{
+	"20": {}, "27": {}, "30": {}, "31": {}, "32": {}, "33": {}, "34": {}, "36": {},
+	"39": {}, "40": {}, "41": {}, "43": {}, "44": {}, "45": {}, "46": {}, "47": {},
+	"48": {}, "49": {}, "51": {}, "52": {}, "53": {}, "54": {}, "55": {}, "56": {},
+	"57": {}, "58": {}, "60": {}, "61": {}, "62": {}, "63": {}, "64": {}, "65": {},
+	"66": {}, "81": {}, "82": {}, "84": {}, "86": {}, "90": {}, "91": {}, "92": {},
+	"93": {}, "94": {}, "95": {}, "98": {},
+}
+
+// CountryCode returns the calling country code of a phone number, or an
+// empty string when the number is too short to contain one.
+func CountryCode(number string) string {
+	digits := NormalizeNumber(number)
+	if digits == "" || digits[0] == '0' {
+		return ""
+	}
+
+	switch {
+	case digits[0] == '1' || digits[0] == '7':
+		return digits[:1]
+	case len(digits) < 2:
+		return ""
+	}
+	if _, ok := twoDigitCodes[digits[:2]]; ok {
+		return digits[:2]
+	}
+	if len(digits) < 3 {
+		return ""
+	}
+	return digits[:3]
+}
@@ -0,0 +1,248 @@
+// Package routing selects delivery providers for a destination using
+// longest-prefix matching over configured routes.
+package routing
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+)
+
+// DefaultRouteName is the name of the route used when no rule matches.
+const DefaultRouteName = "default"
+
+// Destination describes the attributes of a message used for routing.
+type Destination struct {
+	PhoneNumber string
+	Priority    int
+	Campaign    string
+}
+
+// Route is the result of resolving a destination.
+type Route struct {
+	// Name of the matched route.
+	Name string
+	// Prefix is the number prefix that matched, empty for catch-all routes.
+	Prefix string
+	// Providers is the ordered list of provider names to try.
+	Providers []string
+}
+
+// route is a validated route configuration.
+type route struct {
+	name      string
+	providers []string
+	split     []config.WeightedProvider
+	total     int
+}
+
+func newRoute(rc config.RouteConfig, known map[string]struct{}) (*route, error) {
+	r := &route{name: rc.Name, providers: rc.Providers, split: rc.Split}
+
+	for _, w := range rc.Split {
+		if w.Weight <= 0 {
+			return nil, fmt.Errorf("route %q: weight of provider %q must be positive", rc.Name, w.Provider)
+		}
+		r.total += w.Weight
+
+		if len(rc.Providers) == 0 {
+			r.providers = append(r.providers, w.Provider)
+		}
+	}
+
+	if len(r.providers) == 0 {
+		return nil, fmt.Errorf("route %q: at least one provider is required", rc.Name)
+	}
+
+	for _, name := range r.providers {
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("route %q: provider %q is not configured", rc.Name, name)
+		}
+	}
+	for _, w := range rc.Split {
+		if _, ok := known[w.Provider]; !ok {
+			return nil, fmt.Errorf("route %q: provider %q is not configured", rc.Name, w.Provider)
+		}
+	}
+
+	return r, nil
+}
+
+// providersFor returns the provider order for a recipient. Without a split
+// this is the configured order. With a split, the recipient is pinned to a
+// provider chosen by weight from a hash of the number, so the same person
+// always lands on the same provider; the other providers follow in their
+// configured order.
+func (r *route) providersFor(number string) []string {
+	if len(r.split) == 0 {
+		return r.providers
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(r.name + ":" + number))
+	n := int(h.Sum64() % uint64(r.total))
+
+	assigned := r.split[len(r.split)-1].Provider
+	for _, w := range r.split {
+		if n < w.Weight {
+			assigned = w.Provider
+			break
+		}
+		n -= w.Weight
+	}
+
+	providers := make([]string, 0, len(r.providers)+1)
+	providers = append(providers, assigned)
+	for _, name := range r.providers {
+		if name != assigned {
+			providers = append(providers, name)
+		}
+	}
+	return providers
+}
+
+// rule is a single prefix entry of a route.
+type rule struct {
+	route    *route
+	prefix   string
+	priority *int
+	campaign string
+	order    int
+}
+
+// specificity counts the optional conditions a rule requires.
+func (r *rule) specificity() int {
+	n := 0
+	if r.priority != nil {
+		n++
+	}
+	if r.campaign != "" {
+		n++
+	}
+	return n
+}
+
+func (r *rule) matches(number string, dest Destination) bool {
+	if !strings.HasPrefix(number, r.prefix) {
+		return false
+	}
+	if r.priority != nil && *r.priority != dest.Priority {
+		return false
+	}
+	if r.campaign != "" && r.campaign != dest.Campaign {
+		return false
+	}
+	return true
+}
+
+// Table resolves destinations to routes.
+type Table struct {
+	rules    []*rule
+	fallback *route
+}
+
+// NewTable builds a routing table from the configured routes. Messages that
+// match no route use the fallback route, whose name is always
+// DefaultRouteName. Every provider referenced by a route must be present
+// in known.
+func NewTable(routes []config.RouteConfig, fallback config.RouteConfig, known []string) (*Table, error) {
+	knownSet := make(map[string]struct{}, len(known))
+	for _, name := range known {
+		knownSet[name] = struct{}{}
+	}
+
+	fallback.Name = DefaultRouteName
+	fallbackRoute, err := newRoute(fallback, knownSet)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Table{fallback: fallbackRoute}
+
+	for i, rc := range routes {
+		if rc.Name == "" {
+			return nil, fmt.Errorf("route %d: name is required", i)
+		}
+
+		r, err := newRoute(rc, knownSet)
+		if err != nil {
+			return nil, err
+		}
+
+		prefixes := rc.Prefixes
+		if len(prefixes) == 0 {
+			prefixes = []string{""}
+		}
+
+		for _, p := range prefixes {
+			prefix := NormalizeNumber(p)
+			if p != "" && prefix == "" {
+				return nil, fmt.Errorf("route %q: invalid prefix %q", rc.Name, p)
+			}
+
+			t.rules = append(t.rules, &rule{
+				route:    r,
+				prefix:   prefix,
+				priority: rc.Priority,
+				campaign: rc.Campaign,
+				order:    len(t.rules),
+			})
+		}
+	}
+
+	// Longest prefix first, then the rule with more conditions, then
+	// configuration order.
+	sort.SliceStable(t.rules, func(i, j int) bool {
+		a, b := t.rules[i], t.rules[j]
+		if len(a.prefix) != len(b.prefix) {
+			return len(a.prefix) > len(b.prefix)
+		}
+		if a.specificity() != b.specificity() {
+			return a.specificity() > b.specificity()
+		}
+		return a.order < b.order
+	})
+
+	return t, nil
+}
+
+// Resolve returns the route for the destination.
+func (t *Table) Resolve(dest Destination) Route {
+	number := NormalizeNumber(dest.PhoneNumber)
+
+	for _, r := range t.rules {
+		if r.matches(number, dest) {
+			return Route{
+				Name:      r.route.name,
+				Prefix:    r.prefix,
+				Providers: r.route.providersFor(number),
+			}
+		}
+	}
+
+	return Route{
+		Name:      t.fallback.name,
+		Providers: t.fallback.providersFor(number),
+	}
+}
+
+// NormalizeNumber strips formatting and international prefixes from a phone
+// number, leaving only the digits of the E.164 form.
+func NormalizeNumber(number string) string {
+	var b strings.Builder
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+
+	digits := b.String()
+	trimmed := strings.TrimSpace(number)
+	if !strings.HasPrefix(trimmed, "+") && strings.HasPrefix(digits, "00") {
+		digits = digits[2:]
+	}
+	return digits
+}
@@ -0,0 +1,190 @@
+package routing_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/routing"
+)
+
+func TestTable_Resolve(t *testing.T) {
+	high := 1
+	routes := []config.RouteConfig{
+		{Name: "turkey", Prefixes: []string{"+90"}, Providers: []string{"tr-gateway", "webhook"}},
+		{Name: "turkey-mobile", Prefixes: []string{"+905"}, Providers: []string{"tr-mobile"}},
+		{Name: "turkey-urgent", Prefixes: []string{"+90"}, Priority: &high, Providers: []string{"premium"}},
+		{Name: "eu", Prefixes: []string{"+49", "+33", "0031"}, Providers: []string{"eu-gateway"}},
+		{Name: "newsletter", Campaign: "newsletter", Providers: []string{"bulk"}},
+	}
+	known := []string{"webhook", "tr-gateway", "tr-mobile", "premium", "eu-gateway", "bulk"}
+
+	table, err := routing.NewTable(routes, config.RouteConfig{Providers: []string{"webhook"}}, known)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		dest              routing.Destination
+		expectedRoute     string
+		expectedPrefix    string
+		expectedProviders []string
+	}{
+		{
+			name:              "longest prefix wins",
+			dest:              routing.Destination{PhoneNumber: "+905551111111"},
+			expectedRoute:     "turkey-mobile",
+			expectedPrefix:    "905",
+			expectedProviders: []string{"tr-mobile"},
+		},
+		{
+			name:              "country prefix",
+			dest:              routing.Destination{PhoneNumber: "+90 212 555 1111"},
+			expectedRoute:     "turkey",
+			expectedPrefix:    "90",
+			expectedProviders: []string{"tr-gateway", "webhook"},
+		},
+		{
+			name:              "priority specific route beats plain route with same prefix",
+			dest:              routing.Destination{PhoneNumber: "+902125551111", Priority: 1},
+			expectedRoute:     "turkey-urgent",
+			expectedPrefix:    "90",
+			expectedProviders: []string{"premium"},
+		},
+		{
+			name:              "international 00 prefix is normalized",
+			dest:              routing.Destination{PhoneNumber: "0031612345678"},
+			expectedRoute:     "eu",
+			expectedPrefix:    "31",
+			expectedProviders: []string{"eu-gateway"},
+		},
+		{
+			name:              "campaign catch-all route",
+			dest:              routing.Destination{PhoneNumber: "+15551234567", Campaign: "newsletter"},
+			expectedRoute:     "newsletter",
+			expectedProviders: []string{"bulk"},
+		},
+		{
+			name:              "no match uses default route",
+			dest:              routing.Destination{PhoneNumber: "+15551234567"},
+			expectedRoute:     routing.DefaultRouteName,
+			expectedProviders: []string{"webhook"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := table.Resolve(tt.dest)
+
+			assert.Equal(t, tt.expectedRoute, route.Name)
+			assert.Equal(t, tt.expectedPrefix, route.Prefix)
+			assert.Equal(t, tt.expectedProviders, route.Providers)
+		})
+	}
+}
+
+func TestNewTable_Failure(t *testing.T) {
+	tests := []struct {
+		name          string
+		routes        []config.RouteConfig
+		expectedError string
+	}{
+		{
+			name:          "missing name",
+			routes:        []config.RouteConfig{{Prefixes: []string{"90"}, Providers: []string{"webhook"}}},
+			expectedError: "name is required",
+		},
+		{
+			name:          "missing providers",
+			routes:        []config.RouteConfig{{Name: "turkey", Prefixes: []string{"90"}}},
+			expectedError: "at least one provider is required",
+		},
+		{
+			name:          "unknown provider",
+			routes:        []config.RouteConfig{{Name: "turkey", Prefixes: []string{"90"}, Providers: []string{"missing"}}},
+			expectedError: `provider "missing" is not configured`,
+		},
+		{
+			name: "non-positive weight",
+			routes: []config.RouteConfig{{
+				Name:  "turkey",
+				Split: []config.WeightedProvider{{Provider: "webhook", Weight: 0}},
+			}},
+			expectedError: "must be positive",
+		},
+		{
+			name:          "invalid prefix",
+			routes:        []config.RouteConfig{{Name: "turkey", Prefixes: []string{"TR"}, Providers: []string{"webhook"}}},
+			expectedError: `invalid prefix "TR"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := routing.NewTable(tt.routes, config.RouteConfig{Providers: []string{"webhook"}}, []string{"webhook"})
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestTable_Resolve_WeightedSplit(t *testing.T) {
+	fallback := config.RouteConfig{
+		Providers: []string{"current", "fallback"},
+		Split: []config.WeightedProvider{
+			{Provider: "current", Weight: 90},
+			{Provider: "trial", Weight: 10},
+		},
+	}
+	known := []string{"current", "trial", "fallback"}
+
+	table, err := routing.NewTable(nil, fallback, known)
+	require.NoError(t, err)
+
+	assigned := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		number := fmt.Sprintf("+90555%07d", i)
+
+		route := table.Resolve(routing.Destination{PhoneNumber: number})
+		require.NotEmpty(t, route.Providers)
+		assigned[route.Providers[0]]++
+
+		// Assignment is sticky per recipient.
+		assert.Equal(t, route.Providers, table.Resolve(routing.Destination{PhoneNumber: number}).Providers)
+
+		// Remaining providers stay available for failover.
+		if route.Providers[0] == "trial" {
+			assert.Equal(t, []string{"trial", "current", "fallback"}, route.Providers)
+		} else {
+			assert.Equal(t, []string{"current", "fallback"}, route.Providers)
+		}
+	}
+
+	assert.InDelta(t, 1000, assigned["trial"], 200)
+	assert.InDelta(t, 9000, assigned["current"], 200)
+}
+
+func TestCountryCode(t *testing.T) {
+	tests := []struct {
+		number   string
+		expected string
+	}{
+		{number: "+1 415 555 0100", expected: "1"},
+		{number: "+79161234567", expected: "7"},
+		{number: "+905551111111", expected: "90"},
+		{number: "0049301234567", expected: "49"},
+		{number: "+380441234567", expected: "380"},
+		{number: "+971501234567", expected: "971"},
+		{number: "+9", expected: ""},
+		{number: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			assert.Equal(t, tt.expected, routing.CountryCode(tt.number))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// MessagesQueuedChannel is the Postgres notification channel signalled
+// whenever messages are queued.
+const MessagesQueuedChannel = "messages_queued"
+
+const (
+	listenerMinReconnect = time.Second
+	listenerMaxReconnect = 30 * time.Second
+	// listenerPingInterval detects dead connections while no notifications
+	// arrive.
+	listenerPingInterval = 90 * time.Second
+)
+
+// Listener calls a function for every notification on a Postgres channel.
+// It is also called after the connection is re-established, as
+// notifications sent while disconnected are lost.
+type Listener struct {
+	dsn      string
+	channel  string
+	onNotify func()
+	logger   *zap.Logger
+
+	mu       sync.Mutex
+	listener *pq.Listener
+	doneCh   chan struct{}
+}
+
+// NewListener creates a listener for channel on the database at dsn.
+func NewListener(dsn, channel string, onNotify func(), logger *zap.Logger) *Listener {
+	return &Listener{
+		dsn:      dsn,
+		channel:  channel,
+		onNotify: onNotify,
+		logger:   logger,
+	}
+}
+
+// Start connects in the background and begins listening.
+func (l *Listener) Start() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.listener != nil {
+		return ErrSchedulerAlreadyRunning
+	}
+
+	l.listener = pq.NewListener(l.dsn, listenerMinReconnect, listenerMaxReconnect, l.event)
+	l.doneCh = make(chan struct{})
+
+	go l.run(l.listener, l.doneCh)
+	return nil
+}
+
+// Stop closes the connection.
+func (l *Listener) Stop() error {
+	l.mu.Lock()
+	listener, doneCh := l.listener, l.doneCh
+	l.listener, l.doneCh = nil, nil
+	l.mu.Unlock()
+
+	if listener == nil {
+		return ErrSchedulerNotRunning
+	}
+
+	err := listener.Close()
+	<-doneCh
+	return err
+}
+
+func (l *Listener) run(listener *pq.Listener, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	// Listen blocks until connected, which may take a while.
+	if err := listener.Listen(l.channel); err != nil {
+		if !l.stopped(listener) {
+			l.logger.Error("Failed to listen for notifications",
+				zap.String("channel", l.channel),
+				zap.Error(err))
+		}
+		return
+	}
+
+	l.logger.Info("Listening for notifications", zap.String("channel", l.channel))
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case _, ok := <-listener.Notify:
+			if !ok {
+				return
+			}
+			// A nil notification follows a reconnect.
+			l.onNotify()
+		case <-ticker.C:
+			go func() {
+				if err := listener.Ping(); err != nil {
+					l.logger.Warn("Notification connection ping failed", zap.Error(err))
+				}
+			}()
+		}
+	}
+}
+
+// stopped reports whether listener was closed by Stop.
+func (l *Listener) stopped(listener *pq.Listener) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.listener != listener
+}
+
+func (l *Listener) event(event pq.ListenerEventType, err error) {
+	switch event {
+	case pq.ListenerEventDisconnected:
+		l.logger.Warn("Notification connection lost", zap.String("channel", l.channel), zap.Error(err))
+	case pq.ListenerEventReconnected:
+		l.logger.Info("Notification connection re-established", zap.String("channel", l.channel))
+	case pq.ListenerEventConnectionAttemptFailed:
+		l.logger.Debug("Notification connection attempt failed", zap.String("channel", l.channel), zap.Error(err))
+	}
+}
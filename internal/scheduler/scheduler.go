@@ -13,6 +13,8 @@ type Scheduler struct {
 	logger    *zap.Logger
 	interval  time.Duration
 	taskFunc  func(context.Context) error
+	debounce  time.Duration
+	triggerCh chan struct{}
 	stopCh    chan struct{}
 	doneCh    chan struct{}
 	isRunning bool
@@ -22,11 +24,28 @@ type Scheduler struct {
 // NewScheduler creates a new scheduler instance.
 func NewScheduler(logger *zap.Logger, interval time.Duration, taskFunc func(context.Context) error) *Scheduler {
 	return &Scheduler{
-		logger:   logger,
-		interval: interval,
-		taskFunc: taskFunc,
-		stopCh:   make(chan struct{}),
-		doneCh:   make(chan struct{}),
+		logger:    logger,
+		interval:  interval,
+		taskFunc:  taskFunc,
+		triggerCh: make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+	}
+}
+
+// SetDebounce sets how long a triggered run waits for further triggers,
+// so a burst of them results in a single run. It must be called before
+// Start.
+func (s *Scheduler) SetDebounce(d time.Duration) {
+	s.debounce = d
+}
+
+// Trigger requests a run ahead of the next tick. Triggers that arrive
+// while a run is pending are merged; a stopped scheduler ignores them.
+func (s *Scheduler) Trigger() {
+	select {
+	case s.triggerCh <- struct{}{}:
+	default:
 	}
 }
 
@@ -85,6 +104,12 @@ func (s *Scheduler) run(ctx context.Context) {
 		s.mu.Unlock()
 	}()
 
+	// The initial run covers triggers received while stopped.
+	select {
+	case <-s.triggerCh:
+	default:
+	}
+
 	// Execute immediately on start
 	if err := s.executeTask(ctx); err != nil {
 		s.logger.Error("Failed to execute initial task", zap.Error(err))
@@ -105,15 +130,54 @@ func (s *Scheduler) run(ctx context.Context) {
 			if err := s.executeTask(ctx); err != nil {
 				s.logger.Error("Failed to execute scheduled task", zap.Error(err))
 			}
+		case <-s.triggerCh:
+			if !s.wait(ctx, s.debounce) {
+				return
+			}
+			// Triggers received while waiting are served by this run.
+			select {
+			case <-s.triggerCh:
+			default:
+			}
+
+			if err := s.executeTask(ctx); err != nil {
+				s.logger.Error("Failed to execute triggered task", zap.Error(err))
+			}
+			ticker.Reset(s.interval)
 		}
 	}
 }
 
+// wait pauses for d and reports whether the scheduler is still running.
+func (s *Scheduler) wait(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-s.stopCh:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
 // executeTask runs the task function with error handling
 func (s *Scheduler) executeTask(ctx context.Context) error {
 	s.logger.Info("Executing scheduled task")
 
-	taskCtx, cancel := context.WithTimeout(ctx, s.interval-time.Second)
+	// A run should end before the next one is due, with a second to spare
+	// where the interval allows it.
+	timeout := s.interval - time.Second
+	if timeout <= 0 {
+		timeout = s.interval
+	}
+	taskCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	err := s.taskFunc(taskCtx)
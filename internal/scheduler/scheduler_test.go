@@ -190,6 +190,76 @@ func TestScheduler_TaskExecution(t *testing.T) {
 	}
 }
 
+func TestScheduler_Trigger(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	taskFunc := func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return nil
+	}
+	callCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+
+	s := scheduler.NewScheduler(zap.NewNop(), time.Hour, taskFunc)
+	s.SetDebounce(50 * time.Millisecond)
+
+	// Triggers while stopped are covered by the initial run.
+	s.Trigger()
+
+	assert.NoError(t, s.Start(context.Background()))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, callCount())
+
+	// A burst of triggers results in a single run after the debounce.
+	for i := 0; i < 5; i++ {
+		s.Trigger()
+	}
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, callCount())
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 2, callCount())
+
+	assert.NoError(t, s.Stop())
+}
+
+func TestScheduler_TaskDeadline(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		want     time.Duration
+	}{
+		{name: "second to spare", interval: 3 * time.Second, want: 2 * time.Second},
+		{name: "short interval", interval: 50 * time.Millisecond, want: 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining := make(chan time.Duration, 1)
+			taskFunc := func(ctx context.Context) error {
+				deadline, ok := ctx.Deadline()
+				assert.True(t, ok)
+				select {
+				case remaining <- time.Until(deadline):
+				default:
+				}
+				return nil
+			}
+
+			s := scheduler.NewScheduler(zap.NewNop(), tt.interval, taskFunc)
+			assert.NoError(t, s.Start(context.Background()))
+
+			got := <-remaining
+			assert.NoError(t, s.Stop())
+			assert.InDelta(t, tt.want, got, float64(20*time.Millisecond))
+		})
+	}
+}
+
 func TestScheduler_ContextCancellation(t *testing.T) {
 	var mu sync.Mutex
 	taskCalls := 0
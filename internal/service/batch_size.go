@@ -0,0 +1,105 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/popeskul/insdr-messenger/internal/api"
+	"github.com/popeskul/insdr-messenger/internal/config"
+)
+
+// batchRun is what a finished batch tells about the backlog and the
+// health of the providers.
+type batchRun struct {
+	// Backlog is the number of messages still queued after the batch.
+	Backlog int64
+	Sent    int
+	Failed  int
+	// Latency is the average time a send took.
+	Latency time.Duration
+	Breaker api.HealthResponseCircuitBreakerState
+}
+
+// batchController sizes batches: the size doubles while the backlog is
+// deeper than a batch and sends are fast and succeed, and halves when a
+// breaker is not closed, sends fail or their latency exceeds the target
+// or rises sharply. The size stays within min and max.
+type batchController struct {
+	min           int
+	max           int
+	targetLatency time.Duration
+	maxErrorRate  float64
+
+	mu   sync.Mutex
+	size int
+	// latency is a moving average of the send latency of recent batches.
+	latency time.Duration
+}
+
+// newBatchController returns a controller for cfg, or nil when batches
+// have a fixed size.
+func newBatchController(cfg config.SchedulerConfig) *batchController {
+	if !cfg.AdaptiveBatch {
+		return nil
+	}
+
+	minSize := max(cfg.MinBatchSize, 1)
+	maxSize := max(cfg.MaxBatchSize, minSize)
+	return &batchController{
+		min:           minSize,
+		max:           maxSize,
+		targetLatency: time.Duration(cfg.TargetLatencyMs) * time.Millisecond,
+		maxErrorRate:  cfg.MaxErrorRate,
+		size:          min(max(cfg.BatchSize, minSize), maxSize),
+	}
+}
+
+// Size returns the current batch size.
+func (c *batchController) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.size
+}
+
+// observe resizes batches after run and returns the new size with the
+// reason for a change, empty when the size is kept.
+func (c *batchController) observe(run batchRun) (size int, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sends := run.Sent + run.Failed
+	rising := false
+	if sends > 0 {
+		rising = c.latency > 0 && run.Latency > c.latency*3/2
+		if c.latency == 0 {
+			c.latency = run.Latency
+		} else {
+			c.latency = (3*c.latency + run.Latency) / 4
+		}
+	}
+
+	switch {
+	case run.Breaker != api.Closed:
+		reason = "circuit breaker " + string(run.Breaker)
+	case sends == 0:
+		return c.size, ""
+	case float64(run.Failed)/float64(sends) > c.maxErrorRate:
+		reason = "error rate above limit"
+	case c.targetLatency > 0 && run.Latency > c.targetLatency:
+		reason = "latency above target"
+	case rising:
+		reason = "latency rising"
+	case run.Backlog > int64(c.size) && c.size < c.max:
+		c.size = min(c.size*2, c.max)
+		return c.size, "backlog"
+	default:
+		return c.size, ""
+	}
+
+	if c.size == c.min {
+		return c.size, ""
+	}
+	c.size = max(c.size/2, c.min)
+	return c.size, reason
+}
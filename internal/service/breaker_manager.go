@@ -0,0 +1,122 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/api"
+	"github.com/popeskul/insdr-messenger/internal/config"
+)
+
+// breakerKey identifies a circuit breaker. Country is empty unless breakers
+// are kept per destination.
+type breakerKey struct {
+	provider string
+	country  string
+}
+
+func (k breakerKey) name() string {
+	if k.country == "" {
+		return k.provider + "-circuit-breaker"
+	}
+	return k.provider + "-" + k.country + "-circuit-breaker"
+}
+
+// breakerManager lazily creates circuit breakers keyed by provider and,
+// when enabled, destination country, so a failing provider or destination
+// does not stop the rest of the traffic.
+type breakerManager struct {
+	cfg    config.CircuitBreakerConfig
+	logger *zap.Logger
+	// onStateChange, when set, is registered with every new breaker.
+	onStateChange func(key breakerKey, from, to api.HealthResponseCircuitBreakerState)
+
+	mu       sync.Mutex
+	breakers map[breakerKey]*CircuitBreaker
+}
+
+func newBreakerManager(cfg config.CircuitBreakerConfig, logger *zap.Logger) *breakerManager {
+	return &breakerManager{
+		cfg:      cfg,
+		logger:   logger,
+		breakers: make(map[breakerKey]*CircuitBreaker),
+	}
+}
+
+// Get returns the breaker guarding provider for the given destination
+// country, creating it on first use.
+func (m *breakerManager) Get(provider, country string) *CircuitBreaker {
+	key := breakerKey{provider: provider}
+	if m.cfg.PerDestination {
+		key.country = country
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cb, ok := m.breakers[key]; ok {
+		return cb
+	}
+
+	settings := m.cfg.For(key.provider, key.country)
+	cb := NewNamedCircuitBreaker(key.name(), &settings, m.logger)
+	if m.onStateChange != nil {
+		onStateChange := m.onStateChange
+		cb.OnStateChange(func(from, to api.HealthResponseCircuitBreakerState) {
+			onStateChange(key, from, to)
+		})
+	}
+	m.breakers[key] = cb
+	return cb
+}
+
+// Statuses returns the state of every breaker of provider ordered by
+// country.
+func (m *breakerManager) Statuses(provider string) []BreakerStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var statuses []BreakerStatus
+	for key, cb := range m.breakers {
+		if key.provider != provider {
+			continue
+		}
+		requests, failures := cb.GetCounts()
+		statuses = append(statuses, BreakerStatus{
+			Name:     cb.Name(),
+			Country:  key.country,
+			State:    cb.GetState(),
+			Requests: requests,
+			Failures: failures,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Country < statuses[j].Country })
+	return statuses
+}
+
+// combineStates reduces breaker states to one: open when all of them are
+// open, half-open when some are open or half-open, closed otherwise.
+func combineStates(states []api.HealthResponseCircuitBreakerState) api.HealthResponseCircuitBreakerState {
+	open, degraded := 0, 0
+	for _, state := range states {
+		switch state {
+		case api.Open:
+			open++
+			degraded++
+		case api.HalfOpen:
+			degraded++
+		}
+	}
+
+	switch {
+	case len(states) > 0 && open == len(states):
+		return api.Open
+	case degraded > 0:
+		return api.HalfOpen
+	default:
+		return api.Closed
+	}
+}
@@ -12,16 +12,35 @@ import (
 
 	"github.com/popeskul/insdr-messenger/internal/api"
 	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/provider"
 )
 
+// callerDoneError is a failure of a call the caller gave up on, which says
+// nothing about the health of the provider.
+type callerDoneError struct {
+	err error
+}
+
+func (e *callerDoneError) Error() string {
+	return e.err.Error()
+}
+
 type CircuitBreaker struct {
-	cb     *gobreaker.CircuitBreaker
-	logger *zap.Logger
+	cb            *gobreaker.CircuitBreaker
+	logger        *zap.Logger
+	onStateChange func(from, to api.HealthResponseCircuitBreakerState)
 }
 
 func NewCircuitBreaker(cfg *config.CircuitBreakerConfig, logger *zap.Logger) *CircuitBreaker {
+	return NewNamedCircuitBreaker("webhook-circuit-breaker", cfg, logger)
+}
+
+// NewNamedCircuitBreaker creates a circuit breaker with the given name.
+func NewNamedCircuitBreaker(name string, cfg *config.CircuitBreakerConfig, logger *zap.Logger) *CircuitBreaker {
+	breaker := &CircuitBreaker{logger: logger}
+
 	settings := gobreaker.Settings{
-		Name:        "webhook-circuit-breaker",
+		Name:        name,
 		MaxRequests: cfg.MaxRequests,
 		Interval:    time.Duration(cfg.Interval) * time.Second,
 		Timeout:     time.Duration(cfg.Timeout) * time.Second,
@@ -35,36 +54,55 @@ func NewCircuitBreaker(cfg *config.CircuitBreakerConfig, logger *zap.Logger) *Ci
 				zap.String("from", from.String()),
 				zap.String("to", to.String()),
 			)
+			if breaker.onStateChange != nil {
+				breaker.onStateChange(toAPIState(from), toAPIState(to))
+			}
 		},
+		// Only provider health trips the breaker: permanent rejections
+		// of a message, such as an invalid number, do not.
 		IsSuccessful: func(err error) bool {
-			return err == nil
+			var done *callerDoneError
+			return !provider.IsTransient(err) || errors.As(err, &done)
 		},
 	}
 
-	return &CircuitBreaker{
-		cb:     gobreaker.NewCircuitBreaker(settings),
-		logger: logger,
-	}
+	breaker.cb = gobreaker.NewCircuitBreaker(settings)
+	return breaker
+}
+
+// OnStateChange registers fn to be called on every state change. It must
+// be set before the breaker is used; fn runs with the breaker locked.
+func (cb *CircuitBreaker) OnStateChange(fn func(from, to api.HealthResponseCircuitBreakerState)) {
+	cb.onStateChange = fn
 }
 
-// Execute runs the given function through the circuit breaker.
+// Execute runs the given function through the circuit breaker. Nothing
+// runs once ctx is done, and failures after ctx ended are not counted
+// against the breaker.
 func (cb *CircuitBreaker) Execute(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	_, err := cb.cb.Execute(func() (interface{}, error) {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-			return nil, fn()
+		err := fn()
+		if err != nil && ctx.Err() != nil {
+			return nil, &callerDoneError{err: err}
 		}
+		return nil, err
 	})
 
+	var done *callerDoneError
+	if errors.As(err, &done) {
+		return done.err
+	}
 	if err != nil {
 		if errors.Is(err, gobreaker.ErrOpenState) {
-			cb.logger.Warn("Circuit breaker is open, request blocked")
+			cb.logger.Warn("Circuit breaker is open, request blocked", zap.String("name", cb.cb.Name()))
 			return fmt.Errorf("service unavailable: circuit breaker is open")
 		}
 		if errors.Is(err, gobreaker.ErrTooManyRequests) {
-			cb.logger.Warn("Circuit breaker: too many requests")
+			cb.logger.Warn("Circuit breaker: too many requests", zap.String("name", cb.cb.Name()))
 			return fmt.Errorf("service unavailable: too many requests")
 		}
 		return err
@@ -73,9 +111,17 @@ func (cb *CircuitBreaker) Execute(ctx context.Context, fn func() error) error {
 	return nil
 }
 
+// Name returns the name of the circuit breaker.
+func (cb *CircuitBreaker) Name() string {
+	return cb.cb.Name()
+}
+
 // GetState returns the current state of the circuit breaker.
 func (cb *CircuitBreaker) GetState() api.HealthResponseCircuitBreakerState {
-	state := cb.cb.State()
+	return toAPIState(cb.cb.State())
+}
+
+func toAPIState(state gobreaker.State) api.HealthResponseCircuitBreakerState {
 	switch state {
 	case gobreaker.StateClosed:
 		return api.Closed
@@ -8,6 +8,7 @@ import (
 
 	"github.com/popeskul/insdr-messenger/internal/api"
 	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/provider"
 	"github.com/popeskul/insdr-messenger/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -407,3 +408,88 @@ func TestCircuitBreaker_ContextCancellation(t *testing.T) {
 	require.Error(t, err)
 	assert.Equal(t, context.Canceled, err)
 }
+
+func TestCircuitBreaker_CallerDoneNotCounted(t *testing.T) {
+	cfg := &config.CircuitBreakerConfig{
+		MaxRequests:      1,
+		Interval:         10,
+		Timeout:          60,
+		FailureRatio:     0.5,
+		ConsecutiveFails: 1,
+	}
+	cb := service.NewCircuitBreaker(cfg, zap.NewNop())
+
+	// Calls cut off by the caller do not trip the breaker.
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		err := cb.Execute(ctx, func() error {
+			cancel()
+			return ctx.Err()
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+	_, failures := cb.GetCounts()
+	assert.Zero(t, failures)
+	assert.Equal(t, api.Closed, cb.GetState())
+
+	// Nothing runs once the caller is gone.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err := cb.Execute(ctx, func() error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+}
+
+func TestCircuitBreaker_PermanentErrorsNotCounted(t *testing.T) {
+	cfg := &config.CircuitBreakerConfig{
+		MaxRequests:      1,
+		Interval:         10,
+		Timeout:          60,
+		FailureRatio:     0.25,
+		ConsecutiveFails: 1,
+	}
+	cb := service.NewCircuitBreaker(cfg, zap.NewNop())
+
+	for i := 0; i < 3; i++ {
+		err := cb.Execute(context.Background(), func() error {
+			return provider.Permanent(400, "invalid destination")
+		})
+		assert.Error(t, err)
+	}
+	_, failures := cb.GetCounts()
+	assert.Zero(t, failures)
+	assert.Equal(t, api.Closed, cb.GetState())
+
+	err := cb.Execute(context.Background(), func() error {
+		return provider.Transient(503, "unavailable")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, api.Open, cb.GetState())
+}
+
+func TestCircuitBreaker_OnStateChange(t *testing.T) {
+	cfg := &config.CircuitBreakerConfig{
+		MaxRequests:      1,
+		Interval:         10,
+		Timeout:          60,
+		FailureRatio:     0.5,
+		ConsecutiveFails: 2,
+	}
+	cb := service.NewNamedCircuitBreaker("test", cfg, zap.NewNop())
+
+	var changes [][2]api.HealthResponseCircuitBreakerState
+	cb.OnStateChange(func(from, to api.HealthResponseCircuitBreakerState) {
+		changes = append(changes, [2]api.HealthResponseCircuitBreakerState{from, to})
+	})
+
+	for i := 0; i < 2; i++ {
+		_ = cb.Execute(context.Background(), func() error { return errors.New("test error") })
+	}
+
+	require.Len(t, changes, 1)
+	assert.Equal(t, [2]api.HealthResponseCircuitBreakerState{api.Closed, api.Open}, changes[0])
+}
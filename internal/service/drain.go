@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
+
+// drainState tracks a drain of the message service.
+type drainState struct {
+	active atomic.Bool
+	// batches is held shared by running batches so that a drain can wait
+	// for them to end.
+	batches sync.RWMutex
+
+	mu     sync.Mutex
+	report DrainReport
+}
+
+// recordRelease notes the outcome of handing claims back while draining.
+func (d *drainState) recordRelease(released, abandoned []int64) {
+	if !d.active.Load() {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.report.Released = append(d.report.Released, released...)
+	d.report.Abandoned = append(d.report.Abandoned, abandoned...)
+}
+
+// recordSend notes a send that ended while draining.
+func (d *drainState) recordSend(id int64, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if errors.Is(err, errSendAborted) {
+		d.report.Abandoned = append(d.report.Abandoned, id)
+		return
+	}
+	d.report.Completed++
+}
+
+// Drain stops claiming messages and waits up to timeout for running
+// batches. Sends in flight may finish; messages of a batch that were not
+// started yet go back to the queue. When the timeout passes, in-flight
+// provider calls are cancelled and their messages become
+// accepted_unconfirmed, since the provider may have accepted them. The
+// service does not claim messages after a drain.
+func (s *messageService) Drain(timeout time.Duration) *DrainReport {
+	start := time.Now()
+	s.drain.active.Store(true)
+
+	idle := make(chan struct{})
+	go func() {
+		s.drain.batches.Lock()
+		defer s.drain.batches.Unlock()
+		close(idle)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	timedOut := false
+	select {
+	case <-idle:
+	case <-timer.C:
+		timedOut = true
+		s.logger.Warn("Drain timed out, cancelling in-flight sends", zap.Duration("timeout", timeout))
+		s.cancelSends()
+		<-idle
+	}
+
+	s.drain.mu.Lock()
+	defer s.drain.mu.Unlock()
+
+	report := s.drain.report
+	report.TimedOut = timedOut
+	report.Duration = time.Since(start)
+	return &report
+}
+
+// releaseClaims hands claimed messages that were not sent back to the
+// queue, when draining or when the batch was cancelled. Messages that
+// cannot be released stay processing.
+func (s *messageService) releaseClaims(ctx context.Context, messages []*models.Message) {
+	ids := make([]int64, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+
+	released, err := s.queue.Release(ctx, ids)
+	if err != nil {
+		s.logger.Error("Failed to release claimed messages",
+			zap.Int64s("messageIDs", ids),
+			zap.Error(err))
+		s.drain.recordRelease(nil, ids)
+		return
+	}
+
+	isReleased := make(map[int64]bool, len(released))
+	for _, id := range released {
+		isReleased[id] = true
+	}
+	for _, msg := range messages {
+		if isReleased[msg.ID] {
+			s.publishStatus(ctx, msg, models.MessageStatusQueued, "", "", nil)
+		}
+	}
+
+	s.drain.recordRelease(released, nil)
+	s.logger.Info("Released unsent messages", zap.Int64s("messageIDs", released))
+}
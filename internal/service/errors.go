@@ -0,0 +1,30 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/popeskul/insdr-messenger/internal/repository"
+)
+
+// TransitionError is returned when a message cannot move to a status from
+// its current one.
+type TransitionError = repository.TransitionError
+
+var (
+	ErrMessageNotFound         = repository.ErrMessageNotFound
+	ErrInvalidStatusTransition = repository.ErrInvalidStatusTransition
+	ErrSubscriptionNotFound    = repository.ErrSubscriptionNotFound
+	ErrClaimLost               = repository.ErrClaimLost
+)
+
+// ErrInvalidSubscription is returned for subscriptions with a bad URL,
+// event type or secret.
+var ErrInvalidSubscription = errors.New("invalid subscription")
+
+// ErrInvalidMessage is returned for messages without a phone number or
+// with content that is empty or too long.
+var ErrInvalidMessage = errors.New("invalid message")
+
+// errSendAborted is returned for sends cut off when a drain runs out of
+// time or the context of the batch ends.
+var errSendAborted = errors.New("send aborted")
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/api"
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/repository"
+	"github.com/popeskul/insdr-messenger/internal/scheduler"
+	"github.com/popeskul/insdr-messenger/internal/signing"
+)
+
+// Headers identifying the event of a delivery request.
+const (
+	EventIDHeader   = "X-Event-ID"
+	EventTypeHeader = "X-Event-Type"
+)
+
+const (
+	minSecretLength = 16
+	// maxEventError bounds the response body stored as the delivery error.
+	maxEventError = 1024
+)
+
+type eventService struct {
+	cfg        config.EventsConfig
+	repo       repository.Repository
+	httpClient *http.Client
+	dispatcher *scheduler.Scheduler
+	logger     *zap.Logger
+}
+
+func NewEventService(
+	cfg *config.Config,
+	repo repository.Repository,
+	logger *zap.Logger,
+) EventService {
+	svc := &eventService{
+		cfg:  cfg.Events,
+		repo: repo,
+		httpClient: &http.Client{
+			Timeout: time.Duration(cfg.Events.Timeout) * time.Second,
+		},
+		logger: logger,
+	}
+
+	interval := time.Duration(cfg.Events.DispatchInterval) * time.Second
+	svc.dispatcher = scheduler.NewScheduler(logger, interval, func(ctx context.Context) error {
+		return svc.DispatchEvents(ctx)
+	})
+
+	return svc
+}
+
+// CreateSubscription validates and stores a subscription. A signing secret
+// is generated when none is given; it is only part of this response.
+func (s *eventService) CreateSubscription(ctx context.Context, req api.CreateSubscriptionRequest) (*api.Subscription, error) {
+	target, err := url.Parse(req.Url)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https url", ErrInvalidSubscription)
+	}
+
+	if len(req.EventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", ErrInvalidSubscription)
+	}
+	eventTypes := make([]string, 0, len(req.EventTypes))
+	seen := make(map[models.EventType]bool, len(req.EventTypes))
+	for _, t := range req.EventTypes {
+		if !models.IsValidEventType(t) {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidSubscription, t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			eventTypes = append(eventTypes, string(t))
+		}
+	}
+
+	var secret string
+	if req.Secret != nil {
+		if len(*req.Secret) < minSecretLength {
+			return nil, fmt.Errorf("%w: secret must be at least %d characters", ErrInvalidSubscription, minSecretLength)
+		}
+		secret = *req.Secret
+	} else if secret, err = generateSecret(); err != nil {
+		return nil, err
+	}
+
+	sub := &models.Subscription{URL: req.Url, EventTypes: eventTypes, Secret: secret}
+	if err := s.repo.Event().CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Subscription created",
+		zap.Int64("subscriptionID", sub.ID),
+		zap.String("url", sub.URL),
+		zap.Strings("eventTypes", eventTypes))
+
+	result := toAPISubscription(sub)
+	result.Secret = &sub.Secret
+	return &result, nil
+}
+
+// ListSubscriptions returns all subscriptions without their secrets.
+func (s *eventService) ListSubscriptions(ctx context.Context) (*api.SubscriptionListResponse, error) {
+	subscriptions, err := s.repo.Event().GetSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]api.Subscription, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		result = append(result, toAPISubscription(sub))
+	}
+
+	return &api.SubscriptionListResponse{Subscriptions: result}, nil
+}
+
+// DeleteSubscription removes a subscription and its pending events.
+func (s *eventService) DeleteSubscription(ctx context.Context, id int64) error {
+	if err := s.repo.Event().DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("Subscription deleted", zap.Int64("subscriptionID", id))
+	return nil
+}
+
+// GetDeadLetters returns the events that could not be delivered to a
+// subscription.
+func (s *eventService) GetDeadLetters(ctx context.Context, id int64, limit int) (*api.DeadLetterListResponse, error) {
+	if _, err := s.repo.Event().GetSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+
+	events, err := s.repo.Event().GetDeadLetters(ctx, id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters := make([]api.DeadLetter, 0, len(events))
+	for _, ev := range events {
+		var data map[string]interface{}
+		if err := json.Unmarshal(ev.Payload, &data); err != nil {
+			return nil, fmt.Errorf("failed to decode payload of event %d: %w", ev.ID, err)
+		}
+
+		deadLetter := api.DeadLetter{
+			Event: api.Event{
+				Id:        ev.ID,
+				Type:      ev.EventType,
+				CreatedAt: ev.CreatedAt,
+				Data:      data,
+			},
+			Attempts: ev.Attempts,
+			FailedAt: ev.UpdatedAt,
+		}
+		if ev.LastError.Valid {
+			deadLetter.LastError = &ev.LastError.String
+		}
+
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	return &api.DeadLetterListResponse{
+		SubscriptionId: id,
+		DeadLetters:    deadLetters,
+	}, nil
+}
+
+// Publish queues an event for every subscription of its type. Message
+// status events are queued by the database and need not be published.
+func (s *eventService) Publish(ctx context.Context, eventType models.EventType, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event data: %w", err)
+	}
+
+	return s.repo.Event().PublishEvent(ctx, eventType, payload)
+}
+
+// DispatchEvents delivers the events that are due. Deliveries run
+// concurrently; failed ones are retried with exponential backoff until
+// they run out of attempts and become dead letters.
+func (s *eventService) DispatchEvents(ctx context.Context) error {
+	// The lease keeps other dispatchers away from the claimed events while
+	// they are delivered, and lets them retry if this one dies.
+	lease := 2 * s.httpClient.Timeout
+	events, err := s.repo.Event().ClaimEvents(ctx, s.cfg.BatchSize, lease)
+	if err != nil {
+		return err
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.logger.Info("Dispatching events", zap.Int("count", len(events)))
+
+	var wg sync.WaitGroup
+	for _, ev := range events {
+		wg.Add(1)
+		go func(ev *models.OutboxEvent) {
+			defer wg.Done()
+			s.dispatchEvent(ctx, ev)
+		}(ev)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// dispatchEvent delivers a single event and records the outcome, also when
+// ctx ended during the delivery.
+func (s *eventService) dispatchEvent(ctx context.Context, ev *models.OutboxEvent) {
+	deliveryErr := s.deliver(ctx, ev)
+	ctx = context.WithoutCancel(ctx)
+	if deliveryErr == nil {
+		if err := s.repo.Event().MarkEventDelivered(ctx, ev.ID); err != nil {
+			s.logger.Error("Failed to record event delivery", zap.Int64("eventID", ev.ID), zap.Error(err))
+		}
+		return
+	}
+
+	attempts := ev.Attempts + 1
+	if attempts >= s.cfg.MaxAttempts {
+		s.logger.Warn("Event moved to dead letters",
+			zap.Int64("eventID", ev.ID),
+			zap.Int64("subscriptionID", ev.SubscriptionID),
+			zap.Int("attempts", attempts),
+			zap.Error(deliveryErr))
+		if err := s.repo.Event().MarkEventDead(ctx, ev.ID, deliveryErr.Error()); err != nil {
+			s.logger.Error("Failed to record dead event", zap.Int64("eventID", ev.ID), zap.Error(err))
+		}
+		return
+	}
+
+	delay := s.retryDelay(attempts)
+	s.logger.Warn("Event delivery failed, retrying",
+		zap.Int64("eventID", ev.ID),
+		zap.Int64("subscriptionID", ev.SubscriptionID),
+		zap.Int("attempts", attempts),
+		zap.Duration("retryIn", delay),
+		zap.Error(deliveryErr))
+	if err := s.repo.Event().RetryEvent(ctx, ev.ID, deliveryErr.Error(), time.Now().Add(delay)); err != nil {
+		s.logger.Error("Failed to reschedule event", zap.Int64("eventID", ev.ID), zap.Error(err))
+	}
+}
+
+// retryDelay returns the backoff before the next attempt, doubling the
+// configured delay with every failed attempt up to the maximum.
+func (s *eventService) retryDelay(attempts int) time.Duration {
+	delay := time.Duration(s.cfg.Backoff) * time.Second
+	maxDelay := time.Duration(s.cfg.MaxBackoff) * time.Second
+
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// eventEnvelope is the body POSTed to subscriptions.
+type eventEnvelope struct {
+	ID        int64            `json:"id"`
+	Type      models.EventType `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      json.RawMessage  `json:"data"`
+}
+
+// deliver POSTs the signed event to its subscription. Any status other
+// than 2xx counts as a failure.
+func (s *eventService) deliver(ctx context.Context, ev *models.OutboxEvent) error {
+	body, err := json.Marshal(eventEnvelope{
+		ID:        ev.ID,
+		Type:      ev.EventType,
+		CreatedAt: ev.CreatedAt,
+		Data:      ev.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ev.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, strconv.FormatInt(ev.ID, 10))
+	req.Header.Set(EventTypeHeader, string(ev.EventType))
+	signing.NewSigner([]string{ev.Secret}).Sign(req, body)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send event: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.logger.Warn("Failed to close response body", zap.Error(err))
+		}
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxEventError))
+		return fmt.Errorf("subscriber returned status %d: %s", resp.StatusCode, respBody)
+	}
+
+	return nil
+}
+
+// Start starts the event dispatcher.
+func (s *eventService) Start() error {
+	return s.dispatcher.Start(context.Background())
+}
+
+// Stop stops the event dispatcher after the current batch. Undelivered
+// events stay in the outbox.
+func (s *eventService) Stop() error {
+	err := s.dispatcher.Stop()
+	if errors.Is(err, scheduler.ErrSchedulerNotRunning) {
+		return nil
+	}
+	return err
+}
+
+func toAPISubscription(sub *models.Subscription) api.Subscription {
+	eventTypes := make([]api.EventType, len(sub.EventTypes))
+	for i, t := range sub.EventTypes {
+		eventTypes[i] = api.EventType(t)
+	}
+
+	return api.Subscription{
+		Id:         sub.ID,
+		Url:        sub.URL,
+		EventTypes: eventTypes,
+		CreatedAt:  sub.CreatedAt,
+	}
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,296 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/popeskul/insdr-messenger/internal/api"
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/repository"
+	"github.com/popeskul/insdr-messenger/internal/repository/mocks"
+	"github.com/popeskul/insdr-messenger/internal/service"
+	"github.com/popeskul/insdr-messenger/internal/signing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+var eventsConfig = &config.Config{
+	Events: config.EventsConfig{
+		DispatchInterval: 5,
+		BatchSize:        10,
+		Timeout:          1,
+		MaxAttempts:      5,
+		Backoff:          10,
+		MaxBackoff:       30,
+	},
+}
+
+func newEventService(ctrl *gomock.Controller) (service.EventService, *mocks.MockEventRepository) {
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockEventRepo := mocks.NewMockEventRepository(ctrl)
+	mockRepo.EXPECT().Event().Return(mockEventRepo).AnyTimes()
+
+	return service.NewEventService(eventsConfig, mockRepo, zap.NewNop()), mockEventRepo
+}
+
+func TestEventService_CreateSubscription(t *testing.T) {
+	secret := "0123456789abcdef"
+	shortSecret := "short"
+
+	tests := []struct {
+		name           string
+		req            api.CreateSubscriptionRequest
+		wantEventTypes []string
+		wantSecret     string
+		wantErr        bool
+	}{
+		{
+			name:           "with secret",
+			req:            api.CreateSubscriptionRequest{Url: "https://orders.example.com/hooks", EventTypes: []api.EventType{models.EventMessageSent, models.EventMessageSent, models.EventSchedulerStopped}, Secret: &secret},
+			wantEventTypes: []string{"message.sent", "scheduler.stopped"},
+			wantSecret:     secret,
+		},
+		{
+			name:           "generated secret",
+			req:            api.CreateSubscriptionRequest{Url: "http://orders.internal/hooks", EventTypes: []api.EventType{models.EventMessageFailed}},
+			wantEventTypes: []string{"message.failed"},
+		},
+		{
+			name:    "relative url",
+			req:     api.CreateSubscriptionRequest{Url: "/hooks", EventTypes: []api.EventType{models.EventMessageSent}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported scheme",
+			req:     api.CreateSubscriptionRequest{Url: "ftp://orders.example.com", EventTypes: []api.EventType{models.EventMessageSent}},
+			wantErr: true,
+		},
+		{
+			name:    "no event types",
+			req:     api.CreateSubscriptionRequest{Url: "https://orders.example.com/hooks"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown event type",
+			req:     api.CreateSubscriptionRequest{Url: "https://orders.example.com/hooks", EventTypes: []api.EventType{"message.read"}},
+			wantErr: true,
+		},
+		{
+			name:    "short secret",
+			req:     api.CreateSubscriptionRequest{Url: "https://orders.example.com/hooks", EventTypes: []api.EventType{models.EventMessageSent}, Secret: &shortSecret},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			eventService, mockEventRepo := newEventService(ctrl)
+
+			if !tt.wantErr {
+				mockEventRepo.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, sub *models.Subscription) error {
+					assert.Equal(t, tt.req.Url, sub.URL)
+					assert.Equal(t, tt.wantEventTypes, []string(sub.EventTypes))
+					sub.ID = 1
+					return nil
+				})
+			}
+
+			result, err := eventService.CreateSubscription(context.Background(), tt.req)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, service.ErrInvalidSubscription)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), result.Id)
+			require.NotNil(t, result.Secret)
+			if tt.wantSecret != "" {
+				assert.Equal(t, tt.wantSecret, *result.Secret)
+			} else {
+				assert.Len(t, *result.Secret, 64)
+			}
+		})
+	}
+}
+
+func TestEventService_ListSubscriptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	eventService, mockEventRepo := newEventService(ctrl)
+	mockEventRepo.EXPECT().GetSubscriptions(gomock.Any()).Return([]*models.Subscription{
+		{ID: 1, URL: "https://orders.example.com/hooks", EventTypes: []string{"message.sent"}, Secret: "0123456789abcdef"},
+	}, nil)
+
+	result, err := eventService.ListSubscriptions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.Subscriptions, 1)
+	assert.Equal(t, []api.EventType{models.EventMessageSent}, result.Subscriptions[0].EventTypes)
+	assert.Nil(t, result.Subscriptions[0].Secret)
+}
+
+func TestEventService_GetDeadLetters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	eventService, mockEventRepo := newEventService(ctrl)
+
+	failedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockEventRepo.EXPECT().GetSubscription(gomock.Any(), int64(1)).Return(&models.Subscription{ID: 1}, nil)
+	mockEventRepo.EXPECT().GetDeadLetters(gomock.Any(), int64(1), 20).Return([]*models.OutboxEvent{
+		{
+			ID:        7,
+			EventType: models.EventMessageFailed,
+			Payload:   []byte(`{"id": 42, "status": "failed"}`),
+			Status:    models.EventStatusDead,
+			Attempts:  3,
+			LastError: sql.NullString{String: "subscriber returned status 500", Valid: true},
+			UpdatedAt: failedAt,
+		},
+	}, nil)
+	mockEventRepo.EXPECT().GetSubscription(gomock.Any(), int64(2)).Return(nil, repository.ErrSubscriptionNotFound)
+
+	result, err := eventService.GetDeadLetters(context.Background(), 1, 20)
+	require.NoError(t, err)
+	require.Len(t, result.DeadLetters, 1)
+	assert.Equal(t, int64(7), result.DeadLetters[0].Event.Id)
+	assert.Equal(t, float64(42), result.DeadLetters[0].Event.Data["id"])
+	assert.Equal(t, 3, result.DeadLetters[0].Attempts)
+	assert.Equal(t, failedAt, result.DeadLetters[0].FailedAt)
+	assert.Equal(t, "subscriber returned status 500", *result.DeadLetters[0].LastError)
+
+	_, err = eventService.GetDeadLetters(context.Background(), 2, 20)
+	assert.ErrorIs(t, err, service.ErrSubscriptionNotFound)
+}
+
+func TestEventService_Publish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	eventService, mockEventRepo := newEventService(ctrl)
+	mockEventRepo.EXPECT().PublishEvent(gomock.Any(), models.EventSchedulerStopped, []byte(`{"reason":"shutdown"}`)).Return(nil)
+
+	err := eventService.Publish(context.Background(), models.EventSchedulerStopped, map[string]string{"reason": "shutdown"})
+	assert.NoError(t, err)
+}
+
+func TestEventService_DispatchEvents(t *testing.T) {
+	secret := "0123456789abcdef"
+
+	tests := []struct {
+		name       string
+		statusCode int
+		attempts   int
+		setupMocks func(*testing.T, *mocks.MockEventRepository)
+	}{
+		{
+			name:       "delivered",
+			statusCode: http.StatusNoContent,
+			setupMocks: func(t *testing.T, m *mocks.MockEventRepository) {
+				m.EXPECT().MarkEventDelivered(gomock.Any(), int64(7)).Return(nil)
+			},
+		},
+		{
+			name:       "retried with backoff",
+			statusCode: http.StatusServiceUnavailable,
+			attempts:   1,
+			setupMocks: func(t *testing.T, m *mocks.MockEventRepository) {
+				m.EXPECT().RetryEvent(gomock.Any(), int64(7), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ int64, errorMsg string, next time.Time) error {
+						assert.Contains(t, errorMsg, "status 503")
+						assert.WithinDuration(t, time.Now().Add(20*time.Second), next, 2*time.Second)
+						return nil
+					})
+			},
+		},
+		{
+			name:       "backoff capped",
+			statusCode: http.StatusInternalServerError,
+			attempts:   2,
+			setupMocks: func(t *testing.T, m *mocks.MockEventRepository) {
+				m.EXPECT().RetryEvent(gomock.Any(), int64(7), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ int64, _ string, next time.Time) error {
+						assert.WithinDuration(t, time.Now().Add(30*time.Second), next, 2*time.Second)
+						return nil
+					})
+			},
+		},
+		{
+			name:       "dead after last attempt",
+			statusCode: http.StatusBadRequest,
+			attempts:   4,
+			setupMocks: func(t *testing.T, m *mocks.MockEventRepository) {
+				m.EXPECT().MarkEventDead(gomock.Any(), int64(7), gomock.Any()).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				assert.Equal(t, "7", r.Header.Get(service.EventIDHeader))
+				assert.Equal(t, "message.delivered", r.Header.Get(service.EventTypeHeader))
+				assert.NoError(t, signing.Verify([]string{secret},
+					r.Header.Get(signing.SignatureHeader),
+					r.Header.Get(signing.TimestampHeader),
+					body, time.Now(), time.Minute))
+
+				var event api.Event
+				require.NoError(t, json.Unmarshal(body, &event))
+				assert.Equal(t, int64(7), event.Id)
+				assert.Equal(t, models.EventMessageDelivered, event.Type)
+				assert.Equal(t, "delivered", event.Data["status"])
+
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			eventService, mockEventRepo := newEventService(ctrl)
+			mockEventRepo.EXPECT().ClaimEvents(gomock.Any(), 10, 2*time.Second).Return([]*models.OutboxEvent{
+				{
+					ID:             7,
+					SubscriptionID: 1,
+					EventType:      models.EventMessageDelivered,
+					Payload:        []byte(`{"id": 42, "status": "delivered"}`),
+					Status:         models.EventStatusPending,
+					Attempts:       tt.attempts,
+					CreatedAt:      time.Now(),
+					URL:            server.URL,
+					Secret:         secret,
+				},
+			}, nil)
+			tt.setupMocks(t, mockEventRepo)
+
+			assert.NoError(t, eventService.DispatchEvents(context.Background()))
+		})
+	}
+}
+
+func TestEventService_DispatchEvents_ClaimError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	eventService, mockEventRepo := newEventService(ctrl)
+	mockEventRepo.EXPECT().ClaimEvents(gomock.Any(), 10, 2*time.Second).Return(nil, errors.New("database error"))
+
+	assert.Error(t, eventService.DispatchEvents(context.Background()))
+}
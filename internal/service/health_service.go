@@ -32,7 +32,7 @@ func NewHealthService(
 	}
 }
 
-func (s *healthService) GetHealth() *HealthStatus {
+func (s *healthService) GetHealth(ctx context.Context) *HealthStatus {
 	status := &HealthStatus{
 		Status: api.Healthy,
 	}
@@ -42,10 +42,11 @@ func (s *healthService) GetHealth() *HealthStatus {
 	} else {
 		status.SchedulerStatus = api.HealthResponseSchedulerStatusStopped
 	}
+	status.SchedulerBatchSize = s.messageService.BatchSize()
 
-	status.DatabaseStatus = s.checkDatabaseHealth()
+	status.DatabaseStatus = s.checkDatabaseHealth(ctx)
 
-	status.RedisStatus = s.checkRedisHealth()
+	status.RedisStatus = s.checkRedisHealth(ctx)
 
 	state, requests, failures := s.messageService.GetCircuitBreakerStatus()
 	status.CircuitBreakerState = state
@@ -56,29 +57,36 @@ func (s *healthService) GetHealth() *HealthStatus {
 		status.CircuitBreakerStatus = "No requests yet"
 	}
 
+	status.Providers = s.messageService.GetProviderStatuses()
+
 	// Determine overall health
 	if status.DatabaseStatus != api.HealthResponseDatabaseStatusConnected || status.RedisStatus != api.HealthResponseRedisStatusConnected {
 		status.Status = api.Unhealthy
 	}
 
-	// If circuit breaker is open, set status to degraded
+	// If any provider's circuit breaker is open, set status to degraded
 	if state == api.Open {
 		status.Status = api.Degraded
 	}
+	for _, p := range status.Providers {
+		if p.State == api.Open {
+			status.Status = api.Degraded
+		}
+	}
 
 	return status
 }
 
-func (s *healthService) checkDatabaseHealth() api.HealthResponseDatabaseStatus {
-	err := s.repo.Ping()
+func (s *healthService) checkDatabaseHealth(ctx context.Context) api.HealthResponseDatabaseStatus {
+	err := s.repo.Ping(ctx)
 	if err != nil {
 		return api.HealthResponseDatabaseStatusDisconnected
 	}
 	return api.HealthResponseDatabaseStatusConnected
 }
 
-func (s *healthService) checkRedisHealth() api.HealthResponseRedisStatus {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+func (s *healthService) checkRedisHealth(ctx context.Context) api.HealthResponseRedisStatus {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
 	if err := s.redisClient.Ping(ctx).Err(); err != nil {
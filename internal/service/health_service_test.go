@@ -1,6 +1,7 @@
 package service_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -31,19 +32,22 @@ func TestHealthService_GetHealth_Success(t *testing.T) {
 
 	// Set up expectations
 	mockScheduler.EXPECT().IsRunning().Return(true)
-	mockRepo.EXPECT().Ping().Return(nil)
+	mockRepo.EXPECT().Ping(gomock.Any()).Return(nil)
+	mockMessage.EXPECT().BatchSize().Return(2)
 	mockMessage.EXPECT().GetCircuitBreakerStatus().Return(api.Closed, uint32(100), uint32(5))
+	mockMessage.EXPECT().GetProviderStatuses().Return(nil)
 
 	// Create health service
 	healthService := service.NewHealthService(mockRepo, redisClient, mockScheduler, mockMessage)
 
 	// Get health status
-	status := healthService.GetHealth()
+	status := healthService.GetHealth(context.Background())
 
 	// Assertions
 	require.NotNil(t, status)
 	assert.Equal(t, api.Unhealthy, status.Status) // Unhealthy because Redis is disconnected
 	assert.Equal(t, api.HealthResponseSchedulerStatusRunning, status.SchedulerStatus)
+	assert.Equal(t, 2, status.SchedulerBatchSize)
 	assert.Equal(t, api.HealthResponseDatabaseStatusConnected, status.DatabaseStatus)
 	assert.Equal(t, api.HealthResponseRedisStatusDisconnected, status.RedisStatus)
 	assert.Equal(t, api.Closed, status.CircuitBreakerState)
@@ -63,8 +67,10 @@ func TestHealthService_GetHealth_Failure(t *testing.T) {
 			name: "scheduler stopped, database connected, circuit breaker closed",
 			setupMocks: func(repo *mocks.MockRepository, scheduler *servicemocks.MockSchedulerService, message *servicemocks.MockMessageService) {
 				scheduler.EXPECT().IsRunning().Return(false)
-				repo.EXPECT().Ping().Return(nil)
+				repo.EXPECT().Ping(gomock.Any()).Return(nil)
+				message.EXPECT().BatchSize().Return(2)
 				message.EXPECT().GetCircuitBreakerStatus().Return(api.Closed, uint32(50), uint32(10))
+				message.EXPECT().GetProviderStatuses().Return(nil)
 			},
 			expectedStatus:          api.Unhealthy, // Redis disconnected
 			expectedSchedulerStatus: api.HealthResponseSchedulerStatusStopped,
@@ -75,8 +81,10 @@ func TestHealthService_GetHealth_Failure(t *testing.T) {
 			name: "database disconnected",
 			setupMocks: func(repo *mocks.MockRepository, scheduler *servicemocks.MockSchedulerService, message *servicemocks.MockMessageService) {
 				scheduler.EXPECT().IsRunning().Return(true)
-				repo.EXPECT().Ping().Return(errors.New("connection failed"))
+				repo.EXPECT().Ping(gomock.Any()).Return(errors.New("connection failed"))
+				message.EXPECT().BatchSize().Return(2)
 				message.EXPECT().GetCircuitBreakerStatus().Return(api.Closed, uint32(0), uint32(0))
+				message.EXPECT().GetProviderStatuses().Return(nil)
 			},
 			expectedStatus:          api.Unhealthy,
 			expectedSchedulerStatus: api.HealthResponseSchedulerStatusRunning,
@@ -87,8 +95,10 @@ func TestHealthService_GetHealth_Failure(t *testing.T) {
 			name: "circuit breaker open",
 			setupMocks: func(repo *mocks.MockRepository, scheduler *servicemocks.MockSchedulerService, message *servicemocks.MockMessageService) {
 				scheduler.EXPECT().IsRunning().Return(true)
-				repo.EXPECT().Ping().Return(nil)
+				repo.EXPECT().Ping(gomock.Any()).Return(nil)
+				message.EXPECT().BatchSize().Return(2)
 				message.EXPECT().GetCircuitBreakerStatus().Return(api.Open, uint32(100), uint32(60))
+				message.EXPECT().GetProviderStatuses().Return(nil)
 			},
 			expectedStatus:          api.Degraded, // Open circuit breaker means degraded
 			expectedSchedulerStatus: api.HealthResponseSchedulerStatusRunning,
@@ -99,8 +109,10 @@ func TestHealthService_GetHealth_Failure(t *testing.T) {
 			name: "everything failing",
 			setupMocks: func(repo *mocks.MockRepository, scheduler *servicemocks.MockSchedulerService, message *servicemocks.MockMessageService) {
 				scheduler.EXPECT().IsRunning().Return(false)
-				repo.EXPECT().Ping().Return(errors.New("db error"))
+				repo.EXPECT().Ping(gomock.Any()).Return(errors.New("db error"))
+				message.EXPECT().BatchSize().Return(2)
 				message.EXPECT().GetCircuitBreakerStatus().Return(api.Open, uint32(1000), uint32(999))
+				message.EXPECT().GetProviderStatuses().Return(nil)
 			},
 			expectedStatus:          api.Degraded, // DB disconnected + open CB = degraded (CB takes precedence)
 			expectedSchedulerStatus: api.HealthResponseSchedulerStatusStopped,
@@ -131,7 +143,7 @@ func TestHealthService_GetHealth_Failure(t *testing.T) {
 			healthService := service.NewHealthService(mockRepo, redisClient, mockScheduler, mockMessage)
 
 			// Get health status
-			status := healthService.GetHealth()
+			status := healthService.GetHealth(context.Background())
 
 			// Assertions
 			require.NotNil(t, status)
@@ -193,14 +205,16 @@ func TestHealthService_CircuitBreakerStatusFormatting(t *testing.T) {
 
 			// Set up expectations
 			mockScheduler.EXPECT().IsRunning().Return(true)
-			mockRepo.EXPECT().Ping().Return(nil)
+			mockRepo.EXPECT().Ping(gomock.Any()).Return(nil)
+			mockMessage.EXPECT().BatchSize().Return(2)
 			mockMessage.EXPECT().GetCircuitBreakerStatus().Return(api.Closed, tt.requests, tt.failures)
+			mockMessage.EXPECT().GetProviderStatuses().Return(nil)
 
 			// Create health service
 			healthService := service.NewHealthService(mockRepo, redisClient, mockScheduler, mockMessage)
 
 			// Get health status
-			status := healthService.GetHealth()
+			status := healthService.GetHealth(context.Background())
 
 			// Assert circuit breaker status formatting
 			assert.Equal(t, tt.expectedCBStatus, status.CircuitBreakerStatus)
@@ -1,19 +1,57 @@
 package service
 
-import "github.com/popeskul/insdr-messenger/internal/api"
+import (
+	"context"
+	"time"
+
+	"github.com/popeskul/insdr-messenger/internal/api"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/routing"
+	"github.com/popeskul/insdr-messenger/internal/stream"
+)
 
 type MessageService interface {
-	SendPendingMessages() error
-	GetSentMessages(page, limit int) (*api.MessageListResponse, error)
+	SendPendingMessages(ctx context.Context) error
+	CreateMessage(ctx context.Context, req api.CreateMessageRequest) (*api.Message, error)
+	ReconcileUnconfirmed(ctx context.Context) error
+	GetSentMessages(ctx context.Context, page, limit int) (*api.MessageListResponse, error)
+	GetMessageByExternalID(ctx context.Context, externalID string) (*api.Message, error)
+	GetMessageHistory(ctx context.Context, id int64) (*api.MessageHistoryResponse, error)
+	GetMessageAttempts(ctx context.Context, id int64) (*api.MessageAttemptsResponse, error)
+	GetSandboxRequests(ctx context.Context, messageID int64, limit int) (*api.SandboxRequestListResponse, error)
+	HandleDeliveryReceipt(ctx context.Context, receipt DeliveryReceipt) error
 	GetCircuitBreakerStatus() (state api.HealthResponseCircuitBreakerState, requests uint32, failures uint32)
+	GetProviderStatuses() []ProviderStatus
+	GetProviderStats() []ProviderStats
+	ResolveRoute(dest routing.Destination) routing.Route
+	BatchSize() int
+	Drain(timeout time.Duration) *DrainReport
 }
 
 type SchedulerService interface {
 	Start() error
 	Stop() error
+	Drain(timeout time.Duration) *DrainReport
 	IsRunning() bool
 }
 
+type EventService interface {
+	CreateSubscription(ctx context.Context, req api.CreateSubscriptionRequest) (*api.Subscription, error)
+	ListSubscriptions(ctx context.Context) (*api.SubscriptionListResponse, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	GetDeadLetters(ctx context.Context, id int64, limit int) (*api.DeadLetterListResponse, error)
+	Publish(ctx context.Context, eventType models.EventType, data interface{}) error
+	DispatchEvents(ctx context.Context) error
+	Start() error
+	Stop() error
+}
+
+type StreamService interface {
+	Subscribe(ctx context.Context, lastEventID string, filter stream.Filter) (*stream.Subscription, error)
+	Start() error
+	Stop() error
+}
+
 type HealthService interface {
-	GetHealth() *HealthStatus
+	GetHealth(ctx context.Context) *HealthStatus
 }
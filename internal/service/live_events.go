@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/api"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/stream"
+)
+
+// messageStatusEvent is the data of a stream.TypeMessageStatus event.
+type messageStatusEvent struct {
+	ID          int64                `json:"id"`
+	PhoneNumber string               `json:"phone_number"`
+	Campaign    string               `json:"campaign,omitempty"`
+	Status      models.MessageStatus `json:"status"`
+	Provider    string               `json:"provider,omitempty"`
+	MessageID   string               `json:"message_id,omitempty"`
+	Error       string               `json:"error,omitempty"`
+	At          time.Time            `json:"at"`
+}
+
+// schedulerRunEvent is the data of a stream.TypeSchedulerRun event.
+type schedulerRunEvent struct {
+	BatchSize  int       `json:"batch_size"`
+	Claimed    int       `json:"claimed"`
+	Sent       int       `json:"sent"`
+	Failed     int       `json:"failed"`
+	DurationMs int64     `json:"duration_ms"`
+	At         time.Time `json:"at"`
+}
+
+// breakerStateEvent is the data of a stream.TypeBreakerState event.
+type breakerStateEvent struct {
+	Provider string                                `json:"provider"`
+	Country  string                                `json:"country,omitempty"`
+	Name     string                                `json:"name"`
+	From     api.HealthResponseCircuitBreakerState `json:"from"`
+	To       api.HealthResponseCircuitBreakerState `json:"to"`
+	At       time.Time                             `json:"at"`
+}
+
+// publishLive appends an event to the live stream. The stream is best
+// effort, failures are logged and never fail the operation itself.
+func (s *messageService) publishLive(ctx context.Context, eventType string, data interface{}, campaign string, status models.MessageStatus) {
+	ev, err := stream.NewEvent(eventType, data)
+	if err != nil {
+		s.logger.Warn("Failed to encode live event", zap.String("type", eventType), zap.Error(err))
+		return
+	}
+	ev.Campaign = campaign
+	ev.Status = string(status)
+
+	if _, err := s.live.Append(ctx, ev); err != nil {
+		s.logger.Warn("Failed to publish live event", zap.String("type", eventType), zap.Error(err))
+	}
+}
+
+// publishStatus publishes the transition of msg to status.
+func (s *messageService) publishStatus(ctx context.Context, msg *models.Message, status models.MessageStatus, provider, externalID string, errMsg *string) {
+	data := messageStatusEvent{
+		ID:          msg.ID,
+		PhoneNumber: msg.PhoneNumber,
+		Campaign:    msg.Campaign.String,
+		Status:      status,
+		Provider:    provider,
+		MessageID:   externalID,
+		At:          time.Now(),
+	}
+	if errMsg != nil {
+		data.Error = *errMsg
+	}
+
+	s.publishLive(ctx, stream.TypeMessageStatus, data, data.Campaign, status)
+}
+
+// publishBreakerState publishes a circuit breaker state change. It is
+// called with the breaker locked and must not block.
+func (s *messageService) publishBreakerState(key breakerKey, from, to api.HealthResponseCircuitBreakerState) {
+	data := breakerStateEvent{
+		Provider: key.provider,
+		Country:  key.country,
+		Name:     key.name(),
+		From:     from,
+		To:       to,
+		At:       time.Now(),
+	}
+
+	go s.publishLive(context.Background(), stream.TypeBreakerState, data, "", "")
+}
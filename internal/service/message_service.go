@@ -1,12 +1,15 @@
 package service
 
 import (
-	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
@@ -14,16 +17,35 @@ import (
 	"github.com/popeskul/insdr-messenger/internal/api"
 	"github.com/popeskul/insdr-messenger/internal/config"
 	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/provider"
+	"github.com/popeskul/insdr-messenger/internal/queue"
 	"github.com/popeskul/insdr-messenger/internal/repository"
+	"github.com/popeskul/insdr-messenger/internal/routing"
+	"github.com/popeskul/insdr-messenger/internal/stream"
 )
 
+// maxContentLength is the longest message content accepted, in characters.
+const maxContentLength = 160
+
 type messageService struct {
-	cfg            *config.Config
-	repo           repository.Repository
-	redisClient    *redis.Client
-	httpClient     *http.Client
-	logger         *zap.Logger
-	circuitBreaker *CircuitBreaker
+	cfg         *config.Config
+	repo        repository.Repository
+	redisClient *redis.Client
+	router      *providerRouter
+	queue       queue.Queue
+	live        stream.Log
+	logger      *zap.Logger
+
+	// sendCtx is cancelled when a drain runs out of time, cutting off
+	// in-flight provider calls.
+	sendCtx     context.Context
+	cancelSends context.CancelFunc
+	drain       drainState
+	// outcomes buffers send outcomes for bulk writes, nil when they are
+	// written one by one.
+	outcomes *outcomeBuffer
+	// batchSize sizes batches, nil when scheduler.batch_size is used as is.
+	batchSize *batchController
 }
 
 func NewMessageService(
@@ -31,153 +53,491 @@ func NewMessageService(
 	repo repository.Repository,
 	redisClient *redis.Client,
 	logger *zap.Logger,
-) MessageService {
-	cb := NewCircuitBreaker(&cfg.Webhook.CircuitBreaker, logger)
+) (MessageService, error) {
+	router, err := newProviderRouter(cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure delivery providers: %w", err)
+	}
 
-	return &messageService{
+	q, err := queue.New(cfg.Queue, repo, redisClient, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure queue: %w", err)
+	}
+
+	sendCtx, cancelSends := context.WithCancel(context.Background())
+	s := &messageService{
 		cfg:         cfg,
 		repo:        repo,
 		redisClient: redisClient,
-		httpClient: &http.Client{
-			Timeout: time.Duration(cfg.Webhook.Timeout) * time.Second,
-		},
-		logger:         logger,
-		circuitBreaker: cb,
+		router:      router,
+		queue:       q,
+		live:        stream.NewRedisLog(redisClient, stream.DefaultKey, cfg.Stream.MaxLen),
+		logger:      logger,
+		sendCtx:     sendCtx,
+		cancelSends: cancelSends,
+		outcomes: newOutcomeBuffer(cfg.Scheduler.FlushSize,
+			time.Duration(cfg.Scheduler.FlushIntervalMs)*time.Millisecond),
+		batchSize: newBatchController(cfg.Scheduler),
 	}
+	router.breakers.onStateChange = s.publishBreakerState
+
+	return s, nil
 }
 
-// SendPendingMessages sends all pending messages.
-func (s *messageService) SendPendingMessages() error {
+// SendPendingMessages sends all pending messages. Batches are claimed one
+// after another as long as the previous one was full, until ctx is done.
+// Once a drain started or ctx is done it claims nothing and hands back the
+// unsent rest of a running batch.
+func (s *messageService) SendPendingMessages(ctx context.Context) error {
+	s.drain.batches.RLock()
+	defer s.drain.batches.RUnlock()
+
+	for {
+		if s.drain.active.Load() {
+			s.logger.Info("Draining, not claiming messages")
+			return nil
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		full, err := s.sendBatch(ctx)
+		if err != nil || !full {
+			return err
+		}
+	}
+}
+
+// sendBatch claims and sends one batch and reports whether it was full.
+func (s *messageService) sendBatch(ctx context.Context) (bool, error) {
+	// Outcomes never wait for the next batch, and ones journaled by an
+	// earlier run are written with the first batch.
+	defer s.flushOutcomes(context.WithoutCancel(ctx))
+
 	s.logger.Info("Starting to send pending messages")
 
-	messages, err := s.repo.Message().GetUnsentMessages(s.cfg.Scheduler.BatchSize)
+	size := s.BatchSize()
+	messages, err := s.queue.Claim(ctx, size)
 	if err != nil {
-		s.logger.Error("Failed to get unsent messages", zap.Error(err))
-		return fmt.Errorf("failed to get unsent messages: %w", err)
+		s.logger.Error("Failed to claim queued messages", zap.Error(err))
+		return false, fmt.Errorf("failed to claim queued messages: %w", err)
 	}
+	full := len(messages) >= size
+	messages = s.adoptOutcomes(context.WithoutCancel(ctx), messages)
 
 	if len(messages) == 0 {
-		s.logger.Info("No pending messages to send")
-		return nil
+		if !full {
+			s.logger.Info("No pending messages to send")
+		}
+		return full, nil
 	}
 
 	s.logger.Info("Found pending messages", zap.Int("count", len(messages)))
 
+	start := time.Now()
 	for _, msg := range messages {
-		if err := s.sendMessage(msg); err != nil {
-			s.logger.Error("Failed to send message",
-				zap.Int64("messageID", msg.ID),
-				zap.Error(err))
+		s.publishStatus(ctx, msg, models.MessageStatusProcessing, "", "", nil)
+	}
+
+	run := schedulerRunEvent{BatchSize: size, Claimed: len(messages)}
+	var sendTime time.Duration
+	interrupted := false
+	for i, msg := range messages {
+		if s.drain.active.Load() || ctx.Err() != nil {
+			s.releaseClaims(context.WithoutCancel(ctx), messages[i:])
+			interrupted = true
+			break
+		}
+
+		sendStart := time.Now()
+		err := s.sendMessage(ctx, msg)
+		sendTime += time.Since(sendStart)
+		if s.drain.active.Load() {
+			s.drain.recordSend(msg.ID, err)
+		}
+		if err != nil {
+			if !errors.Is(err, errSendAborted) {
+				s.logger.Error("Failed to send message",
+					zap.Int64("messageID", msg.ID),
+					zap.Error(err))
+			}
+			run.Failed++
 			continue
 		}
+		run.Sent++
 	}
 
-	return nil
+	run.DurationMs = time.Since(start).Milliseconds()
+	run.At = time.Now()
+	s.publishLive(context.WithoutCancel(ctx), stream.TypeSchedulerRun, run, "", "")
+
+	if !interrupted && !s.drain.active.Load() {
+		s.resizeBatches(context.WithoutCancel(ctx), run, sendTime)
+	}
+
+	return full && !interrupted, nil
 }
 
-// sendMessage sends a single message
-func (s *messageService) sendMessage(msg *models.Message) error {
-	// Execute through circuit breaker
-	err := s.circuitBreaker.Execute(context.Background(), func() error {
-		reqBody := models.WebhookRequest{
-			To:      msg.PhoneNumber,
-			Content: msg.Content,
-		}
+// BatchSize returns the number of messages claimed by the next batch.
+func (s *messageService) BatchSize() int {
+	if s.batchSize == nil {
+		return s.cfg.Scheduler.BatchSize
+	}
+	return s.batchSize.Size()
+}
 
-		jsonData, err := json.Marshal(reqBody)
-		if err != nil {
-			return fmt.Errorf("failed to marshal request: %w", err)
-		}
+// resizeBatches adapts the batch size to the outcome of a batch and the
+// remaining backlog. Without a backlog count batches do not grow.
+func (s *messageService) resizeBatches(ctx context.Context, run schedulerRunEvent, sendTime time.Duration) {
+	if s.batchSize == nil {
+		return
+	}
 
-		req, err := http.NewRequest("POST", s.cfg.Webhook.URL, bytes.NewBuffer(jsonData))
-		if err != nil {
-			return fmt.Errorf("failed to create request: %w", err)
-		}
+	backlog, err := s.repo.Message().GetQueuedCount(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to count queued messages", zap.Error(err))
+		backlog = 0
+	}
 
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("x-ins-auth-key", s.cfg.Webhook.AuthKey)
+	observed := batchRun{
+		Backlog: backlog,
+		Sent:    run.Sent,
+		Failed:  run.Failed,
+	}
+	if sends := run.Sent + run.Failed; sends > 0 {
+		observed.Latency = sendTime / time.Duration(sends)
+	}
+	observed.Breaker, _, _ = s.router.State()
+
+	size, reason := s.batchSize.observe(observed)
+	if reason != "" {
+		s.logger.Info("Batch size changed",
+			zap.Int("from", run.BatchSize),
+			zap.Int("to", size),
+			zap.String("reason", reason),
+			zap.Int64("backlog", backlog),
+			zap.Duration("latency", observed.Latency))
+	}
+}
 
-		resp, err := s.httpClient.Do(req)
-		if err != nil {
-			return fmt.Errorf("failed to send request: %w", err)
+// sendMessage sends a single message. The provider call is cut off when
+// ctx is done or a drain runs out of time.
+func (s *messageService) sendMessage(ctx context.Context, msg *models.Message) error {
+	sendCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(s.sendCtx, cancel)
+	defer stop()
+
+	sent, attempts, err := s.router.Send(sendCtx, msg)
+	cutOff := sendCtx.Err() != nil
+
+	// The outcome is recorded even when ctx ended during the send.
+	ctx = context.WithoutCancel(ctx)
+	if attemptErr := s.repo.Attempt().CreateAttempts(ctx, attempts); attemptErr != nil {
+		s.logger.Warn("Failed to record delivery attempts",
+			zap.Int64("messageID", msg.ID),
+			zap.Error(attemptErr))
+	}
+	if err != nil && cutOff {
+		s.markAborted(ctx, msg, attempts, err)
+		return fmt.Errorf("%w: %v", errSendAborted, err)
+	}
+	if err != nil {
+		update := models.StatusUpdate{
+			ID:         msg.ID,
+			Status:     models.MessageStatusFailed,
+			Error:      err.Error(),
+			At:         time.Now(),
+			ClaimToken: msg.ClaimToken.String,
 		}
-		defer func() {
-			if err := resp.Body.Close(); err != nil {
-				s.logger.Warn("Failed to close response body", zap.Error(err))
-			}
-		}()
-
-		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-			return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		if updateErr := s.recordOutcome(ctx, msg, update); updateErr != nil {
+			s.logger.Error("Failed to update message status",
+				zap.Int64("messageID", msg.ID),
+				zap.Error(updateErr))
 		}
 
-		var webhookResp models.WebhookResponse
-		if err := json.NewDecoder(resp.Body).Decode(&webhookResp); err != nil {
-			if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusAccepted {
-				tempMessageID := fmt.Sprintf("temp-%d-%d", msg.ID, time.Now().Unix())
-				webhookResp.MessageID = tempMessageID
-			} else {
-				return fmt.Errorf("failed to decode response: %w", err)
-			}
-		}
+		state, requests, failures := s.router.State()
+		s.logger.Error("Failed to send message",
+			zap.Int64("messageID", msg.ID),
+			zap.String("errorClass", string(provider.ClassOf(err))),
+			zap.Error(err),
+			zap.String("circuitBreakerState", string(state)),
+			zap.Uint32("totalRequests", requests),
+			zap.Uint32("totalFailures", failures))
+
+		return err
+	}
+
+	if sent.result.Request != nil {
+		s.recordSandboxRequest(ctx, msg, sent)
+	}
 
-		// Update message status to sent
-		if err := s.repo.Message().UpdateMessageStatus(msg.ID, models.MessageStatusSent, &webhookResp.MessageID, nil); err != nil {
+	if sent.result.ExternalID == "" {
+		if err := s.repo.Message().MarkAcceptedUnconfirmed(ctx, msg.ID, msg.ClaimToken.String, sent.provider, sent.result.Body); err != nil {
 			return fmt.Errorf("failed to update message status: %w", err)
 		}
+		s.ack(ctx, msg.ID)
+		s.publishStatus(ctx, msg, models.MessageStatusAcceptedUnconfirmed, sent.provider, "", nil)
+
+		s.logger.Warn("Message accepted without message ID, awaiting reconciliation",
+			zap.Int64("messageID", msg.ID),
+			zap.String("provider", sent.provider),
+			zap.String("route", sent.route))
+		return nil
+	}
 
-		// Cache message ID in Redis (bonus feature)
-		ctx := context.Background()
-		cacheKey := fmt.Sprintf("message:%s", webhookResp.MessageID)
-		cacheValue := fmt.Sprintf("%d:%s", msg.ID, time.Now().Format(time.RFC3339))
+	update := models.StatusUpdate{
+		ID:         msg.ID,
+		Status:     models.MessageStatusSent,
+		ExternalID: sent.result.ExternalID,
+		Provider:   sent.provider,
+		At:         time.Now(),
+		ClaimToken: msg.ClaimToken.String,
+	}
+	if err := s.recordOutcome(ctx, msg, update); err != nil {
+		return err
+	}
+
+	s.logger.Info("Message sent successfully",
+		zap.Int64("messageID", msg.ID),
+		zap.String("provider", sent.provider),
+		zap.String("route", sent.route),
+		zap.String("externalMessageID", sent.result.ExternalID))
 
-		if err := s.redisClient.Set(ctx, cacheKey, cacheValue, 24*time.Hour).Err(); err != nil {
-			s.logger.Warn("Failed to cache message ID in Redis",
-				zap.String("messageID", webhookResp.MessageID),
+	return nil
+}
+
+// markAborted records a send cut off by a drain or the end of ctx. The
+// provider may have accepted the message before the call was cut off, so it
+// is not queued again but left to the reconciler as accepted_unconfirmed.
+// Providers without status lookups could never confirm it, so their
+// messages fail instead.
+func (s *messageService) markAborted(ctx context.Context, msg *models.Message, attempts []*models.DeliveryAttempt, sendErr error) {
+	var providerName string
+	if len(attempts) > 0 {
+		providerName = attempts[len(attempts)-1].Provider
+	}
+
+	if _, ok := s.router.Lookup(providerName); !ok {
+		update := models.StatusUpdate{
+			ID:         msg.ID,
+			Status:     models.MessageStatusFailed,
+			Provider:   providerName,
+			Error:      "send cut off, delivery unknown: " + sendErr.Error(),
+			At:         time.Now(),
+			ClaimToken: msg.ClaimToken.String,
+		}
+		if err := s.recordOutcome(ctx, msg, update); err != nil {
+			s.logger.Error("Failed to update message status",
+				zap.Int64("messageID", msg.ID),
 				zap.Error(err))
+			return
 		}
 
-		s.logger.Info("Message sent successfully",
+		s.logger.Warn("Send cut off, provider has no status lookup, marked failed",
 			zap.Int64("messageID", msg.ID),
-			zap.String("externalMessageID", webhookResp.MessageID),
-			zap.String("circuitBreakerState", string(s.circuitBreaker.GetState())))
+			zap.String("provider", providerName),
+			zap.Error(sendErr))
+		return
+	}
 
-		return nil
-	})
+	if err := s.repo.Message().MarkAcceptedUnconfirmed(ctx, msg.ID, msg.ClaimToken.String, providerName, sendErr.Error()); err != nil {
+		// The claim runs out and the message is sent again.
+		s.logger.Error("Failed to mark cut off send, message left processing",
+			zap.Int64("messageID", msg.ID),
+			zap.Error(err))
+		return
+	}
+	s.ack(ctx, msg.ID)
+	s.publishStatus(ctx, msg, models.MessageStatusAcceptedUnconfirmed, providerName, "", nil)
+
+	s.logger.Warn("Send cut off, awaiting reconciliation",
+		zap.Int64("messageID", msg.ID),
+		zap.String("provider", providerName),
+		zap.Error(sendErr))
+}
+
+// recordSandboxRequest stores the request a sandbox provider built for
+// msg. Failing to store it does not fail the message.
+func (s *messageService) recordSandboxRequest(ctx context.Context, msg *models.Message, sent *delivery) {
+	req := sent.result.Request
+	// A map of strings always encodes.
+	headers, _ := json.Marshal(req.Headers)
+
+	record := &models.SandboxRequest{
+		MessageID:  msg.ID,
+		Provider:   sent.provider,
+		ExternalID: sent.result.ExternalID,
+		Method:     req.Method,
+		URL:        req.URL,
+		Headers:    headers,
+		Body:       req.Body,
+	}
+	if err := s.repo.Sandbox().CreateSandboxRequest(ctx, record); err != nil {
+		s.logger.Warn("Failed to record sandbox request",
+			zap.Int64("messageID", msg.ID),
+			zap.String("provider", sent.provider),
+			zap.Error(err))
+	}
+}
+
+// ack releases a message from the queue once its status is recorded. A
+// message that is not acknowledged is handed out again later.
+func (s *messageService) ack(ctx context.Context, id int64) {
+	if err := s.queue.Ack(ctx, id); err != nil {
+		s.logger.Warn("Failed to acknowledge queued message",
+			zap.Int64("messageID", id),
+			zap.Error(err))
+	}
+}
+
+// externalIDCacheTTL is how long the message ID of an external ID stays
+// cached.
+const externalIDCacheTTL = 24 * time.Hour
+
+// externalIDCacheEntry returns the cache key and value mapping an external
+// ID to our message ID.
+func externalIDCacheEntry(externalID string, id int64) (key, value string) {
+	return fmt.Sprintf("message:%s", externalID), fmt.Sprintf("%d:%s", id, time.Now().Format(time.RFC3339))
+}
+
+// cacheExternalID caches the mapping of an external ID to our message ID
+// for delivery receipts (bonus feature).
+func (s *messageService) cacheExternalID(ctx context.Context, externalID string, id int64) {
+	cacheKey, cacheValue := externalIDCacheEntry(externalID, id)
+
+	if err := s.redisClient.Set(ctx, cacheKey, cacheValue, externalIDCacheTTL).Err(); err != nil {
+		s.logger.Warn("Failed to cache message ID in Redis",
+			zap.Int64("messageID", id),
+			zap.String("externalMessageID", externalID),
+			zap.Error(err))
+	}
+}
 
-	// Handle circuit breaker errors
+// ReconcileUnconfirmed asks providers for the state of messages they
+// accepted without a usable message ID. Messages of providers without
+// status lookups stay unconfirmed.
+func (s *messageService) ReconcileUnconfirmed(ctx context.Context) error {
+	messages, err := s.repo.Message().GetUnconfirmedMessages(ctx, s.cfg.Scheduler.ReconcileBatchSize)
 	if err != nil {
-		errMsg := err.Error()
-		if updateErr := s.repo.Message().UpdateMessageStatus(msg.ID, models.MessageStatusFailed, nil, &errMsg); updateErr != nil {
-			s.logger.Error("Failed to update message status",
+		return fmt.Errorf("failed to get unconfirmed messages: %w", err)
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	s.logger.Info("Reconciling unconfirmed messages", zap.Int("count", len(messages)))
+
+	for _, msg := range messages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.reconcileMessage(ctx, msg); err != nil {
+			s.logger.Warn("Failed to reconcile message",
 				zap.Int64("messageID", msg.ID),
-				zap.Error(updateErr))
+				zap.String("provider", msg.Provider.String),
+				zap.Error(err))
 		}
+	}
 
-		requests, failures := s.circuitBreaker.GetCounts()
-		s.logger.Error("Failed to send message",
+	return nil
+}
+
+// reconcileMessage resolves a single unconfirmed message through its
+// provider's status lookup.
+func (s *messageService) reconcileMessage(ctx context.Context, msg *models.Message) error {
+	lookup, ok := s.router.Lookup(msg.Provider.String)
+	if !ok {
+		s.logger.Debug("Provider does not support status lookups",
 			zap.Int64("messageID", msg.ID),
-			zap.Error(err),
-			zap.String("circuitBreakerState", string(s.circuitBreaker.GetState())),
-			zap.Uint32("totalRequests", requests),
-			zap.Uint32("totalFailures", failures))
+			zap.String("provider", msg.Provider.String))
+		return nil
+	}
 
-		return err
+	status, err := lookup.LookupStatus(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("failed to look up message status: %w", err)
 	}
 
+	// A known ID without a final status means the gateway has the message.
+	if status.Status == "" && status.ExternalID != "" {
+		status.Status = models.MessageStatusSent
+	}
+
+	var errMsg *string
+	if status.Error != "" {
+		errMsg = &status.Error
+	}
+
+	if err := s.repo.Message().ResolveUnconfirmed(ctx, msg.ID, status.ExternalID, status.Status, errMsg); err != nil {
+		return fmt.Errorf("failed to resolve message: %w", err)
+	}
+
+	if status.Status == "" {
+		return nil
+	}
+
+	if status.ExternalID != "" {
+		s.cacheExternalID(ctx, status.ExternalID, msg.ID)
+	}
+	s.publishStatus(ctx, msg, status.Status, msg.Provider.String, status.ExternalID, errMsg)
+
+	s.logger.Info("Unconfirmed message reconciled",
+		zap.Int64("messageID", msg.ID),
+		zap.String("provider", msg.Provider.String),
+		zap.String("externalMessageID", status.ExternalID),
+		zap.String("status", string(status.Status)))
+
 	return nil
 }
 
+// CreateMessage stores a queued message and hands it to the queue.
+func (s *messageService) CreateMessage(ctx context.Context, req api.CreateMessageRequest) (*api.Message, error) {
+	if routing.NormalizeNumber(req.PhoneNumber) == "" {
+		return nil, fmt.Errorf("%w: phone number must contain digits", ErrInvalidMessage)
+	}
+	if n := utf8.RuneCountInString(req.Content); n == 0 || n > maxContentLength {
+		return nil, fmt.Errorf("%w: content must be 1 to %d characters", ErrInvalidMessage, maxContentLength)
+	}
+
+	msg := &models.Message{PhoneNumber: req.PhoneNumber, Content: req.Content}
+	if req.Priority != nil {
+		msg.Priority = *req.Priority
+	}
+	if req.Campaign != nil && *req.Campaign != "" {
+		msg.Campaign = sql.NullString{String: *req.Campaign, Valid: true}
+	}
+
+	if err := s.repo.Message().InsertMessage(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	// The message is stored, a failed enqueue only delays it until the
+	// queue picks up waiting messages again.
+	if err := s.queue.Enqueue(ctx, msg.ID); err != nil {
+		s.logger.Warn("Failed to enqueue message",
+			zap.Int64("messageID", msg.ID),
+			zap.Error(err))
+	}
+	s.publishStatus(ctx, msg, models.MessageStatusQueued, "", "", nil)
+
+	s.logger.Info("Message queued", zap.Int64("messageID", msg.ID))
+
+	result := toAPIMessage(msg)
+	return &result, nil
+}
+
 // GetSentMessages retrieves sent messages with pagination.
-func (s *messageService) GetSentMessages(page, limit int) (*api.MessageListResponse, error) {
+func (s *messageService) GetSentMessages(ctx context.Context, page, limit int) (*api.MessageListResponse, error) {
 	offset := (page - 1) * limit
 
-	messages, err := s.repo.Message().GetSentMessages(offset, limit)
+	messages, err := s.repo.Message().GetSentMessages(ctx, offset, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sent messages: %w", err)
 	}
 
-	totalCount, err := s.repo.Message().GetTotalSentCount()
+	totalCount, err := s.repo.Message().GetTotalSentCount(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total count: %w", err)
 	}
@@ -189,26 +549,7 @@ func (s *messageService) GetSentMessages(page, limit int) (*api.MessageListRespo
 
 	var messageResponses []api.Message
 	for _, msg := range messages {
-		msgResp := api.Message{
-			Id:          msg.ID,
-			PhoneNumber: msg.PhoneNumber,
-			Content:     &msg.Content,
-			Status:      msg.Status,
-		}
-
-		if msg.SentAt.Valid {
-			msgResp.SentAt = &msg.SentAt.Time
-		}
-
-		if msg.MessageID.Valid {
-			msgResp.MessageId = &msg.MessageID.String
-		}
-
-		if msg.Error.Valid {
-			msgResp.Error = &msg.Error.String
-		}
-
-		messageResponses = append(messageResponses, msgResp)
+		messageResponses = append(messageResponses, toAPIMessage(msg))
 	}
 
 	return &api.MessageListResponse{
@@ -222,8 +563,282 @@ func (s *messageService) GetSentMessages(page, limit int) (*api.MessageListRespo
 	}, nil
 }
 
+// toAPIMessage converts a stored message to its API representation.
+func toAPIMessage(msg *models.Message) api.Message {
+	msgResp := api.Message{
+		Id:          msg.ID,
+		PhoneNumber: msg.PhoneNumber,
+		Content:     &msg.Content,
+		Status:      msg.Status,
+	}
+
+	if msg.SentAt.Valid {
+		msgResp.SentAt = &msg.SentAt.Time
+	}
+
+	if msg.MessageID.Valid {
+		msgResp.MessageId = &msg.MessageID.String
+	}
+
+	if msg.Error.Valid {
+		msgResp.Error = &msg.Error.String
+	}
+
+	if msg.Provider.Valid {
+		msgResp.Provider = &msg.Provider.String
+	}
+
+	if msg.DeliveredAt.Valid {
+		msgResp.DeliveredAt = &msg.DeliveredAt.Time
+	}
+
+	if msg.ProviderResponse.Valid {
+		msgResp.ProviderResponse = &msg.ProviderResponse.String
+	}
+
+	return msgResp
+}
+
+// GetMessageByExternalID returns the message a provider knows by
+// externalID.
+func (s *messageService) GetMessageByExternalID(ctx context.Context, externalID string) (*api.Message, error) {
+	if id, ok := s.cachedExternalID(ctx, externalID); ok {
+		msg, err := s.repo.Message().GetMessage(ctx, id)
+		switch {
+		case err == nil && msg.MessageID.String == externalID:
+			result := toAPIMessage(msg)
+			return &result, nil
+		case err != nil && !errors.Is(err, ErrMessageNotFound):
+			return nil, fmt.Errorf("failed to get message: %w", err)
+		}
+		s.logger.Warn("Stale message cache entry",
+			zap.String("externalMessageID", externalID),
+			zap.Int64("messageID", id))
+	}
+
+	msg, err := s.findByExternalID(ctx, externalID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := toAPIMessage(msg)
+	return &result, nil
+}
+
+// GetMessageAttempts returns the delivery attempts of a message.
+func (s *messageService) GetMessageAttempts(ctx context.Context, id int64) (*api.MessageAttemptsResponse, error) {
+	if _, err := s.repo.Message().GetMessage(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	attempts, err := s.repo.Attempt().GetAttempts(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery attempts: %w", err)
+	}
+
+	result := make([]api.DeliveryAttempt, 0, len(attempts))
+	for _, a := range attempts {
+		attempt := api.DeliveryAttempt{
+			Provider:            a.Provider,
+			Route:               a.Route,
+			RequestedAt:         a.RequestedAt,
+			LatencyMs:           a.LatencyMs,
+			CircuitBreakerState: api.HealthResponseCircuitBreakerState(a.BreakerState),
+		}
+
+		if a.StatusCode.Valid {
+			code := int(a.StatusCode.Int32)
+			attempt.StatusCode = &code
+		}
+
+		if a.ResponseBody.Valid {
+			attempt.ResponseBody = &a.ResponseBody.String
+		}
+
+		if a.Error.Valid {
+			attempt.Error = &a.Error.String
+		}
+
+		if a.ErrorClass.Valid {
+			class := api.DeliveryAttemptErrorClass(a.ErrorClass.String)
+			attempt.ErrorClass = &class
+		}
+
+		result = append(result, attempt)
+	}
+
+	return &api.MessageAttemptsResponse{
+		MessageId: id,
+		Attempts:  result,
+	}, nil
+}
+
+// GetSandboxRequests returns up to limit requests recorded in sandbox
+// mode, newest first. A non-zero messageID restricts them to that message.
+func (s *messageService) GetSandboxRequests(ctx context.Context, messageID int64, limit int) (*api.SandboxRequestListResponse, error) {
+	records, err := s.repo.Sandbox().GetSandboxRequests(ctx, messageID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]api.SandboxRequest, 0, len(records))
+	for _, rec := range records {
+		headers := map[string]string{}
+		if err := json.Unmarshal(rec.Headers, &headers); err != nil {
+			return nil, fmt.Errorf("failed to decode headers of sandbox request %d: %w", rec.ID, err)
+		}
+
+		requests = append(requests, api.SandboxRequest{
+			Id:         rec.ID,
+			MessageId:  rec.MessageID,
+			Provider:   rec.Provider,
+			ExternalId: rec.ExternalID,
+			Method:     rec.Method,
+			Url:        rec.URL,
+			Headers:    headers,
+			Body:       rec.Body,
+			CreatedAt:  rec.CreatedAt,
+		})
+	}
+
+	return &api.SandboxRequestListResponse{Requests: requests}, nil
+}
+
+// GetMessageHistory returns the status timeline of a message.
+func (s *messageService) GetMessageHistory(ctx context.Context, id int64) (*api.MessageHistoryResponse, error) {
+	history, err := s.repo.Message().GetStatusHistory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message history: %w", err)
+	}
+
+	changes := make([]api.StatusChange, 0, len(history))
+	for _, h := range history {
+		change := api.StatusChange{
+			ToStatus:  h.ToStatus,
+			ChangedAt: h.ChangedAt,
+		}
+
+		if h.FromStatus.Valid {
+			from := models.MessageStatus(h.FromStatus.String)
+			change.FromStatus = &from
+		}
+
+		if h.Error.Valid {
+			change.Error = &h.Error.String
+		}
+
+		changes = append(changes, change)
+	}
+
+	return &api.MessageHistoryResponse{
+		MessageId: id,
+		History:   changes,
+	}, nil
+}
+
+// HandleDeliveryReceipt applies a provider delivery receipt to the message
+// identified by its external ID.
+func (s *messageService) HandleDeliveryReceipt(ctx context.Context, receipt DeliveryReceipt) error {
+	id, err := s.lookupExternalID(ctx, receipt.MessageID)
+	if err != nil {
+		return err
+	}
+
+	at := receipt.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	err = s.repo.Message().UpdateDeliveryStatus(ctx, id, receipt.Status, at, receipt.Error)
+	// A receipt may overtake the write of the send outcome it follows.
+	var transitionErr *TransitionError
+	if errors.As(err, &transitionErr) && transitionErr.From == models.MessageStatusProcessing &&
+		s.settleOutcome(context.WithoutCancel(ctx), id) {
+		err = s.repo.Message().UpdateDeliveryStatus(ctx, id, receipt.Status, at, receipt.Error)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply delivery receipt: %w", err)
+	}
+
+	// Receipts only carry the external ID, load the message for the event.
+	if msg, err := s.repo.Message().GetMessage(ctx, id); err != nil {
+		s.logger.Warn("Failed to load message for live event",
+			zap.Int64("messageID", id),
+			zap.Error(err))
+	} else {
+		s.publishStatus(ctx, msg, msg.Status, msg.Provider.String, receipt.MessageID, receipt.Error)
+	}
+
+	s.logger.Info("Delivery receipt applied",
+		zap.Int64("messageID", id),
+		zap.String("externalMessageID", receipt.MessageID),
+		zap.String("status", string(receipt.Status)))
+
+	return nil
+}
+
+// lookupExternalID returns our message ID for an external ID, using the
+// Redis cache written on send and falling back to the database.
+func (s *messageService) lookupExternalID(ctx context.Context, externalID string) (int64, error) {
+	if id, ok := s.cachedExternalID(ctx, externalID); ok {
+		return id, nil
+	}
+
+	msg, err := s.findByExternalID(ctx, externalID)
+	if err != nil {
+		return 0, err
+	}
+	return msg.ID, nil
+}
+
+// cachedExternalID reads the message ID cached for an external ID.
+func (s *messageService) cachedExternalID(ctx context.Context, externalID string) (int64, bool) {
+	cached, err := s.redisClient.Get(ctx, fmt.Sprintf("message:%s", externalID)).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			s.logger.Warn("Failed to read message ID from Redis",
+				zap.String("externalMessageID", externalID),
+				zap.Error(err))
+		}
+		return 0, false
+	}
+
+	idPart, _, _ := strings.Cut(cached, ":")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		s.logger.Warn("Malformed message cache entry",
+			zap.String("externalMessageID", externalID),
+			zap.String("value", cached))
+		return 0, false
+	}
+	return id, true
+}
+
+// findByExternalID loads a message by external ID from the database and
+// repopulates the cache.
+func (s *messageService) findByExternalID(ctx context.Context, externalID string) (*models.Message, error) {
+	msg, err := s.repo.Message().GetMessageByExternalID(ctx, externalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find message %s: %w", externalID, err)
+	}
+
+	s.cacheExternalID(ctx, externalID, msg.ID)
+	return msg, nil
+}
+
 func (s *messageService) GetCircuitBreakerStatus() (state api.HealthResponseCircuitBreakerState, requests uint32, failures uint32) {
-	state = s.circuitBreaker.GetState()
-	requests, failures = s.circuitBreaker.GetCounts()
-	return
+	return s.router.State()
+}
+
+func (s *messageService) GetProviderStatuses() []ProviderStatus {
+	return s.router.Statuses()
+}
+
+func (s *messageService) GetProviderStats() []ProviderStats {
+	return s.router.Stats()
+}
+
+// ResolveRoute returns the route a message to the destination would take.
+func (s *messageService) ResolveRoute(dest routing.Destination) routing.Route {
+	return s.router.Resolve(dest)
 }
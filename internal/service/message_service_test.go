@@ -1,19 +1,24 @@
 package service_test
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
 	"github.com/popeskul/insdr-messenger/internal/api"
 	"github.com/popeskul/insdr-messenger/internal/config"
 	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/repository"
 	"github.com/popeskul/insdr-messenger/internal/repository/mocks"
 	"github.com/popeskul/insdr-messenger/internal/service"
 	"github.com/stretchr/testify/assert"
@@ -52,28 +57,30 @@ func TestMessageService_SendPendingMessages_Success(t *testing.T) {
 	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
 
 	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+	allowAttempts(ctrl, mockRepo)
 
 	testMessages := []*models.Message{
 		{
 			ID:          1,
 			PhoneNumber: "+1234567890",
 			Content:     "Test message 1",
-			Status:      models.MessageStatusPending,
+			Status:      models.MessageStatusProcessing,
 		},
 		{
 			ID:          2,
 			PhoneNumber: "+0987654321",
 			Content:     "Test message 2",
-			Status:      models.MessageStatusPending,
+			Status:      models.MessageStatusProcessing,
 		},
 	}
 
-	mockMessageRepo.EXPECT().GetUnsentMessages(10).Return(testMessages, nil)
+	mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 10, gomock.Any()).Return(testMessages, nil)
 
+	providerName := config.DefaultProviderName
 	for i, msg := range testMessages {
 		messageID := fmt.Sprintf("msg-%d", i)
 		mockMessageRepo.EXPECT().
-			UpdateMessageStatus(msg.ID, models.MessageStatusSent, &messageID, nil).
+			UpdateMessageStatus(gomock.Any(), msg.ID, "", models.MessageStatusSent, &messageID, &providerName, nil).
 			Return(nil)
 	}
 
@@ -100,12 +107,92 @@ func TestMessageService_SendPendingMessages_Success(t *testing.T) {
 	}
 
 	logger := zap.NewNop()
-	messageService := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+	require.NoError(t, err)
 
-	err := messageService.SendPendingMessages()
+	err = messageService.SendPendingMessages(context.Background())
 	assert.NoError(t, err)
 }
 
+func TestMessageService_SendPendingMessages_FullBatches(t *testing.T) {
+	processing := func(ids ...int64) []*models.Message {
+		messages := make([]*models.Message, len(ids))
+		for i, id := range ids {
+			messages[i] = &models.Message{ID: id, PhoneNumber: "+1234567890", Content: "Test", Status: models.MessageStatusProcessing}
+		}
+		return messages
+	}
+
+	tests := []struct {
+		name    string
+		claims  [][]*models.Message
+		timeout time.Duration
+	}{
+		{
+			name:   "claims until a batch is not full",
+			claims: [][]*models.Message{processing(1, 2), processing(3, 4), processing(5)},
+		},
+		{
+			name:   "claims until the queue is empty",
+			claims: [][]*models.Message{processing(1, 2), nil},
+		},
+		{
+			name:    "stops when ctx ends",
+			claims:  [][]*models.Message{processing(1, 2)},
+			timeout: 100 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.timeout > 0 {
+					time.Sleep(tt.timeout)
+				}
+				_ = json.NewEncoder(w).Encode(models.WebhookResponse{Message: "Success", MessageID: "msg-1"})
+			}))
+			defer server.Close()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+			mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+			allowAttempts(ctrl, mockRepo)
+
+			var calls []any
+			for _, claim := range tt.claims {
+				calls = append(calls, mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 2, gomock.Any()).Return(claim, nil))
+			}
+			gomock.InOrder(calls...)
+			mockMessageRepo.EXPECT().
+				UpdateMessageStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+			// The rest of a batch cut off by ctx is released.
+			mockMessageRepo.EXPECT().ReleaseMessages(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+			cfg := &config.Config{
+				Webhook:   config.WebhookConfig{URL: server.URL, Timeout: 5},
+				Scheduler: config.SchedulerConfig{BatchSize: 2},
+			}
+
+			redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+			messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			assert.NoError(t, messageService.SendPendingMessages(ctx))
+		})
+	}
+}
+
 func TestMessageService_SendPendingMessages_Failure(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -114,21 +201,21 @@ func TestMessageService_SendPendingMessages_Failure(t *testing.T) {
 		expectedError  string
 	}{
 		{
-			name: "failed to get unsent messages",
+			name: "failed to claim queued messages",
 			setupMocks: func(mockRepo *mocks.MockRepository, mockMessageRepo *mocks.MockMessageRepository) {
 				mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
 				mockMessageRepo.EXPECT().
-					GetUnsentMessages(gomock.Any()).
+					ClaimMessages(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(nil, errors.New("database error"))
 			},
-			expectedError: "failed to get unsent messages",
+			expectedError: "failed to claim queued messages",
 		},
 		{
 			name: "no pending messages",
 			setupMocks: func(mockRepo *mocks.MockRepository, mockMessageRepo *mocks.MockMessageRepository) {
 				mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
 				mockMessageRepo.EXPECT().
-					GetUnsentMessages(gomock.Any()).
+					ClaimMessages(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return([]*models.Message{}, nil)
 			},
 			expectedError: "",
@@ -142,15 +229,15 @@ func TestMessageService_SendPendingMessages_Failure(t *testing.T) {
 					ID:          1,
 					PhoneNumber: "+1234567890",
 					Content:     "Test message",
-					Status:      models.MessageStatusPending,
+					Status:      models.MessageStatusProcessing,
 				}
 
 				mockMessageRepo.EXPECT().
-					GetUnsentMessages(gomock.Any()).
+					ClaimMessages(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return([]*models.Message{testMessage}, nil)
 
 				mockMessageRepo.EXPECT().
-					UpdateMessageStatus(testMessage.ID, models.MessageStatusFailed, nil, gomock.Any()).
+					UpdateMessageStatus(gomock.Any(), testMessage.ID, "", models.MessageStatusFailed, nil, nil, gomock.Any()).
 					Return(nil)
 			},
 			serverResponse: func(w http.ResponseWriter, r *http.Request) {
@@ -173,6 +260,7 @@ func TestMessageService_SendPendingMessages_Failure(t *testing.T) {
 
 			mockRepo := mocks.NewMockRepository(ctrl)
 			mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+			allowAttempts(ctrl, mockRepo)
 
 			tt.setupMocks(mockRepo, mockMessageRepo)
 
@@ -204,9 +292,10 @@ func TestMessageService_SendPendingMessages_Failure(t *testing.T) {
 			}
 
 			logger := zap.NewNop()
-			messageService := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+			messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+			require.NoError(t, err)
 
-			err := messageService.SendPendingMessages()
+			err = messageService.SendPendingMessages(context.Background())
 
 			if tt.expectedError != "" {
 				require.Error(t, err)
@@ -255,15 +344,16 @@ func TestMessageService_GetSentMessages_Success(t *testing.T) {
 	offset := 0
 	totalCount := int64(50)
 
-	mockMessageRepo.EXPECT().GetSentMessages(offset, limit).Return(testMessages, nil)
-	mockMessageRepo.EXPECT().GetTotalSentCount().Return(totalCount, nil)
+	mockMessageRepo.EXPECT().GetSentMessages(gomock.Any(), offset, limit).Return(testMessages, nil)
+	mockMessageRepo.EXPECT().GetTotalSentCount(gomock.Any()).Return(totalCount, nil)
 
 	cfg := &config.Config{}
 	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
 	logger := zap.NewNop()
-	messageService := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+	require.NoError(t, err)
 
-	result, err := messageService.GetSentMessages(page, limit)
+	result, err := messageService.GetSentMessages(context.Background(), page, limit)
 
 	require.NoError(t, err)
 	require.NotNil(t, result)
@@ -301,7 +391,7 @@ func TestMessageService_GetSentMessages_Failure(t *testing.T) {
 			setupMocks: func(mockRepo *mocks.MockRepository, mockMessageRepo *mocks.MockMessageRepository) {
 				mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
 				mockMessageRepo.EXPECT().
-					GetSentMessages(gomock.Any(), gomock.Any()).
+					GetSentMessages(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(nil, errors.New("database error"))
 			},
 			page:          1,
@@ -313,10 +403,10 @@ func TestMessageService_GetSentMessages_Failure(t *testing.T) {
 			setupMocks: func(mockRepo *mocks.MockRepository, mockMessageRepo *mocks.MockMessageRepository) {
 				mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
 				mockMessageRepo.EXPECT().
-					GetSentMessages(gomock.Any(), gomock.Any()).
+					GetSentMessages(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return([]*models.Message{}, nil)
 				mockMessageRepo.EXPECT().
-					GetTotalSentCount().
+					GetTotalSentCount(gomock.Any()).
 					Return(int64(0), errors.New("count error"))
 			},
 			page:          1,
@@ -338,9 +428,10 @@ func TestMessageService_GetSentMessages_Failure(t *testing.T) {
 			cfg := &config.Config{}
 			redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
 			logger := zap.NewNop()
-			messageService := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+			messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+			require.NoError(t, err)
 
-			result, err := messageService.GetSentMessages(tt.page, tt.limit)
+			result, err := messageService.GetSentMessages(context.Background(), tt.page, tt.limit)
 
 			require.Error(t, err)
 			assert.Contains(t, err.Error(), tt.expectedError)
@@ -372,7 +463,8 @@ func TestMessageService_GetCircuitBreakerStatus_Success(t *testing.T) {
 	}
 
 	logger := zap.NewNop()
-	messageService := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+	require.NoError(t, err)
 
 	state, requests, failures := messageService.GetCircuitBreakerStatus()
 
@@ -394,21 +486,22 @@ func TestMessageService_GetCircuitBreakerStatus_Failure(t *testing.T) {
 	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
 
 	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+	allowAttempts(ctrl, mockRepo)
 
 	testMessage := &models.Message{
 		ID:          1,
 		PhoneNumber: "+1234567890",
 		Content:     "Test message",
-		Status:      models.MessageStatusPending,
+		Status:      models.MessageStatusProcessing,
 	}
 
 	mockMessageRepo.EXPECT().
-		GetUnsentMessages(gomock.Any()).
+		ClaimMessages(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return([]*models.Message{testMessage}, nil).
 		Times(5)
 
 	mockMessageRepo.EXPECT().
-		UpdateMessageStatus(testMessage.ID, models.MessageStatusFailed, nil, gomock.Any()).
+		UpdateMessageStatus(gomock.Any(), testMessage.ID, "", models.MessageStatusFailed, nil, nil, gomock.Any()).
 		Return(nil).
 		Times(5)
 
@@ -435,10 +528,11 @@ func TestMessageService_GetCircuitBreakerStatus_Failure(t *testing.T) {
 	}
 
 	logger := zap.NewNop()
-	messageService := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+	require.NoError(t, err)
 
 	for i := 0; i < 5; i++ {
-		_ = messageService.SendPendingMessages()
+		_ = messageService.SendPendingMessages(context.Background())
 	}
 
 	state, requests, failures := messageService.GetCircuitBreakerStatus()
@@ -500,17 +594,19 @@ func TestMessageService_PaginationCalculation(t *testing.T) {
 			mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
 
 			mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+			allowAttempts(ctrl, mockRepo)
 
 			offset := (tt.page - 1) * tt.limit
-			mockMessageRepo.EXPECT().GetSentMessages(offset, tt.limit).Return([]*models.Message{}, nil)
-			mockMessageRepo.EXPECT().GetTotalSentCount().Return(tt.totalCount, nil)
+			mockMessageRepo.EXPECT().GetSentMessages(gomock.Any(), offset, tt.limit).Return([]*models.Message{}, nil)
+			mockMessageRepo.EXPECT().GetTotalSentCount(gomock.Any()).Return(tt.totalCount, nil)
 
 			cfg := &config.Config{}
 			redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
 			logger := zap.NewNop()
-			messageService := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+			messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, logger)
+			require.NoError(t, err)
 
-			result, err := messageService.GetSentMessages(tt.page, tt.limit)
+			result, err := messageService.GetSentMessages(context.Background(), tt.page, tt.limit)
 
 			require.NoError(t, err)
 			require.NotNil(t, result)
@@ -519,3 +615,1344 @@ func TestMessageService_PaginationCalculation(t *testing.T) {
 		})
 	}
 }
+
+func TestMessageService_SendPendingMessages_Failover(t *testing.T) {
+	tests := []struct {
+		name             string
+		primaryStatus    int
+		expectedStatus   models.MessageStatus
+		expectedProvider *string
+		secondaryCalls   int
+	}{
+		{
+			name:             "transient primary failure fails over to secondary",
+			primaryStatus:    http.StatusServiceUnavailable,
+			expectedStatus:   models.MessageStatusSent,
+			expectedProvider: func() *string { s := "secondary"; return &s }(),
+			secondaryCalls:   1,
+		},
+		{
+			name:           "permanent primary failure does not fail over",
+			primaryStatus:  http.StatusBadRequest,
+			expectedStatus: models.MessageStatusFailed,
+			secondaryCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.primaryStatus)
+			}))
+			defer primary.Close()
+
+			secondaryCalls := 0
+			secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				secondaryCalls++
+				w.WriteHeader(http.StatusOK)
+				_ = json.NewEncoder(w).Encode(models.WebhookResponse{MessageID: "secondary-1"})
+			}))
+			defer secondary.Close()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+			mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+
+			var attempts []*models.DeliveryAttempt
+			mockAttemptRepo := mocks.NewMockAttemptRepository(ctrl)
+			mockRepo.EXPECT().Attempt().Return(mockAttemptRepo)
+			mockAttemptRepo.EXPECT().CreateAttempts(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, a []*models.DeliveryAttempt) error {
+					attempts = a
+					return nil
+				})
+
+			testMessage := &models.Message{ID: 1, PhoneNumber: "+905551111111", Content: "Test", Status: models.MessageStatusProcessing}
+			mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*models.Message{testMessage}, nil)
+			mockMessageRepo.EXPECT().
+				UpdateMessageStatus(gomock.Any(), testMessage.ID, "", tt.expectedStatus, gomock.Any(), tt.expectedProvider, gomock.Any()).
+				Return(nil)
+
+			cfg := &config.Config{
+				Webhook: config.WebhookConfig{
+					Timeout: 1,
+					CircuitBreaker: config.CircuitBreakerConfig{
+						MaxRequests:      10,
+						Interval:         60,
+						Timeout:          60,
+						FailureRatio:     0.6,
+						ConsecutiveFails: 5,
+					},
+				},
+				Providers: []config.ProviderConfig{
+					{Name: "primary", Type: "webhook", URL: primary.URL},
+					{Name: "secondary", Type: "webhook", URL: secondary.URL},
+				},
+				Routing: config.RoutingConfig{
+					Failover: []string{"primary", "secondary"},
+				},
+				Scheduler: config.SchedulerConfig{
+					BatchSize: 10,
+				},
+			}
+
+			redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+			messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+			require.NoError(t, err)
+
+			err = messageService.SendPendingMessages(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.secondaryCalls, secondaryCalls)
+
+			statuses := messageService.GetProviderStatuses()
+			require.Len(t, statuses, 2)
+			assert.Equal(t, "primary", statuses[0].Name)
+			assert.Equal(t, "secondary", statuses[1].Name)
+
+			require.Len(t, attempts, 1+tt.secondaryCalls)
+			assert.Equal(t, "primary", attempts[0].Provider)
+			assert.Equal(t, "default", attempts[0].Route)
+			assert.Equal(t, int32(tt.primaryStatus), attempts[0].StatusCode.Int32)
+			assert.Equal(t, "closed", attempts[0].BreakerState)
+			assert.True(t, attempts[0].ErrorClass.Valid)
+			if tt.secondaryCalls > 0 {
+				assert.Equal(t, "secondary", attempts[1].Provider)
+				assert.False(t, attempts[1].Error.Valid)
+				assert.Contains(t, attempts[1].ResponseBody.String, "secondary-1")
+			}
+
+			stats := messageService.GetProviderStats()
+			require.Len(t, stats, 2)
+			assert.Equal(t, uint64(1), stats[0].Requests)
+			assert.Equal(t, uint64(1), stats[0].Failures)
+			assert.Equal(t, float64(0), stats[0].SuccessRate)
+			assert.Equal(t, uint64(tt.secondaryCalls), stats[1].Successes)
+		})
+	}
+}
+
+func TestMessageService_SendPendingMessages_PerDestinationBreakers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		var req models.WebhookRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if strings.HasPrefix(req.To, "+90") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(models.WebhookResponse{MessageID: "ext-1"})
+	}))
+	defer server.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+	allowAttempts(ctrl, mockRepo)
+
+	messages := []*models.Message{
+		{ID: 1, PhoneNumber: "+905551111111", Content: "Test"},
+		{ID: 2, PhoneNumber: "+905552222222", Content: "Test"},
+		{ID: 3, PhoneNumber: "+905553333333", Content: "Test"},
+		{ID: 4, PhoneNumber: "+4915112345678", Content: "Test"},
+	}
+	mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), gomock.Any(), gomock.Any()).Return(messages, nil)
+	for _, id := range []int64{1, 2, 3} {
+		mockMessageRepo.EXPECT().
+			UpdateMessageStatus(gomock.Any(), id, "", models.MessageStatusFailed, gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil)
+	}
+	mockMessageRepo.EXPECT().
+		UpdateMessageStatus(gomock.Any(), int64(4), "", models.MessageStatusSent, gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{
+			URL:     server.URL,
+			Timeout: 1,
+			CircuitBreaker: config.CircuitBreakerConfig{
+				MaxRequests:      1,
+				Interval:         60,
+				Timeout:          60,
+				FailureRatio:     0.6,
+				ConsecutiveFails: 5,
+				PerDestination:   true,
+				Overrides: []config.CircuitBreakerOverride{
+					{Country: "+90", ConsecutiveFails: 2},
+				},
+			},
+		},
+		Scheduler: config.SchedulerConfig{
+			BatchSize: 10,
+		},
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+	require.NoError(t, err)
+
+	err = messageService.SendPendingMessages(context.Background())
+	assert.NoError(t, err)
+
+	// The third Turkish message is blocked by the open breaker.
+	assert.Equal(t, 3, requests)
+
+	statuses := messageService.GetProviderStatuses()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, api.HalfOpen, statuses[0].State)
+	require.Len(t, statuses[0].Breakers, 2)
+	assert.Equal(t, "49", statuses[0].Breakers[0].Country)
+	assert.Equal(t, api.Closed, statuses[0].Breakers[0].State)
+	assert.Equal(t, "90", statuses[0].Breakers[1].Country)
+	assert.Equal(t, "webhook-90-circuit-breaker", statuses[0].Breakers[1].Name)
+	assert.Equal(t, api.Open, statuses[0].Breakers[1].State)
+}
+
+func TestMessageService_HandleDeliveryReceipt(t *testing.T) {
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		receipt     service.DeliveryReceipt
+		setupMocks  func(*mocks.MockMessageRepository)
+		expectedErr error
+	}{
+		{
+			name:    "falls back to database lookup",
+			receipt: service.DeliveryReceipt{MessageID: "ext-1", Status: models.MessageStatusDelivered, Timestamp: timestamp},
+			setupMocks: func(m *mocks.MockMessageRepository) {
+				m.EXPECT().GetMessageByExternalID(gomock.Any(), "ext-1").Return(&models.Message{ID: 7}, nil)
+				m.EXPECT().UpdateDeliveryStatus(gomock.Any(), int64(7), models.MessageStatusDelivered, timestamp, nil).Return(nil)
+				m.EXPECT().GetMessage(gomock.Any(), int64(7)).Return(&models.Message{ID: 7, Status: models.MessageStatusDelivered}, nil)
+			},
+		},
+		{
+			name:    "unknown external id",
+			receipt: service.DeliveryReceipt{MessageID: "missing", Status: models.MessageStatusDelivered},
+			setupMocks: func(m *mocks.MockMessageRepository) {
+				m.EXPECT().GetMessageByExternalID(gomock.Any(), "missing").Return(nil, repository.ErrMessageNotFound)
+			},
+			expectedErr: service.ErrMessageNotFound,
+		},
+		{
+			name:    "invalid transition",
+			receipt: service.DeliveryReceipt{MessageID: "ext-1", Status: models.MessageStatusUndelivered, Timestamp: timestamp},
+			setupMocks: func(m *mocks.MockMessageRepository) {
+				m.EXPECT().GetMessageByExternalID(gomock.Any(), "ext-1").Return(&models.Message{ID: 7}, nil)
+				m.EXPECT().UpdateDeliveryStatus(gomock.Any(), int64(7), models.MessageStatusUndelivered, timestamp, nil).
+					Return(fmt.Errorf("%w: delivered to undelivered", repository.ErrInvalidStatusTransition))
+			},
+			expectedErr: service.ErrInvalidStatusTransition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+			mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+			tt.setupMocks(mockMessageRepo)
+
+			cfg := &config.Config{
+				Webhook: config.WebhookConfig{URL: "http://localhost", Timeout: 1},
+			}
+
+			redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+			messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+			require.NoError(t, err)
+
+			err = messageService.HandleDeliveryReceipt(context.Background(), tt.receipt)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMessageService_GetMessageByExternalID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+
+	mockMessageRepo.EXPECT().GetMessageByExternalID(gomock.Any(), "ext-1").Return(&models.Message{
+		ID:          7,
+		PhoneNumber: "+905551111111",
+		Content:     "Hello",
+		Status:      models.MessageStatusDelivered,
+		MessageID:   sql.NullString{String: "ext-1", Valid: true},
+		Provider:    sql.NullString{String: "primary", Valid: true},
+	}, nil)
+	mockMessageRepo.EXPECT().GetMessageByExternalID(gomock.Any(), "missing").Return(nil, repository.ErrMessageNotFound)
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{URL: "http://localhost", Timeout: 1},
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+	require.NoError(t, err)
+
+	result, err := messageService.GetMessageByExternalID(context.Background(), "ext-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), result.Id)
+	assert.Equal(t, models.MessageStatusDelivered, result.Status)
+	assert.Equal(t, "ext-1", *result.MessageId)
+	assert.Equal(t, "primary", *result.Provider)
+
+	_, err = messageService.GetMessageByExternalID(context.Background(), "missing")
+	assert.ErrorIs(t, err, service.ErrMessageNotFound)
+}
+
+func TestMessageService_GetMessageHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+
+	changedAt := time.Now()
+	mockMessageRepo.EXPECT().GetStatusHistory(gomock.Any(), int64(1)).Return([]*models.StatusChange{
+		{ID: 1, MessageID: 1, ToStatus: models.MessageStatusQueued, ChangedAt: changedAt},
+		{ID: 2, MessageID: 1, FromStatus: sql.NullString{String: "queued", Valid: true}, ToStatus: models.MessageStatusProcessing, ChangedAt: changedAt},
+		{ID: 3, MessageID: 1, FromStatus: sql.NullString{String: "processing", Valid: true}, ToStatus: models.MessageStatusFailed, Error: sql.NullString{String: "timeout", Valid: true}, ChangedAt: changedAt},
+	}, nil)
+	mockMessageRepo.EXPECT().GetStatusHistory(gomock.Any(), int64(2)).Return(nil, repository.ErrMessageNotFound)
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{URL: "http://localhost", Timeout: 1},
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+	require.NoError(t, err)
+
+	result, err := messageService.GetMessageHistory(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.MessageId)
+	require.Len(t, result.History, 3)
+	assert.Nil(t, result.History[0].FromStatus)
+	assert.Equal(t, models.MessageStatusProcessing, *result.History[2].FromStatus)
+	assert.Equal(t, "timeout", *result.History[2].Error)
+
+	_, err = messageService.GetMessageHistory(context.Background(), 2)
+	assert.ErrorIs(t, err, service.ErrMessageNotFound)
+}
+
+// allowAttempts accepts any delivery attempts recorded through mockRepo.
+func allowAttempts(ctrl *gomock.Controller, mockRepo *mocks.MockRepository) {
+	mockAttemptRepo := mocks.NewMockAttemptRepository(ctrl)
+	mockRepo.EXPECT().Attempt().Return(mockAttemptRepo).AnyTimes()
+	mockAttemptRepo.EXPECT().CreateAttempts(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+}
+
+func TestMessageService_GetMessageAttempts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockAttemptRepo := mocks.NewMockAttemptRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+	mockRepo.EXPECT().Attempt().Return(mockAttemptRepo).AnyTimes()
+
+	requestedAt := time.Now()
+	mockMessageRepo.EXPECT().GetMessage(gomock.Any(), int64(1)).Return(&models.Message{ID: 1}, nil)
+	mockAttemptRepo.EXPECT().GetAttempts(gomock.Any(), int64(1)).Return([]*models.DeliveryAttempt{
+		{
+			ID:           1,
+			MessageID:    1,
+			Provider:     "primary",
+			Route:        "default",
+			RequestedAt:  requestedAt,
+			LatencyMs:    120,
+			StatusCode:   sql.NullInt32{Int32: 503, Valid: true},
+			ResponseBody: sql.NullString{String: "unavailable", Valid: true},
+			Error:        sql.NullString{String: "unexpected status code: 503", Valid: true},
+			ErrorClass:   sql.NullString{String: "transient", Valid: true},
+			BreakerState: "closed",
+		},
+	}, nil)
+	mockMessageRepo.EXPECT().GetMessage(gomock.Any(), int64(2)).Return(nil, repository.ErrMessageNotFound)
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{URL: "http://localhost", Timeout: 1},
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+	require.NoError(t, err)
+
+	result, err := messageService.GetMessageAttempts(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, result.Attempts, 1)
+	attempt := result.Attempts[0]
+	assert.Equal(t, "primary", attempt.Provider)
+	assert.Equal(t, int64(120), attempt.LatencyMs)
+	assert.Equal(t, 503, *attempt.StatusCode)
+	assert.Equal(t, "unavailable", *attempt.ResponseBody)
+	assert.Equal(t, api.Transient, *attempt.ErrorClass)
+	assert.Equal(t, api.Closed, attempt.CircuitBreakerState)
+
+	_, err = messageService.GetMessageAttempts(context.Background(), 2)
+	assert.ErrorIs(t, err, service.ErrMessageNotFound)
+}
+
+func TestMessageService_SendPendingMessages_Unconfirmed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+	allowAttempts(ctrl, mockRepo)
+
+	mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 2, gomock.Any()).Return([]*models.Message{
+		{ID: 1, PhoneNumber: "+1234567890", Content: "Test", Status: models.MessageStatusProcessing},
+	}, nil)
+	mockMessageRepo.EXPECT().MarkAcceptedUnconfirmed(gomock.Any(), int64(1), "", config.DefaultProviderName, "OK").Return(nil)
+
+	cfg := &config.Config{
+		Webhook:   config.WebhookConfig{URL: server.URL, Timeout: 5},
+		Scheduler: config.SchedulerConfig{BatchSize: 2},
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+	require.NoError(t, err)
+
+	assert.NoError(t, messageService.SendPendingMessages(context.Background()))
+}
+
+func TestMessageService_SendPendingMessages_Sandbox(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("sandbox mode called the gateway")
+	}))
+	defer server.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockSandboxRepo := mocks.NewMockSandboxRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+	mockRepo.EXPECT().Sandbox().Return(mockSandboxRepo).AnyTimes()
+	allowAttempts(ctrl, mockRepo)
+
+	mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 2, gomock.Any()).Return([]*models.Message{
+		{ID: 1, PhoneNumber: "+1234567890", Content: "Test", Status: models.MessageStatusProcessing},
+	}, nil)
+
+	var recorded *models.SandboxRequest
+	mockSandboxRepo.EXPECT().CreateSandboxRequest(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *models.SandboxRequest) error {
+		recorded = req
+		return nil
+	})
+
+	providerName := config.DefaultProviderName
+	mockMessageRepo.EXPECT().
+		UpdateMessageStatus(gomock.Any(), int64(1), "", models.MessageStatusSent, gomock.Any(), &providerName, nil).
+		DoAndReturn(func(_ context.Context, _ int64, _ string, _ models.MessageStatus, externalID *string, _ *string, _ *string) error {
+			require.NotNil(t, recorded)
+			assert.Equal(t, recorded.ExternalID, *externalID)
+			return nil
+		})
+
+	cfg := &config.Config{
+		Webhook:   config.WebhookConfig{URL: server.URL, AuthKey: "secret-key", Timeout: 5},
+		Scheduler: config.SchedulerConfig{BatchSize: 2},
+		Sandbox:   config.SandboxConfig{Enabled: true},
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, messageService.SendPendingMessages(context.Background()))
+
+	assert.Equal(t, int64(1), recorded.MessageID)
+	assert.Equal(t, config.DefaultProviderName, recorded.Provider)
+	assert.Equal(t, http.MethodPost, recorded.Method)
+	assert.Equal(t, server.URL, recorded.URL)
+	assert.JSONEq(t, `{"to":"+1234567890","content":"Test"}`, recorded.Body)
+	assert.NotContains(t, string(recorded.Headers), "secret-key")
+}
+
+func TestMessageService_GetSandboxRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockSandboxRepo := mocks.NewMockSandboxRepository(ctrl)
+	mockRepo.EXPECT().Sandbox().Return(mockSandboxRepo).AnyTimes()
+
+	createdAt := time.Now()
+	mockSandboxRepo.EXPECT().GetSandboxRequests(gomock.Any(), int64(7), 10).Return([]*models.SandboxRequest{
+		{
+			ID:         1,
+			MessageID:  7,
+			Provider:   "primary",
+			ExternalID: "sandbox-1",
+			Method:     http.MethodPost,
+			URL:        "https://gateway.example.com/send",
+			Headers:    []byte(`{"Content-Type":"application/json","X-Ins-Auth-Key":"[redacted]"}`),
+			Body:       `{"to":"+1234567890","content":"Test"}`,
+			CreatedAt:  createdAt,
+		},
+	}, nil)
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{URL: "http://localhost", Timeout: 1},
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+	require.NoError(t, err)
+
+	result, err := messageService.GetSandboxRequests(context.Background(), 7, 10)
+	require.NoError(t, err)
+	require.Len(t, result.Requests, 1)
+	assert.Equal(t, "sandbox-1", result.Requests[0].ExternalId)
+	assert.Equal(t, "[redacted]", result.Requests[0].Headers["X-Ins-Auth-Key"])
+	assert.Equal(t, createdAt, result.Requests[0].CreatedAt)
+}
+
+func TestMessageService_ReconcileUnconfirmed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("ref") {
+		case "1":
+			_, _ = w.Write([]byte(`{"id":"gw-1","state":"delivered"}`))
+		case "2":
+			_, _ = w.Write([]byte(`{"state":"enroute"}`))
+		case "3":
+			_, _ = w.Write([]byte(`{"id":"gw-3","state":"enroute"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+
+	generic := sql.NullString{String: "generic", Valid: true}
+	mockMessageRepo.EXPECT().GetUnconfirmedMessages(gomock.Any(), 5).Return([]*models.Message{
+		{ID: 1, Provider: generic, Status: models.MessageStatusAcceptedUnconfirmed},
+		{ID: 2, Provider: generic, Status: models.MessageStatusAcceptedUnconfirmed},
+		{ID: 3, Provider: generic, Status: models.MessageStatusAcceptedUnconfirmed},
+		{ID: 4, Provider: generic, Status: models.MessageStatusAcceptedUnconfirmed},
+		{ID: 5, Provider: sql.NullString{String: "webhook", Valid: true}, Status: models.MessageStatusAcceptedUnconfirmed},
+	}, nil)
+	mockMessageRepo.EXPECT().ResolveUnconfirmed(gomock.Any(), int64(1), "gw-1", models.MessageStatusDelivered, nil).Return(nil)
+	mockMessageRepo.EXPECT().ResolveUnconfirmed(gomock.Any(), int64(2), "", models.MessageStatus(""), nil).Return(nil)
+	mockMessageRepo.EXPECT().ResolveUnconfirmed(gomock.Any(), int64(3), "gw-3", models.MessageStatusSent, nil).Return(nil)
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{URL: server.URL, Timeout: 5},
+		Providers: []config.ProviderConfig{
+			{Name: "webhook", Type: "webhook", URL: server.URL},
+			{
+				Name: "generic",
+				Type: "http",
+				URL:  server.URL,
+				HTTP: config.HTTPMappingConfig{
+					Body: `{"to":{{json .To}}}`,
+					Lookup: config.HTTPLookupConfig{
+						URL:        server.URL + "?ref={{.ID}}",
+						IDPath:     "$.id",
+						StatusPath: "$.state",
+						Statuses:   map[string]string{"delivered": "delivered"},
+					},
+				},
+			},
+		},
+		Routing:   config.RoutingConfig{Failover: []string{"webhook", "generic"}},
+		Scheduler: config.SchedulerConfig{ReconcileBatchSize: 5},
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+	require.NoError(t, err)
+
+	assert.NoError(t, messageService.ReconcileUnconfirmed(context.Background()))
+}
+
+func TestMessageService_CreateMessage(t *testing.T) {
+	campaign := "spring"
+	dbErr := errors.New("database error")
+
+	tests := []struct {
+		name       string
+		req        api.CreateMessageRequest
+		setupMocks func(*mocks.MockMessageRepository)
+		wantErr    error
+	}{
+		{
+			name: "queued",
+			req:  api.CreateMessageRequest{PhoneNumber: "+905551111111", Content: "Hello", Campaign: &campaign},
+			setupMocks: func(m *mocks.MockMessageRepository) {
+				m.EXPECT().InsertMessage(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, msg *models.Message) error {
+					assert.Equal(t, "spring", msg.Campaign.String)
+					msg.ID = 7
+					msg.Status = models.MessageStatusQueued
+					return nil
+				})
+			},
+		},
+		{
+			name:       "phone number without digits",
+			req:        api.CreateMessageRequest{PhoneNumber: "+", Content: "Hello"},
+			setupMocks: func(m *mocks.MockMessageRepository) {},
+			wantErr:    service.ErrInvalidMessage,
+		},
+		{
+			name:       "empty content",
+			req:        api.CreateMessageRequest{PhoneNumber: "+905551111111"},
+			setupMocks: func(m *mocks.MockMessageRepository) {},
+			wantErr:    service.ErrInvalidMessage,
+		},
+		{
+			name:       "content too long",
+			req:        api.CreateMessageRequest{PhoneNumber: "+905551111111", Content: strings.Repeat("ş", 161)},
+			setupMocks: func(m *mocks.MockMessageRepository) {},
+			wantErr:    service.ErrInvalidMessage,
+		},
+		{
+			name: "repository error",
+			req:  api.CreateMessageRequest{PhoneNumber: "+905551111111", Content: "Hello"},
+			setupMocks: func(m *mocks.MockMessageRepository) {
+				m.EXPECT().InsertMessage(gomock.Any(), gomock.Any()).Return(dbErr)
+			},
+			wantErr: dbErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+			mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+			tt.setupMocks(mockMessageRepo)
+
+			cfg := &config.Config{
+				Webhook: config.WebhookConfig{URL: "http://localhost", Timeout: 1},
+			}
+
+			redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+			messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+			require.NoError(t, err)
+
+			result, err := messageService.CreateMessage(context.Background(), tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, result)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(7), result.Id)
+			assert.Equal(t, models.MessageStatusQueued, result.Status)
+		})
+	}
+}
+
+func TestMessageService_Drain(t *testing.T) {
+	testMessages := func() []*models.Message {
+		return []*models.Message{
+			{ID: 1, PhoneNumber: "+1234567890", Content: "Test 1", Status: models.MessageStatusProcessing},
+			{ID: 2, PhoneNumber: "+0987654321", Content: "Test 2", Status: models.MessageStatusProcessing},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		// finish lets the blocked first send complete; without it the send
+		// only ends when the drain cancels it.
+		finish     bool
+		setupMocks func(*mocks.MockMessageRepository)
+		want       service.DrainReport
+	}{
+		{
+			name:    "in-flight send finishes",
+			timeout: 5 * time.Second,
+			finish:  true,
+			setupMocks: func(m *mocks.MockMessageRepository) {
+				externalID := "msg-1"
+				providerName := config.DefaultProviderName
+				m.EXPECT().UpdateMessageStatus(gomock.Any(), int64(1), "", models.MessageStatusSent, &externalID, &providerName, nil).Return(nil)
+				m.EXPECT().ReleaseMessages(gomock.Any(), []int64{2}).Return([]int64{2}, nil)
+			},
+			want: service.DrainReport{Completed: 1, Released: []int64{2}},
+		},
+		{
+			name:    "deadline cuts off in-flight send",
+			timeout: 50 * time.Millisecond,
+			setupMocks: func(m *mocks.MockMessageRepository) {
+				// The webhook provider cannot be asked whether it took the
+				// message, so it fails instead of staying unconfirmed.
+				providerName := config.DefaultProviderName
+				m.EXPECT().UpdateMessageStatus(gomock.Any(), int64(1), "", models.MessageStatusFailed, nil, &providerName, gomock.Any()).Return(nil)
+				m.EXPECT().ReleaseMessages(gomock.Any(), []int64{2}).Return([]int64{2}, nil)
+			},
+			want: service.DrainReport{Released: []int64{2}, Abandoned: []int64{1}, TimedOut: true},
+		},
+		{
+			name:    "release fails",
+			timeout: 5 * time.Second,
+			finish:  true,
+			setupMocks: func(m *mocks.MockMessageRepository) {
+				externalID := "msg-1"
+				providerName := config.DefaultProviderName
+				m.EXPECT().UpdateMessageStatus(gomock.Any(), int64(1), "", models.MessageStatusSent, &externalID, &providerName, nil).Return(nil)
+				m.EXPECT().ReleaseMessages(gomock.Any(), []int64{2}).Return(nil, errors.New("connection refused"))
+			},
+			want: service.DrainReport{Completed: 1, Abandoned: []int64{2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			started := make(chan struct{})
+			finish := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// The request is only cancelled once its body was read.
+				_, _ = io.Copy(io.Discard, r.Body)
+				close(started)
+				select {
+				case <-finish:
+				case <-r.Context().Done():
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				_ = json.NewEncoder(w).Encode(models.WebhookResponse{Message: "Accepted", MessageID: "msg-1"})
+			}))
+			defer server.Close()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+			mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+			allowAttempts(ctrl, mockRepo)
+
+			mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 2, gomock.Any()).Return(testMessages(), nil)
+			tt.setupMocks(mockMessageRepo)
+
+			cfg := &config.Config{
+				Webhook:   config.WebhookConfig{URL: server.URL, Timeout: 5},
+				Scheduler: config.SchedulerConfig{BatchSize: 2},
+			}
+
+			redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+			messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+			require.NoError(t, err)
+
+			sent := make(chan error, 1)
+			go func() {
+				sent <- messageService.SendPendingMessages(context.Background())
+			}()
+			<-started
+
+			drained := make(chan *service.DrainReport, 1)
+			go func() {
+				drained <- messageService.Drain(tt.timeout)
+			}()
+			if tt.finish {
+				// Give the drain time to start before the send completes.
+				time.Sleep(20 * time.Millisecond)
+				close(finish)
+			}
+
+			report := <-drained
+			assert.NoError(t, <-sent)
+			assert.Equal(t, tt.want.Completed, report.Completed)
+			assert.Equal(t, tt.want.Released, report.Released)
+			assert.Equal(t, tt.want.Abandoned, report.Abandoned)
+			assert.Equal(t, tt.want.TimedOut, report.TimedOut)
+
+			// Nothing is claimed after a drain.
+			assert.NoError(t, messageService.SendPendingMessages(context.Background()))
+		})
+	}
+}
+
+func TestMessageService_Drain_Idle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+
+	cfg := &config.Config{
+		Webhook:   config.WebhookConfig{URL: "http://localhost", Timeout: 1},
+		Scheduler: config.SchedulerConfig{BatchSize: 2},
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+	require.NoError(t, err)
+
+	report := messageService.Drain(time.Second)
+	assert.Zero(t, report.Completed)
+	assert.Empty(t, report.Released)
+	assert.Empty(t, report.Abandoned)
+	assert.False(t, report.TimedOut)
+
+	assert.NoError(t, messageService.SendPendingMessages(context.Background()))
+}
+
+func TestMessageService_SendPendingMessages_Cancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The request is only cancelled once its body was read.
+		_, _ = io.Copy(io.Discard, r.Body)
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	// The route fails over to secondary, which is not tried once the batch
+	// context ended.
+	secondaryCalls := 0
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls++
+	}))
+	defer secondary.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+	allowAttempts(ctrl, mockRepo)
+
+	mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 2, gomock.Any()).Return([]*models.Message{
+		{ID: 1, PhoneNumber: "+1234567890", Content: "Test 1", Status: models.MessageStatusProcessing,
+			ClaimToken: sql.NullString{String: "claim-1", Valid: true}},
+		{ID: 2, PhoneNumber: "+0987654321", Content: "Test 2", Status: models.MessageStatusProcessing},
+	}, nil)
+	// The provider may have accepted the cut off send, so it is left to
+	// reconciliation, recorded although the batch context ended, under the
+	// claim the send was made with.
+	mockMessageRepo.EXPECT().
+		MarkAcceptedUnconfirmed(gomock.Any(), int64(1), "claim-1", "primary", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ int64, _, _ string, _ string) error {
+			assert.NoError(t, ctx.Err())
+			return nil
+		})
+	mockMessageRepo.EXPECT().
+		ReleaseMessages(gomock.Any(), []int64{2}).
+		DoAndReturn(func(ctx context.Context, ids []int64) ([]int64, error) {
+			assert.NoError(t, ctx.Err())
+			return ids, nil
+		})
+
+	cfg := &config.Config{
+		Webhook: config.WebhookConfig{Timeout: 5},
+		Providers: []config.ProviderConfig{
+			{
+				Name: "primary",
+				Type: "http",
+				URL:  server.URL,
+				HTTP: config.HTTPMappingConfig{
+					Body: `{"to":{{json .To}}}`,
+					Lookup: config.HTTPLookupConfig{
+						URL:        server.URL + "?ref={{.ID}}",
+						StatusPath: "$.state",
+						Statuses:   map[string]string{"delivered": "delivered"},
+					},
+				},
+			},
+			{Name: "secondary", Type: "webhook", URL: secondary.URL},
+		},
+		Routing:   config.RoutingConfig{Failover: []string{"primary", "secondary"}},
+		Scheduler: config.SchedulerConfig{BatchSize: 2},
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	assert.NoError(t, messageService.SendPendingMessages(ctx))
+	assert.Zero(t, secondaryCalls)
+	for _, status := range messageService.GetProviderStatuses() {
+		assert.Zero(t, status.Failures, status.Name)
+	}
+}
+
+func TestMessageService_SendPendingMessages_BufferedOutcomes(t *testing.T) {
+	messages := []*models.Message{
+		{ID: 1, PhoneNumber: "+1234567890", Content: "Test 1", Status: models.MessageStatusProcessing},
+		{ID: 2, PhoneNumber: "+1234567890", Content: "Test 2", Status: models.MessageStatusProcessing},
+		{ID: 3, PhoneNumber: "+1234567890", Content: "fail", Status: models.MessageStatusProcessing},
+	}
+
+	tests := []struct {
+		name      string
+		flushSize int
+		// wantFlushes lists the message IDs written by each bulk update.
+		wantFlushes [][]int64
+	}{
+		{name: "flushed at batch end", flushSize: 10, wantFlushes: [][]int64{{1, 2, 3}}},
+		{name: "flushed when full", flushSize: 2, wantFlushes: [][]int64{{1, 2}, {3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req models.WebhookRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				if req.Content == "fail" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				_ = json.NewEncoder(w).Encode(models.WebhookResponse{Message: "Accepted", MessageID: "ext-" + req.Content[len(req.Content)-1:]})
+			}))
+			defer server.Close()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+			mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+			allowAttempts(ctrl, mockRepo)
+
+			mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 4, gomock.Any()).Return(messages, nil)
+
+			var flushes [][]int64
+			var updates []models.StatusUpdate
+			mockMessageRepo.EXPECT().UpdateMessageStatuses(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, batch []models.StatusUpdate) ([]int64, error) {
+					ids := make([]int64, len(batch))
+					for i, u := range batch {
+						ids[i] = u.ID
+					}
+					flushes = append(flushes, ids)
+					updates = append(updates, batch...)
+					return ids, nil
+				}).
+				Times(len(tt.wantFlushes))
+
+			redisServer := miniredis.RunT(t)
+			cfg := &config.Config{
+				Webhook:   config.WebhookConfig{URL: server.URL, Timeout: 5},
+				Scheduler: config.SchedulerConfig{BatchSize: 4, FlushSize: tt.flushSize, FlushIntervalMs: 60000},
+				Queue:     config.QueueConfig{Consumer: "sender-1"},
+			}
+
+			redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+			messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+			require.NoError(t, err)
+
+			require.NoError(t, messageService.SendPendingMessages(context.Background()))
+
+			assert.Equal(t, tt.wantFlushes, flushes)
+			require.Len(t, updates, 3)
+			assert.Equal(t, models.MessageStatusSent, updates[0].Status)
+			assert.Equal(t, "ext-1", updates[0].ExternalID)
+			assert.Equal(t, config.DefaultProviderName, updates[0].Provider)
+			assert.Equal(t, models.MessageStatusFailed, updates[2].Status)
+			assert.NotEmpty(t, updates[2].Error)
+
+			cached, err := redisServer.Get("message:ext-2")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(cached, "2:"))
+			assert.False(t, redisServer.Exists("messages:outcomes"))
+		})
+	}
+}
+
+func TestMessageService_SendPendingMessages_ReplaysOutcomes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(models.WebhookResponse{Message: "Accepted", MessageID: "ext-1"})
+	}))
+	defer server.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+	allowAttempts(ctrl, mockRepo)
+
+	redisServer := miniredis.RunT(t)
+	cfg := &config.Config{
+		Webhook:   config.WebhookConfig{URL: server.URL, Timeout: 5},
+		Scheduler: config.SchedulerConfig{BatchSize: 2, FlushSize: 10, FlushIntervalMs: 60000},
+		Queue:     config.QueueConfig{Consumer: "sender-1"},
+	}
+
+	// The first run sends the message but cannot write its status.
+	gomock.InOrder(
+		mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 2, gomock.Any()).Return([]*models.Message{
+			{ID: 1, PhoneNumber: "+1234567890", Content: "Test", Status: models.MessageStatusProcessing},
+		}, nil),
+		mockMessageRepo.EXPECT().UpdateMessageStatuses(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("connection refused")),
+	)
+
+	first, err := service.NewMessageService(cfg, mockRepo, redis.NewClient(&redis.Options{Addr: redisServer.Addr()}), zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, first.SendPendingMessages(context.Background()))
+	journaled, err := redisServer.HKeys("messages:outcomes")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, journaled)
+
+	// Another sender writes the journaled outcome with its first run.
+	gomock.InOrder(
+		mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 2, gomock.Any()).Return(nil, nil),
+		mockMessageRepo.EXPECT().UpdateMessageStatuses(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, updates []models.StatusUpdate) ([]int64, error) {
+				require.Len(t, updates, 1)
+				assert.Equal(t, int64(1), updates[0].ID)
+				assert.Equal(t, models.MessageStatusSent, updates[0].Status)
+				assert.Equal(t, "ext-1", updates[0].ExternalID)
+				return []int64{1}, nil
+			}),
+	)
+
+	cfg.Queue.Consumer = "sender-2"
+	second, err := service.NewMessageService(cfg, mockRepo, redis.NewClient(&redis.Options{Addr: redisServer.Addr()}), zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, second.SendPendingMessages(context.Background()))
+	assert.False(t, redisServer.Exists("messages:outcomes"))
+}
+
+func TestMessageService_SendPendingMessages_AdoptsJournaledOutcomes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sends := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sends++
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(models.WebhookResponse{Message: "Accepted", MessageID: "ext-1"})
+	}))
+	defer server.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+	allowAttempts(ctrl, mockRepo)
+
+	redisServer := miniredis.RunT(t)
+	cfg := &config.Config{
+		Webhook:   config.WebhookConfig{URL: server.URL, Timeout: 5},
+		Scheduler: config.SchedulerConfig{BatchSize: 2, FlushSize: 10, FlushIntervalMs: 60000},
+	}
+
+	// The first sender sends the message but cannot write its status, and
+	// its lease runs out.
+	gomock.InOrder(
+		mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 2, gomock.Any()).Return([]*models.Message{
+			{ID: 1, PhoneNumber: "+1234567890", Content: "Test", Status: models.MessageStatusProcessing,
+				ClaimToken: sql.NullString{String: "claim-1", Valid: true}},
+		}, nil),
+		mockMessageRepo.EXPECT().UpdateMessageStatuses(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("connection refused")),
+	)
+
+	first, err := service.NewMessageService(cfg, mockRepo, redis.NewClient(&redis.Options{Addr: redisServer.Addr()}), zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, first.SendPendingMessages(context.Background()))
+	require.Equal(t, 1, sends)
+
+	// The sender claiming it again writes the journaled outcome under its
+	// own claim instead of sending it again.
+	gomock.InOrder(
+		mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 2, gomock.Any()).Return([]*models.Message{
+			{ID: 1, PhoneNumber: "+1234567890", Content: "Test", Status: models.MessageStatusProcessing,
+				ClaimToken: sql.NullString{String: "claim-2", Valid: true}},
+		}, nil),
+		mockMessageRepo.EXPECT().UpdateMessageStatuses(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, updates []models.StatusUpdate) ([]int64, error) {
+				require.Len(t, updates, 1)
+				assert.Equal(t, models.MessageStatusSent, updates[0].Status)
+				assert.Equal(t, "ext-1", updates[0].ExternalID)
+				assert.Equal(t, "claim-2", updates[0].ClaimToken)
+				return []int64{1}, nil
+			}),
+	)
+
+	second, err := service.NewMessageService(cfg, mockRepo, redis.NewClient(&redis.Options{Addr: redisServer.Addr()}), zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, second.SendPendingMessages(context.Background()))
+	assert.Equal(t, 1, sends)
+	assert.False(t, redisServer.Exists("messages:outcomes"))
+}
+
+func TestMessageService_HandleDeliveryReceipt_JournaledOutcome(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(models.WebhookResponse{Message: "Accepted", MessageID: "ext-1"})
+	}))
+	defer server.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+	allowAttempts(ctrl, mockRepo)
+
+	redisServer := miniredis.RunT(t)
+	cfg := &config.Config{
+		Webhook:   config.WebhookConfig{URL: server.URL, Timeout: 5},
+		Scheduler: config.SchedulerConfig{BatchSize: 2, FlushSize: 10, FlushIntervalMs: 60000},
+	}
+
+	// The sender's status write fails, leaving the outcome journaled.
+	mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 2, gomock.Any()).Return([]*models.Message{
+		{ID: 1, PhoneNumber: "+1234567890", Content: "Test", Status: models.MessageStatusProcessing,
+			ClaimToken: sql.NullString{String: "claim-1", Valid: true}},
+	}, nil)
+	mockMessageRepo.EXPECT().UpdateMessageStatuses(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("connection refused"))
+
+	sender, err := service.NewMessageService(cfg, mockRepo, redis.NewClient(&redis.Options{Addr: redisServer.Addr()}), zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, sender.SendPendingMessages(context.Background()))
+
+	// The receipt reaches another instance before the outcome is written,
+	// which writes the outcome first instead of rejecting the receipt.
+	providerName := config.DefaultProviderName
+	externalID := "ext-1"
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	gomock.InOrder(
+		mockMessageRepo.EXPECT().UpdateDeliveryStatus(gomock.Any(), int64(1), models.MessageStatusDelivered, timestamp, nil).
+			Return(&service.TransitionError{MessageID: 1, From: models.MessageStatusProcessing, To: models.MessageStatusDelivered}),
+		mockMessageRepo.EXPECT().
+			UpdateMessageStatus(gomock.Any(), int64(1), "claim-1", models.MessageStatusSent, &externalID, &providerName, nil).
+			Return(nil),
+		mockMessageRepo.EXPECT().UpdateDeliveryStatus(gomock.Any(), int64(1), models.MessageStatusDelivered, timestamp, nil).
+			Return(nil),
+		mockMessageRepo.EXPECT().GetMessage(gomock.Any(), int64(1)).
+			Return(&models.Message{ID: 1, Status: models.MessageStatusDelivered}, nil),
+	)
+
+	receiver, err := service.NewMessageService(cfg, mockRepo, redis.NewClient(&redis.Options{Addr: redisServer.Addr()}), zap.NewNop())
+	require.NoError(t, err)
+	err = receiver.HandleDeliveryReceipt(context.Background(), service.DeliveryReceipt{
+		MessageID: "ext-1", Status: models.MessageStatusDelivered, Timestamp: timestamp,
+	})
+	require.NoError(t, err)
+	assert.False(t, redisServer.Exists("messages:outcomes"))
+}
+
+func TestMessageService_SendPendingMessages_OutcomesWithoutRedis(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(models.WebhookResponse{Message: "Accepted", MessageID: "ext-1"})
+	}))
+	defer server.Close()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+	mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+	allowAttempts(ctrl, mockRepo)
+
+	mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), 2, gomock.Any()).Return([]*models.Message{
+		{ID: 1, PhoneNumber: "+1234567890", Content: "Test", Status: models.MessageStatusProcessing},
+	}, nil)
+	// Outcomes that cannot be journaled are written all the same.
+	mockMessageRepo.EXPECT().UpdateMessageStatuses(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, updates []models.StatusUpdate) ([]int64, error) {
+			require.Len(t, updates, 1)
+			assert.Equal(t, models.MessageStatusSent, updates[0].Status)
+			return []int64{1}, nil
+		})
+
+	cfg := &config.Config{
+		Webhook:   config.WebhookConfig{URL: server.URL, Timeout: 5},
+		Scheduler: config.SchedulerConfig{BatchSize: 2, FlushSize: 10, FlushIntervalMs: 1000},
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+	messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+	require.NoError(t, err)
+
+	assert.NoError(t, messageService.SendPendingMessages(context.Background()))
+}
+
+func TestMessageService_SendPendingMessages_AdaptiveBatchSize(t *testing.T) {
+	succeed := func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(models.WebhookResponse{Message: "Success", MessageID: "msg-1"})
+	}
+
+	tests := []struct {
+		name              string
+		scheduler         config.SchedulerConfig
+		claimed           int
+		backlog           int64
+		serverResponse    http.HandlerFunc
+		expectedBatchSize int
+	}{
+		{
+			name:              "grows with deep backlog",
+			scheduler:         config.SchedulerConfig{BatchSize: 2, MaxBatchSize: 8, TargetLatencyMs: 1000, MaxErrorRate: 0.1},
+			claimed:           2,
+			backlog:           100,
+			serverResponse:    succeed,
+			expectedBatchSize: 4,
+		},
+		{
+			name:              "growth stops at max",
+			scheduler:         config.SchedulerConfig{BatchSize: 6, MaxBatchSize: 8, TargetLatencyMs: 1000, MaxErrorRate: 0.1},
+			claimed:           6,
+			backlog:           100,
+			serverResponse:    succeed,
+			expectedBatchSize: 8,
+		},
+		{
+			name:              "kept without backlog",
+			scheduler:         config.SchedulerConfig{BatchSize: 2, MaxBatchSize: 8, TargetLatencyMs: 1000, MaxErrorRate: 0.1},
+			claimed:           2,
+			backlog:           0,
+			serverResponse:    succeed,
+			expectedBatchSize: 2,
+		},
+		{
+			name:      "shrinks when sends fail",
+			scheduler: config.SchedulerConfig{BatchSize: 4, MaxBatchSize: 8, TargetLatencyMs: 1000, MaxErrorRate: 0.1},
+			claimed:   4,
+			backlog:   100,
+			serverResponse: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			expectedBatchSize: 2,
+		},
+		{
+			name:      "shrinks when latency exceeds target",
+			scheduler: config.SchedulerConfig{BatchSize: 4, MaxBatchSize: 8, TargetLatencyMs: 1, MaxErrorRate: 0.1},
+			claimed:   4,
+			backlog:   100,
+			serverResponse: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(5 * time.Millisecond)
+				succeed(w, r)
+			},
+			expectedBatchSize: 2,
+		},
+		{
+			name:              "shrinking stops at min",
+			scheduler:         config.SchedulerConfig{BatchSize: 1, MinBatchSize: 1, MaxBatchSize: 8, TargetLatencyMs: 1000, MaxErrorRate: 0.1},
+			claimed:           1,
+			backlog:           100,
+			serverResponse:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) },
+			expectedBatchSize: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := httptest.NewServer(tt.serverResponse)
+			defer server.Close()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockMessageRepo := mocks.NewMockMessageRepository(ctrl)
+			mockRepo.EXPECT().Message().Return(mockMessageRepo).AnyTimes()
+			allowAttempts(ctrl, mockRepo)
+
+			messages := make([]*models.Message, tt.claimed)
+			for i := range messages {
+				messages[i] = &models.Message{ID: int64(i + 1), PhoneNumber: "+1234567890", Content: "Test", Status: models.MessageStatusProcessing}
+			}
+			mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), tt.scheduler.BatchSize, gomock.Any()).Return(messages, nil)
+			// A full batch is followed by another claim, at the new size.
+			mockMessageRepo.EXPECT().ClaimMessages(gomock.Any(), tt.expectedBatchSize, gomock.Any()).Return(nil, nil).MaxTimes(1)
+			mockMessageRepo.EXPECT().
+				UpdateMessageStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil).
+				Times(tt.claimed)
+			mockMessageRepo.EXPECT().GetQueuedCount(gomock.Any()).Return(tt.backlog, nil)
+
+			tt.scheduler.AdaptiveBatch = true
+			cfg := &config.Config{
+				Webhook: config.WebhookConfig{
+					URL:     server.URL,
+					Timeout: 5,
+					CircuitBreaker: config.CircuitBreakerConfig{
+						MaxRequests:      10,
+						Interval:         60,
+						Timeout:          60,
+						FailureRatio:     0.6,
+						ConsecutiveFails: 100,
+					},
+				},
+				Scheduler: tt.scheduler,
+			}
+
+			redisClient := redis.NewClient(&redis.Options{Addr: "localhost:9999"})
+			messageService, err := service.NewMessageService(cfg, mockRepo, redisClient, zap.NewNop())
+			require.NoError(t, err)
+
+			require.NoError(t, messageService.SendPendingMessages(context.Background()))
+			assert.Equal(t, tt.expectedBatchSize, messageService.BatchSize())
+		})
+	}
+}
+
+func TestMessageService_BatchSize_Fixed(t *testing.T) {
+	cfg := &config.Config{
+		Webhook:   config.WebhookConfig{URL: "http://localhost:1234"},
+		Scheduler: config.SchedulerConfig{BatchSize: 20, MaxBatchSize: 8},
+	}
+
+	messageService, err := service.NewMessageService(cfg, nil, redis.NewClient(&redis.Options{Addr: "localhost:9999"}), zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 20, messageService.BatchSize())
+
+	cfg.Scheduler.AdaptiveBatch = true
+	messageService, err = service.NewMessageService(cfg, nil, redis.NewClient(&redis.Options{Addr: "localhost:9999"}), zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 8, messageService.BatchSize(), "initial size is clamped to the bounds")
+}
@@ -1,19 +1,24 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/popeskul/insdr-messenger/internal/service (interfaces: MessageService,SchedulerService,HealthService)
+// Source: github.com/popeskul/insdr-messenger/internal/service (interfaces: MessageService,SchedulerService,EventService,StreamService,HealthService)
 //
 // Generated by this command:
 //
-//	mockgen -destination=mocks/mock_services.go -package=mocks github.com/popeskul/insdr-messenger/internal/service MessageService,SchedulerService,HealthService
+//	mockgen -destination=mocks/mock_services.go -package=mocks github.com/popeskul/insdr-messenger/internal/service MessageService,SchedulerService,EventService,StreamService,HealthService
 //
 
 // Package mocks is a generated GoMock package.
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	api "github.com/popeskul/insdr-messenger/internal/api"
+	models "github.com/popeskul/insdr-messenger/internal/models"
+	routing "github.com/popeskul/insdr-messenger/internal/routing"
 	service "github.com/popeskul/insdr-messenger/internal/service"
+	stream "github.com/popeskul/insdr-messenger/internal/stream"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -41,6 +46,49 @@ func (m *MockMessageService) EXPECT() *MockMessageServiceMockRecorder {
 	return m.recorder
 }
 
+// BatchSize mocks base method.
+func (m *MockMessageService) BatchSize() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchSize")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// BatchSize indicates an expected call of BatchSize.
+func (mr *MockMessageServiceMockRecorder) BatchSize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchSize", reflect.TypeOf((*MockMessageService)(nil).BatchSize))
+}
+
+// CreateMessage mocks base method.
+func (m *MockMessageService) CreateMessage(ctx context.Context, req api.CreateMessageRequest) (*api.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMessage", ctx, req)
+	ret0, _ := ret[0].(*api.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMessage indicates an expected call of CreateMessage.
+func (mr *MockMessageServiceMockRecorder) CreateMessage(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockMessageService)(nil).CreateMessage), ctx, req)
+}
+
+// Drain mocks base method.
+func (m *MockMessageService) Drain(timeout time.Duration) *service.DrainReport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Drain", timeout)
+	ret0, _ := ret[0].(*service.DrainReport)
+	return ret0
+}
+
+// Drain indicates an expected call of Drain.
+func (mr *MockMessageServiceMockRecorder) Drain(timeout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Drain", reflect.TypeOf((*MockMessageService)(nil).Drain), timeout)
+}
+
 // GetCircuitBreakerStatus mocks base method.
 func (m *MockMessageService) GetCircuitBreakerStatus() (api.HealthResponseCircuitBreakerState, uint32, uint32) {
 	m.ctrl.T.Helper()
@@ -57,33 +105,163 @@ func (mr *MockMessageServiceMockRecorder) GetCircuitBreakerStatus() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCircuitBreakerStatus", reflect.TypeOf((*MockMessageService)(nil).GetCircuitBreakerStatus))
 }
 
+// GetMessageAttempts mocks base method.
+func (m *MockMessageService) GetMessageAttempts(ctx context.Context, id int64) (*api.MessageAttemptsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessageAttempts", ctx, id)
+	ret0, _ := ret[0].(*api.MessageAttemptsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessageAttempts indicates an expected call of GetMessageAttempts.
+func (mr *MockMessageServiceMockRecorder) GetMessageAttempts(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessageAttempts", reflect.TypeOf((*MockMessageService)(nil).GetMessageAttempts), ctx, id)
+}
+
+// GetMessageByExternalID mocks base method.
+func (m *MockMessageService) GetMessageByExternalID(ctx context.Context, externalID string) (*api.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessageByExternalID", ctx, externalID)
+	ret0, _ := ret[0].(*api.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessageByExternalID indicates an expected call of GetMessageByExternalID.
+func (mr *MockMessageServiceMockRecorder) GetMessageByExternalID(ctx, externalID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessageByExternalID", reflect.TypeOf((*MockMessageService)(nil).GetMessageByExternalID), ctx, externalID)
+}
+
+// GetMessageHistory mocks base method.
+func (m *MockMessageService) GetMessageHistory(ctx context.Context, id int64) (*api.MessageHistoryResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessageHistory", ctx, id)
+	ret0, _ := ret[0].(*api.MessageHistoryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessageHistory indicates an expected call of GetMessageHistory.
+func (mr *MockMessageServiceMockRecorder) GetMessageHistory(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessageHistory", reflect.TypeOf((*MockMessageService)(nil).GetMessageHistory), ctx, id)
+}
+
+// GetProviderStats mocks base method.
+func (m *MockMessageService) GetProviderStats() []service.ProviderStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProviderStats")
+	ret0, _ := ret[0].([]service.ProviderStats)
+	return ret0
+}
+
+// GetProviderStats indicates an expected call of GetProviderStats.
+func (mr *MockMessageServiceMockRecorder) GetProviderStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProviderStats", reflect.TypeOf((*MockMessageService)(nil).GetProviderStats))
+}
+
+// GetProviderStatuses mocks base method.
+func (m *MockMessageService) GetProviderStatuses() []service.ProviderStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProviderStatuses")
+	ret0, _ := ret[0].([]service.ProviderStatus)
+	return ret0
+}
+
+// GetProviderStatuses indicates an expected call of GetProviderStatuses.
+func (mr *MockMessageServiceMockRecorder) GetProviderStatuses() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProviderStatuses", reflect.TypeOf((*MockMessageService)(nil).GetProviderStatuses))
+}
+
+// GetSandboxRequests mocks base method.
+func (m *MockMessageService) GetSandboxRequests(ctx context.Context, messageID int64, limit int) (*api.SandboxRequestListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSandboxRequests", ctx, messageID, limit)
+	ret0, _ := ret[0].(*api.SandboxRequestListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSandboxRequests indicates an expected call of GetSandboxRequests.
+func (mr *MockMessageServiceMockRecorder) GetSandboxRequests(ctx, messageID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSandboxRequests", reflect.TypeOf((*MockMessageService)(nil).GetSandboxRequests), ctx, messageID, limit)
+}
+
 // GetSentMessages mocks base method.
-func (m *MockMessageService) GetSentMessages(page, limit int) (*api.MessageListResponse, error) {
+func (m *MockMessageService) GetSentMessages(ctx context.Context, page, limit int) (*api.MessageListResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSentMessages", page, limit)
+	ret := m.ctrl.Call(m, "GetSentMessages", ctx, page, limit)
 	ret0, _ := ret[0].(*api.MessageListResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetSentMessages indicates an expected call of GetSentMessages.
-func (mr *MockMessageServiceMockRecorder) GetSentMessages(page, limit any) *gomock.Call {
+func (mr *MockMessageServiceMockRecorder) GetSentMessages(ctx, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSentMessages", reflect.TypeOf((*MockMessageService)(nil).GetSentMessages), ctx, page, limit)
+}
+
+// HandleDeliveryReceipt mocks base method.
+func (m *MockMessageService) HandleDeliveryReceipt(ctx context.Context, receipt service.DeliveryReceipt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleDeliveryReceipt", ctx, receipt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleDeliveryReceipt indicates an expected call of HandleDeliveryReceipt.
+func (mr *MockMessageServiceMockRecorder) HandleDeliveryReceipt(ctx, receipt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleDeliveryReceipt", reflect.TypeOf((*MockMessageService)(nil).HandleDeliveryReceipt), ctx, receipt)
+}
+
+// ReconcileUnconfirmed mocks base method.
+func (m *MockMessageService) ReconcileUnconfirmed(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileUnconfirmed", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileUnconfirmed indicates an expected call of ReconcileUnconfirmed.
+func (mr *MockMessageServiceMockRecorder) ReconcileUnconfirmed(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSentMessages", reflect.TypeOf((*MockMessageService)(nil).GetSentMessages), page, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileUnconfirmed", reflect.TypeOf((*MockMessageService)(nil).ReconcileUnconfirmed), ctx)
+}
+
+// ResolveRoute mocks base method.
+func (m *MockMessageService) ResolveRoute(dest routing.Destination) routing.Route {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveRoute", dest)
+	ret0, _ := ret[0].(routing.Route)
+	return ret0
+}
+
+// ResolveRoute indicates an expected call of ResolveRoute.
+func (mr *MockMessageServiceMockRecorder) ResolveRoute(dest any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveRoute", reflect.TypeOf((*MockMessageService)(nil).ResolveRoute), dest)
 }
 
 // SendPendingMessages mocks base method.
-func (m *MockMessageService) SendPendingMessages() error {
+func (m *MockMessageService) SendPendingMessages(ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SendPendingMessages")
+	ret := m.ctrl.Call(m, "SendPendingMessages", ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SendPendingMessages indicates an expected call of SendPendingMessages.
-func (mr *MockMessageServiceMockRecorder) SendPendingMessages() *gomock.Call {
+func (mr *MockMessageServiceMockRecorder) SendPendingMessages(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendPendingMessages", reflect.TypeOf((*MockMessageService)(nil).SendPendingMessages))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendPendingMessages", reflect.TypeOf((*MockMessageService)(nil).SendPendingMessages), ctx)
 }
 
 // MockSchedulerService is a mock of SchedulerService interface.
@@ -110,6 +288,20 @@ func (m *MockSchedulerService) EXPECT() *MockSchedulerServiceMockRecorder {
 	return m.recorder
 }
 
+// Drain mocks base method.
+func (m *MockSchedulerService) Drain(timeout time.Duration) *service.DrainReport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Drain", timeout)
+	ret0, _ := ret[0].(*service.DrainReport)
+	return ret0
+}
+
+// Drain indicates an expected call of Drain.
+func (mr *MockSchedulerServiceMockRecorder) Drain(timeout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Drain", reflect.TypeOf((*MockSchedulerService)(nil).Drain), timeout)
+}
+
 // IsRunning mocks base method.
 func (m *MockSchedulerService) IsRunning() bool {
 	m.ctrl.T.Helper()
@@ -152,6 +344,212 @@ func (mr *MockSchedulerServiceMockRecorder) Stop() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockSchedulerService)(nil).Stop))
 }
 
+// MockEventService is a mock of EventService interface.
+type MockEventService struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockEventServiceMockRecorder is the mock recorder for MockEventService.
+type MockEventServiceMockRecorder struct {
+	mock *MockEventService
+}
+
+// NewMockEventService creates a new mock instance.
+func NewMockEventService(ctrl *gomock.Controller) *MockEventService {
+	mock := &MockEventService{ctrl: ctrl}
+	mock.recorder = &MockEventServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventService) EXPECT() *MockEventServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateSubscription mocks base method.
+func (m *MockEventService) CreateSubscription(ctx context.Context, req api.CreateSubscriptionRequest) (*api.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubscription", ctx, req)
+	ret0, _ := ret[0].(*api.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSubscription indicates an expected call of CreateSubscription.
+func (mr *MockEventServiceMockRecorder) CreateSubscription(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubscription", reflect.TypeOf((*MockEventService)(nil).CreateSubscription), ctx, req)
+}
+
+// DeleteSubscription mocks base method.
+func (m *MockEventService) DeleteSubscription(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubscription", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSubscription indicates an expected call of DeleteSubscription.
+func (mr *MockEventServiceMockRecorder) DeleteSubscription(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscription", reflect.TypeOf((*MockEventService)(nil).DeleteSubscription), ctx, id)
+}
+
+// DispatchEvents mocks base method.
+func (m *MockEventService) DispatchEvents(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DispatchEvents", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DispatchEvents indicates an expected call of DispatchEvents.
+func (mr *MockEventServiceMockRecorder) DispatchEvents(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DispatchEvents", reflect.TypeOf((*MockEventService)(nil).DispatchEvents), ctx)
+}
+
+// GetDeadLetters mocks base method.
+func (m *MockEventService) GetDeadLetters(ctx context.Context, id int64, limit int) (*api.DeadLetterListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadLetters", ctx, id, limit)
+	ret0, _ := ret[0].(*api.DeadLetterListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetters indicates an expected call of GetDeadLetters.
+func (mr *MockEventServiceMockRecorder) GetDeadLetters(ctx, id, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLetters", reflect.TypeOf((*MockEventService)(nil).GetDeadLetters), ctx, id, limit)
+}
+
+// ListSubscriptions mocks base method.
+func (m *MockEventService) ListSubscriptions(ctx context.Context) (*api.SubscriptionListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriptions", ctx)
+	ret0, _ := ret[0].(*api.SubscriptionListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptions indicates an expected call of ListSubscriptions.
+func (mr *MockEventServiceMockRecorder) ListSubscriptions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptions", reflect.TypeOf((*MockEventService)(nil).ListSubscriptions), ctx)
+}
+
+// Publish mocks base method.
+func (m *MockEventService) Publish(ctx context.Context, eventType models.EventType, data any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, eventType, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockEventServiceMockRecorder) Publish(ctx, eventType, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockEventService)(nil).Publish), ctx, eventType, data)
+}
+
+// Start mocks base method.
+func (m *MockEventService) Start() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Start")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockEventServiceMockRecorder) Start() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockEventService)(nil).Start))
+}
+
+// Stop mocks base method.
+func (m *MockEventService) Stop() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stop")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stop indicates an expected call of Stop.
+func (mr *MockEventServiceMockRecorder) Stop() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockEventService)(nil).Stop))
+}
+
+// MockStreamService is a mock of StreamService interface.
+type MockStreamService struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockStreamServiceMockRecorder is the mock recorder for MockStreamService.
+type MockStreamServiceMockRecorder struct {
+	mock *MockStreamService
+}
+
+// NewMockStreamService creates a new mock instance.
+func NewMockStreamService(ctrl *gomock.Controller) *MockStreamService {
+	mock := &MockStreamService{ctrl: ctrl}
+	mock.recorder = &MockStreamServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStreamService) EXPECT() *MockStreamServiceMockRecorder {
+	return m.recorder
+}
+
+// Start mocks base method.
+func (m *MockStreamService) Start() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Start")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockStreamServiceMockRecorder) Start() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockStreamService)(nil).Start))
+}
+
+// Stop mocks base method.
+func (m *MockStreamService) Stop() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stop")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stop indicates an expected call of Stop.
+func (mr *MockStreamServiceMockRecorder) Stop() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockStreamService)(nil).Stop))
+}
+
+// Subscribe mocks base method.
+func (m *MockStreamService) Subscribe(ctx context.Context, lastEventID string, filter stream.Filter) (*stream.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, lastEventID, filter)
+	ret0, _ := ret[0].(*stream.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockStreamServiceMockRecorder) Subscribe(ctx, lastEventID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockStreamService)(nil).Subscribe), ctx, lastEventID, filter)
+}
+
 // MockHealthService is a mock of HealthService interface.
 type MockHealthService struct {
 	ctrl     *gomock.Controller
@@ -177,15 +575,15 @@ func (m *MockHealthService) EXPECT() *MockHealthServiceMockRecorder {
 }
 
 // GetHealth mocks base method.
-func (m *MockHealthService) GetHealth() *service.HealthStatus {
+func (m *MockHealthService) GetHealth(ctx context.Context) *service.HealthStatus {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetHealth")
+	ret := m.ctrl.Call(m, "GetHealth", ctx)
 	ret0, _ := ret[0].(*service.HealthStatus)
 	return ret0
 }
 
 // GetHealth indicates an expected call of GetHealth.
-func (mr *MockHealthServiceMockRecorder) GetHealth() *gomock.Call {
+func (mr *MockHealthServiceMockRecorder) GetHealth(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHealth", reflect.TypeOf((*MockHealthService)(nil).GetHealth))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHealth", reflect.TypeOf((*MockHealthService)(nil).GetHealth), ctx)
 }
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
+
+// outcomeJournal is the Redis hash journaling send outcomes whose write
+// is under way, keyed by message ID. It is shared by all senders so that
+// any of them replays the outcomes of one that crashed.
+const outcomeJournal = "messages:outcomes"
+
+// outcome is a send outcome waiting to be written, together with the
+// message it belongs to for acknowledgement and live events.
+type outcome struct {
+	Update  models.StatusUpdate `json:"update"`
+	Message *models.Message     `json:"message"`
+
+	journaled bool
+}
+
+// outcomeBuffer collects send outcomes so that they are written with one
+// statement. Each outcome is journaled in Redis before it is buffered, so
+// outcomes not written yet survive a crash: they are written by the next
+// sender that starts, or that claims their messages again.
+type outcomeBuffer struct {
+	size     int
+	interval time.Duration
+
+	mu       sync.Mutex
+	pending  []outcome
+	since    time.Time
+	replayed bool
+}
+
+// newOutcomeBuffer returns a buffer, or nil when outcomes are written one
+// by one.
+func newOutcomeBuffer(size int, interval time.Duration) *outcomeBuffer {
+	if size <= 1 {
+		return nil
+	}
+	return &outcomeBuffer{
+		size:     size,
+		interval: interval,
+	}
+}
+
+// recordOutcome writes the outcome of a send, buffered when enabled. A
+// buffered outcome is journaled first. The buffer is flushed when it is
+// full or its oldest outcome waited for the flush interval.
+func (s *messageService) recordOutcome(ctx context.Context, msg *models.Message, update models.StatusUpdate) error {
+	if s.outcomes == nil {
+		return s.applyOutcome(ctx, msg, update)
+	}
+
+	recorded := []outcome{{Update: update, Message: msg}}
+	s.journalOutcomes(ctx, recorded)
+
+	b := s.outcomes
+	b.mu.Lock()
+	if len(b.pending) == 0 {
+		b.since = time.Now()
+	}
+	b.pending = append(b.pending, recorded[0])
+	due := len(b.pending) >= b.size || time.Since(b.since) >= b.interval
+	b.mu.Unlock()
+
+	if due {
+		s.flushOutcomes(ctx)
+	}
+	return nil
+}
+
+// applyOutcome writes a single outcome.
+func (s *messageService) applyOutcome(ctx context.Context, msg *models.Message, update models.StatusUpdate) error {
+	var externalID, provider, errMsg *string
+	if update.ExternalID != "" {
+		externalID = &update.ExternalID
+	}
+	if update.Provider != "" {
+		provider = &update.Provider
+	}
+	if update.Error != "" {
+		errMsg = &update.Error
+	}
+
+	if err := s.repo.Message().UpdateMessageStatus(ctx, update.ID, update.ClaimToken, update.Status, externalID, provider, errMsg); err != nil {
+		return fmt.Errorf("failed to update message status: %w", err)
+	}
+
+	s.ack(ctx, update.ID)
+	if update.ExternalID != "" {
+		s.cacheExternalID(ctx, update.ExternalID, update.ID)
+	}
+	s.publishStatus(ctx, msg, update.Status, update.Provider, update.ExternalID, errMsg)
+	return nil
+}
+
+// flushOutcomes writes the buffered outcomes with one bulk update, then
+// clears the journal in one Redis pipeline. Outcomes whose journaling
+// failed are journaled again first. When the update fails the outcomes stay
+// buffered and journaled for the next flush.
+func (s *messageService) flushOutcomes(ctx context.Context) {
+	b := s.outcomes
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.replayed {
+		b.replayed = s.replayOutcomes(ctx)
+	}
+	if len(b.pending) == 0 {
+		return
+	}
+
+	s.journalOutcomes(ctx, b.pending)
+
+	updates := make([]models.StatusUpdate, len(b.pending))
+	for i, o := range b.pending {
+		updates[i] = o.Update
+	}
+
+	updated, err := s.repo.Message().UpdateMessageStatuses(ctx, updates)
+	if err != nil {
+		s.logger.Error("Failed to write message statuses, retrying with the next flush",
+			zap.Int("count", len(updates)),
+			zap.Error(err))
+		return
+	}
+
+	isUpdated := make(map[int64]bool, len(updated))
+	for _, id := range updated {
+		isUpdated[id] = true
+	}
+
+	pipe := s.redisClient.Pipeline()
+	fields := make([]string, len(b.pending))
+	for i, o := range b.pending {
+		fields[i] = strconv.FormatInt(o.Update.ID, 10)
+		// Journaled outcomes cached their external ID with the journal.
+		if isUpdated[o.Update.ID] && !o.journaled && o.Update.ExternalID != "" {
+			key, value := externalIDCacheEntry(o.Update.ExternalID, o.Update.ID)
+			pipe.Set(ctx, key, value, externalIDCacheTTL)
+		}
+	}
+	pipe.HDel(ctx, outcomeJournal, fields...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.logger.Warn("Failed to cache message IDs and clear outcome journal", zap.Error(err))
+	}
+
+	for _, o := range b.pending {
+		if !isUpdated[o.Update.ID] {
+			// The message moved on, for example by a replay of the journal,
+			// or was claimed again by another sender.
+			s.logger.Warn("Message status not written, transition not allowed or claim lost",
+				zap.Int64("messageID", o.Update.ID),
+				zap.String("status", string(o.Update.Status)))
+			continue
+		}
+
+		var errMsg *string
+		if o.Update.Error != "" {
+			errMsg = &o.Update.Error
+		}
+		s.ack(ctx, o.Update.ID)
+		s.publishStatus(ctx, o.Message, o.Update.Status, o.Update.Provider, o.Update.ExternalID, errMsg)
+	}
+
+	s.logger.Info("Message statuses written",
+		zap.Int("count", len(updates)),
+		zap.Int("updated", len(updated)))
+
+	b.pending = b.pending[:0]
+}
+
+// journalOutcomes stores the outcomes not journaled yet and marks the ones
+// written. The external IDs are cached in the same pipeline, so that
+// delivery receipts find their messages before the outcomes are written.
+// Without the journal the outcomes are still written, they are only lost
+// if the write fails and the sender stops before the next flush.
+func (s *messageService) journalOutcomes(ctx context.Context, outcomes []outcome) {
+	entries := make(map[string]interface{})
+	var encoded []int
+	pipe := s.redisClient.Pipeline()
+	for i, o := range outcomes {
+		if o.journaled {
+			continue
+		}
+		entry, err := json.Marshal(o)
+		if err != nil {
+			s.logger.Warn("Failed to encode outcome",
+				zap.Int64("messageID", o.Update.ID),
+				zap.Error(err))
+			continue
+		}
+		entries[strconv.FormatInt(o.Update.ID, 10)] = entry
+		encoded = append(encoded, i)
+		if o.Update.ExternalID != "" {
+			key, value := externalIDCacheEntry(o.Update.ExternalID, o.Update.ID)
+			pipe.Set(ctx, key, value, externalIDCacheTTL)
+		}
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	journal := pipe.HSet(ctx, outcomeJournal, entries)
+	_, _ = pipe.Exec(ctx)
+	if err := journal.Err(); err != nil {
+		s.logger.Warn("Failed to journal outcomes", zap.Int("count", len(entries)), zap.Error(err))
+		return
+	}
+	for _, i := range encoded {
+		outcomes[i].journaled = true
+	}
+}
+
+// adoptOutcomes takes over the journaled outcomes of claimed messages and
+// returns the messages still to be sent. A message is only claimed again
+// while processing when the sender holding it stopped or its lease ran
+// out; if that sender journaled an outcome the message was sent, so the
+// outcome is buffered under the new claim instead of sending it again.
+func (s *messageService) adoptOutcomes(ctx context.Context, messages []*models.Message) []*models.Message {
+	b := s.outcomes
+	if b == nil || len(messages) == 0 {
+		return messages
+	}
+
+	fields := make([]string, len(messages))
+	for i, msg := range messages {
+		fields[i] = strconv.FormatInt(msg.ID, 10)
+	}
+	entries, err := s.redisClient.HMGet(ctx, outcomeJournal, fields...).Result()
+	if err != nil {
+		s.logger.Warn("Failed to read outcome journal", zap.Error(err))
+		return messages
+	}
+
+	unsent := make([]*models.Message, 0, len(messages))
+	adopted := make(map[int64]outcome)
+	for i, msg := range messages {
+		entry, ok := entries[i].(string)
+		var o outcome
+		if !ok || json.Unmarshal([]byte(entry), &o) != nil {
+			unsent = append(unsent, msg)
+			continue
+		}
+
+		o.Update.ClaimToken = msg.ClaimToken.String
+		o.Message = msg
+		o.journaled = true
+		adopted[msg.ID] = o
+
+		s.logger.Info("Claimed message has a journaled outcome, not sending it again",
+			zap.Int64("messageID", msg.ID),
+			zap.String("status", string(o.Update.Status)))
+	}
+	if len(adopted) == 0 {
+		return messages
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pending := b.pending[:0]
+	for _, o := range b.pending {
+		if _, ok := adopted[o.Update.ID]; !ok {
+			pending = append(pending, o)
+		}
+	}
+	if len(pending) == 0 {
+		b.since = time.Now()
+	}
+	for _, msg := range messages {
+		if o, ok := adopted[msg.ID]; ok {
+			pending = append(pending, o)
+		}
+	}
+	b.pending = pending
+
+	return unsent
+}
+
+// settleOutcome writes the outcome of a message ahead of its flush,
+// whether it is buffered here or journaled by another sender. It reports
+// whether an outcome was found.
+func (s *messageService) settleOutcome(ctx context.Context, id int64) bool {
+	b := s.outcomes
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	buffered := false
+	for _, o := range b.pending {
+		if o.Update.ID == id {
+			buffered = true
+			break
+		}
+	}
+	b.mu.Unlock()
+
+	if buffered {
+		s.flushOutcomes(ctx)
+		return true
+	}
+
+	field := strconv.FormatInt(id, 10)
+	entry, err := s.redisClient.HGet(ctx, outcomeJournal, field).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			s.logger.Warn("Failed to read outcome journal", zap.Int64("messageID", id), zap.Error(err))
+		}
+		return false
+	}
+
+	var o outcome
+	if err := json.Unmarshal([]byte(entry), &o); err != nil || o.Message == nil {
+		return false
+	}
+	if err := s.applyOutcome(ctx, o.Message, o.Update); err != nil {
+		s.logger.Warn("Failed to write journaled outcome",
+			zap.Int64("messageID", id),
+			zap.Error(err))
+		return false
+	}
+	// The sender that journaled it skips the outcome with its flush.
+	if err := s.redisClient.HDel(ctx, outcomeJournal, field).Err(); err != nil {
+		s.logger.Warn("Failed to clear outcome journal", zap.Int64("messageID", id), zap.Error(err))
+	}
+	return true
+}
+
+// replayOutcomes buffers the journaled outcomes left behind by senders
+// that stopped before their write succeeded. It reports whether the
+// journal could be read. Outcomes of a running sender may be replayed as
+// well; the update only applies allowed transitions, so they are written
+// once. The caller holds the buffer lock.
+func (s *messageService) replayOutcomes(ctx context.Context) bool {
+	b := s.outcomes
+
+	entries, err := s.redisClient.HGetAll(ctx, outcomeJournal).Result()
+	if err != nil {
+		s.logger.Warn("Failed to read outcome journal", zap.Error(err))
+		return false
+	}
+
+	buffered := make(map[int64]bool, len(b.pending))
+	for _, o := range b.pending {
+		buffered[o.Update.ID] = true
+	}
+
+	replayed := 0
+	for field, entry := range entries {
+		var o outcome
+		if err := json.Unmarshal([]byte(entry), &o); err != nil || o.Message == nil {
+			s.logger.Warn("Dropping malformed outcome journal entry",
+				zap.String("field", field),
+				zap.Error(err))
+			s.redisClient.HDel(ctx, outcomeJournal, field)
+			continue
+		}
+		if buffered[o.Update.ID] {
+			continue
+		}
+		o.journaled = true
+		b.pending = append(b.pending, o)
+		replayed++
+	}
+
+	if replayed > 0 {
+		s.logger.Info("Replaying journaled outcomes", zap.Int("count", replayed))
+	}
+	return true
+}
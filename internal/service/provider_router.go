@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/api"
+	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
+	"github.com/popeskul/insdr-messenger/internal/provider"
+	"github.com/popeskul/insdr-messenger/internal/routing"
+)
+
+// routedProvider pairs a delivery provider with its delivery statistics.
+type routedProvider struct {
+	provider provider.Provider
+	stats    *providerStats
+}
+
+// delivery describes a message accepted by one of the providers.
+type delivery struct {
+	provider string
+	route    string
+	result   *provider.Result
+}
+
+// destinationOf returns the routing attributes of a message.
+func destinationOf(msg *models.Message) routing.Destination {
+	return routing.Destination{
+		PhoneNumber: msg.PhoneNumber,
+		Priority:    msg.Priority,
+		Campaign:    msg.Campaign.String,
+	}
+}
+
+// providerRouter delivers messages through the ordered provider list of the
+// route matching each destination. When a provider's breaker is open or a
+// send fails transiently the next provider in the list is tried; permanent
+// failures stop the chain.
+type providerRouter struct {
+	providers map[string]*routedProvider
+	order     []string
+	table     *routing.Table
+	breakers  *breakerManager
+	logger    *zap.Logger
+}
+
+func newProviderRouter(cfg *config.Config, logger *zap.Logger) (*providerRouter, error) {
+	failover := cfg.FailoverOrder()
+	if len(failover) == 0 {
+		return nil, fmt.Errorf("no delivery providers configured")
+	}
+
+	var known []string
+	for _, pc := range cfg.ProviderConfigs() {
+		known = append(known, pc.Name)
+	}
+
+	fallback := config.RouteConfig{Providers: failover, Split: cfg.Routing.Split}
+	table, err := routing.NewTable(cfg.Routing.Routes, fallback, known)
+	if err != nil {
+		return nil, fmt.Errorf("invalid routing configuration: %w", err)
+	}
+
+	router := &providerRouter{
+		providers: make(map[string]*routedProvider),
+		table:     table,
+		breakers:  newBreakerManager(cfg.Webhook.CircuitBreaker, logger),
+		logger:    logger,
+	}
+
+	referenced := append([]string{}, failover...)
+	for _, w := range cfg.Routing.Split {
+		referenced = append(referenced, w.Provider)
+	}
+	for _, route := range cfg.Routing.Routes {
+		referenced = append(referenced, route.Providers...)
+		for _, w := range route.Split {
+			referenced = append(referenced, w.Provider)
+		}
+	}
+
+	for _, name := range referenced {
+		if _, ok := router.providers[name]; ok {
+			continue
+		}
+
+		p, err := provider.Select(cfg, name, provider.Options{Logger: logger})
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Sandbox.Enabled {
+			p = provider.NewSandbox(p)
+		}
+
+		router.providers[name] = &routedProvider{
+			provider: p,
+			stats:    newProviderStats(),
+		}
+		router.order = append(router.order, name)
+	}
+
+	if cfg.Sandbox.Enabled {
+		logger.Warn("Sandbox mode enabled, messages are recorded instead of sent",
+			zap.Strings("providers", router.order))
+	}
+
+	return router, nil
+}
+
+// Lookup returns the named provider if it supports status lookups.
+func (r *providerRouter) Lookup(name string) (provider.StatusLookup, bool) {
+	rp, ok := r.providers[name]
+	if !ok {
+		return nil, false
+	}
+
+	lookup, ok := rp.provider.(provider.StatusLookup)
+	return lookup, ok
+}
+
+// Resolve returns the route used for the destination.
+func (r *providerRouter) Resolve(dest routing.Destination) routing.Route {
+	return r.table.Resolve(dest)
+}
+
+// Send delivers msg through the first provider of its route that accepts it.
+// Every provider tried is reported as a delivery attempt, including the ones
+// skipped because their breaker was open.
+func (r *providerRouter) Send(ctx context.Context, msg *models.Message) (*delivery, []*models.DeliveryAttempt, error) {
+	route := r.table.Resolve(destinationOf(msg))
+	country := routing.CountryCode(msg.PhoneNumber)
+
+	var (
+		attempts []*models.DeliveryAttempt
+		lastErr  error
+	)
+	for i, name := range route.Providers {
+		rp := r.providers[name]
+		breaker := r.breakers.Get(name, country)
+
+		attempt := &models.DeliveryAttempt{
+			MessageID:    msg.ID,
+			Provider:     name,
+			Route:        route.Name,
+			RequestedAt:  time.Now(),
+			BreakerState: string(breaker.GetState()),
+		}
+
+		var result *provider.Result
+		err := breaker.Execute(ctx, func() error {
+			start := time.Now()
+			var sendErr error
+			result, sendErr = rp.provider.Send(ctx, msg)
+			rp.stats.record(time.Since(start), sendErr)
+			return sendErr
+		})
+		attempt.LatencyMs = time.Since(attempt.RequestedAt).Milliseconds()
+		recordOutcome(attempt, result, err)
+		attempts = append(attempts, attempt)
+
+		if err == nil {
+			return &delivery{provider: name, route: route.Name, result: result}, attempts, nil
+		}
+
+		lastErr = fmt.Errorf("provider %s: %w", name, err)
+		// Once ctx ended every other provider would fail the same way.
+		if !provider.IsTransient(err) || ctx.Err() != nil {
+			break
+		}
+
+		if i < len(route.Providers)-1 {
+			r.logger.Warn("Provider unavailable, failing over",
+				zap.Int64("messageID", msg.ID),
+				zap.String("route", route.Name),
+				zap.String("provider", name),
+				zap.String("next", route.Providers[i+1]),
+				zap.Error(err))
+		}
+	}
+
+	return nil, attempts, lastErr
+}
+
+// attemptBodyLimit is the number of response bytes kept with an attempt.
+const attemptBodyLimit = 2048
+
+// recordOutcome fills the response details of an attempt.
+func recordOutcome(attempt *models.DeliveryAttempt, result *provider.Result, err error) {
+	var statusCode int
+	var body string
+
+	if err == nil {
+		statusCode, body = result.StatusCode, result.Body
+	} else {
+		attempt.Error = sql.NullString{String: err.Error(), Valid: true}
+		attempt.ErrorClass = sql.NullString{String: string(provider.ClassOf(err)), Valid: true}
+
+		var providerErr *provider.Error
+		if errors.As(err, &providerErr) {
+			statusCode, body = providerErr.StatusCode, providerErr.Body
+		}
+	}
+
+	if statusCode != 0 {
+		attempt.StatusCode = sql.NullInt32{Int32: int32(statusCode), Valid: true}
+	}
+	if body != "" {
+		if len(body) > attemptBodyLimit {
+			body = strings.ToValidUTF8(body[:attemptBodyLimit], "")
+		}
+		attempt.ResponseBody = sql.NullString{String: body, Valid: true}
+	}
+}
+
+// Statuses returns the circuit breaker state of every provider together
+// with the breakers created for it so far.
+func (r *providerRouter) Statuses() []ProviderStatus {
+	statuses := make([]ProviderStatus, 0, len(r.providers))
+	for _, name := range r.order {
+		status := ProviderStatus{
+			Name:     r.providers[name].provider.Name(),
+			Breakers: r.breakers.Statuses(name),
+		}
+
+		states := make([]api.HealthResponseCircuitBreakerState, 0, len(status.Breakers))
+		for _, b := range status.Breakers {
+			status.Requests += b.Requests
+			status.Failures += b.Failures
+			states = append(states, b.State)
+		}
+		status.State = combineStates(states)
+
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Stats returns delivery statistics of every provider.
+func (r *providerRouter) Stats() []ProviderStats {
+	stats := make([]ProviderStats, 0, len(r.providers))
+	for _, name := range r.order {
+		stats = append(stats, r.providers[name].stats.snapshot(name))
+	}
+	return stats
+}
+
+// State returns the combined breaker state: open when every provider is
+// open, half-open when only some of them are, closed otherwise.
+func (r *providerRouter) State() (state api.HealthResponseCircuitBreakerState, requests uint32, failures uint32) {
+	statuses := r.Statuses()
+	states := make([]api.HealthResponseCircuitBreakerState, 0, len(statuses))
+	for _, status := range statuses {
+		requests += status.Requests
+		failures += status.Failures
+		states = append(states, status.State)
+	}
+	return combineStates(states), requests, failures
+}
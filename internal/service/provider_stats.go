@@ -0,0 +1,85 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencySamples is the number of recent latencies kept per provider for
+// percentile calculation.
+const latencySamples = 1024
+
+// providerStats accumulates delivery outcomes and latencies of a provider.
+type providerStats struct {
+	mu        sync.Mutex
+	successes uint64
+	failures  uint64
+	total     time.Duration
+	samples   []time.Duration
+	next      int
+}
+
+func newProviderStats() *providerStats {
+	return &providerStats{
+		samples: make([]time.Duration, 0, latencySamples),
+	}
+}
+
+// record adds the outcome of a single send.
+func (s *providerStats) record(latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.failures++
+	} else {
+		s.successes++
+	}
+	s.total += latency
+
+	if len(s.samples) < latencySamples {
+		s.samples = append(s.samples, latency)
+		return
+	}
+	s.samples[s.next] = latency
+	s.next = (s.next + 1) % latencySamples
+}
+
+// snapshot returns the accumulated statistics.
+func (s *providerStats) snapshot(name string) ProviderStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := ProviderStats{
+		Name:      name,
+		Requests:  s.successes + s.failures,
+		Successes: s.successes,
+		Failures:  s.failures,
+	}
+	if stats.Requests == 0 {
+		return stats
+	}
+
+	stats.SuccessRate = float64(s.successes) / float64(stats.Requests)
+	stats.AvgLatency = s.total / time.Duration(stats.Requests)
+
+	sorted := make([]time.Duration, len(s.samples))
+	copy(sorted, s.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	stats.P50Latency = percentile(sorted, 0.50)
+	stats.P95Latency = percentile(sorted, 0.95)
+	stats.P99Latency = percentile(sorted, 0.99)
+
+	return stats
+}
+
+// percentile returns the p-th percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx]
+}
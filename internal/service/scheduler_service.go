@@ -2,49 +2,140 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
 	"github.com/popeskul/insdr-messenger/internal/scheduler"
 )
 
 type schedulerService struct {
 	scheduler      *scheduler.Scheduler
+	reconciler     *scheduler.Scheduler
+	listener       *scheduler.Listener
 	messageService MessageService
+	events         EventService
 	logger         *zap.Logger
 }
 
 func NewSchedulerService(
 	cfg *config.Config,
 	messageService MessageService,
+	events EventService,
 	logger *zap.Logger,
 ) SchedulerService {
 	interval := time.Duration(cfg.Scheduler.IntervalMinutes) * time.Minute
 
 	svc := &schedulerService{
 		messageService: messageService,
+		events:         events,
 		logger:         logger,
 	}
 
 	svc.scheduler = scheduler.NewScheduler(logger, interval, svc.executeSendTask)
+	svc.scheduler.SetDebounce(time.Duration(cfg.Scheduler.DebounceMs) * time.Millisecond)
+
+	if cfg.Scheduler.Notify {
+		svc.listener = scheduler.NewListener(cfg.Database.GetDSN(), scheduler.MessagesQueuedChannel,
+			svc.scheduler.Trigger, logger)
+	}
+
+	if cfg.Scheduler.ReconcileIntervalMinutes > 0 {
+		reconcileInterval := time.Duration(cfg.Scheduler.ReconcileIntervalMinutes) * time.Minute
+		svc.reconciler = scheduler.NewScheduler(logger, reconcileInterval, svc.executeReconcileTask)
+	}
+
 	return svc
 }
 
+// Start starts sending, woken early by notifications about queued messages,
+// and, when enabled, the reconciliation of unconfirmed messages.
 func (s *schedulerService) Start() error {
 	ctx := context.Background()
-	return s.scheduler.Start(ctx)
+	if err := s.scheduler.Start(ctx); err != nil {
+		return err
+	}
+
+	if s.reconciler != nil {
+		if err := s.reconciler.Start(ctx); err != nil {
+			s.logger.Error("Failed to start reconciliation", zap.Error(err))
+		}
+	}
+
+	if s.listener != nil {
+		if err := s.listener.Start(); err != nil {
+			s.logger.Error("Failed to listen for queued messages", zap.Error(err))
+		}
+	}
+
+	s.publish(models.EventSchedulerStarted)
+	return nil
 }
 
 func (s *schedulerService) Stop() error {
-	return s.scheduler.Stop()
+	s.stopTriggers()
+	if err := s.scheduler.Stop(); err != nil {
+		return err
+	}
+
+	s.publish(models.EventSchedulerStopped)
+	return nil
+}
+
+// Drain stops the scheduler for shutdown. Unlike Stop it lets the running
+// batch finish its in-flight sends for up to timeout and hands the rest of
+// the batch back to the queue.
+func (s *schedulerService) Drain(timeout time.Duration) *DrainReport {
+	s.stopTriggers()
+	report := s.messageService.Drain(timeout)
+
+	if err := s.scheduler.Stop(); err != nil {
+		if !errors.Is(err, scheduler.ErrSchedulerNotRunning) {
+			s.logger.Error("Failed to stop scheduler", zap.Error(err))
+		}
+		return report
+	}
+
+	s.publish(models.EventSchedulerStopped)
+	return report
+}
+
+// stopTriggers stops listening for queued messages and the reconciliation.
+func (s *schedulerService) stopTriggers() {
+	if s.listener != nil {
+		if err := s.listener.Stop(); err != nil && !errors.Is(err, scheduler.ErrSchedulerNotRunning) {
+			s.logger.Error("Failed to stop listening for queued messages", zap.Error(err))
+		}
+	}
+	if s.reconciler != nil && s.reconciler.IsRunning() {
+		if err := s.reconciler.Stop(); err != nil {
+			s.logger.Error("Failed to stop reconciliation", zap.Error(err))
+		}
+	}
+}
+
+// publish queues a scheduler event. Failures only affect subscribers and
+// are logged.
+func (s *schedulerService) publish(eventType models.EventType) {
+	data := map[string]interface{}{"at": time.Now().UTC()}
+	if err := s.events.Publish(context.Background(), eventType, data); err != nil {
+		s.logger.Warn("Failed to publish scheduler event",
+			zap.String("eventType", string(eventType)),
+			zap.Error(err))
+	}
 }
 
 func (s *schedulerService) IsRunning() bool {
 	return s.scheduler.IsRunning()
 }
 
-func (s *schedulerService) executeSendTask(_ context.Context) error {
-	return s.messageService.SendPendingMessages()
+func (s *schedulerService) executeSendTask(ctx context.Context) error {
+	return s.messageService.SendPendingMessages(ctx)
+}
+
+func (s *schedulerService) executeReconcileTask(ctx context.Context) error {
+	return s.messageService.ReconcileUnconfirmed(ctx)
 }
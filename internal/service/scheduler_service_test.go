@@ -1,11 +1,13 @@
 package service_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/popeskul/insdr-messenger/internal/config"
+	"github.com/popeskul/insdr-messenger/internal/models"
 	"github.com/popeskul/insdr-messenger/internal/service"
 	"github.com/popeskul/insdr-messenger/internal/service/mocks"
 	"github.com/stretchr/testify/assert"
@@ -14,6 +16,13 @@ import (
 	"go.uber.org/zap"
 )
 
+// newMockEvents returns an event service accepting any published event.
+func newMockEvents(ctrl *gomock.Controller) *mocks.MockEventService {
+	events := mocks.NewMockEventService(ctrl)
+	events.EXPECT().Publish(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return events
+}
+
 func TestSchedulerService_Start_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -22,7 +31,7 @@ func TestSchedulerService_Start_Success(t *testing.T) {
 	mockMessageService := mocks.NewMockMessageService(ctrl)
 
 	// Expect SendPendingMessages to be called (scheduler might call it immediately)
-	mockMessageService.EXPECT().SendPendingMessages().Return(nil).AnyTimes()
+	mockMessageService.EXPECT().SendPendingMessages(gomock.Any()).Return(nil).AnyTimes()
 
 	// Create config
 	cfg := &config.Config{
@@ -33,7 +42,7 @@ func TestSchedulerService_Start_Success(t *testing.T) {
 
 	// Create scheduler service
 	logger := zap.NewNop()
-	schedulerService := service.NewSchedulerService(cfg, mockMessageService, logger)
+	schedulerService := service.NewSchedulerService(cfg, mockMessageService, newMockEvents(ctrl), logger)
 
 	// Start scheduler
 	err := schedulerService.Start()
@@ -58,7 +67,7 @@ func TestSchedulerService_Start_Failure(t *testing.T) {
 	mockMessageService := mocks.NewMockMessageService(ctrl)
 
 	// Expect SendPendingMessages to be called
-	mockMessageService.EXPECT().SendPendingMessages().Return(nil).AnyTimes()
+	mockMessageService.EXPECT().SendPendingMessages(gomock.Any()).Return(nil).AnyTimes()
 
 	// Create config
 	cfg := &config.Config{
@@ -69,7 +78,7 @@ func TestSchedulerService_Start_Failure(t *testing.T) {
 
 	// Create scheduler service
 	logger := zap.NewNop()
-	schedulerService := service.NewSchedulerService(cfg, mockMessageService, logger)
+	schedulerService := service.NewSchedulerService(cfg, mockMessageService, newMockEvents(ctrl), logger)
 
 	// Start scheduler
 	err := schedulerService.Start()
@@ -93,7 +102,7 @@ func TestSchedulerService_Stop_Success(t *testing.T) {
 	mockMessageService := mocks.NewMockMessageService(ctrl)
 
 	// Expect SendPendingMessages to be called
-	mockMessageService.EXPECT().SendPendingMessages().Return(nil).AnyTimes()
+	mockMessageService.EXPECT().SendPendingMessages(gomock.Any()).Return(nil).AnyTimes()
 
 	// Create config
 	cfg := &config.Config{
@@ -104,7 +113,7 @@ func TestSchedulerService_Stop_Success(t *testing.T) {
 
 	// Create scheduler service
 	logger := zap.NewNop()
-	schedulerService := service.NewSchedulerService(cfg, mockMessageService, logger)
+	schedulerService := service.NewSchedulerService(cfg, mockMessageService, newMockEvents(ctrl), logger)
 
 	// Start scheduler
 	err := schedulerService.Start()
@@ -134,7 +143,7 @@ func TestSchedulerService_Stop_Failure(t *testing.T) {
 
 	// Create scheduler service
 	logger := zap.NewNop()
-	schedulerService := service.NewSchedulerService(cfg, mockMessageService, logger)
+	schedulerService := service.NewSchedulerService(cfg, mockMessageService, newMockEvents(ctrl), logger)
 
 	// Try to stop without starting - should fail
 	err := schedulerService.Stop()
@@ -142,6 +151,42 @@ func TestSchedulerService_Stop_Failure(t *testing.T) {
 	assert.Contains(t, err.Error(), "not running")
 }
 
+func TestSchedulerService_Drain(t *testing.T) {
+	tests := []struct {
+		name  string
+		start bool
+	}{
+		{name: "running", start: true},
+		{name: "not running", start: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			want := &service.DrainReport{Completed: 1, Released: []int64{2}}
+			mockMessageService := mocks.NewMockMessageService(ctrl)
+			mockMessageService.EXPECT().SendPendingMessages(gomock.Any()).Return(nil).AnyTimes()
+			mockMessageService.EXPECT().Drain(time.Second).Return(want)
+
+			cfg := &config.Config{
+				Scheduler: config.SchedulerConfig{
+					IntervalMinutes: 1,
+				},
+			}
+
+			schedulerService := service.NewSchedulerService(cfg, mockMessageService, newMockEvents(ctrl), zap.NewNop())
+			if tt.start {
+				require.NoError(t, schedulerService.Start())
+			}
+
+			assert.Equal(t, want, schedulerService.Drain(time.Second))
+			assert.False(t, schedulerService.IsRunning())
+		})
+	}
+}
+
 func TestSchedulerService_IsRunning_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -150,7 +195,7 @@ func TestSchedulerService_IsRunning_Success(t *testing.T) {
 	mockMessageService := mocks.NewMockMessageService(ctrl)
 
 	// Expect SendPendingMessages to be called
-	mockMessageService.EXPECT().SendPendingMessages().Return(nil).AnyTimes()
+	mockMessageService.EXPECT().SendPendingMessages(gomock.Any()).Return(nil).AnyTimes()
 
 	// Create config
 	cfg := &config.Config{
@@ -161,7 +206,7 @@ func TestSchedulerService_IsRunning_Success(t *testing.T) {
 
 	// Create scheduler service
 	logger := zap.NewNop()
-	schedulerService := service.NewSchedulerService(cfg, mockMessageService, logger)
+	schedulerService := service.NewSchedulerService(cfg, mockMessageService, newMockEvents(ctrl), logger)
 
 	// Initially should not be running
 	assert.False(t, schedulerService.IsRunning())
@@ -191,7 +236,7 @@ func TestSchedulerService_IsRunning_Failure(t *testing.T) {
 	mockMessageService := mocks.NewMockMessageService(ctrl)
 
 	// Expect SendPendingMessages to be called
-	mockMessageService.EXPECT().SendPendingMessages().Return(nil).AnyTimes()
+	mockMessageService.EXPECT().SendPendingMessages(gomock.Any()).Return(nil).AnyTimes()
 
 	// Create config
 	cfg := &config.Config{
@@ -202,7 +247,7 @@ func TestSchedulerService_IsRunning_Failure(t *testing.T) {
 
 	// Create scheduler service
 	logger := zap.NewNop()
-	schedulerService := service.NewSchedulerService(cfg, mockMessageService, logger)
+	schedulerService := service.NewSchedulerService(cfg, mockMessageService, newMockEvents(ctrl), logger)
 
 	// Should return false when not started
 	assert.False(t, schedulerService.IsRunning())
@@ -227,8 +272,8 @@ func TestSchedulerService_ExecuteTask(t *testing.T) {
 	// Set up expectation - the task should call SendPendingMessages
 	callCount := 0
 	mockMessageService.EXPECT().
-		SendPendingMessages().
-		DoAndReturn(func() error {
+		SendPendingMessages(gomock.Any()).
+		DoAndReturn(func(_ context.Context) error {
 			callCount++
 			return nil
 		}).
@@ -246,7 +291,7 @@ func TestSchedulerService_ExecuteTask(t *testing.T) {
 
 	// We need to create a custom scheduler for this test
 	// since we want to test the task execution
-	svc := service.NewSchedulerService(cfg, mockMessageService, logger)
+	svc := service.NewSchedulerService(cfg, mockMessageService, newMockEvents(ctrl), logger)
 
 	// Start the scheduler
 	err := svc.Start()
@@ -272,7 +317,7 @@ func TestSchedulerService_ExecuteTaskError(t *testing.T) {
 
 	// Set up expectation - the task should handle errors gracefully
 	mockMessageService.EXPECT().
-		SendPendingMessages().
+		SendPendingMessages(gomock.Any()).
 		Return(errors.New("send error")).
 		MinTimes(1)
 
@@ -285,7 +330,7 @@ func TestSchedulerService_ExecuteTaskError(t *testing.T) {
 
 	// Create scheduler service
 	logger := zap.NewNop()
-	schedulerService := service.NewSchedulerService(cfg, mockMessageService, logger)
+	schedulerService := service.NewSchedulerService(cfg, mockMessageService, newMockEvents(ctrl), logger)
 
 	// Start the scheduler
 	err := schedulerService.Start()
@@ -310,7 +355,7 @@ func TestSchedulerService_MultipleStartStop(t *testing.T) {
 	mockMessageService := mocks.NewMockMessageService(ctrl)
 
 	// Expect SendPendingMessages to be called
-	mockMessageService.EXPECT().SendPendingMessages().Return(nil).AnyTimes()
+	mockMessageService.EXPECT().SendPendingMessages(gomock.Any()).Return(nil).AnyTimes()
 
 	// Create config
 	cfg := &config.Config{
@@ -321,7 +366,7 @@ func TestSchedulerService_MultipleStartStop(t *testing.T) {
 
 	// Create scheduler service
 	logger := zap.NewNop()
-	schedulerService := service.NewSchedulerService(cfg, mockMessageService, logger)
+	schedulerService := service.NewSchedulerService(cfg, mockMessageService, newMockEvents(ctrl), logger)
 
 	// Multiple start/stop cycles
 	for i := 0; i < 3; i++ {
@@ -345,7 +390,7 @@ func TestSchedulerService_ConcurrentAccess(t *testing.T) {
 	mockMessageService := mocks.NewMockMessageService(ctrl)
 
 	// Expect SendPendingMessages to be called
-	mockMessageService.EXPECT().SendPendingMessages().Return(nil).AnyTimes()
+	mockMessageService.EXPECT().SendPendingMessages(gomock.Any()).Return(nil).AnyTimes()
 
 	// Create config
 	cfg := &config.Config{
@@ -356,7 +401,7 @@ func TestSchedulerService_ConcurrentAccess(t *testing.T) {
 
 	// Create scheduler service
 	logger := zap.NewNop()
-	schedulerService := service.NewSchedulerService(cfg, mockMessageService, logger)
+	schedulerService := service.NewSchedulerService(cfg, mockMessageService, newMockEvents(ctrl), logger)
 
 	// Start scheduler
 	err := schedulerService.Start()
@@ -401,3 +446,69 @@ func TestSchedulerService_ConcurrentAccess(t *testing.T) {
 	err = schedulerService.Stop()
 	assert.NoError(t, err)
 }
+
+func TestSchedulerService_Reconciliation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := mocks.NewMockMessageService(ctrl)
+	mockMessageService.EXPECT().SendPendingMessages(gomock.Any()).Return(nil).AnyTimes()
+
+	reconciled := make(chan struct{}, 1)
+	mockMessageService.EXPECT().
+		ReconcileUnconfirmed(gomock.Any()).
+		DoAndReturn(func(_ context.Context) error {
+			reconciled <- struct{}{}
+			return nil
+		}).
+		MinTimes(1)
+
+	cfg := &config.Config{
+		Scheduler: config.SchedulerConfig{
+			IntervalMinutes:          1,
+			ReconcileIntervalMinutes: 1,
+		},
+	}
+
+	svc := service.NewSchedulerService(cfg, mockMessageService, newMockEvents(ctrl), zap.NewNop())
+
+	require.NoError(t, svc.Start())
+
+	select {
+	case <-reconciled:
+	case <-time.After(time.Second):
+		t.Fatal("reconciliation did not run")
+	}
+
+	require.NoError(t, svc.Stop())
+	assert.False(t, svc.IsRunning())
+}
+
+func TestSchedulerService_PublishesEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessageService := mocks.NewMockMessageService(ctrl)
+	mockMessageService.EXPECT().SendPendingMessages(gomock.Any()).Return(nil).AnyTimes()
+
+	mockEvents := mocks.NewMockEventService(ctrl)
+	gomock.InOrder(
+		mockEvents.EXPECT().Publish(gomock.Any(), models.EventSchedulerStarted, gomock.Any()).Return(nil),
+		mockEvents.EXPECT().Publish(gomock.Any(), models.EventSchedulerStopped, gomock.Any()).Return(errors.New("database error")),
+	)
+
+	cfg := &config.Config{
+		Scheduler: config.SchedulerConfig{
+			IntervalMinutes: 1,
+		},
+	}
+
+	schedulerService := service.NewSchedulerService(cfg, mockMessageService, mockEvents, zap.NewNop())
+
+	require.NoError(t, schedulerService.Start())
+	assert.Error(t, schedulerService.Start())
+
+	// A failed publish does not fail the stop.
+	require.NoError(t, schedulerService.Stop())
+	assert.Error(t, schedulerService.Stop())
+}
@@ -6,11 +6,14 @@ import (
 
 	"github.com/popeskul/insdr-messenger/internal/config"
 	"github.com/popeskul/insdr-messenger/internal/repository"
+	"github.com/popeskul/insdr-messenger/internal/stream"
 )
 
 type Service struct {
 	Message   MessageService
 	Scheduler SchedulerService
+	Events    EventService
+	Stream    StreamService
 	Health    HealthService
 }
 
@@ -19,14 +22,22 @@ func NewService(
 	repo repository.Repository,
 	redisClient *redis.Client,
 	logger *zap.Logger,
-) *Service {
-	messageService := NewMessageService(cfg, repo, redisClient, logger)
-	schedulerService := NewSchedulerService(cfg, messageService, logger)
+) (*Service, error) {
+	messageService, err := NewMessageService(cfg, repo, redisClient, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	eventService := NewEventService(cfg, repo, logger)
+	schedulerService := NewSchedulerService(cfg, messageService, eventService, logger)
+	streamHub := stream.NewHub(stream.NewRedisLog(redisClient, stream.DefaultKey, cfg.Stream.MaxLen), logger)
 	healthService := NewHealthService(repo, redisClient, schedulerService, messageService)
 
 	return &Service{
 		Message:   messageService,
 		Scheduler: schedulerService,
+		Events:    eventService,
+		Stream:    streamHub,
 		Health:    healthService,
-	}
+	}, nil
 }
@@ -1,12 +1,75 @@
 package service
 
-import "github.com/popeskul/insdr-messenger/internal/api"
+import (
+	"time"
+
+	"github.com/popeskul/insdr-messenger/internal/api"
+	"github.com/popeskul/insdr-messenger/internal/models"
+)
 
 type HealthStatus struct {
 	Status               api.HealthResponseStatus              `json:"status"`
 	SchedulerStatus      api.HealthResponseSchedulerStatus     `json:"scheduler_status"`
+	SchedulerBatchSize   int                                   `json:"scheduler_batch_size"`
 	DatabaseStatus       api.HealthResponseDatabaseStatus      `json:"database_status"`
 	RedisStatus          api.HealthResponseRedisStatus         `json:"redis_status"`
 	CircuitBreakerStatus string                                `json:"circuit_breaker_status,omitempty"`
 	CircuitBreakerState  api.HealthResponseCircuitBreakerState `json:"circuit_breaker_state,omitempty"`
+	Providers            []ProviderStatus                      `json:"providers,omitempty"`
+}
+
+// ProviderStatus describes the circuit breaker state of a delivery provider.
+// State and counts are combined over all breakers of the provider.
+type ProviderStatus struct {
+	Name     string                                `json:"name"`
+	State    api.HealthResponseCircuitBreakerState `json:"state"`
+	Requests uint32                                `json:"requests"`
+	Failures uint32                                `json:"failures"`
+	Breakers []BreakerStatus                       `json:"breakers,omitempty"`
+}
+
+// BreakerStatus describes a single circuit breaker of a provider.
+type BreakerStatus struct {
+	Name     string                                `json:"name"`
+	Country  string                                `json:"country,omitempty"`
+	State    api.HealthResponseCircuitBreakerState `json:"state"`
+	Requests uint32                                `json:"requests"`
+	Failures uint32                                `json:"failures"`
+}
+
+// ProviderStats summarizes delivery outcomes and latency of a provider.
+type ProviderStats struct {
+	Name        string        `json:"name"`
+	Requests    uint64        `json:"requests"`
+	Successes   uint64        `json:"successes"`
+	Failures    uint64        `json:"failures"`
+	SuccessRate float64       `json:"success_rate"`
+	AvgLatency  time.Duration `json:"avg_latency"`
+	P50Latency  time.Duration `json:"p50_latency"`
+	P95Latency  time.Duration `json:"p95_latency"`
+	P99Latency  time.Duration `json:"p99_latency"`
+}
+
+// DeliveryReceipt is the final handset status of a message reported by its
+// provider.
+type DeliveryReceipt struct {
+	MessageID string               `json:"message_id"`
+	Status    models.MessageStatus `json:"status"`
+	Timestamp time.Time            `json:"timestamp"`
+	Error     *string              `json:"error,omitempty"`
+}
+
+// DrainReport describes what a drain before shutdown left behind.
+type DrainReport struct {
+	// Completed counts sends that finished while draining.
+	Completed int `json:"completed"`
+	// Released holds claimed messages handed back to the queue unsent.
+	Released []int64 `json:"released"`
+	// Abandoned holds sends cut off by the deadline, which are marked
+	// accepted_unconfirmed for the reconciler, or failed when their
+	// provider has no status lookup, and claims that could not be
+	// released, which are claimed again after queue.claim_idle.
+	Abandoned []int64       `json:"abandoned"`
+	TimedOut  bool          `json:"timed_out"`
+	Duration  time.Duration `json:"duration"`
 }
@@ -0,0 +1,143 @@
+package signing_test
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/insdr-messenger/internal/signing"
+)
+
+func TestCompute(t *testing.T) {
+	// Reference value: printf '1700000000.{"a":1}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686",
+		signing.Compute("secret", 1700000000, []byte(`{"a":1}`)))
+}
+
+func TestSigner_Sign(t *testing.T) {
+	body := []byte(`{"to":"+905551111111","content":"Hello"}`)
+
+	signer := signing.NewSigner([]string{"new-secret", "", "old-secret"})
+	require.NotNil(t, signer)
+
+	req := httptest.NewRequest("POST", "/", nil)
+	signer.Sign(req, body)
+
+	timestamp := req.Header.Get(signing.TimestampHeader)
+	header := req.Header.Get(signing.SignatureHeader)
+	require.NotEmpty(t, timestamp)
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"v1="+signing.Compute("new-secret", ts, body)+",v1="+signing.Compute("old-secret", ts, body),
+		header)
+
+	// Receivers that only know one of the secrets accept the request.
+	assert.NoError(t, signing.Verify([]string{"old-secret"}, header, timestamp, body, time.Now(), time.Minute))
+	assert.NoError(t, signing.Verify([]string{"new-secret"}, header, timestamp, body, time.Now(), time.Minute))
+}
+
+func TestNewSigner_NoSecrets(t *testing.T) {
+	signer := signing.NewSigner([]string{""})
+	assert.Nil(t, signer)
+
+	req := httptest.NewRequest("POST", "/", nil)
+	signer.Sign(req, []byte("body"))
+	assert.Empty(t, req.Header.Get(signing.SignatureHeader))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"messageId":"ext-1","status":"delivered"}`)
+	valid := "v1=" + signing.Compute("secret", now.Unix(), body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name          string
+		secrets       []string
+		header        string
+		timestamp     string
+		body          []byte
+		now           time.Time
+		expectedError error
+	}{
+		{
+			name:      "valid signature",
+			secrets:   []string{"secret"},
+			header:    valid,
+			timestamp: timestamp,
+			body:      body,
+			now:       now.Add(30 * time.Second),
+		},
+		{
+			name:      "valid signature among unknown versions",
+			secrets:   []string{"other", "secret"},
+			header:    "v0=abc, " + valid,
+			timestamp: timestamp,
+			body:      body,
+			now:       now,
+		},
+		{
+			name:          "missing header",
+			secrets:       []string{"secret"},
+			timestamp:     timestamp,
+			body:          body,
+			now:           now,
+			expectedError: signing.ErrMissingSignature,
+		},
+		{
+			name:          "malformed timestamp",
+			secrets:       []string{"secret"},
+			header:        valid,
+			timestamp:     "yesterday",
+			body:          body,
+			now:           now,
+			expectedError: signing.ErrInvalidTimestamp,
+		},
+		{
+			name:          "replayed request",
+			secrets:       []string{"secret"},
+			header:        valid,
+			timestamp:     timestamp,
+			body:          body,
+			now:           now.Add(10 * time.Minute),
+			expectedError: signing.ErrExpiredTimestamp,
+		},
+		{
+			name:          "tampered body",
+			secrets:       []string{"secret"},
+			header:        valid,
+			timestamp:     timestamp,
+			body:          []byte(`{"messageId":"ext-1","status":"undelivered"}`),
+			now:           now,
+			expectedError: signing.ErrInvalidSignature,
+		},
+		{
+			name:          "unknown secret",
+			secrets:       []string{"rotated-out"},
+			header:        valid,
+			timestamp:     timestamp,
+			body:          body,
+			now:           now,
+			expectedError: signing.ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := signing.Verify(tt.secrets, tt.header, tt.timestamp, tt.body, tt.now, 5*time.Minute)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// readBlock bounds a single blocking read of the log, so Stop does not
+	// wait on Redis for long.
+	readBlock = 5 * time.Second
+	// readCount is the page size of log reads.
+	readCount = 500
+	// subscriberBuffer is the number of events a client may lag behind
+	// before it is dropped.
+	subscriberBuffer = 256
+	// retryDelay is the pause after a failed log read.
+	retryDelay = time.Second
+)
+
+var (
+	// ErrHubRunning is returned when starting a hub that is already running.
+	ErrHubRunning = errors.New("stream hub is already running")
+	// ErrInvalidEventID is returned when resuming from a malformed event ID.
+	ErrInvalidEventID = errors.New("invalid event ID")
+)
+
+// Hub publishes events to the log and fans out the events of all replicas
+// to the subscriptions of this replica.
+type Hub struct {
+	log    Log
+	logger *zap.Logger
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewHub creates a hub reading and writing events through log.
+func NewHub(log Log, logger *zap.Logger) *Hub {
+	return &Hub{
+		log:    log,
+		logger: logger,
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish appends an event to the log and returns its ID. Subscribers
+// receive it once the hub reads it back, on this and every other replica.
+func (h *Hub) Publish(ctx context.Context, ev Event) (string, error) {
+	return h.log.Append(ctx, ev)
+}
+
+// Start begins following the log from its current end.
+func (h *Hub) Start() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cancel != nil {
+		return ErrHubRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	h.done = make(chan struct{})
+
+	go h.run(ctx, h.done)
+
+	h.logger.Info("Stream hub started")
+	return nil
+}
+
+// Stop stops following the log and closes all subscriptions.
+func (h *Hub) Stop() error {
+	h.mu.Lock()
+	cancel, done := h.cancel, h.done
+	h.cancel, h.done = nil, nil
+	subs := h.subscribers()
+	h.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	<-done
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+
+	h.logger.Info("Stream hub stopped")
+	return nil
+}
+
+// Subscribe registers a subscription for events matching filter. With a
+// lastEventID the events logged after it are replayed first, so a client
+// can resume where its previous connection ended. The subscription is
+// closed when ctx is done.
+func (h *Hub) Subscribe(ctx context.Context, lastEventID string, filter Filter) (*Subscription, error) {
+	sub := &Subscription{
+		hub:    h,
+		filter: filter,
+		events: make(chan Event, subscriberBuffer),
+		done:   make(chan struct{}),
+	}
+
+	if lastEventID != "" {
+		if !ValidID(lastEventID) {
+			return nil, ErrInvalidEventID
+		}
+		sub.replaying = true
+		sub.lastSent = lastEventID
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	if sub.replaying {
+		go sub.replay(ctx, h.log, lastEventID)
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			sub.Close()
+		case <-sub.done:
+		}
+	}()
+
+	return sub, nil
+}
+
+// run reads the log and hands every event to the subscriptions.
+func (h *Hub) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	lastID := ""
+	for ctx.Err() == nil {
+		if lastID == "" {
+			id, err := h.log.LastID(ctx)
+			if err != nil {
+				h.retry(ctx, "Failed to read stream position", err)
+				continue
+			}
+			lastID = id
+		}
+
+		events, err := h.log.Read(ctx, lastID, readCount, readBlock)
+		if err != nil {
+			h.retry(ctx, "Failed to read stream events", err)
+			continue
+		}
+
+		for _, ev := range events {
+			lastID = ev.ID
+			h.broadcast(ev)
+		}
+	}
+}
+
+func (h *Hub) retry(ctx context.Context, msg string, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	h.logger.Warn(msg, zap.Error(err))
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(retryDelay):
+	}
+}
+
+func (h *Hub) broadcast(ev Event) {
+	h.mu.Lock()
+	subs := h.subscribers()
+	h.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.deliver(ev)
+	}
+}
+
+// subscribers returns the registered subscriptions; h.mu must be held.
+func (h *Hub) subscribers() []*Subscription {
+	subs := make([]*Subscription, 0, len(h.subs))
+	for sub := range h.subs {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	delete(h.subs, sub)
+	h.mu.Unlock()
+}
@@ -0,0 +1,223 @@
+package stream_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/popeskul/insdr-messenger/internal/stream"
+)
+
+// memLog is an in-memory stream.Log.
+type memLog struct {
+	mu      sync.Mutex
+	events  []stream.Event
+	changed chan struct{}
+}
+
+func newMemLog() *memLog {
+	return &memLog{changed: make(chan struct{})}
+}
+
+func (l *memLog) Append(_ context.Context, ev stream.Event) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ev.ID = fmt.Sprintf("1-%d", len(l.events)+1)
+	l.events = append(l.events, ev)
+	close(l.changed)
+	l.changed = make(chan struct{})
+	return ev.ID, nil
+}
+
+func (l *memLog) Read(ctx context.Context, afterID string, count int64, block time.Duration) ([]stream.Event, error) {
+	for {
+		l.mu.Lock()
+		var seq int
+		_, _ = fmt.Sscanf(afterID, "1-%d", &seq)
+		var events []stream.Event
+		for i := seq; i < len(l.events) && int64(len(events)) < count; i++ {
+			events = append(events, l.events[i])
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		if len(events) > 0 || block < 0 {
+			return events, nil
+		}
+
+		select {
+		case <-changed:
+		case <-time.After(block):
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (l *memLog) LastID(context.Context) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return fmt.Sprintf("1-%d", len(l.events)), nil
+}
+
+func startHub(t *testing.T, log stream.Log) *stream.Hub {
+	hub := stream.NewHub(log, zap.NewNop())
+	require.NoError(t, hub.Start())
+	t.Cleanup(func() { _ = hub.Stop() })
+	return hub
+}
+
+func publish(t *testing.T, hub *stream.Hub, ev stream.Event) string {
+	id, err := hub.Publish(context.Background(), ev)
+	require.NoError(t, err)
+	return id
+}
+
+func receive(t *testing.T, sub *stream.Subscription, n int) []string {
+	var ids []string
+	for len(ids) < n {
+		select {
+		case ev := <-sub.Events():
+			ids = append(ids, ev.ID)
+		case <-sub.Done():
+			t.Fatalf("subscription closed after %v", ids)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out after %v", ids)
+		}
+	}
+	return ids
+}
+
+func messageEvent(campaign string, status string) stream.Event {
+	return stream.Event{Type: stream.TypeMessageStatus, Campaign: campaign, Status: status, Data: []byte(`{}`)}
+}
+
+func TestHub_FanOut(t *testing.T) {
+	hub := startHub(t, newMemLog())
+
+	first, err := hub.Subscribe(context.Background(), "", stream.Filter{})
+	require.NoError(t, err)
+	second, err := hub.Subscribe(context.Background(), "", stream.Filter{})
+	require.NoError(t, err)
+
+	// Give the hub time to catch up with the empty log.
+	time.Sleep(50 * time.Millisecond)
+
+	id := publish(t, hub, stream.Event{Type: stream.TypeSchedulerRun, Data: []byte(`{}`)})
+
+	assert.Equal(t, []string{id}, receive(t, first, 1))
+	assert.Equal(t, []string{id}, receive(t, second, 1))
+}
+
+func TestHub_Filter(t *testing.T) {
+	hub := startHub(t, newMemLog())
+
+	sub, err := hub.Subscribe(context.Background(), "", stream.Filter{
+		Campaigns: []string{"spring"},
+		Statuses:  []string{"sent", "failed"},
+	})
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+
+	publish(t, hub, messageEvent("autumn", "sent"))
+	publish(t, hub, messageEvent("spring", "processing"))
+	sent := publish(t, hub, messageEvent("spring", "sent"))
+	breaker := publish(t, hub, stream.Event{Type: stream.TypeBreakerState, Data: []byte(`{}`)})
+
+	assert.Equal(t, []string{sent, breaker}, receive(t, sub, 2))
+}
+
+func TestHub_Resume(t *testing.T) {
+	log := newMemLog()
+	hub := startHub(t, log)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		ids = append(ids, publish(t, hub, messageEvent("spring", "sent")))
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	sub, err := hub.Subscribe(context.Background(), ids[0], stream.Filter{})
+	require.NoError(t, err)
+
+	ids = append(ids, publish(t, hub, messageEvent("spring", "sent")))
+
+	// Replayed and live events arrive once and in order.
+	assert.Equal(t, ids[1:], receive(t, sub, 3))
+
+	select {
+	case ev := <-sub.Events():
+		t.Fatalf("unexpected event %s", ev.ID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestHub_InvalidLastEventID(t *testing.T) {
+	hub := startHub(t, newMemLog())
+
+	_, err := hub.Subscribe(context.Background(), "latest", stream.Filter{})
+	assert.ErrorIs(t, err, stream.ErrInvalidEventID)
+}
+
+func TestHub_DropsSlowSubscriber(t *testing.T) {
+	hub := startHub(t, newMemLog())
+
+	sub, err := hub.Subscribe(context.Background(), "", stream.Filter{})
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+
+	for i := 0; i < 300; i++ {
+		publish(t, hub, messageEvent("spring", "sent"))
+	}
+
+	select {
+	case <-sub.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("slow subscriber was not dropped")
+	}
+}
+
+func TestHub_ClosesOnContextDone(t *testing.T) {
+	hub := startHub(t, newMemLog())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := hub.Subscribe(ctx, "", stream.Filter{})
+	require.NoError(t, err)
+
+	cancel()
+
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		t.Fatal("subscription was not closed")
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter stream.Filter
+		event  stream.Event
+		want   bool
+	}{
+		{name: "empty filter", event: messageEvent("spring", "sent"), want: true},
+		{name: "campaign match", filter: stream.Filter{Campaigns: []string{"spring"}}, event: messageEvent("spring", "sent"), want: true},
+		{name: "campaign mismatch", filter: stream.Filter{Campaigns: []string{"spring"}}, event: messageEvent("", "sent"), want: false},
+		{name: "status mismatch", filter: stream.Filter{Statuses: []string{"failed"}}, event: messageEvent("spring", "sent"), want: false},
+		{name: "other event types", filter: stream.Filter{Statuses: []string{"failed"}}, event: stream.Event{Type: stream.TypeSchedulerRun}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(tt.event))
+		})
+	}
+}
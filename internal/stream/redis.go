@@ -0,0 +1,94 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultKey is the Redis key of the event stream.
+const DefaultKey = "stream:events"
+
+// RedisLog is a Log kept in a Redis stream capped at about maxLen entries.
+type RedisLog struct {
+	client *redis.Client
+	key    string
+	maxLen int64
+}
+
+// NewRedisLog creates a Log backed by the Redis stream at key.
+func NewRedisLog(client *redis.Client, key string, maxLen int64) *RedisLog {
+	return &RedisLog{client: client, key: key, maxLen: maxLen}
+}
+
+// Append implements Log.
+func (l *RedisLog) Append(ctx context.Context, ev Event) (string, error) {
+	id, err := l.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: l.key,
+		MaxLen: l.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":     ev.Type,
+			"campaign": ev.Campaign,
+			"status":   ev.Status,
+			"data":     string(ev.Data),
+		},
+	}).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to append event: %w", err)
+	}
+	return id, nil
+}
+
+// Read implements Log.
+func (l *RedisLog) Read(ctx context.Context, afterID string, count int64, block time.Duration) ([]Event, error) {
+	if block < 0 {
+		block = -1
+	}
+
+	streams, err := l.client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{l.key, afterID},
+		Count:   count,
+		Block:   block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	var events []Event
+	for _, s := range streams {
+		for _, msg := range s.Messages {
+			events = append(events, Event{
+				ID:       msg.ID,
+				Type:     value(msg.Values, "type"),
+				Campaign: value(msg.Values, "campaign"),
+				Status:   value(msg.Values, "status"),
+				Data:     []byte(value(msg.Values, "data")),
+			})
+		}
+	}
+	return events, nil
+}
+
+// LastID implements Log.
+func (l *RedisLog) LastID(ctx context.Context) (string, error) {
+	msgs, err := l.client.XRevRangeN(ctx, l.key, "+", "-", 1).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to read last event: %w", err)
+	}
+	if len(msgs) == 0 {
+		return "0-0", nil
+	}
+	return msgs[0].ID, nil
+}
+
+func value(values map[string]interface{}, key string) string {
+	s, _ := values[key].(string)
+	return s
+}
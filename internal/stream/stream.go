@@ -0,0 +1,124 @@
+// Package stream fans out live events to streaming clients. Events are
+// appended to a shared log, a Redis stream in production, so every replica
+// sees the events of all replicas and clients can resume from the ID of the
+// last event they received.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event types.
+const (
+	// TypeMessageStatus is a message status transition.
+	TypeMessageStatus = "message.status"
+	// TypeSchedulerRun summarizes a sending run of the scheduler.
+	TypeSchedulerRun = "scheduler.run"
+	// TypeBreakerState is a circuit breaker state change.
+	TypeBreakerState = "breaker.state"
+)
+
+// Event is a single entry of the log. Campaign and Status are set for
+// message events and used for filtering; clients only receive Data.
+type Event struct {
+	ID       string
+	Type     string
+	Campaign string
+	Status   string
+	Data     json.RawMessage
+}
+
+// NewEvent returns an event of the given type with data encoded as JSON.
+func NewEvent(eventType string, data interface{}) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Type: eventType, Data: raw}, nil
+}
+
+// Log is an append only, ordered event log shared by all replicas.
+type Log interface {
+	// Append adds an event and returns its ID.
+	Append(ctx context.Context, ev Event) (string, error)
+	// Read returns up to count events following afterID. A non-negative
+	// block waits that long for new events; an empty result is not an
+	// error.
+	Read(ctx context.Context, afterID string, count int64, block time.Duration) ([]Event, error)
+	// LastID returns the ID of the newest event, or "0-0" when empty.
+	LastID(ctx context.Context) (string, error)
+}
+
+// Filter selects message events by campaign and status. Empty lists match
+// everything; other event types always match.
+type Filter struct {
+	Campaigns []string
+	Statuses  []string
+}
+
+// Match reports whether ev passes the filter.
+func (f Filter) Match(ev Event) bool {
+	if ev.Type != TypeMessageStatus {
+		return true
+	}
+	return matches(f.Campaigns, ev.Campaign) && matches(f.Statuses, ev.Status)
+}
+
+func matches(allowed []string, value string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidID reports whether id is a log ID of the form "<ms>-<seq>".
+func ValidID(id string) bool {
+	_, _, ok := parseID(id)
+	return ok
+}
+
+// compareIDs orders log IDs like Redis stream IDs. Invalid IDs sort first.
+func compareIDs(a, b string) int {
+	aMs, aSeq, _ := parseID(a)
+	bMs, bSeq, _ := parseID(b)
+
+	switch {
+	case aMs != bMs:
+		if aMs < bMs {
+			return -1
+		}
+		return 1
+	case aSeq != bSeq:
+		if aSeq < bSeq {
+			return -1
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+func parseID(id string) (uint64, uint64, bool) {
+	msPart, seqPart, ok := strings.Cut(id, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ms, seq, true
+}
@@ -0,0 +1,147 @@
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// maxPending bounds the live events buffered while a subscription replays
+// its backlog.
+const maxPending = 4 * subscriberBuffer
+
+// Subscription receives the events of a hub matching its filter, in log
+// order and without duplicates. A subscriber that falls behind is closed;
+// it can reconnect with the ID of the last event it received.
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+
+	mu sync.Mutex
+	// replaying is set while the backlog is read from the log; live events
+	// are kept in pending until it is sent.
+	replaying bool
+	pending   []Event
+	// lastSent is the ID of the newest event handed to the subscriber.
+	lastSent string
+}
+
+// Events returns the channel of events. It is never closed, wait on Done
+// as well.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed once the subscription ends.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.hub.remove(s)
+	})
+}
+
+// deliver hands a live event to the subscription without blocking the hub.
+func (s *Subscription) deliver(ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.replaying {
+		if len(s.pending) >= maxPending {
+			go s.Close()
+			return
+		}
+		s.pending = append(s.pending, ev)
+		return
+	}
+
+	if !s.advance(ev) {
+		return
+	}
+
+	select {
+	case s.events <- ev:
+	default:
+		go s.Close()
+	}
+}
+
+// advance records ev as sent and reports whether it should be sent; s.mu
+// must be held.
+func (s *Subscription) advance(ev Event) bool {
+	if s.lastSent != "" && compareIDs(ev.ID, s.lastSent) <= 0 {
+		return false
+	}
+	s.lastSent = ev.ID
+	return s.filter.Match(ev)
+}
+
+// replay sends the events logged after lastEventID, then the live events
+// that arrived meanwhile, and switches the subscription to live delivery.
+func (s *Subscription) replay(ctx context.Context, log Log, lastEventID string) {
+	afterID := lastEventID
+	for {
+		events, err := log.Read(ctx, afterID, readCount, -1)
+		if err != nil {
+			s.hub.logger.Warn("Failed to replay stream events", zap.Error(err))
+			s.Close()
+			return
+		}
+
+		for _, ev := range events {
+			afterID = ev.ID
+			if !s.send(ev) {
+				return
+			}
+		}
+
+		if len(events) < readCount {
+			break
+		}
+	}
+
+	for {
+		s.mu.Lock()
+		pending := s.pending
+		s.pending = nil
+		if len(pending) == 0 {
+			s.replaying = false
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+
+		for _, ev := range pending {
+			if !s.send(ev) {
+				return
+			}
+		}
+	}
+}
+
+// send hands a replayed event to the subscriber, waiting for room in the
+// buffer. It returns false once the subscription is closed.
+func (s *Subscription) send(ev Event) bool {
+	s.mu.Lock()
+	ok := s.advance(ev)
+	s.mu.Unlock()
+
+	if !ok {
+		return true
+	}
+
+	select {
+	case s.events <- ev:
+		return true
+	case <-s.done:
+		return false
+	}
+}
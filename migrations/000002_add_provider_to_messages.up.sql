@@ -0,0 +1,3 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS provider VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_messages_provider ON messages(provider);
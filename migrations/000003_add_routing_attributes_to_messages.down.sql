@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_messages_campaign;
+ALTER TABLE messages DROP COLUMN IF EXISTS campaign;
+ALTER TABLE messages DROP COLUMN IF EXISTS priority;
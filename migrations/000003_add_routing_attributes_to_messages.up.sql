@@ -0,0 +1,4 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS campaign VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_messages_campaign ON messages(campaign);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_messages_message_id;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS delivered_at;
+
+UPDATE messages SET status = 'sent' WHERE status IN ('delivered', 'undelivered');
+
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_status_check;
+ALTER TABLE messages ADD CONSTRAINT messages_status_check
+    CHECK (status IN ('pending', 'sent', 'failed'));
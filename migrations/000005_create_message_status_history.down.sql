@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS record_messages_status_change ON messages;
+DROP FUNCTION IF EXISTS record_message_status_change();
+
+DROP TABLE IF EXISTS message_status_history;
+
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_status_check;
+
+UPDATE messages SET status = 'pending' WHERE status IN ('queued', 'processing');
+UPDATE messages SET status = 'failed' WHERE status IN ('cancelled', 'expired');
+
+ALTER TABLE messages ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE messages ADD CONSTRAINT messages_status_check
+    CHECK (status IN ('pending', 'sent', 'failed', 'delivered', 'undelivered'));
@@ -0,0 +1,42 @@
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_status_check;
+
+UPDATE messages SET status = 'queued' WHERE status = 'pending';
+
+ALTER TABLE messages ALTER COLUMN status SET DEFAULT 'queued';
+ALTER TABLE messages ADD CONSTRAINT messages_status_check
+    CHECK (status IN ('queued', 'processing', 'sent', 'delivered', 'undelivered', 'failed', 'cancelled', 'expired'));
+
+CREATE TABLE IF NOT EXISTS message_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    error TEXT,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_status_history_message_id ON message_status_history(message_id, changed_at);
+
+INSERT INTO message_status_history (message_id, from_status, to_status, error, changed_at)
+SELECT id, NULL, status, error, COALESCE(delivered_at, sent_at, updated_at)
+FROM messages;
+
+CREATE OR REPLACE FUNCTION record_message_status_change()
+RETURNS TRIGGER AS '
+BEGIN
+    IF TG_OP = ''INSERT'' THEN
+        INSERT INTO message_status_history (message_id, from_status, to_status, error)
+        VALUES (NEW.id, NULL, NEW.status, NEW.error);
+    ELSIF NEW.status IS DISTINCT FROM OLD.status THEN
+        INSERT INTO message_status_history (message_id, from_status, to_status, error)
+        VALUES (NEW.id, OLD.status, NEW.status, NEW.error);
+    END IF;
+    RETURN NEW;
+END;
+' LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS record_messages_status_change ON messages;
+CREATE TRIGGER record_messages_status_change
+    AFTER INSERT OR UPDATE OF status ON messages
+    FOR EACH ROW
+    EXECUTE FUNCTION record_message_status_change();
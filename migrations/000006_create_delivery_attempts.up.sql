@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS delivery_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    route VARCHAR(64) NOT NULL,
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    status_code INTEGER,
+    response_body TEXT,
+    error TEXT,
+    error_class VARCHAR(20) CHECK (error_class IN ('transient', 'permanent')),
+    breaker_state VARCHAR(20) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_attempts_message_id ON delivery_attempts(message_id, requested_at);
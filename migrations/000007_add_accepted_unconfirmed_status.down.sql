@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_messages_accepted_unconfirmed;
+
+UPDATE messages SET status = 'sent' WHERE status = 'accepted_unconfirmed';
+
+ALTER TABLE messages DROP COLUMN IF EXISTS provider_response;
+
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_status_check;
+ALTER TABLE messages ADD CONSTRAINT messages_status_check
+    CHECK (status IN ('queued', 'processing', 'sent', 'delivered', 'undelivered', 'failed', 'cancelled', 'expired'));
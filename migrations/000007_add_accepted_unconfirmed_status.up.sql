@@ -0,0 +1,8 @@
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_status_check;
+ALTER TABLE messages ADD CONSTRAINT messages_status_check
+    CHECK (status IN ('queued', 'processing', 'accepted_unconfirmed', 'sent', 'delivered', 'undelivered', 'failed', 'cancelled', 'expired'));
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS provider_response TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_messages_accepted_unconfirmed ON messages(sent_at)
+    WHERE status = 'accepted_unconfirmed';
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS enqueue_message_status_event ON message_status_history;
+DROP FUNCTION IF EXISTS enqueue_message_event();
+
+DROP TABLE IF EXISTS event_outbox;
+DROP TABLE IF EXISTS event_subscriptions;
@@ -0,0 +1,53 @@
+CREATE TABLE IF NOT EXISTS event_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES event_subscriptions(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON event_outbox(next_attempt_at)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_event_outbox_dead ON event_outbox(subscription_id, updated_at)
+    WHERE status = 'dead';
+
+CREATE OR REPLACE FUNCTION enqueue_message_event()
+RETURNS TRIGGER AS '
+BEGIN
+    IF NEW.to_status IN (''sent'', ''delivered'', ''undelivered'', ''failed'') THEN
+        INSERT INTO event_outbox (subscription_id, event_type, payload)
+        SELECT s.id, ''message.'' || NEW.to_status, jsonb_build_object(
+            ''id'', m.id,
+            ''phone_number'', m.phone_number,
+            ''message_id'', m.message_id,
+            ''provider'', m.provider,
+            ''from_status'', NEW.from_status,
+            ''status'', NEW.to_status,
+            ''error'', NEW.error,
+            ''changed_at'', NEW.changed_at)
+        FROM event_subscriptions s
+        JOIN messages m ON m.id = NEW.message_id
+        WHERE ''message.'' || NEW.to_status = ANY(s.event_types);
+    END IF;
+    RETURN NEW;
+END;
+' LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS enqueue_message_status_event ON message_status_history;
+CREATE TRIGGER enqueue_message_status_event
+    AFTER INSERT ON message_status_history
+    FOR EACH ROW
+    EXECUTE FUNCTION enqueue_message_event();
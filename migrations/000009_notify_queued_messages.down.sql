@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS notify_messages_queued_update ON messages;
+DROP TRIGGER IF EXISTS notify_messages_queued_insert ON messages;
+DROP FUNCTION IF EXISTS notify_messages_queued();
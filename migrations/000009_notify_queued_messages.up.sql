@@ -0,0 +1,27 @@
+-- Wake the schedulers as soon as messages are queued. One notification is
+-- sent per statement, and Postgres folds identical notifications of a
+-- transaction into one, so bulk inserts do not flood the listeners.
+CREATE OR REPLACE FUNCTION notify_messages_queued()
+RETURNS TRIGGER AS '
+BEGIN
+    IF EXISTS (SELECT 1 FROM queued_rows WHERE status = ''queued'') THEN
+        PERFORM pg_notify(''messages_queued'', '''');
+    END IF;
+    RETURN NULL;
+END;
+' LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS notify_messages_queued_insert ON messages;
+CREATE TRIGGER notify_messages_queued_insert
+    AFTER INSERT ON messages
+    REFERENCING NEW TABLE AS queued_rows
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION notify_messages_queued();
+
+-- Messages also return to the queue, e.g. when a claim is released.
+DROP TRIGGER IF EXISTS notify_messages_queued_update ON messages;
+CREATE TRIGGER notify_messages_queued_update
+    AFTER UPDATE ON messages
+    REFERENCING NEW TABLE AS queued_rows
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION notify_messages_queued();
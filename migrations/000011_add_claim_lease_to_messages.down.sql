@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_messages_claimed_until;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS claimed_until;
@@ -0,0 +1,6 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMP WITH TIME ZONE;
+
+-- Messages claimed before leases existed may be taken over right away.
+UPDATE messages SET claimed_until = updated_at WHERE status = 'processing';
+
+CREATE INDEX IF NOT EXISTS idx_messages_claimed_until ON messages(claimed_until) WHERE status = 'processing';
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS notify_messages_queued_update ON messages;
+CREATE TRIGGER notify_messages_queued_update
+    AFTER UPDATE ON messages
+    REFERENCING NEW TABLE AS queued_rows
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION notify_messages_queued();
+
+DROP FUNCTION IF EXISTS notify_message_requeued();
@@ -0,0 +1,18 @@
+-- Only updates that put a message back into the queue wake the schedulers.
+-- Transition tables are not allowed on triggers with a column list, so the
+-- update trigger fires per row, filtered by its WHEN clause; Postgres folds
+-- the identical notifications of a transaction into one.
+CREATE OR REPLACE FUNCTION notify_message_requeued()
+RETURNS TRIGGER AS '
+BEGIN
+    PERFORM pg_notify(''messages_queued'', '''');
+    RETURN NULL;
+END;
+' LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS notify_messages_queued_update ON messages;
+CREATE TRIGGER notify_messages_queued_update
+    AFTER UPDATE OF status ON messages
+    FOR EACH ROW
+    WHEN (NEW.status = 'queued' AND OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION notify_message_requeued();
@@ -0,0 +1,2 @@
+-- Each claim stores a new token; outcomes are only written by its holder.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS claim_token TEXT;
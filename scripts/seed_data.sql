@@ -1,31 +1,31 @@
 -- Insert test messages
 INSERT INTO messages (phone_number, content, status) VALUES
-    ('+905551111111', 'Test message 1 from Insdr', 'pending'),
-    ('+905552222222', 'Test message 2 from Insdr', 'pending'),
-    ('+905553333333', 'Test message 3 from Insdr', 'pending'),
-    ('+905554444444', 'Test message 4 from Insdr', 'pending'),
-    ('+905555555555', 'Test message 5 from Insdr', 'pending'),
-    ('+905551111111', 'Test message 6 from Insdr', 'pending'),
-    ('+905552222222', 'Test message 7 from Insdr', 'pending'),
-    ('+905553333333', 'Test message 8 from Insdr', 'pending'),
-    ('+905554444444', 'Test message 9 from Insdr', 'pending'),
-    ('+905555555555', 'Test message 10 from Insdr', 'pending'),
-    ('+905551111111', 'Test message 11 from Insdr', 'pending'),
-    ('+905552222222', 'Test message 12 from Insdr', 'pending'),
-    ('+905553333333', 'Test message 13 from Insdr', 'pending'),
-    ('+905554444444', 'Test message 14 from Insdr', 'pending'),
-    ('+905555555555', 'Test message 15 from Insdr', 'pending'),
-    ('+905551111111', 'Test message 16 from Insdr', 'pending'),
-    ('+905552222222', 'Test message 17 from Insdr', 'pending'),
-    ('+905553333333', 'Test message 18 from Insdr', 'pending'),
-    ('+905554444444', 'Test message 19 from Insdr', 'pending'),
-    ('+905554444444', 'Test message 20 from Insdr', 'pending'),
-    ('+905551111111', 'Test message 21 from Insdr', 'pending'),
-    ('+905552222222', 'Test message 22 from Insdr', 'pending'),
-    ('+905553333333', 'Test message 23 from Insdr', 'pending'),
-    ('+905554444444', 'Test message 24 from Insdr', 'pending'),
-    ('+905555555555', 'Test message 25 from Insdr', 'pending'),
-    ('+905551111111', 'Test message 26 from Insdr', 'pending'),
-    ('+905552222222', 'Test message 27 from Insdr', 'pending'),
-    ('+905553333333', 'Test message 28 from Insdr', 'pending'),
-    ('+905554444444', 'Test message 29 from Insdr', 'pending');
+    ('+905551111111', 'Test message 1 from Insdr', 'queued'),
+    ('+905552222222', 'Test message 2 from Insdr', 'queued'),
+    ('+905553333333', 'Test message 3 from Insdr', 'queued'),
+    ('+905554444444', 'Test message 4 from Insdr', 'queued'),
+    ('+905555555555', 'Test message 5 from Insdr', 'queued'),
+    ('+905551111111', 'Test message 6 from Insdr', 'queued'),
+    ('+905552222222', 'Test message 7 from Insdr', 'queued'),
+    ('+905553333333', 'Test message 8 from Insdr', 'queued'),
+    ('+905554444444', 'Test message 9 from Insdr', 'queued'),
+    ('+905555555555', 'Test message 10 from Insdr', 'queued'),
+    ('+905551111111', 'Test message 11 from Insdr', 'queued'),
+    ('+905552222222', 'Test message 12 from Insdr', 'queued'),
+    ('+905553333333', 'Test message 13 from Insdr', 'queued'),
+    ('+905554444444', 'Test message 14 from Insdr', 'queued'),
+    ('+905555555555', 'Test message 15 from Insdr', 'queued'),
+    ('+905551111111', 'Test message 16 from Insdr', 'queued'),
+    ('+905552222222', 'Test message 17 from Insdr', 'queued'),
+    ('+905553333333', 'Test message 18 from Insdr', 'queued'),
+    ('+905554444444', 'Test message 19 from Insdr', 'queued'),
+    ('+905554444444', 'Test message 20 from Insdr', 'queued'),
+    ('+905551111111', 'Test message 21 from Insdr', 'queued'),
+    ('+905552222222', 'Test message 22 from Insdr', 'queued'),
+    ('+905553333333', 'Test message 23 from Insdr', 'queued'),
+    ('+905554444444', 'Test message 24 from Insdr', 'queued'),
+    ('+905555555555', 'Test message 25 from Insdr', 'queued'),
+    ('+905551111111', 'Test message 26 from Insdr', 'queued'),
+    ('+905552222222', 'Test message 27 from Insdr', 'queued'),
+    ('+905553333333', 'Test message 28 from Insdr', 'queued'),
+    ('+905554444444', 'Test message 29 from Insdr', 'queued');